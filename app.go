@@ -3,8 +3,8 @@ package main
 import (
 	"GoMusic/internal/domain/model"
 	"context"
+	stderrors "errors"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -13,8 +13,15 @@ import (
 	"GoMusic/internal/application/mapper"
 	"GoMusic/internal/controller"
 	"GoMusic/internal/domain/repository"
+	"GoMusic/internal/media"
+	analysisRepo "GoMusic/internal/repository/analysis"
+	artworkRepo "GoMusic/internal/repository/artwork"
 	configRepo "GoMusic/internal/repository/config"
+	identityRepo "GoMusic/internal/repository/identity"
+	moveJournalRepo "GoMusic/internal/repository/movejournal"
+	tagJournalRepo "GoMusic/internal/repository/tagjournal"
 	"GoMusic/internal/service"
+	"GoMusic/internal/util/errors"
 )
 
 // App struct
@@ -22,13 +29,28 @@ type App struct {
 	ctx context.Context
 
 	// Services
-	libraryService *service.LibraryService
-	configService  *service.ConfigService
+	libraryService  *service.LibraryService
+	configService   *service.ConfigService
+	scrobbleService *service.ScrobbleService
+	analysisService *service.AnalysisService
+	waveformService *service.WaveformService
+	tagEditService  *service.TagEditService
+	organizer       *service.OrganizerService
+	artworkCache    *service.ArtworkCacheService
+
+	// IDs of tracks whose files were moved, nil when the store cannot be opened
+	trackIdentities repository.TrackIdentityRepository
 
 	// Controllers
 	sourceController     *controller.SourceController
 	scanController       *controller.ScanController
 	filesystemController *controller.FilesystemController
+	scrobbleController   *controller.ScrobbleController
+	serverController     *controller.ServerController
+	analysisController   *controller.AnalysisController
+
+	// HTTP media serving shared with the embedded servers
+	mediaHandler *media.Handler
 
 	// Mappers
 	trackMapper *mapper.TrackMapper
@@ -43,10 +65,66 @@ func NewApp() *App {
 	configRepository := configRepo.NewJSONConfigRepository(configPath)
 	configService := service.NewConfigService(configRepository)
 
+	// Plays are queued on disk so they survive restarts while offline
+	scrobbleService, err := service.NewScrobbleService(configService, libraryService, filepath.Join(getDataDir(), "scrobble-queue.json"))
+	if err != nil {
+		fmt.Printf("Failed to open scrobble queue: %v\n", err)
+	}
+
+	// Loudness measurements are kept in GoMusic's store, not written to the files
+	var analysisService *service.AnalysisService
+	analysisRepository, err := analysisRepo.NewJSONAnalysisRepository(filepath.Join(getDataDir(), "analysis.json"))
+	if err != nil {
+		fmt.Printf("Failed to open analysis store: %v\n", err)
+	} else {
+		analysisService = service.NewAnalysisService(libraryService, analysisRepository)
+	}
+
+	waveformService := service.NewWaveformService(libraryService, filepath.Join(getDataDir(), "waveforms"))
+
+	// Batch tag edits are journaled so they can be undone
+	var tagEditService *service.TagEditService
+	tagJournal, err := tagJournalRepo.NewJSONTagJournalRepository(filepath.Join(getDataDir(), "tag-journal.json"))
+	if err != nil {
+		fmt.Printf("Failed to open tag journal: %v\n", err)
+	} else {
+		tagEditService = service.NewTagEditService(libraryService, tagJournal)
+	}
+
+	// Moved files keep the IDs derived from their original paths
+	var trackIdentities repository.TrackIdentityRepository
+	identities, err := identityRepo.NewJSONTrackIdentityRepository(filepath.Join(getDataDir(), "track-identities.json"))
+	if err != nil {
+		fmt.Printf("Failed to open track identities: %v\n", err)
+	} else {
+		trackIdentities = identities
+	}
+
+	// Organizer runs are journaled so a crash mid-move can be rolled back
+	moveJournal := moveJournalRepo.NewJSONMoveJournalRepository(filepath.Join(getDataDir(), "organize-journal.json"))
+
+	// Artwork files are shared by content, so each source's references are
+	// recorded to tell which files are no longer used
+	var artworkCache *service.ArtworkCacheService
+	artworkReferences, err := artworkRepo.NewJSONArtworkReferenceRepository(filepath.Join(getDataDir(), "artwork-refs.json"))
+	if err != nil {
+		fmt.Printf("Failed to open artwork references: %v\n", err)
+	} else {
+		artworkCache = service.NewArtworkCacheService(libraryService, configService, artworkReferences, filepath.Join(getDataDir(), "artwork"))
+	}
+
 	return &App{
-		libraryService: libraryService,
-		configService:  configService,
-		trackMapper:    mapper.NewTrackMapper(),
+		libraryService:  libraryService,
+		configService:   configService,
+		scrobbleService: scrobbleService,
+		analysisService: analysisService,
+		waveformService: waveformService,
+		tagEditService:  tagEditService,
+		organizer:       service.NewOrganizerService(libraryService, moveJournal),
+		artworkCache:    artworkCache,
+		trackIdentities: trackIdentities,
+		mediaHandler:    media.NewHandler(libraryService, waveformService, filepath.Join(getDataDir(), "artwork")),
+		trackMapper:     mapper.NewTrackMapper(),
 	}
 }
 
@@ -56,9 +134,17 @@ func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
 
 	// Initialize controllers
-	a.sourceController = controller.NewSourceController(a.configService, a.libraryService)
+	a.sourceController = controller.NewSourceController(a.configService, a.libraryService, a.trackIdentities)
 	a.scanController = controller.NewScanController(a.libraryService, ctx)
 	a.filesystemController = controller.NewFilesystemController(a.libraryService, ctx)
+	a.serverController = controller.NewServerController(a.configService, a.libraryService, a.mediaHandler, ctx)
+	if a.scrobbleService != nil {
+		a.scrobbleController = controller.NewScrobbleController(a.scrobbleService, a.configService, ctx)
+	}
+	if a.analysisService != nil {
+		a.analysisController = controller.NewAnalysisController(a.analysisService, ctx)
+	}
+	a.scanController.SetCompleteListener(a.onScanComplete)
 
 	// Initialize configuration
 	if err := a.configService.Initialize(ctx); err != nil {
@@ -66,10 +152,61 @@ func (a *App) startup(ctx context.Context) {
 		return
 	}
 
+	// Start submitting queued plays in the background
+	if a.scrobbleService != nil {
+		a.scrobbleService.Start(ctx)
+	}
+
+	// Put back files an interrupted organizer run left moved, before the
+	// sources scan them
+	if err := a.organizer.Recover(ctx); err != nil {
+		fmt.Printf("Failed to recover organizer run: %v\n", err)
+	}
+
 	// Load sources from configuration
 	if err := a.sourceController.LoadSourcesFromConfig(); err != nil {
 		fmt.Printf("Failed to load sources from config: %v\n", err)
 	}
+
+	// Start the embedded servers that are enabled
+	a.serverController.StartEnabled()
+}
+
+// onScanComplete refreshes data derived from the library after a scan
+func (a *App) onScanComplete(sourceID string) {
+	if a.analysisController != nil {
+		if err := a.analysisController.ApplyStoredResults(); err != nil {
+			fmt.Printf("Failed to apply track analysis: %v\n", err)
+		}
+	}
+
+	if a.configService.GetPlaybackConfig().PregenerateWaveforms {
+		go a.waveformService.Pregenerate(a.ctx)
+	}
+
+	if a.artworkCache != nil {
+		sourceIDs := []string{sourceID}
+		if sourceID == "all" {
+			sourceIDs = sourceIDs[:0]
+			for _, source := range a.libraryService.GetSources() {
+				sourceIDs = append(sourceIDs, source.ID)
+			}
+		}
+		for _, id := range sourceIDs {
+			if err := a.artworkCache.RecordReferences(a.ctx, id); err != nil {
+				fmt.Printf("Failed to record artwork references for %s: %v\n", id, err)
+			}
+		}
+		go a.cleanArtworkCache()
+	}
+}
+
+// cleanArtworkCache deletes artwork no source uses in the background, logging
+// failures; a scan in progress postpones it to that scan's completion
+func (a *App) cleanArtworkCache() {
+	if _, err := a.artworkCache.CollectGarbage(a.ctx); err != nil && !stderrors.Is(err, errors.ErrScanInProgress) {
+		fmt.Printf("Failed to clean artwork cache: %v\n", err)
+	}
 }
 
 // getConfigPath returns the path to the configuration file
@@ -86,6 +223,17 @@ func getConfigPath() string {
 	return filepath.Join(configDir, "config.json")
 }
 
+// getDataDir returns the directory for GoMusic's own data files (~/.gomusic)
+func getDataDir() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		// Fallback to current directory
+		return "./.gomusic"
+	}
+
+	return filepath.Join(homeDir, ".gomusic")
+}
+
 // === WAILS-EXPOSED METHODS (callable from Svelte frontend) ===
 
 // GetAllTracks returns all tracks from all sources
@@ -98,6 +246,18 @@ func (a *App) GetAllTracks() ([]*dto.TrackDTO, error) {
 	return a.trackMapper.ToDTOList(tracks), nil
 }
 
+// QueryTracks returns tracks sorted and filtered by opts
+// Besides the text fields, tracks sort by "bpm" and "key" (Camelot order) and
+// filter by "bpmMin", "bpmMax", "key" and "harmonicKey"
+func (a *App) QueryTracks(opts repository.QueryOptions) ([]*dto.TrackDTO, error) {
+	tracks, err := a.libraryService.GetAllTracks(a.ctx, &opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.trackMapper.ToDTOList(tracks), nil
+}
+
 // GetTrack retrieves a single track by ID
 func (a *App) GetTrack(id string) (*dto.TrackDTO, error) {
 	track, err := a.libraryService.GetTrackByID(a.ctx, id)
@@ -139,6 +299,80 @@ func (a *App) GetTracksByArtist(artistID string) ([]*dto.TrackDTO, error) {
 	return a.trackMapper.ToDTOList(tracks), nil
 }
 
+// UpdateTrackMetadata writes tag edits to a track's file and refreshes the track
+// in the library; fields left out are unchanged, empty ones are removed
+func (a *App) UpdateTrackMetadata(trackID string, fields model.TagChanges) (*dto.TrackDTO, error) {
+	if fields.IsEmpty() {
+		return a.GetTrack(trackID)
+	}
+
+	tracks, err := a.libraryService.UpdateTrackMetadata(a.ctx, trackID, &fields)
+	if err != nil {
+		return nil, err
+	}
+	if len(tracks) == 0 {
+		return nil, fmt.Errorf("no tracks read from the updated file")
+	}
+
+	return a.trackMapper.ToDTO(tracks[0]), nil
+}
+
+// PreviewTagEdits returns the changes a batch of tag operations would make to each
+// track's file, without writing anything
+func (a *App) PreviewTagEdits(trackIDs []string, ops []model.TagOperation) ([]*model.TagEditPreview, error) {
+	if a.tagEditService == nil {
+		return nil, fmt.Errorf("batch tag editing is not available")
+	}
+	return a.tagEditService.PreviewBatch(a.ctx, trackIDs, ops)
+}
+
+// ApplyTagEdits writes a batch of tag operations to the tracks' files and records
+// it in the journal
+func (a *App) ApplyTagEdits(trackIDs []string, ops []model.TagOperation) (*model.TagEditBatch, error) {
+	if a.tagEditService == nil {
+		return nil, fmt.Errorf("batch tag editing is not available")
+	}
+	return a.tagEditService.ApplyBatch(a.ctx, trackIDs, ops)
+}
+
+// UndoTagEdits restores the tag values replaced by a journaled batch
+func (a *App) UndoTagEdits(batchID string) (*model.TagEditBatch, error) {
+	if a.tagEditService == nil {
+		return nil, fmt.Errorf("batch tag editing is not available")
+	}
+	return a.tagEditService.UndoBatch(a.ctx, batchID)
+}
+
+// WriteInferredTags writes the tags inferred from the tracks' file and folder
+// names into their files, recording the batch in the tag journal
+func (a *App) WriteInferredTags(trackIDs []string) (*model.TagEditBatch, error) {
+	if a.tagEditService == nil {
+		return nil, fmt.Errorf("batch tag editing is not available")
+	}
+	return a.tagEditService.ApplyInferredTags(a.ctx, trackIDs)
+}
+
+// GetTagEditHistory returns the journaled tag edit batches, newest first
+func (a *App) GetTagEditHistory() ([]*model.TagEditBatch, error) {
+	if a.tagEditService == nil {
+		return []*model.TagEditBatch{}, nil
+	}
+	return a.tagEditService.GetHistory(a.ctx)
+}
+
+// PreviewOrganizeLibrary returns the moves that would arrange a source's files by
+// a template such as "%albumartist%/%album%/%track% %title%", without moving anything
+// An empty trackIDs covers every track of the source
+func (a *App) PreviewOrganizeLibrary(sourceID string, template string, trackIDs []string) (*model.OrganizePlan, error) {
+	return a.organizer.Preview(a.ctx, sourceID, template, trackIDs)
+}
+
+// OrganizeLibrary moves a source's files, with their sidecar files, as the
+// template lays out; if any move fails, every file is put back
+func (a *App) OrganizeLibrary(sourceID string, template string, trackIDs []string) (*model.OrganizePlan, error) {
+	return a.organizer.Apply(a.ctx, sourceID, template, trackIDs)
+}
+
 // === Scan Operations (delegated to ScanController) ===
 
 // ScanLibrary triggers a library scan for a specific source
@@ -188,9 +422,30 @@ func (a *App) UpdateFilesystemSource(sourceID string, name string, rootPaths []s
 	return a.sourceController.UpdateFilesystemSource(a.ctx, sourceID, name, rootPaths, includeSubfolders, formats)
 }
 
+// AddSubsonicSource adds a Subsonic-compatible server as a music source
+func (a *App) AddSubsonicSource(name string, baseURL string, username string, password string, apiKey string) error {
+	return a.sourceController.AddSubsonicSource(a.ctx, name, baseURL, username, password, apiKey)
+}
+
+// AddJellyfinSource adds a Jellyfin server as a music source
+func (a *App) AddJellyfinSource(name string, baseURL string, username string, password string, apiKey string) error {
+	return a.sourceController.AddJellyfinSource(a.ctx, name, baseURL, username, password, apiKey)
+}
+
 // RemoveSource removes a music source
+// Artwork only the source used is deleted afterwards
 func (a *App) RemoveSource(sourceID string) error {
-	return a.sourceController.RemoveSource(a.ctx, sourceID)
+	if err := a.sourceController.RemoveSource(a.ctx, sourceID); err != nil {
+		return err
+	}
+
+	if a.artworkCache != nil {
+		if err := a.artworkCache.ForgetSource(a.ctx, sourceID); err != nil {
+			fmt.Printf("Failed to forget artwork references for %s: %v\n", sourceID, err)
+		}
+		go a.cleanArtworkCache()
+	}
+	return nil
 }
 
 // === Filesystem Operations (delegated to FilesystemController) ===
@@ -200,6 +455,20 @@ func (a *App) BrowseDirectory(sourceID string, relativePath string) (*dto.Direct
 	return a.filesystemController.BrowseDirectory(sourceID, relativePath)
 }
 
+// SetSourceNamingPatterns sets the file naming patterns a filesystem source infers
+// missing tags from, e.g. "%albumartist%/%year% - %album%/%track% %title%"
+func (a *App) SetSourceNamingPatterns(sourceID string, patterns []string) error {
+	return a.sourceController.SetNamingPatterns(a.ctx, sourceID, patterns)
+}
+
+// SetSourceArtworkSettings sets the image file patterns a filesystem source
+// searches for folder artwork, in order (e.g. "cover.*", "folder.*"), and
+// whether "embedded" pictures or "folder" images are preferred
+// Empty patterns restore the defaults; changes apply from the next scan
+func (a *App) SetSourceArtworkSettings(sourceID string, patterns []string, preference string) error {
+	return a.sourceController.SetArtworkSettings(a.ctx, sourceID, patterns, preference)
+}
+
 // GetSourceRootPath returns the root path for a filesystem source
 func (a *App) GetSourceRootPath(sourceID string) (string, error) {
 	return a.filesystemController.GetSourceRootPath(sourceID)
@@ -210,9 +479,150 @@ func (a *App) SelectDirectory() (string, error) {
 	return a.filesystemController.SelectDirectory()
 }
 
+// === Scrobbling (delegated to ScrobbleController) ===
+
+// ScrobbleNowPlaying announces a track as currently playing
+func (a *App) ScrobbleNowPlaying(trackID string) error {
+	if a.scrobbleController == nil {
+		return fmt.Errorf("scrobbling is not available")
+	}
+	return a.scrobbleController.NowPlaying(trackID)
+}
+
+// SubmitPlay records a finished play (startedAt is a Unix timestamp in seconds)
+// Returns true if the play met the scrobbling rules and was queued
+func (a *App) SubmitPlay(trackID string, startedAt int64, playedSeconds float64) (bool, error) {
+	if a.scrobbleController == nil {
+		return false, fmt.Errorf("scrobbling is not available")
+	}
+	return a.scrobbleController.SubmitPlay(trackID, startedAt, playedSeconds)
+}
+
+// GetScrobbleStatus returns the current scrobble submission status
+func (a *App) GetScrobbleStatus() *dto.ScrobbleStatusDTO {
+	if a.scrobbleController == nil {
+		return &dto.ScrobbleStatusDTO{}
+	}
+	return a.scrobbleController.GetStatus()
+}
+
+// GetScrobblerConfig returns the scrobbler configuration
+func (a *App) GetScrobblerConfig() *model.ScrobblerConfig {
+	return a.configService.GetScrobblerConfig()
+}
+
+// UpdateScrobblerConfig saves the scrobbler configuration
+func (a *App) UpdateScrobblerConfig(config model.ScrobblerConfig) error {
+	if a.scrobbleController == nil {
+		return fmt.Errorf("scrobbling is not available")
+	}
+	return a.scrobbleController.UpdateConfig(config)
+}
+
+// === Embedded Servers (delegated to ServerController) ===
+
+// GetSubsonicServerConfig returns the embedded Subsonic server configuration
+func (a *App) GetSubsonicServerConfig() *model.SubsonicServerConfig {
+	return a.configService.GetSubsonicServerConfig()
+}
+
+// UpdateSubsonicServerConfig saves the Subsonic server configuration and restarts the server
+func (a *App) UpdateSubsonicServerConfig(config model.SubsonicServerConfig) error {
+	return a.serverController.UpdateSubsonicServerConfig(config)
+}
+
+// GetDLNAConfig returns the DLNA media server configuration
+func (a *App) GetDLNAConfig() *model.DLNAConfig {
+	return a.configService.GetDLNAConfig()
+}
+
+// UpdateDLNAConfig saves the DLNA media server configuration and restarts the server
+func (a *App) UpdateDLNAConfig(config model.DLNAConfig) error {
+	return a.serverController.UpdateDLNAConfig(config)
+}
+
+// GetServerStatus returns the state of the embedded servers
+func (a *App) GetServerStatus() *dto.ServerStatusDTO {
+	return a.serverController.GetStatus()
+}
+
+// === Playback Settings ===
+
+// GetPlaybackConfig returns the playback preferences such as loudness normalization
+func (a *App) GetPlaybackConfig() *model.PlaybackConfig {
+	return a.configService.GetPlaybackConfig()
+}
+
+// UpdatePlaybackConfig saves the playback preferences
+func (a *App) UpdatePlaybackConfig(config model.PlaybackConfig) error {
+	return a.configService.UpdatePlaybackConfig(a.ctx, &config)
+}
+
+// === Artwork Cache ===
+
+// GetArtworkCacheStats returns the number and total size of cached artwork files
+func (a *App) GetArtworkCacheStats() (*model.ArtworkCacheStats, error) {
+	if a.artworkCache == nil {
+		return nil, fmt.Errorf("artwork cache is unavailable")
+	}
+	return a.artworkCache.GetStats(a.ctx)
+}
+
+// CleanArtworkCache deletes artwork no source uses and trims the cache to its
+// size cap, returning what was removed; fails while a scan is running
+func (a *App) CleanArtworkCache() (*model.ArtworkCacheStats, error) {
+	if a.artworkCache == nil {
+		return nil, fmt.Errorf("artwork cache is unavailable")
+	}
+	return a.artworkCache.CollectGarbage(a.ctx)
+}
+
+// GetArtworkCacheConfig returns the artwork cache size cap
+func (a *App) GetArtworkCacheConfig() *model.ArtworkCacheConfig {
+	return a.configService.GetArtworkCacheConfig()
+}
+
+// UpdateArtworkCacheConfig saves the artwork cache size cap and trims the
+// cache to it
+func (a *App) UpdateArtworkCacheConfig(config model.ArtworkCacheConfig) error {
+	if err := a.configService.UpdateArtworkCacheConfig(a.ctx, &config); err != nil {
+		return err
+	}
+
+	if a.artworkCache != nil {
+		go a.cleanArtworkCache()
+	}
+	return nil
+}
+
+// === Track Analysis (delegated to AnalysisController) ===
+
+// StartTrackAnalysis measures loudness, tempo and key of tracks missing them in the background
+func (a *App) StartTrackAnalysis() error {
+	if a.analysisController == nil {
+		return fmt.Errorf("track analysis is unavailable")
+	}
+	return a.analysisController.StartAnalysis()
+}
+
+// CancelTrackAnalysis stops a running track analysis
+func (a *App) CancelTrackAnalysis() {
+	if a.analysisController != nil {
+		a.analysisController.CancelAnalysis()
+	}
+}
+
+// GetTrackAnalysisProgress returns the progress of the track analysis
+func (a *App) GetTrackAnalysisProgress() *dto.ScanProgressDTO {
+	if a.analysisController == nil {
+		return &dto.ScanProgressDTO{}
+	}
+	return a.analysisController.GetProgress()
+}
+
 // === HTTP MIDDLEWARE ===
 
-// AudioFileMiddleware intercepts audio streaming and artwork requests
+// AudioFileMiddleware intercepts audio streaming, waveform and artwork requests
 func (a *App) AudioFileMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Handle requests to /audio/* path
@@ -221,13 +631,19 @@ func (a *App) AudioFileMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		// Handle requests to /waveform/* path
+		if len(r.URL.Path) >= 10 && r.URL.Path[:10] == "/waveform/" {
+			a.serveWaveform(w, r)
+			return
+		}
+
 		// Handle requests to /artwork/* path
 		if len(r.URL.Path) >= 9 && r.URL.Path[:9] == "/artwork/" {
 			a.serveArtworkFile(w, r)
 			return
 		}
 
-		// Not an audio or artwork request, pass to next handler
+		// Not a media request, pass to next handler
 		next.ServeHTTP(w, r)
 	})
 }
@@ -253,158 +669,15 @@ func (a *App) GetTrackFilePath(trackID string) (string, error) {
 
 // serveAudioFile handles HTTP requests for streaming audio files by track ID
 func (a *App) serveAudioFile(w http.ResponseWriter, r *http.Request) {
-	// Extract track ID from URL query parameter
-	trackID := r.URL.Query().Get("id")
-	if trackID == "" {
-		http.Error(w, "Missing id parameter", http.StatusBadRequest)
-		return
-	}
-
-	// Look up track in library service cache
-	track, err := a.libraryService.GetTrackByID(a.ctx, trackID)
-	if err != nil {
-		http.Error(w, "Track not found", http.StatusNotFound)
-		return
-	}
-
-	// Get file path from track metadata
-	filePath := track.FilePath
-	if filePath == "" {
-		http.Error(w, "Track has no file path", http.StatusInternalServerError)
-		return
-	}
-
-	// Verify file exists and is readable
-	fileInfo, err := os.Stat(filePath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			http.Error(w, "File not found", http.StatusNotFound)
-		} else {
-			http.Error(w, "Cannot access file", http.StatusInternalServerError)
-		}
-		return
-	}
-
-	if fileInfo.IsDir() {
-		http.Error(w, "Path is a directory, not a file", http.StatusBadRequest)
-		return
-	}
-
-	// Open the file
-	file, err := os.Open(filePath)
-	if err != nil {
-		http.Error(w, "Cannot open file", http.StatusNotFound)
-		return
-	}
-	defer func() {
-		if err := file.Close(); err != nil {
-			fmt.Printf("Error closing file: %v\n", err)
-		}
-	}()
-
-	// Set response headers
-	w.Header().Set("Content-Type", getAudioContentType(filePath))
-	w.Header().Set("Content-Length", fmt.Sprintf("%d", fileInfo.Size()))
-	w.Header().Set("Accept-Ranges", "bytes")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
+	a.mediaHandler.ServeAudio(w, r, r.URL.Query().Get("id"))
+}
 
-	// Stream the file
-	_, _ = io.Copy(w, file)
+// serveWaveform handles HTTP requests for seek bar waveform data by track ID
+func (a *App) serveWaveform(w http.ResponseWriter, r *http.Request) {
+	a.mediaHandler.ServeWaveform(w, r, r.URL.Query().Get("id"))
 }
 
 // serveArtworkFile handles HTTP requests for serving album artwork by track ID
 func (a *App) serveArtworkFile(w http.ResponseWriter, r *http.Request) {
-	// Extract track ID from URL query parameter
-	trackID := r.URL.Query().Get("id")
-	if trackID == "" {
-		http.Error(w, "Missing id parameter", http.StatusBadRequest)
-		return
-	}
-
-	// Look up track in library service cache
-	track, err := a.libraryService.GetTrackByID(a.ctx, trackID)
-	if err != nil {
-		http.Error(w, "Track not found", http.StatusNotFound)
-		return
-	}
-
-	// Get artwork path from track metadata
-	artworkFilename := track.ArtworkPath
-	if artworkFilename == "" {
-		http.Error(w, "Track has no artwork", http.StatusNotFound)
-		return
-	}
-
-	// Construct full path to artwork file
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		http.Error(w, "Failed to get home directory", http.StatusInternalServerError)
-		return
-	}
-	artworkDir := filepath.Join(homeDir, ".gomusic", "artwork")
-	fullPath := filepath.Join(artworkDir, artworkFilename)
-
-	// Open the artwork file
-	file, err := os.Open(fullPath)
-	if err != nil {
-		http.Error(w, "Artwork not found", http.StatusNotFound)
-		return
-	}
-	defer file.Close()
-
-	// Get file info for Content-Length
-	fileInfo, err := file.Stat()
-	if err != nil {
-		http.Error(w, "Cannot stat file", http.StatusInternalServerError)
-		return
-	}
-
-	// Set response headers
-	w.Header().Set("Content-Type", getImageContentType(fullPath))
-	w.Header().Set("Content-Length", fmt.Sprintf("%d", fileInfo.Size()))
-	w.Header().Set("Cache-Control", "public, max-age=31536000") // Cache for 1 year
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-
-	// Stream the image
-	if _, err := io.Copy(w, file); err != nil {
-		fmt.Printf("Error streaming artwork: %v\n", err)
-	}
-}
-
-// getAudioContentType determines the MIME type for audio files
-func getAudioContentType(filePath string) string {
-	ext := filepath.Ext(filePath)
-	switch ext {
-	case ".mp3":
-		return "audio/mpeg"
-	case ".m4a":
-		return "audio/mp4"
-	case ".flac":
-		return "audio/flac"
-	case ".ogg":
-		return "audio/ogg"
-	case ".wav":
-		return "audio/wav"
-	default:
-		return "application/octet-stream"
-	}
-}
-
-// getImageContentType determines the MIME type for image files
-func getImageContentType(filePath string) string {
-	ext := filepath.Ext(filePath)
-	switch ext {
-	case ".jpg", ".jpeg":
-		return "image/jpeg"
-	case ".png":
-		return "image/png"
-	case ".gif":
-		return "image/gif"
-	case ".bmp":
-		return "image/bmp"
-	case ".webp":
-		return "image/webp"
-	default:
-		return "image/jpeg"
-	}
+	a.mediaHandler.ServeArtwork(w, r, r.URL.Query().Get("id"))
 }
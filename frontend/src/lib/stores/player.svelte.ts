@@ -1,4 +1,7 @@
 import type { dto } from '../../../wailsjs/go/models';
+import { GetPlaybackConfig, UpdatePlaybackConfig } from '../../../wailsjs/go/main/App.js';
+
+export type NormalizationMode = 'off' | 'track' | 'album' | 'auto';
 
 /**
  * Manages audio playback state and playlist queue
@@ -16,6 +19,11 @@ class PlayerStore {
   repeatMode = $state<'none' | 'all' | 'one'>('none');
   shuffleEnabled = $state<boolean>(false);
   previewMode = $state<boolean>(false);
+  normalizationMode = $state<NormalizationMode>('auto');
+  pregenerateWaveforms = $state<boolean>(false);
+
+  // Why the current track cannot be played, shown in place of playback
+  playbackError = $state<string | null>(null);
 
   duration = $derived(this.currentTrack?.duration || 0);
   progress = $derived(
@@ -34,6 +42,40 @@ class PlayerStore {
     return Math.pow(10, db / 20);
   });
 
+  // Whether the current track is part of an album played in order,
+  // judged by its neighbours in the queue
+  isAlbumContext = $derived.by(() => {
+    const albumId = this.currentTrack?.albumId;
+    if (!albumId) return false;
+
+    const prev = this.playlist[this.currentIndex - 1];
+    const next = this.playlist[this.currentIndex + 1];
+    return prev?.albumId === albumId || next?.albumId === albumId;
+  });
+
+  // Linear ReplayGain factor for the current track, limited so the peak never clips
+  normalizationGain = $derived.by(() => {
+    const track = this.currentTrack;
+    if (!track || this.normalizationMode === 'off') return 1;
+
+    const useAlbum =
+      this.normalizationMode === 'album' ||
+      (this.normalizationMode === 'auto' && this.isAlbumContext);
+
+    const gain = useAlbum ? (track.albumGain ?? track.trackGain) : (track.trackGain ?? track.albumGain);
+    if (gain === undefined) return 1;
+
+    const peak = useAlbum ? (track.albumPeak ?? track.trackPeak) : (track.trackPeak ?? track.albumPeak);
+    let linear = Math.pow(10, gain / 20);
+    if (peak && peak > 0) {
+      linear = Math.min(linear, 1 / peak);
+    }
+    return linear;
+  });
+
+  // Volume sent to the audio element; it cannot exceed 1, so positive gains are capped
+  outputVolume = $derived(Math.min(1, this.actualVolume * this.normalizationGain));
+
   // Check if we can go to next/previous track
   canGoNext = $derived(this.currentIndex < this.playlist.length - 1);
   canGoPrevious = $derived(this.currentIndex > 0);
@@ -42,12 +84,14 @@ class PlayerStore {
    * Play a specific track
    * This sets the track as current and starts playback
    * In preview mode, automatically seeks to 1/15th of the track
+   * Tracks the backend marked unplayable are selected but not started
    */
   play(track: dto.TrackDTO) {
     if (!track) return;
 
     this.currentTrack = track;
-    this.isPlaying = true;
+    this.playbackError = track.unplayableReason || null;
+    this.isPlaying = !this.playbackError;
 
     if (this.previewMode && track.duration) {
       this.currentTime = track.duration / 15;
@@ -67,7 +111,7 @@ class PlayerStore {
    * Resume playback
    */
   resume() {
-    if (!this.currentTrack) return;
+    if (!this.currentTrack || this.playbackError) return;
     this.isPlaying = true;
   }
 
@@ -97,6 +141,59 @@ class PlayerStore {
     this.volume = Math.max(0, Math.min(1, vol));
   }
 
+  /**
+   * Load playback preferences from the backend
+   */
+  async loadSettings() {
+    try {
+      const config = await GetPlaybackConfig();
+      this.normalizationMode = config.normalizationMode as NormalizationMode;
+      this.pregenerateWaveforms = config.pregenerateWaveforms;
+    } catch (err) {
+      console.error('Failed to load playback settings:', err);
+    }
+  }
+
+  /**
+   * Change the loudness normalization mode and persist it
+   */
+  async setNormalizationMode(mode: NormalizationMode) {
+    const previous = this.normalizationMode;
+    this.normalizationMode = mode;
+
+    try {
+      await this.saveSettings();
+    } catch (err) {
+      console.error('Failed to save playback settings:', err);
+      this.normalizationMode = previous;
+    }
+  }
+
+  /**
+   * Choose whether seek bar waveforms are computed after each scan and persist it
+   */
+  async setPregenerateWaveforms(enabled: boolean) {
+    const previous = this.pregenerateWaveforms;
+    this.pregenerateWaveforms = enabled;
+
+    try {
+      await this.saveSettings();
+    } catch (err) {
+      console.error('Failed to save playback settings:', err);
+      this.pregenerateWaveforms = previous;
+    }
+  }
+
+  /**
+   * Persist the playback preferences
+   */
+  private async saveSettings() {
+    await UpdatePlaybackConfig({
+      normalizationMode: this.normalizationMode,
+      pregenerateWaveforms: this.pregenerateWaveforms,
+    });
+  }
+
   /**
    * Toggle mute state
    */
@@ -0,0 +1,85 @@
+import { GetScrobbleStatus, ScrobbleNowPlaying, SubmitPlay } from '../../../wailsjs/go/main/App.js';
+import { EventsOn } from '../../../wailsjs/runtime/runtime.js';
+import type { dto } from '../../../wailsjs/go/models';
+
+// Position jumps larger than this are seeks, not listening time
+const MAX_PLAYBACK_STEP = 2;
+
+/**
+ * Tracks how long each track was actually listened to and reports
+ * finished plays to the backend, which applies the scrobbling rules
+ */
+class ScrobbleStore {
+  status = $state<dto.ScrobbleStatusDTO | null>(null);
+
+  private trackId: string | null = null;
+  private startedAt = 0;
+  private playedSeconds = 0;
+  private lastPosition = 0;
+  private announced = false;
+
+  /**
+   * Load the initial status and subscribe to backend updates
+   */
+  async init() {
+    EventsOn('scrobble:status', (status: dto.ScrobbleStatusDTO) => {
+      this.status = status;
+    });
+
+    try {
+      this.status = await GetScrobbleStatus();
+    } catch (err) {
+      console.error('Failed to load scrobble status:', err);
+    }
+  }
+
+  /**
+   * Start a new listening session, finishing the previous one
+   */
+  begin(trackId: string) {
+    this.finish();
+
+    this.trackId = trackId;
+    this.startedAt = Math.floor(Date.now() / 1000);
+    this.playedSeconds = 0;
+    this.lastPosition = 0;
+    this.announced = false;
+  }
+
+  /**
+   * Accumulate listening time from audio element position updates
+   */
+  progress(position: number, isPlaying: boolean) {
+    if (!this.trackId) return;
+
+    const step = position - this.lastPosition;
+    this.lastPosition = position;
+
+    if (!isPlaying || step <= 0 || step > MAX_PLAYBACK_STEP) return;
+    this.playedSeconds += step;
+
+    if (!this.announced) {
+      this.announced = true;
+      ScrobbleNowPlaying(this.trackId).catch(err => {
+        console.error('Failed to send now playing:', err);
+      });
+    }
+  }
+
+  /**
+   * Report the current session to the backend and reset
+   */
+  finish() {
+    if (!this.trackId || this.playedSeconds <= 0) {
+      this.trackId = null;
+      return;
+    }
+
+    SubmitPlay(this.trackId, this.startedAt, this.playedSeconds).catch(err => {
+      console.error('Failed to submit play:', err);
+    });
+    this.trackId = null;
+  }
+}
+
+export const scrobbler = new ScrobbleStore();
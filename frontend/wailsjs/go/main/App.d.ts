@@ -1,45 +1,110 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
+import {model} from '../models';
 import {http} from '../models';
 import {dto} from '../models';
-import {model} from '../models';
+import {repository} from '../models';
 
 export function AddFilesystemSource(arg1:string,arg2:Array<string>,arg3:boolean,arg4:Array<string>):Promise<void>;
 
+export function AddJellyfinSource(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string):Promise<void>;
+
+export function AddSubsonicSource(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string):Promise<void>;
+
+export function ApplyTagEdits(arg1:Array<string>,arg2:Array<model.TagOperation>):Promise<model.TagEditBatch>;
+
 export function AudioFileMiddleware(arg1:http.Handler):Promise<http.Handler>;
 
 export function BrowseDirectory(arg1:string,arg2:string):Promise<dto.DirectoryContentsDTO>;
 
+export function CancelTrackAnalysis():Promise<void>;
+
+export function CleanArtworkCache():Promise<model.ArtworkCacheStats>;
+
 export function GetAllScanProgress():Promise<Record<string, dto.ScanProgressDTO>>;
 
 export function GetAllTracks():Promise<Array<dto.TrackDTO>>;
 
+export function GetArtworkCacheConfig():Promise<model.ArtworkCacheConfig>;
+
+export function GetArtworkCacheStats():Promise<model.ArtworkCacheStats>;
+
+export function GetDLNAConfig():Promise<model.DLNAConfig>;
+
+export function GetPlaybackConfig():Promise<model.PlaybackConfig>;
+
 export function GetScanProgress(arg1:string):Promise<dto.ScanProgressDTO>;
 
+export function GetScrobbleStatus():Promise<dto.ScrobbleStatusDTO>;
+
+export function GetScrobblerConfig():Promise<model.ScrobblerConfig>;
+
+export function GetServerStatus():Promise<dto.ServerStatusDTO>;
+
 export function GetSourceConfig(arg1:string):Promise<model.SourceConfiguration>;
 
 export function GetSourceRootPath(arg1:string):Promise<string>;
 
 export function GetSources():Promise<Array<dto.SourceDTO>>;
 
+export function GetSubsonicServerConfig():Promise<model.SubsonicServerConfig>;
+
 export function GetSupportedFormats():Promise<Array<string>>;
 
+export function GetTagEditHistory():Promise<Array<model.TagEditBatch>>;
+
 export function GetTrack(arg1:string):Promise<dto.TrackDTO>;
 
+export function GetTrackAnalysisProgress():Promise<dto.ScanProgressDTO>;
+
 export function GetTrackFilePath(arg1:string):Promise<string>;
 
 export function GetTracksByAlbum(arg1:string):Promise<Array<dto.TrackDTO>>;
 
 export function GetTracksByArtist(arg1:string):Promise<Array<dto.TrackDTO>>;
 
+export function OrganizeLibrary(arg1:string,arg2:string,arg3:Array<string>):Promise<model.OrganizePlan>;
+
+export function PreviewOrganizeLibrary(arg1:string,arg2:string,arg3:Array<string>):Promise<model.OrganizePlan>;
+
+export function PreviewTagEdits(arg1:Array<string>,arg2:Array<model.TagOperation>):Promise<Array<model.TagEditPreview>>;
+
+export function QueryTracks(arg1:repository.QueryOptions):Promise<Array<dto.TrackDTO>>;
+
 export function RemoveSource(arg1:string):Promise<void>;
 
 export function ScanAllLibraries():Promise<void>;
 
 export function ScanLibrary(arg1:string):Promise<void>;
 
+export function ScrobbleNowPlaying(arg1:string):Promise<void>;
+
 export function SearchTracks(arg1:string):Promise<Array<dto.TrackDTO>>;
 
 export function SelectDirectory():Promise<string>;
 
+export function SetSourceArtworkSettings(arg1:string,arg2:Array<string>,arg3:string):Promise<void>;
+
+export function SetSourceNamingPatterns(arg1:string,arg2:Array<string>):Promise<void>;
+
+export function StartTrackAnalysis():Promise<void>;
+
+export function SubmitPlay(arg1:string,arg2:number,arg3:number):Promise<boolean>;
+
+export function UndoTagEdits(arg1:string):Promise<model.TagEditBatch>;
+
+export function UpdateArtworkCacheConfig(arg1:model.ArtworkCacheConfig):Promise<void>;
+
+export function UpdateDLNAConfig(arg1:model.DLNAConfig):Promise<void>;
+
 export function UpdateFilesystemSource(arg1:string,arg2:string,arg3:Array<string>,arg4:boolean,arg5:Array<string>):Promise<void>;
+
+export function UpdatePlaybackConfig(arg1:model.PlaybackConfig):Promise<void>;
+
+export function UpdateScrobblerConfig(arg1:model.ScrobblerConfig):Promise<void>;
+
+export function UpdateSubsonicServerConfig(arg1:model.SubsonicServerConfig):Promise<void>;
+
+export function UpdateTrackMetadata(arg1:string,arg2:model.TagChanges):Promise<dto.TrackDTO>;
+
+export function WriteInferredTags(arg1:Array<string>):Promise<model.TagEditBatch>;
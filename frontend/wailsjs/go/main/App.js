@@ -6,6 +6,18 @@ export function AddFilesystemSource(arg1, arg2, arg3, arg4) {
   return window['go']['main']['App']['AddFilesystemSource'](arg1, arg2, arg3, arg4);
 }
 
+export function AddJellyfinSource(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['App']['AddJellyfinSource'](arg1, arg2, arg3, arg4, arg5);
+}
+
+export function AddSubsonicSource(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['App']['AddSubsonicSource'](arg1, arg2, arg3, arg4, arg5);
+}
+
+export function ApplyTagEdits(arg1, arg2) {
+  return window['go']['main']['App']['ApplyTagEdits'](arg1, arg2);
+}
+
 export function AudioFileMiddleware(arg1) {
   return window['go']['main']['App']['AudioFileMiddleware'](arg1);
 }
@@ -14,6 +26,14 @@ export function BrowseDirectory(arg1, arg2) {
   return window['go']['main']['App']['BrowseDirectory'](arg1, arg2);
 }
 
+export function CancelTrackAnalysis() {
+  return window['go']['main']['App']['CancelTrackAnalysis']();
+}
+
+export function CleanArtworkCache() {
+  return window['go']['main']['App']['CleanArtworkCache']();
+}
+
 export function GetAllScanProgress() {
   return window['go']['main']['App']['GetAllScanProgress']();
 }
@@ -22,10 +42,38 @@ export function GetAllTracks() {
   return window['go']['main']['App']['GetAllTracks']();
 }
 
+export function GetArtworkCacheConfig() {
+  return window['go']['main']['App']['GetArtworkCacheConfig']();
+}
+
+export function GetArtworkCacheStats() {
+  return window['go']['main']['App']['GetArtworkCacheStats']();
+}
+
+export function GetDLNAConfig() {
+  return window['go']['main']['App']['GetDLNAConfig']();
+}
+
+export function GetPlaybackConfig() {
+  return window['go']['main']['App']['GetPlaybackConfig']();
+}
+
 export function GetScanProgress(arg1) {
   return window['go']['main']['App']['GetScanProgress'](arg1);
 }
 
+export function GetScrobbleStatus() {
+  return window['go']['main']['App']['GetScrobbleStatus']();
+}
+
+export function GetScrobblerConfig() {
+  return window['go']['main']['App']['GetScrobblerConfig']();
+}
+
+export function GetServerStatus() {
+  return window['go']['main']['App']['GetServerStatus']();
+}
+
 export function GetSourceConfig(arg1) {
   return window['go']['main']['App']['GetSourceConfig'](arg1);
 }
@@ -38,14 +86,26 @@ export function GetSources() {
   return window['go']['main']['App']['GetSources']();
 }
 
+export function GetSubsonicServerConfig() {
+  return window['go']['main']['App']['GetSubsonicServerConfig']();
+}
+
 export function GetSupportedFormats() {
   return window['go']['main']['App']['GetSupportedFormats']();
 }
 
+export function GetTagEditHistory() {
+  return window['go']['main']['App']['GetTagEditHistory']();
+}
+
 export function GetTrack(arg1) {
   return window['go']['main']['App']['GetTrack'](arg1);
 }
 
+export function GetTrackAnalysisProgress() {
+  return window['go']['main']['App']['GetTrackAnalysisProgress']();
+}
+
 export function GetTrackFilePath(arg1) {
   return window['go']['main']['App']['GetTrackFilePath'](arg1);
 }
@@ -58,6 +118,22 @@ export function GetTracksByArtist(arg1) {
   return window['go']['main']['App']['GetTracksByArtist'](arg1);
 }
 
+export function OrganizeLibrary(arg1, arg2, arg3) {
+  return window['go']['main']['App']['OrganizeLibrary'](arg1, arg2, arg3);
+}
+
+export function PreviewOrganizeLibrary(arg1, arg2, arg3) {
+  return window['go']['main']['App']['PreviewOrganizeLibrary'](arg1, arg2, arg3);
+}
+
+export function PreviewTagEdits(arg1, arg2) {
+  return window['go']['main']['App']['PreviewTagEdits'](arg1, arg2);
+}
+
+export function QueryTracks(arg1) {
+  return window['go']['main']['App']['QueryTracks'](arg1);
+}
+
 export function RemoveSource(arg1) {
   return window['go']['main']['App']['RemoveSource'](arg1);
 }
@@ -70,6 +146,10 @@ export function ScanLibrary(arg1) {
   return window['go']['main']['App']['ScanLibrary'](arg1);
 }
 
+export function ScrobbleNowPlaying(arg1) {
+  return window['go']['main']['App']['ScrobbleNowPlaying'](arg1);
+}
+
 export function SearchTracks(arg1) {
   return window['go']['main']['App']['SearchTracks'](arg1);
 }
@@ -78,6 +158,54 @@ export function SelectDirectory() {
   return window['go']['main']['App']['SelectDirectory']();
 }
 
+export function SetSourceArtworkSettings(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetSourceArtworkSettings'](arg1, arg2, arg3);
+}
+
+export function SetSourceNamingPatterns(arg1, arg2) {
+  return window['go']['main']['App']['SetSourceNamingPatterns'](arg1, arg2);
+}
+
+export function StartTrackAnalysis() {
+  return window['go']['main']['App']['StartTrackAnalysis']();
+}
+
+export function SubmitPlay(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SubmitPlay'](arg1, arg2, arg3);
+}
+
+export function UndoTagEdits(arg1) {
+  return window['go']['main']['App']['UndoTagEdits'](arg1);
+}
+
+export function UpdateArtworkCacheConfig(arg1) {
+  return window['go']['main']['App']['UpdateArtworkCacheConfig'](arg1);
+}
+
+export function UpdateDLNAConfig(arg1) {
+  return window['go']['main']['App']['UpdateDLNAConfig'](arg1);
+}
+
 export function UpdateFilesystemSource(arg1, arg2, arg3, arg4, arg5) {
   return window['go']['main']['App']['UpdateFilesystemSource'](arg1, arg2, arg3, arg4, arg5);
 }
+
+export function UpdatePlaybackConfig(arg1) {
+  return window['go']['main']['App']['UpdatePlaybackConfig'](arg1);
+}
+
+export function UpdateScrobblerConfig(arg1) {
+  return window['go']['main']['App']['UpdateScrobblerConfig'](arg1);
+}
+
+export function UpdateSubsonicServerConfig(arg1) {
+  return window['go']['main']['App']['UpdateSubsonicServerConfig'](arg1);
+}
+
+export function UpdateTrackMetadata(arg1, arg2) {
+  return window['go']['main']['App']['UpdateTrackMetadata'](arg1, arg2);
+}
+
+export function WriteInferredTags(arg1) {
+  return window['go']['main']['App']['WriteInferredTags'](arg1);
+}
@@ -75,6 +75,54 @@ export namespace dto {
 	        this.errors = source["errors"];
 	    }
 	}
+	export class ScrobbleStatusDTO {
+	    enabled: boolean;
+	    service: string;
+	    queued: number;
+	    submitted: number;
+	    lastSubmittedAt?: number;
+	    lastError?: string;
+	    nextRetryAt?: number;
+	    authFailed: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ScrobbleStatusDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.service = source["service"];
+	        this.queued = source["queued"];
+	        this.submitted = source["submitted"];
+	        this.lastSubmittedAt = source["lastSubmittedAt"];
+	        this.lastError = source["lastError"];
+	        this.nextRetryAt = source["nextRetryAt"];
+	        this.authFailed = source["authFailed"];
+	    }
+	}
+	export class ServerStatusDTO {
+	    subsonicRunning: boolean;
+	    subsonicAddress?: string;
+	    subsonicError?: string;
+	    dlnaRunning: boolean;
+	    dlnaAddress?: string;
+	    dlnaError?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ServerStatusDTO(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.subsonicRunning = source["subsonicRunning"];
+	        this.subsonicAddress = source["subsonicAddress"];
+	        this.subsonicError = source["subsonicError"];
+	        this.dlnaRunning = source["dlnaRunning"];
+	        this.dlnaAddress = source["dlnaAddress"];
+	        this.dlnaError = source["dlnaError"];
+	    }
+	}
 	export class SourceDTO {
 	    id: string;
 	    name: string;
@@ -111,7 +159,33 @@ export namespace dto {
 	    format?: string;
 	    bitRate?: number;
 	    sampleRate?: number;
+	    codec?: string;
+	    bitDepth?: number;
+	    lossless?: boolean;
+	    hiRes?: boolean;
 	    hasArtwork: boolean;
+	    musicBrainzTrackId?: string;
+	    musicBrainzAlbumId?: string;
+	    musicBrainzReleaseGroupId?: string;
+	    musicBrainzArtistId?: string;
+	    inferredFields?: string[];
+	    unplayableReason?: string;
+	    channels?: number;
+	    channelMode?: string;
+	    vbr?: boolean;
+	    totalSamples?: number;
+	    encoderDelay?: number;
+	    encoderPadding?: number;
+	    trackGain?: number;
+	    trackPeak?: number;
+	    albumGain?: number;
+	    albumPeak?: number;
+	    loudness?: number;
+	    truePeak?: number;
+	    loudnessRange?: number;
+	    bpm?: number;
+	    key?: string;
+	    camelot?: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new TrackDTO(source);
@@ -138,7 +212,33 @@ export namespace dto {
 	        this.format = source["format"];
 	        this.bitRate = source["bitRate"];
 	        this.sampleRate = source["sampleRate"];
+	        this.codec = source["codec"];
+	        this.bitDepth = source["bitDepth"];
+	        this.lossless = source["lossless"];
+	        this.hiRes = source["hiRes"];
 	        this.hasArtwork = source["hasArtwork"];
+	        this.musicBrainzTrackId = source["musicBrainzTrackId"];
+	        this.musicBrainzAlbumId = source["musicBrainzAlbumId"];
+	        this.musicBrainzReleaseGroupId = source["musicBrainzReleaseGroupId"];
+	        this.musicBrainzArtistId = source["musicBrainzArtistId"];
+	        this.inferredFields = source["inferredFields"];
+	        this.unplayableReason = source["unplayableReason"];
+	        this.channels = source["channels"];
+	        this.channelMode = source["channelMode"];
+	        this.vbr = source["vbr"];
+	        this.totalSamples = source["totalSamples"];
+	        this.encoderDelay = source["encoderDelay"];
+	        this.encoderPadding = source["encoderPadding"];
+	        this.trackGain = source["trackGain"];
+	        this.trackPeak = source["trackPeak"];
+	        this.albumGain = source["albumGain"];
+	        this.albumPeak = source["albumPeak"];
+	        this.loudness = source["loudness"];
+	        this.truePeak = source["truePeak"];
+	        this.loudnessRange = source["loudnessRange"];
+	        this.bpm = source["bpm"];
+	        this.key = source["key"];
+	        this.camelot = source["camelot"];
 	    }
 	}
 
@@ -146,6 +246,169 @@ export namespace dto {
 
 export namespace model {
 	
+	export class ArtworkCacheConfig {
+	    maxSizeMB: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ArtworkCacheConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.maxSizeMB = source["maxSizeMB"];
+	    }
+	}
+	export class ArtworkCacheStats {
+	    files: number;
+	    bytes: number;
+	    maxBytes: number;
+	    removedFiles: number;
+	    freedBytes: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ArtworkCacheStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.files = source["files"];
+	        this.bytes = source["bytes"];
+	        this.maxBytes = source["maxBytes"];
+	        this.removedFiles = source["removedFiles"];
+	        this.freedBytes = source["freedBytes"];
+	    }
+	}
+	export class DLNAConfig {
+	    enabled: boolean;
+	    friendlyName: string;
+	    listenAddress: string;
+	    interface?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DLNAConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.friendlyName = source["friendlyName"];
+	        this.listenAddress = source["listenAddress"];
+	        this.interface = source["interface"];
+	    }
+	}
+	export class FileMove {
+	    from: string;
+	    to: string;
+	    trackIds?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new FileMove(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.from = source["from"];
+	        this.to = source["to"];
+	        this.trackIds = source["trackIds"];
+	    }
+	}
+	export class OrganizeSkip {
+	    trackId?: string;
+	    filePath: string;
+	    reason: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new OrganizeSkip(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.trackId = source["trackId"];
+	        this.filePath = source["filePath"];
+	        this.reason = source["reason"];
+	    }
+	}
+	export class OrganizePlan {
+	    sourceId: string;
+	    template: string;
+	    moves: FileMove[];
+	    skipped: OrganizeSkip[];
+	
+	    static createFrom(source: any = {}) {
+	        return new OrganizePlan(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sourceId = source["sourceId"];
+	        this.template = source["template"];
+	        this.moves = this.convertValues(source["moves"], FileMove);
+	        this.skipped = this.convertValues(source["skipped"], OrganizeSkip);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class PlaybackConfig {
+	    normalizationMode: string;
+	    pregenerateWaveforms: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new PlaybackConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.normalizationMode = source["normalizationMode"];
+	        this.pregenerateWaveforms = source["pregenerateWaveforms"];
+	    }
+	}
+	export class ScrobblerConfig {
+	    enabled: boolean;
+	    service: string;
+	    baseUrl: string;
+	    timeout: number;
+	    token?: string;
+	    apiKey?: string;
+	    apiSecret?: string;
+	    sessionKey?: string;
+	    username?: string;
+	    password?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ScrobblerConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.service = source["service"];
+	        this.baseUrl = source["baseUrl"];
+	        this.timeout = source["timeout"];
+	        this.token = source["token"];
+	        this.apiKey = source["apiKey"];
+	        this.apiSecret = source["apiSecret"];
+	        this.sessionKey = source["sessionKey"];
+	        this.username = source["username"];
+	        this.password = source["password"];
+	    }
+	}
 	export class SourceConfiguration {
 	    id: string;
 	    name: string;
@@ -190,6 +453,227 @@ export namespace model {
 		    return a;
 		}
 	}
+	export class SubsonicServerConfig {
+	    enabled: boolean;
+	    listenAddress: string;
+	    username: string;
+	    password: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SubsonicServerConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.listenAddress = source["listenAddress"];
+	        this.username = source["username"];
+	        this.password = source["password"];
+	    }
+	}
+	export class TagChanges {
+	    title?: string;
+	    artist?: string;
+	    album?: string;
+	    albumArtist?: string;
+	    genre?: string;
+	    year?: number;
+	    trackNumber?: number;
+	    discNumber?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TagChanges(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.title = source["title"];
+	        this.artist = source["artist"];
+	        this.album = source["album"];
+	        this.albumArtist = source["albumArtist"];
+	        this.genre = source["genre"];
+	        this.year = source["year"];
+	        this.trackNumber = source["trackNumber"];
+	        this.discNumber = source["discNumber"];
+	    }
+	}
+	export class TagFieldChange {
+	    field: string;
+	    old: string;
+	    new: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new TagFieldChange(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.field = source["field"];
+	        this.old = source["old"];
+	        this.new = source["new"];
+	    }
+	}
+	export class TagEditEntry {
+	    trackId: string;
+	    filePath: string;
+	    changes: TagFieldChange[];
+	    applied: boolean;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new TagEditEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.trackId = source["trackId"];
+	        this.filePath = source["filePath"];
+	        this.changes = this.convertValues(source["changes"], TagFieldChange);
+	        this.applied = source["applied"];
+	        this.error = source["error"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class TagEditBatch {
+	    id: string;
+	    // Go type: time
+	    createdAt: any;
+	    // Go type: time
+	    undoneAt?: any;
+	    entries: TagEditEntry[];
+	
+	    static createFrom(source: any = {}) {
+	        return new TagEditBatch(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	        this.undoneAt = this.convertValues(source["undoneAt"], null);
+	        this.entries = this.convertValues(source["entries"], TagEditEntry);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class TagEditPreview {
+	    trackId: string;
+	    filePath: string;
+	    changes: TagFieldChange[];
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new TagEditPreview(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.trackId = source["trackId"];
+	        this.filePath = source["filePath"];
+	        this.changes = this.convertValues(source["changes"], TagFieldChange);
+	        this.error = source["error"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class TagOperation {
+	    field: string;
+	    type: string;
+	    value?: string;
+	    find?: string;
+	    replace?: string;
+	    start?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TagOperation(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.field = source["field"];
+	        this.type = source["type"];
+	        this.value = source["value"];
+	        this.find = source["find"];
+	        this.replace = source["replace"];
+	        this.start = source["start"];
+	    }
+	}
+
+}
+
+export namespace repository {
+	
+	export class QueryOptions {
+	    limit: number;
+	    offset: number;
+	    sortBy: string;
+	    sortOrder: string;
+	    filters: Record<string, any>;
+	
+	    static createFrom(source: any = {}) {
+	        return new QueryOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.limit = source["limit"];
+	        this.offset = source["offset"];
+	        this.sortBy = source["sortBy"];
+	        this.sortOrder = source["sortOrder"];
+	        this.filters = source["filters"];
+	    }
+	}
 
 }
 
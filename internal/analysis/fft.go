@@ -0,0 +1,63 @@
+package analysis
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// fft is a radix-2 FFT for a fixed power-of-two size with a Hann window
+type fft struct {
+	size    int
+	window  []float64
+	twiddle []complex128
+	buf     []complex128
+	mags    []float64
+}
+
+func newFFT(size int) *fft {
+	f := &fft{
+		size:    size,
+		window:  make([]float64, size),
+		twiddle: make([]complex128, size/2),
+		buf:     make([]complex128, size),
+		mags:    make([]float64, size/2+1),
+	}
+	for i := range f.window {
+		f.window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(size))
+	}
+	for i := range f.twiddle {
+		f.twiddle[i] = cmplx.Exp(complex(0, -2*math.Pi*float64(i)/float64(size)))
+	}
+	return f
+}
+
+// magnitudes windows frame (len size) and returns the magnitude of bins 0..size/2
+// The returned slice is reused by the next call
+func (f *fft) magnitudes(frame []float64) []float64 {
+	n := f.size
+	for i, j := 0, 0; i < n; i++ {
+		f.buf[j] = complex(frame[i]*f.window[i], 0)
+		// Bit-reversed increment of j
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j |= bit
+	}
+
+	for size := 2; size <= n; size <<= 1 {
+		half, step := size/2, n/size
+		for start := 0; start < n; start += size {
+			for k := 0; k < half; k++ {
+				t := f.twiddle[k*step] * f.buf[start+k+half]
+				f.buf[start+k+half] = f.buf[start+k] - t
+				f.buf[start+k] += t
+			}
+		}
+	}
+
+	for i := range f.mags {
+		f.mags[i] = cmplx.Abs(f.buf[i])
+	}
+	return f.mags
+}
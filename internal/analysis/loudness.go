@@ -0,0 +1,268 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+
+	"GoMusic/internal/domain/model"
+)
+
+// EBU R128 / ITU-R BS.1770-4 constants
+const (
+	absoluteGate        = model.LoudnessGate
+	relativeGate        = -10.0 // LU below the ungated integrated loudness
+	rangeRelativeGate   = -20.0 // LU, EBU Tech 3342
+	segmentsPerBlock    = 4     // 400 ms momentary blocks built from 100 ms segments
+	segmentsPerShort    = 30    // 3 s short-term windows for loudness range
+	shortTermHop        = 10    // short-term windows start every second
+	rangeLowPercentile  = 0.10
+	rangeHighPercentile = 0.95
+)
+
+// LoudnessMeter measures integrated loudness, loudness range and true peak
+// Samples are accumulated in 100 ms segments so album values can be derived by
+// pooling the segments of several tracks
+type LoudnessMeter struct {
+	channels int
+	weights  []float64
+	filters  []kWeighting
+
+	segmentSamples int
+	segmentPos     int
+	segmentEnergy  float64
+	segments       []float64 // mean weighted square per 100 ms segment
+
+	peak *truePeakMeter
+}
+
+// NewLoudnessMeter creates a meter for interleaved samples
+// Channels follow the FLAC/WAV order, such as L, R, C, LFE, Ls, Rs for 5.1
+func NewLoudnessMeter(sampleRate, channels int) *LoudnessMeter {
+	m := &LoudnessMeter{
+		channels:       channels,
+		weights:        make([]float64, channels),
+		filters:        make([]kWeighting, channels),
+		segmentSamples: sampleRate / 10,
+		peak:           newTruePeakMeter(sampleRate, channels),
+	}
+
+	for ch := 0; ch < channels; ch++ {
+		m.filters[ch] = newKWeighting(float64(sampleRate))
+		m.weights[ch] = channelWeight(channels, ch)
+	}
+
+	return m
+}
+
+// channelWeight returns the BS.1770 weight of a channel in the default WAV
+// layout for the channel count: quad (L, R, Ls, Rs), 5.0 (L, R, C, Ls, Rs), and
+// 5.1 to 7.1 with the LFE fourth
+func channelWeight(channels, ch int) float64 {
+	switch {
+	case channels >= 6 && ch == 3:
+		return 0 // LFE is excluded
+	case channels == 4 && ch >= 2, channels == 5 && ch >= 3, channels >= 6 && ch >= 4:
+		return 1.41 // surround channels
+	default:
+		return 1
+	}
+}
+
+// Process feeds interleaved samples to the meter
+func (m *LoudnessMeter) Process(samples []float64) {
+	m.peak.process(samples)
+
+	for i := 0; i+m.channels <= len(samples); i += m.channels {
+		var energy float64
+		for ch := 0; ch < m.channels; ch++ {
+			if m.weights[ch] == 0 {
+				continue
+			}
+			y := m.filters[ch].process(samples[i+ch])
+			energy += m.weights[ch] * y * y
+		}
+
+		m.segmentEnergy += energy
+		m.segmentPos++
+		if m.segmentPos == m.segmentSamples {
+			m.segments = append(m.segments, m.segmentEnergy/float64(m.segmentSamples))
+			m.segmentEnergy = 0
+			m.segmentPos = 0
+		}
+	}
+}
+
+// Result returns the measurements for the audio processed so far
+func (m *LoudnessMeter) Result() *model.Loudness {
+	return measure([]*LoudnessMeter{m})
+}
+
+// AlbumLoudness pools the meters of an album's tracks into one measurement
+func AlbumLoudness(meters []*LoudnessMeter) *model.Loudness {
+	return measure(meters)
+}
+
+// measure computes gated loudness over the segments of all meters
+func measure(meters []*LoudnessMeter) *model.Loudness {
+	var blocks, shortTerm []float64
+	peak := 0.0
+	for _, m := range meters {
+		blocks = append(blocks, windows(m.segments, segmentsPerBlock, 1)...)
+		shortTerm = append(shortTerm, windows(m.segments, segmentsPerShort, shortTermHop)...)
+		peak = math.Max(peak, m.peak.max)
+	}
+
+	result := &model.Loudness{
+		Integrated: gatedLoudness(blocks, relativeGate),
+		Range:      loudnessRange(shortTerm),
+		TruePeak:   math.Inf(-1),
+	}
+	if peak > 0 {
+		result.TruePeak = 20 * math.Log10(peak)
+	}
+	if math.IsInf(result.Integrated, -1) {
+		// Silence; report the gate rather than -Inf, which JSON cannot encode
+		result.Integrated = absoluteGate
+	}
+	if math.IsInf(result.TruePeak, -1) {
+		result.TruePeak = absoluteGate
+	}
+	return result
+}
+
+// windows returns the mean energy of each window of size segments, stepping by hop
+func windows(segments []float64, size, hop int) []float64 {
+	var energies []float64
+	for start := 0; start+size <= len(segments); start += hop {
+		var sum float64
+		for _, e := range segments[start : start+size] {
+			sum += e
+		}
+		energies = append(energies, sum/float64(size))
+	}
+	return energies
+}
+
+// gatedLoudness applies the absolute and relative gates and returns the loudness
+// of the remaining blocks
+func gatedLoudness(energies []float64, relative float64) float64 {
+	gated := gate(energies, relative)
+	if len(gated) == 0 {
+		return math.Inf(-1)
+	}
+	return energyToLUFS(mean(gated))
+}
+
+// loudnessRange is the spread between the 10th and 95th percentile of gated short-term loudness
+func loudnessRange(energies []float64) float64 {
+	gated := gate(energies, rangeRelativeGate)
+	if len(gated) < 2 {
+		return 0
+	}
+
+	values := make([]float64, len(gated))
+	for i, e := range gated {
+		values[i] = energyToLUFS(e)
+	}
+	sort.Float64s(values)
+
+	return percentile(values, rangeHighPercentile) - percentile(values, rangeLowPercentile)
+}
+
+// gate drops blocks below the absolute gate and then below the relative gate
+func gate(energies []float64, relative float64) []float64 {
+	var aboveAbsolute []float64
+	for _, e := range energies {
+		if energyToLUFS(e) > absoluteGate {
+			aboveAbsolute = append(aboveAbsolute, e)
+		}
+	}
+	if len(aboveAbsolute) == 0 {
+		return nil
+	}
+
+	threshold := energyToLUFS(mean(aboveAbsolute)) + relative
+	var gated []float64
+	for _, e := range aboveAbsolute {
+		if energyToLUFS(e) > threshold {
+			gated = append(gated, e)
+		}
+	}
+	return gated
+}
+
+func energyToLUFS(energy float64) float64 {
+	return -0.691 + 10*math.Log10(energy)
+}
+
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// percentile returns the nearest-rank percentile of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	index := int(math.Round(p * float64(len(sorted)-1)))
+	return sorted[index]
+}
+
+// kWeighting is the BS.1770 pre-filter: a high shelf followed by a high pass
+type kWeighting struct {
+	shelf, highPass biquad
+}
+
+// newKWeighting derives the filter coefficients for a sample rate
+// The reference coefficients are specified at 48 kHz; these are the analog
+// prototypes re-discretised for fs
+func newKWeighting(fs float64) kWeighting {
+	// Stage 1: high shelf
+	f0 := 1681.974450955533
+	gain := 3.999843853973347
+	q := 0.7071752369554196
+	k := math.Tan(math.Pi * f0 / fs)
+	vh := math.Pow(10, gain/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1 + k/q + k*k
+	shelf := biquad{
+		b0: (vh + vb*k/q + k*k) / a0,
+		b1: 2 * (k*k - vh) / a0,
+		b2: (vh - vb*k/q + k*k) / a0,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	// Stage 2: high pass
+	f0 = 38.13547087602444
+	q = 0.5003270373238773
+	k = math.Tan(math.Pi * f0 / fs)
+	a0 = 1 + k/q + k*k
+	highPass := biquad{
+		b0: 1,
+		b1: -2,
+		b2: 1,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	return kWeighting{shelf: shelf, highPass: highPass}
+}
+
+func (k *kWeighting) process(x float64) float64 {
+	return k.highPass.process(k.shelf.process(x))
+}
+
+// biquad is a direct form II transposed second-order section
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	z1, z2             float64
+}
+
+func (f *biquad) process(x float64) float64 {
+	y := f.b0*x + f.z1
+	f.z1 = f.b1*x - f.a1*y + f.z2
+	f.z2 = f.b2*x - f.a2*y
+	return y
+}
@@ -0,0 +1,144 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+
+	"GoMusic/internal/domain/model"
+)
+
+// sine returns interleaved samples of a sine wave in every channel, level
+// dBFS of peak amplitude, starting at phase radians
+func sine(sampleRate, channels int, freq, level, phase, seconds float64) []float64 {
+	amplitude := math.Pow(10, level/20)
+	frames := int(seconds * float64(sampleRate))
+	samples := make([]float64, frames*channels)
+	for i := 0; i < frames; i++ {
+		v := amplitude * math.Sin(2*math.Pi*freq*float64(i)/float64(sampleRate)+phase)
+		for ch := 0; ch < channels; ch++ {
+			samples[i*channels+ch] = v
+		}
+	}
+	return samples
+}
+
+// Reference signals and results from EBU Tech 3341 and 3342
+func TestLoudnessMeter(t *testing.T) {
+	tests := []struct {
+		name           string
+		sampleRate     int
+		channels       int
+		signal         [][]float64 // fed to the meter in order
+		wantIntegrated float64
+		wantPeak       float64
+		wantRange      float64
+		tolerance      float64 // for integrated loudness and range
+		peakTolerance  float64
+	}{
+		{
+			name:           "stereo 1 kHz at -23 dBFS",
+			sampleRate:     48000,
+			channels:       2,
+			signal:         [][]float64{sine(48000, 2, 1000, -23, 0, 20)},
+			wantIntegrated: -23,
+			wantPeak:       -23,
+			tolerance:      0.1,
+			peakTolerance:  0.2,
+		},
+		{
+			name:           "stereo 1 kHz at -33 dBFS",
+			sampleRate:     44100,
+			channels:       2,
+			signal:         [][]float64{sine(44100, 2, 1000, -33, 0, 20)},
+			wantIntegrated: -33,
+			wantPeak:       -33,
+			tolerance:      0.1,
+			peakTolerance:  0.2,
+		},
+		{
+			name:           "mono 1 kHz at -20 dBFS",
+			sampleRate:     48000,
+			channels:       1,
+			signal:         [][]float64{sine(48000, 1, 1000, -20, 0, 20)},
+			wantIntegrated: -23,
+			wantPeak:       -20,
+			tolerance:      0.1,
+			peakTolerance:  0.2,
+		},
+		{
+			// Samples land at ±0.707, so only an oversampling meter sees the 0 dBTP peak
+			name:           "quarter sample rate sine between samples",
+			sampleRate:     48000,
+			channels:       2,
+			signal:         [][]float64{sine(48000, 2, 12000, 0, math.Pi/4, 5)},
+			wantIntegrated: math.NaN(),
+			wantPeak:       0,
+			peakTolerance:  0.4,
+		},
+		{
+			name:       "loudness range of two levels",
+			sampleRate: 48000,
+			channels:   2,
+			signal: [][]float64{
+				sine(48000, 2, 1000, -20, 0, 20),
+				sine(48000, 2, 1000, -30, 0, 20),
+			},
+			wantIntegrated: math.NaN(),
+			wantPeak:       -20,
+			wantRange:      10,
+			tolerance:      1,
+			peakTolerance:  0.2,
+		},
+		{
+			name:           "silence",
+			sampleRate:     48000,
+			channels:       2,
+			signal:         [][]float64{make([]float64, 48000*2*5)},
+			wantIntegrated: model.LoudnessGate,
+			wantPeak:       model.LoudnessGate,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewLoudnessMeter(tt.sampleRate, tt.channels)
+			for _, samples := range tt.signal {
+				// Feed the signal in uneven buffers, as a decoder would
+				for len(samples) > 0 {
+					n := min(len(samples), 4093*tt.channels)
+					m.Process(samples[:n])
+					samples = samples[n:]
+				}
+			}
+			result := m.Result()
+
+			if !math.IsNaN(tt.wantIntegrated) && math.Abs(result.Integrated-tt.wantIntegrated) > tt.tolerance {
+				t.Errorf("integrated = %.2f LUFS, want %.2f ± %.2f", result.Integrated, tt.wantIntegrated, tt.tolerance)
+			}
+			if math.Abs(result.TruePeak-tt.wantPeak) > tt.peakTolerance {
+				t.Errorf("true peak = %.2f dBTP, want %.2f ± %.2f", result.TruePeak, tt.wantPeak, tt.peakTolerance)
+			}
+			if math.Abs(result.Range-tt.wantRange) > max(tt.tolerance, 0.1) {
+				t.Errorf("range = %.2f LU, want %.2f", result.Range, tt.wantRange)
+			}
+		})
+	}
+}
+
+func TestAlbumLoudness(t *testing.T) {
+	// Two tracks at different levels pool to the loudness of their combined
+	// energy rather than the mean of their values
+	loud := NewLoudnessMeter(48000, 2)
+	loud.Process(sine(48000, 2, 1000, -20, 0, 10))
+	quiet := NewLoudnessMeter(48000, 2)
+	quiet.Process(sine(48000, 2, 1000, -26, 0, 10))
+
+	want := -20 + 10*math.Log10((1+math.Pow(10, -0.6))/2)
+	album := AlbumLoudness([]*LoudnessMeter{loud, quiet})
+	if math.Abs(album.Integrated-want) > 0.1 {
+		t.Errorf("album integrated = %.2f LUFS, want %.2f", album.Integrated, want)
+	}
+	if math.Abs(album.TruePeak-(-20)) > 0.2 {
+		t.Errorf("album true peak = %.2f dBTP, want -20", album.TruePeak)
+	}
+}
@@ -0,0 +1,266 @@
+package analysis
+
+import (
+	"math"
+
+	"GoMusic/internal/domain/model"
+)
+
+// Tempo and key estimation parameters
+const (
+	tonalSampleRate = 11025.0 // audio is decimated to about this rate first
+
+	onsetFrameSize = 512 // ~46 ms spectral flux frames
+	onsetHop       = 64  // ~6 ms envelope resolution
+
+	chromaFrameSize = 4096 // ~2.7 Hz bins, fine enough to separate low semitones
+	chromaHop       = 2048
+	chromaMinFreq   = 65.0   // C2
+	chromaMaxFreq   = 2100.0 // C7
+
+	minBPM        = 60.0
+	maxBPM        = 200.0
+	preferredBPM  = 120.0 // centre of the tempo prior
+	minTempoAudio = 10.0  // seconds of audio needed for a tempo estimate
+)
+
+// Krumhansl-Kessler key profiles, starting from the tonic
+var (
+	majorProfile = [12]float64{6.35, 2.23, 3.48, 2.33, 4.38, 4.09, 2.52, 5.19, 2.39, 3.66, 2.29, 2.88}
+	minorProfile = [12]float64{6.33, 2.68, 3.52, 5.38, 2.60, 3.53, 2.54, 4.75, 3.98, 2.69, 3.34, 3.17}
+)
+
+// TempoKeyMeter estimates the tempo and musical key of a track
+// Tempo comes from the autocorrelation of a spectral flux onset envelope and key
+// from correlating the track's chromagram with major and minor key profiles
+type TempoKeyMeter struct {
+	channels   int
+	decimation int
+	rate       float64 // after decimation
+	lowpass    [2]biquad
+	phase      int
+
+	samples   []float64 // decimated mono samples not yet consumed by both analyses
+	onsetPos  int
+	chromaPos int
+
+	onsetFFT     *fft
+	prevSpectrum []float64
+	envelope     []float64
+
+	chromaFFT   *fft
+	pitchClass  []int // pitch class of each chroma bin, -1 outside the range
+	chromaTotal [12]float64
+}
+
+// NewTempoKeyMeter creates a meter for interleaved samples
+func NewTempoKeyMeter(sampleRate, channels int) *TempoKeyMeter {
+	decimation := max(1, int(math.Round(float64(sampleRate)/tonalSampleRate)))
+	rate := float64(sampleRate) / float64(decimation)
+
+	m := &TempoKeyMeter{
+		channels:     channels,
+		decimation:   decimation,
+		rate:         rate,
+		onsetFFT:     newFFT(onsetFrameSize),
+		prevSpectrum: make([]float64, onsetFrameSize/2+1),
+		chromaFFT:    newFFT(chromaFrameSize),
+		pitchClass:   make([]int, chromaFrameSize/2+1),
+	}
+
+	// Anti-aliasing before decimation: two Butterworth sections
+	for i := range m.lowpass {
+		m.lowpass[i] = newLowpass(float64(sampleRate), 0.4*rate, []float64{0.5412, 1.3066}[i])
+	}
+
+	for bin := range m.pitchClass {
+		freq := float64(bin) * rate / chromaFrameSize
+		if freq < chromaMinFreq || freq > chromaMaxFreq {
+			m.pitchClass[bin] = -1
+			continue
+		}
+		midi := 69 + 12*math.Log2(freq/440)
+		m.pitchClass[bin] = ((int(math.Round(midi)) % 12) + 12) % 12
+	}
+
+	return m
+}
+
+// Process feeds interleaved samples to the meter
+func (m *TempoKeyMeter) Process(samples []float64) {
+	for i := 0; i+m.channels <= len(samples); i += m.channels {
+		var mono float64
+		for _, s := range samples[i : i+m.channels] {
+			mono += s
+		}
+		mono /= float64(m.channels)
+
+		if m.decimation > 1 {
+			mono = m.lowpass[1].process(m.lowpass[0].process(mono))
+		}
+		m.phase++
+		if m.phase == m.decimation {
+			m.phase = 0
+			m.samples = append(m.samples, mono)
+		}
+	}
+
+	for ; m.onsetPos+onsetFrameSize <= len(m.samples); m.onsetPos += onsetHop {
+		m.processOnset(m.samples[m.onsetPos : m.onsetPos+onsetFrameSize])
+	}
+	for ; m.chromaPos+chromaFrameSize <= len(m.samples); m.chromaPos += chromaHop {
+		m.processChroma(m.samples[m.chromaPos : m.chromaPos+chromaFrameSize])
+	}
+
+	// Drop samples both analyses are done with
+	consumed := min(m.onsetPos, m.chromaPos)
+	m.samples = append(m.samples[:0], m.samples[consumed:]...)
+	m.onsetPos -= consumed
+	m.chromaPos -= consumed
+}
+
+// processOnset appends the spectral flux of one frame to the onset envelope
+func (m *TempoKeyMeter) processOnset(frame []float64) {
+	var flux float64
+	for bin, mag := range m.onsetFFT.magnitudes(frame) {
+		compressed := math.Log1p(100 * mag)
+		if diff := compressed - m.prevSpectrum[bin]; diff > 0 {
+			flux += diff
+		}
+		m.prevSpectrum[bin] = compressed
+	}
+	m.envelope = append(m.envelope, flux)
+}
+
+// processChroma adds one frame's magnitude per pitch class to the chromagram
+func (m *TempoKeyMeter) processChroma(frame []float64) {
+	for bin, mag := range m.chromaFFT.magnitudes(frame) {
+		if pc := m.pitchClass[bin]; pc >= 0 {
+			m.chromaTotal[pc] += mag
+		}
+	}
+}
+
+// Tempo returns the estimated tempo in BPM rounded to 0.1, or 0 when the track
+// is too short or has no rhythmic content
+// Near the ends of the range the result may be half or double the felt tempo
+func (m *TempoKeyMeter) Tempo() float64 {
+	envelopeRate := m.rate / onsetHop
+	if float64(len(m.envelope)) < minTempoAudio*envelopeRate {
+		return 0
+	}
+
+	// Remove the slowly varying level so only onsets correlate
+	onsets := make([]float64, len(m.envelope))
+	window := int(envelopeRate / 2)
+	var sum float64
+	for i, v := range m.envelope {
+		sum += v
+		if i >= window {
+			sum -= m.envelope[i-window]
+		}
+		onsets[i] = max(0, v-sum/float64(min(i+1, window)))
+	}
+
+	minLag := int(math.Floor(60 * envelopeRate / maxBPM))
+	maxLag := int(math.Ceil(60 * envelopeRate / minBPM))
+	ac := make([]float64, 2*maxLag+3)
+	for lag := range ac {
+		for i := 0; i+lag < len(onsets); i++ {
+			ac[lag] += onsets[i] * onsets[i+lag]
+		}
+		ac[lag] /= float64(len(onsets) - lag)
+	}
+
+	scores := make([]float64, maxLag+2)
+	best := 0
+	for lag := minLag - 1; lag <= maxLag+1; lag++ {
+		// A real beat period also lines up with its half and double; this
+		// rejects lags such as 1.5 beats that only match off-beat hits
+		score := ac[lag] + 0.5*ac[2*lag] + 0.5*ac[lag/2]
+
+		// Log-normal prior favouring common tempos resolves octave ambiguity
+		bpm := 60 * envelopeRate / float64(lag)
+		octaves := math.Log2(bpm / preferredBPM)
+		scores[lag] = score * math.Exp(-0.5*octaves*octaves)
+
+		if lag >= minLag && lag <= maxLag && (best == 0 || scores[lag] > scores[best]) {
+			best = lag
+		}
+	}
+	if best == 0 || scores[best] <= 0 {
+		return 0
+	}
+
+	// Parabolic interpolation around the peak for sub-frame lag precision
+	lag := float64(best)
+	prev, peak, next := scores[best-1], scores[best], scores[best+1]
+	if denom := prev - 2*peak + next; denom < 0 {
+		lag += 0.5 * (prev - next) / denom
+	}
+
+	return math.Round(600*envelopeRate/lag) / 10
+}
+
+// Key returns the estimated key, or "" when the track has no tonal content
+func (m *TempoKeyMeter) Key() model.MusicalKey {
+	var total float64
+	for _, e := range m.chromaTotal {
+		total += e
+	}
+	if total == 0 {
+		return ""
+	}
+
+	var key model.MusicalKey
+	best := math.Inf(-1)
+	for tonic := 0; tonic < 12; tonic++ {
+		var rotated [12]float64
+		for pc := range rotated {
+			rotated[pc] = m.chromaTotal[(tonic+pc)%12]
+		}
+		if r := correlation(rotated, majorProfile); r > best {
+			best, key = r, model.NewMusicalKey(tonic, false)
+		}
+		if r := correlation(rotated, minorProfile); r > best {
+			best, key = r, model.NewMusicalKey(tonic, true)
+		}
+	}
+	return key
+}
+
+// correlation returns the Pearson correlation of two pitch class vectors
+func correlation(a, b [12]float64) float64 {
+	var meanA, meanB float64
+	for i := range a {
+		meanA += a[i] / 12
+		meanB += b[i] / 12
+	}
+
+	var cov, varA, varB float64
+	for i := range a {
+		da, db := a[i]-meanA, b[i]-meanB
+		cov += da * db
+		varA += da * da
+		varB += db * db
+	}
+	if varA == 0 || varB == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(varA*varB)
+}
+
+// newLowpass returns a second-order low pass section with the given Q
+func newLowpass(fs, cutoff, q float64) biquad {
+	w0 := 2 * math.Pi * cutoff / fs
+	alpha := math.Sin(w0) / (2 * q)
+	cos := math.Cos(w0)
+	a0 := 1 + alpha
+	return biquad{
+		b0: (1 - cos) / 2 / a0,
+		b1: (1 - cos) / a0,
+		b2: (1 - cos) / 2 / a0,
+		a1: -2 * cos / a0,
+		a2: (1 - alpha) / a0,
+	}
+}
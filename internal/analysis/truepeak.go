@@ -0,0 +1,103 @@
+package analysis
+
+import "math"
+
+// truePeakTaps is the interpolation filter length per oversampled phase
+const truePeakTaps = 12
+
+// truePeakMeter estimates inter-sample peaks by oversampling, as in BS.1770-4 Annex 2
+// Rates below 96 kHz are oversampled 4x, below 192 kHz 2x
+type truePeakMeter struct {
+	channels int
+	factor   int
+	phases   [][]float64 // polyphase interpolation filter, one set of taps per phase
+	history  [][]float64 // most recent input samples per channel, newest first
+	max      float64
+}
+
+func newTruePeakMeter(sampleRate, channels int) *truePeakMeter {
+	factor := 1
+	switch {
+	case sampleRate < 96000:
+		factor = 4
+	case sampleRate < 192000:
+		factor = 2
+	}
+
+	m := &truePeakMeter{
+		channels: channels,
+		factor:   factor,
+		history:  make([][]float64, channels),
+	}
+	for ch := range m.history {
+		m.history[ch] = make([]float64, truePeakTaps)
+	}
+
+	if factor > 1 {
+		m.phases = interpolationFilter(factor)
+	}
+	return m
+}
+
+// interpolationFilter builds a Hann-windowed sinc low-pass split into polyphase components
+func interpolationFilter(factor int) [][]float64 {
+	length := truePeakTaps * factor
+	center := float64(length-1) / 2
+
+	phases := make([][]float64, factor)
+	for p := range phases {
+		phases[p] = make([]float64, truePeakTaps)
+	}
+
+	for n := 0; n < length; n++ {
+		t := (float64(n) - center) / float64(factor)
+		sinc := 1.0
+		if t != 0 {
+			sinc = math.Sin(math.Pi*t) / (math.Pi * t)
+		}
+		window := 0.5 - 0.5*math.Cos(2*math.Pi*float64(n+1)/float64(length+1))
+		phases[n%factor][n/factor] = sinc * window
+	}
+
+	// Normalise each phase to unity gain at DC
+	for _, taps := range phases {
+		var sum float64
+		for _, h := range taps {
+			sum += h
+		}
+		for i := range taps {
+			taps[i] /= sum
+		}
+	}
+
+	return phases
+}
+
+// process updates the peak with interleaved samples
+func (m *truePeakMeter) process(samples []float64) {
+	for i := 0; i+m.channels <= len(samples); i += m.channels {
+		for ch := 0; ch < m.channels; ch++ {
+			x := samples[i+ch]
+			if abs := math.Abs(x); abs > m.max {
+				m.max = abs
+			}
+			if m.phases == nil {
+				continue
+			}
+
+			history := m.history[ch]
+			copy(history[1:], history[:len(history)-1])
+			history[0] = x
+
+			for _, taps := range m.phases {
+				var y float64
+				for k, h := range taps {
+					y += h * history[k]
+				}
+				if abs := math.Abs(y); abs > m.max {
+					m.max = abs
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package analysis
+
+import (
+	"math"
+)
+
+// WaveformMeter reduces interleaved samples to per-bucket peak and RMS amplitudes
+// All channels are folded together, so the envelope shows the loudest channel
+type WaveformMeter struct {
+	channels        int
+	framesPerBucket int64
+
+	frame  int64
+	peak   float64
+	energy float64
+	count  int
+
+	peaks []float32
+	rms   []float32
+}
+
+// NewWaveformMeter creates a meter that spreads frames over roughly buckets buckets
+// frames may be an estimate; the last bucket absorbs any difference
+func NewWaveformMeter(channels int, frames int64, buckets int) *WaveformMeter {
+	framesPerBucket := (frames + int64(buckets) - 1) / int64(buckets)
+	if framesPerBucket < 1 {
+		framesPerBucket = 1
+	}
+
+	return &WaveformMeter{
+		channels:        channels,
+		framesPerBucket: framesPerBucket,
+		peaks:           make([]float32, 0, buckets),
+		rms:             make([]float32, 0, buckets),
+	}
+}
+
+// Process feeds interleaved samples to the meter
+func (m *WaveformMeter) Process(samples []float64) {
+	for i := 0; i+m.channels <= len(samples); i += m.channels {
+		for _, s := range samples[i : i+m.channels] {
+			m.peak = math.Max(m.peak, math.Abs(s))
+			m.energy += s * s
+		}
+		m.count += m.channels
+
+		m.frame++
+		if m.frame%m.framesPerBucket == 0 {
+			m.flush()
+		}
+	}
+}
+
+// Result returns the peak and RMS envelope, including a final partial bucket
+func (m *WaveformMeter) Result() (peaks, rms []float32) {
+	if m.count > 0 {
+		m.flush()
+	}
+	return m.peaks, m.rms
+}
+
+func (m *WaveformMeter) flush() {
+	m.peaks = append(m.peaks, float32(math.Min(m.peak, 1)))
+	m.rms = append(m.rms, float32(math.Min(math.Sqrt(m.energy/float64(m.count)), 1)))
+	m.peak, m.energy, m.count = 0, 0, 0
+}
@@ -0,0 +1,13 @@
+package dto
+
+// ScrobbleStatusDTO is the data transfer object for scrobble submission status
+type ScrobbleStatusDTO struct {
+	Enabled         bool   `json:"enabled"`
+	Service         string `json:"service"`
+	Queued          int    `json:"queued"`
+	Submitted       int    `json:"submitted"`
+	LastSubmittedAt int64  `json:"lastSubmittedAt,omitempty"` // Unix timestamp in seconds
+	LastError       string `json:"lastError,omitempty"`
+	NextRetryAt     int64  `json:"nextRetryAt,omitempty"` // Unix timestamp in seconds
+	AuthFailed      bool   `json:"authFailed"`
+}
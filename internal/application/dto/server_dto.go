@@ -0,0 +1,11 @@
+package dto
+
+// ServerStatusDTO is the data transfer object for the embedded servers' state
+type ServerStatusDTO struct {
+	SubsonicRunning bool   `json:"subsonicRunning"`
+	SubsonicAddress string `json:"subsonicAddress,omitempty"`
+	SubsonicError   string `json:"subsonicError,omitempty"`
+	DLNARunning     bool   `json:"dlnaRunning"`
+	DLNAAddress     string `json:"dlnaAddress,omitempty"`
+	DLNAError       string `json:"dlnaError,omitempty"`
+}
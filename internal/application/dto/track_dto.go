@@ -21,5 +21,47 @@ type TrackDTO struct {
 	Format      string  `json:"format,omitempty"`
 	BitRate     int     `json:"bitRate,omitempty"`
 	SampleRate  int     `json:"sampleRate,omitempty"`
+	Codec       string  `json:"codec,omitempty"`
+	BitDepth    int     `json:"bitDepth,omitempty"`
+	Lossless    bool    `json:"lossless,omitempty"`
+	HiRes       bool    `json:"hiRes,omitempty"`
 	HasArtwork  bool    `json:"hasArtwork"`
+
+	// MusicBrainz identifiers from the file's tags
+	MusicBrainzTrackID        string `json:"musicBrainzTrackId,omitempty"`
+	MusicBrainzAlbumID        string `json:"musicBrainzAlbumId,omitempty"`
+	MusicBrainzReleaseGroupID string `json:"musicBrainzReleaseGroupId,omitempty"`
+	MusicBrainzArtistID       string `json:"musicBrainzArtistId,omitempty"`
+
+	// Fields parsed from the file's path rather than read from its tags
+	InferredFields []string `json:"inferredFields,omitempty"`
+
+	// Set when the track is listed but cannot be played
+	UnplayableReason string `json:"unplayableReason,omitempty"`
+
+	// Stream layout
+	Channels    int    `json:"channels,omitempty"`
+	ChannelMode string `json:"channelMode,omitempty"`
+	VBR         bool   `json:"vbr,omitempty"`
+
+	// Gapless playback
+	TotalSamples   int64 `json:"totalSamples,omitempty"`
+	EncoderDelay   int   `json:"encoderDelay,omitempty"`
+	EncoderPadding int   `json:"encoderPadding,omitempty"`
+
+	// Loudness normalization (ReplayGain dB and linear peaks)
+	TrackGain *float64 `json:"trackGain,omitempty"`
+	TrackPeak *float64 `json:"trackPeak,omitempty"`
+	AlbumGain *float64 `json:"albumGain,omitempty"`
+	AlbumPeak *float64 `json:"albumPeak,omitempty"`
+
+	// Measured by GoMusic's loudness analysis (LUFS, dBTP, LU)
+	Loudness      *float64 `json:"loudness,omitempty"`
+	TruePeak      *float64 `json:"truePeak,omitempty"`
+	LoudnessRange *float64 `json:"loudnessRange,omitempty"`
+
+	// Tempo and key (Camelot notation alongside the standard name)
+	BPM     float64 `json:"bpm,omitempty"`
+	Key     string  `json:"key,omitempty"`
+	Camelot string  `json:"camelot,omitempty"`
 }
\ No newline at end of file
@@ -0,0 +1,23 @@
+package dto
+
+import "GoMusic/internal/domain/model"
+
+// WaveformDTO is the waveform envelope served to the seek bar
+type WaveformDTO struct {
+	Duration float64   `json:"duration"` // seconds
+	Peaks    []float32 `json:"peaks"`
+	RMS      []float32 `json:"rms"`
+}
+
+// ToWaveformDTO converts model.Waveform to DTO
+func ToWaveformDTO(waveform *model.Waveform) *WaveformDTO {
+	if waveform == nil {
+		return nil
+	}
+
+	return &WaveformDTO{
+		Duration: waveform.Duration,
+		Peaks:    waveform.Peaks,
+		RMS:      waveform.RMS,
+	}
+}
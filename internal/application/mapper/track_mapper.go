@@ -19,7 +19,7 @@ func (m *TrackMapper) ToDTO(track *model.Track) *dto.TrackDTO {
 		return nil
 	}
 
-	return &dto.TrackDTO{
+	trackDTO := &dto.TrackDTO{
 		ID:          track.ID,
 		SourceID:    track.SourceID,
 		SourceType:  string(track.SourceType),
@@ -39,8 +39,48 @@ func (m *TrackMapper) ToDTO(track *model.Track) *dto.TrackDTO {
 		Format:      track.Format,
 		BitRate:     track.BitRate,
 		SampleRate:  track.SampleRate,
+		Codec:       track.Codec,
+		BitDepth:    track.BitDepth,
+		Lossless:    track.Lossless,
+		HiRes:       track.IsHiRes(),
 		HasArtwork:  track.ArtworkPath != "",
+
+		MusicBrainzTrackID:        track.MusicBrainzTrackID,
+		MusicBrainzAlbumID:        track.MusicBrainzAlbumID,
+		MusicBrainzReleaseGroupID: track.MusicBrainzReleaseGroupID,
+		MusicBrainzArtistID:       track.MusicBrainzArtistID,
+
+		UnplayableReason: track.UnplayableReason,
+
+		Channels:    track.Channels,
+		ChannelMode: track.ChannelMode,
+		VBR:         track.VBR,
+
+		TotalSamples:   track.TotalSamples,
+		EncoderDelay:   track.EncoderDelay,
+		EncoderPadding: track.EncoderPadding,
+
+		BPM:     track.BPM,
+		Key:     string(track.Key),
+		Camelot: track.Key.Camelot(),
 	}
+
+	for _, field := range track.InferredFields {
+		trackDTO.InferredFields = append(trackDTO.InferredFields, string(field))
+	}
+	if rg := track.ReplayGain; rg != nil {
+		trackDTO.TrackGain = rg.TrackGain
+		trackDTO.TrackPeak = rg.TrackPeak
+		trackDTO.AlbumGain = rg.AlbumGain
+		trackDTO.AlbumPeak = rg.AlbumPeak
+	}
+	if l := track.Loudness; l != nil {
+		trackDTO.Loudness = &l.Integrated
+		trackDTO.TruePeak = &l.TruePeak
+		trackDTO.LoudnessRange = &l.Range
+	}
+
+	return trackDTO
 }
 
 // ToDTOList converts a slice of Tracks to TrackDTOs
@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"context"
+	stderrors "errors"
+	"sync"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+
+	"GoMusic/internal/application/dto"
+	"GoMusic/internal/service"
+	"GoMusic/internal/util/errors"
+)
+
+// AnalysisController runs the background track analysis job
+type AnalysisController struct {
+	analysisService *service.AnalysisService
+	ctx             context.Context
+
+	cancel context.CancelFunc
+	mu     sync.Mutex
+}
+
+// NewAnalysisController creates a new AnalysisController
+func NewAnalysisController(analysisService *service.AnalysisService, ctx context.Context) *AnalysisController {
+	return &AnalysisController{
+		analysisService: analysisService,
+		ctx:             ctx,
+	}
+}
+
+// StartAnalysis starts analyzing the library in the background
+// Emits "analysis:started", then "analysis:complete", "analysis:cancelled" or "analysis:error"
+func (c *AnalysisController) StartAnalysis() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cancel != nil {
+		return errors.ErrScanInProgress
+	}
+
+	ctx, cancel := context.WithCancel(c.ctx)
+	c.cancel = cancel
+
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			c.cancel = nil
+			c.mu.Unlock()
+			cancel()
+		}()
+
+		runtime.EventsEmit(c.ctx, "analysis:started")
+
+		err := c.analysisService.AnalyzeLibrary(ctx)
+		switch {
+		case stderrors.Is(err, context.Canceled):
+			runtime.EventsEmit(c.ctx, "analysis:cancelled")
+		case err != nil:
+			runtime.EventsEmit(c.ctx, "analysis:error", err.Error())
+		default:
+			runtime.EventsEmit(c.ctx, "analysis:complete")
+		}
+	}()
+
+	return nil
+}
+
+// CancelAnalysis stops a running analysis; albums already finished are kept
+func (c *AnalysisController) CancelAnalysis() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cancel != nil {
+		c.cancel()
+	}
+}
+
+// GetProgress retrieves the current analysis progress
+func (c *AnalysisController) GetProgress() *dto.ScanProgressDTO {
+	return dto.ToScanProgressDTO(c.analysisService.GetProgress())
+}
+
+// ApplyStoredResults attaches stored measurements to freshly scanned tracks
+func (c *AnalysisController) ApplyStoredResults() error {
+	return c.analysisService.ApplyStoredResults(c.ctx)
+}
@@ -13,6 +13,7 @@ import (
 type ScanController struct {
 	libraryService *service.LibraryService
 	ctx            context.Context
+	onComplete     func(sourceID string)
 }
 
 // NewScanController creates a new ScanController
@@ -23,6 +24,12 @@ func NewScanController(libraryService *service.LibraryService, ctx context.Conte
 	}
 }
 
+// SetCompleteListener registers a callback run after a successful scan of a
+// source, or of "all" sources, before "scan:complete" is emitted
+func (c *ScanController) SetCompleteListener(listener func(sourceID string)) {
+	c.onComplete = listener
+}
+
 // ScanLibrary triggers a library scan for a specific source
 // Runs asynchronously and emits events for progress updates
 func (c *ScanController) ScanLibrary(sourceID string) error {
@@ -77,6 +84,10 @@ func (c *ScanController) runScanWithEvents(sourceID string, scanFunc func(contex
 			return
 		}
 
+		if c.onComplete != nil {
+			c.onComplete(sourceID)
+		}
+
 		runtime.EventsEmit(c.ctx, "scan:complete", sourceID)
 	}()
 
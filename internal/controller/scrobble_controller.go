@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+
+	"GoMusic/internal/application/dto"
+	"GoMusic/internal/domain/model"
+	"GoMusic/internal/service"
+)
+
+// ScrobbleController handles play reporting and scrobbler configuration
+type ScrobbleController struct {
+	scrobbleService *service.ScrobbleService
+	configService   *service.ConfigService
+	ctx             context.Context
+}
+
+// NewScrobbleController creates a new ScrobbleController
+// Status changes are forwarded to the frontend as "scrobble:status" events
+func NewScrobbleController(scrobbleService *service.ScrobbleService, configService *service.ConfigService, ctx context.Context) *ScrobbleController {
+	c := &ScrobbleController{
+		scrobbleService: scrobbleService,
+		configService:   configService,
+		ctx:             ctx,
+	}
+
+	scrobbleService.SetStatusListener(func(status service.ScrobbleStatus) {
+		runtime.EventsEmit(c.ctx, "scrobble:status", toScrobbleStatusDTO(status))
+	})
+
+	return c
+}
+
+// NowPlaying announces a track as currently playing
+func (c *ScrobbleController) NowPlaying(trackID string) error {
+	return c.scrobbleService.NowPlaying(c.ctx, trackID)
+}
+
+// SubmitPlay records a finished play; startedAt is a Unix timestamp in seconds
+func (c *ScrobbleController) SubmitPlay(trackID string, startedAt int64, playedSeconds float64) (bool, error) {
+	played := time.Duration(playedSeconds * float64(time.Second))
+	return c.scrobbleService.RecordPlay(c.ctx, trackID, time.Unix(startedAt, 0), played)
+}
+
+// GetStatus returns the current submission status
+func (c *ScrobbleController) GetStatus() *dto.ScrobbleStatusDTO {
+	return toScrobbleStatusDTO(c.scrobbleService.GetStatus())
+}
+
+// GetConfig returns the scrobbler configuration
+func (c *ScrobbleController) GetConfig() *model.ScrobblerConfig {
+	return c.configService.GetScrobblerConfig()
+}
+
+// UpdateConfig saves the scrobbler configuration and reconnects the client
+func (c *ScrobbleController) UpdateConfig(config model.ScrobblerConfig) error {
+	if err := c.configService.UpdateScrobblerConfig(c.ctx, &config); err != nil {
+		return fmt.Errorf("failed to update scrobbler config: %w", err)
+	}
+
+	return c.scrobbleService.Reload()
+}
+
+// toScrobbleStatusDTO converts service.ScrobbleStatus to DTO
+func toScrobbleStatusDTO(status service.ScrobbleStatus) *dto.ScrobbleStatusDTO {
+	result := &dto.ScrobbleStatusDTO{
+		Enabled:    status.Enabled,
+		Service:    string(status.Service),
+		Queued:     status.Queued,
+		Submitted:  status.Submitted,
+		LastError:  status.LastError,
+		AuthFailed: status.AuthFailed,
+	}
+	if !status.LastSubmittedAt.IsZero() {
+		result.LastSubmittedAt = status.LastSubmittedAt.Unix()
+	}
+	if !status.NextRetryAt.IsZero() {
+		result.NextRetryAt = status.NextRetryAt.Unix()
+	}
+
+	return result
+}
@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+
+	"GoMusic/internal/application/dto"
+	"GoMusic/internal/domain/model"
+	"GoMusic/internal/media"
+	"GoMusic/internal/server/dlna"
+	"GoMusic/internal/server/subsonic"
+	"GoMusic/internal/service"
+)
+
+// shutdownTimeout bounds how long a restart waits for in-flight streams
+const shutdownTimeout = 5 * time.Second
+
+// ServerController manages the embedded servers that share the library with other devices
+type ServerController struct {
+	configService  *service.ConfigService
+	libraryService *service.LibraryService
+	mediaHandler   *media.Handler
+	ctx            context.Context
+
+	subsonicServer *subsonic.Server
+	subsonicError  string
+	dlnaServer     *dlna.Server
+	dlnaError      string
+	mu             sync.Mutex
+}
+
+// NewServerController creates a new ServerController
+// Status changes are forwarded to the frontend as "server:status" events
+func NewServerController(configService *service.ConfigService, libraryService *service.LibraryService, mediaHandler *media.Handler, ctx context.Context) *ServerController {
+	return &ServerController{
+		configService:  configService,
+		libraryService: libraryService,
+		mediaHandler:   mediaHandler,
+		ctx:            ctx,
+	}
+}
+
+// StartEnabled starts every server enabled in the configuration
+// Failures are logged and reported through the status rather than aborting startup
+func (c *ServerController) StartEnabled() {
+	if err := c.restartSubsonic(); err != nil {
+		fmt.Printf("Failed to start Subsonic server: %v\n", err)
+	}
+	if err := c.restartDLNA(); err != nil {
+		fmt.Printf("Failed to start DLNA server: %v\n", err)
+	}
+}
+
+// UpdateSubsonicServerConfig saves the Subsonic server configuration and restarts the server
+func (c *ServerController) UpdateSubsonicServerConfig(config model.SubsonicServerConfig) error {
+	if err := c.configService.UpdateSubsonicServerConfig(c.ctx, &config); err != nil {
+		return err
+	}
+
+	return c.restartSubsonic()
+}
+
+// UpdateDLNAConfig saves the DLNA media server configuration and restarts the server
+func (c *ServerController) UpdateDLNAConfig(config model.DLNAConfig) error {
+	if err := c.configService.UpdateDLNAConfig(c.ctx, &config); err != nil {
+		return err
+	}
+
+	return c.restartDLNA()
+}
+
+// GetStatus returns the state of the embedded servers
+func (c *ServerController) GetStatus() *dto.ServerStatusDTO {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status := &dto.ServerStatusDTO{SubsonicError: c.subsonicError}
+	if c.subsonicServer != nil {
+		status.SubsonicRunning = true
+		status.SubsonicAddress = c.subsonicServer.Addr()
+	}
+	status.DLNAError = c.dlnaError
+	if c.dlnaServer != nil {
+		status.DLNARunning = true
+		status.DLNAAddress = c.dlnaServer.Addr()
+	}
+	return status
+}
+
+// restartSubsonic stops the running Subsonic server and starts it again if enabled
+func (c *ServerController) restartSubsonic() error {
+	c.mu.Lock()
+
+	if c.subsonicServer != nil {
+		ctx, cancel := context.WithTimeout(c.ctx, shutdownTimeout)
+		if err := c.subsonicServer.Stop(ctx); err != nil {
+			fmt.Printf("Error stopping Subsonic server: %v\n", err)
+		}
+		cancel()
+		c.subsonicServer = nil
+	}
+	c.subsonicError = ""
+
+	config := c.configService.GetSubsonicServerConfig()
+	var err error
+	if config.Enabled {
+		server := subsonic.NewServer(config, c.libraryService, c.configService, c.mediaHandler)
+		if err = server.Start(); err != nil {
+			c.subsonicError = err.Error()
+		} else {
+			c.subsonicServer = server
+		}
+	}
+
+	c.mu.Unlock()
+
+	runtime.EventsEmit(c.ctx, "server:status", c.GetStatus())
+	return err
+}
+
+// restartDLNA stops the running DLNA server and starts it again if enabled
+func (c *ServerController) restartDLNA() error {
+	c.mu.Lock()
+
+	if c.dlnaServer != nil {
+		ctx, cancel := context.WithTimeout(c.ctx, shutdownTimeout)
+		if err := c.dlnaServer.Stop(ctx); err != nil {
+			fmt.Printf("Error stopping DLNA server: %v\n", err)
+		}
+		cancel()
+		c.dlnaServer = nil
+	}
+	c.dlnaError = ""
+
+	config := c.configService.GetDLNAConfig()
+	var err error
+	if config.Enabled {
+		server := dlna.NewServer(config, c.libraryService, c.mediaHandler)
+		if err = server.Start(); err != nil {
+			c.dlnaError = err.Error()
+		} else {
+			c.dlnaServer = server
+		}
+	}
+
+	c.mu.Unlock()
+
+	runtime.EventsEmit(c.ctx, "server:status", c.GetStatus())
+	return err
+}
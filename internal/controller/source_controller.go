@@ -5,26 +5,34 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
 	"GoMusic/internal/application/dto"
 	"GoMusic/internal/domain/model"
+	"GoMusic/internal/domain/repository"
+	"GoMusic/internal/domain/source/capability"
 	"GoMusic/internal/service"
 	"GoMusic/internal/sources/filesystem"
+	"GoMusic/internal/sources/jellyfin"
+	"GoMusic/internal/sources/subsonic"
 )
 
 // SourceController handles all source management operations
 type SourceController struct {
-	configService  *service.ConfigService
-	libraryService *service.LibraryService
+	configService   *service.ConfigService
+	libraryService  *service.LibraryService
+	trackIdentities repository.TrackIdentityRepository
 }
 
 // NewSourceController creates a new SourceController
-func NewSourceController(configService *service.ConfigService, libraryService *service.LibraryService) *SourceController {
+// trackIdentities keeps the IDs of files moved by the organizer and may be nil
+func NewSourceController(configService *service.ConfigService, libraryService *service.LibraryService, trackIdentities repository.TrackIdentityRepository) *SourceController {
 	return &SourceController{
-		configService:  configService,
-		libraryService: libraryService,
+		configService:   configService,
+		libraryService:  libraryService,
+		trackIdentities: trackIdentities,
 	}
 }
 
@@ -55,7 +63,7 @@ func (c *SourceController) GetSourceConfig(sourceID string) (*model.SourceConfig
 
 // GetSupportedFormats returns all supported audio file formats
 func (c *SourceController) GetSupportedFormats() []string {
-	return []string{".mp3", ".flac", ".m4a", ".ogg", ".oga"}
+	return slices.Clone(model.SupportedFormats)
 }
 
 // AddFilesystemSource adds a new filesystem music source
@@ -80,9 +88,8 @@ func (c *SourceController) AddFilesystemSource(ctx context.Context, name string,
 	}
 
 	// Validate formats
-	supportedFormats := map[string]bool{".mp3": true, ".flac": true, ".m4a": true, ".ogg": true, ".oga": true}
 	for _, format := range formats {
-		if !supportedFormats[format] {
+		if !model.IsSupportedFormat(format) {
 			return fmt.Errorf("unsupported format: %s", format)
 		}
 	}
@@ -113,6 +120,64 @@ func (c *SourceController) AddFilesystemSource(ctx context.Context, name string,
 	return nil
 }
 
+// AddSubsonicSource adds a new Subsonic-compatible server (Navidrome, Airsonic, Gonic, ...)
+// Either username/password or an OpenSubsonic API key is required
+func (c *SourceController) AddSubsonicSource(ctx context.Context, name string, baseURL string, username string, password string, apiKey string) error {
+	return c.addAPISource(ctx, model.SourceTypeAPISelfHosted, "subsonic", name, baseURL, username, password, apiKey)
+}
+
+// AddJellyfinSource adds a new Jellyfin server
+// Either username/password or an API key is required; with an API key the
+// username selects whose library is imported (defaults to the first administrator)
+func (c *SourceController) AddJellyfinSource(ctx context.Context, name string, baseURL string, username string, password string, apiKey string) error {
+	return c.addAPISource(ctx, model.SourceTypeJellyfin, "jellyfin", name, baseURL, username, password, apiKey)
+}
+
+// addAPISource validates and registers a new API-based source
+func (c *SourceController) addAPISource(ctx context.Context, sourceType model.SourceType, idPrefix string, name string, baseURL string, username string, password string, apiKey string) error {
+	// Validate input
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("source name cannot be empty")
+	}
+
+	baseURL = strings.TrimSuffix(strings.TrimSpace(baseURL), "/")
+	if !strings.HasPrefix(baseURL, "http://") && !strings.HasPrefix(baseURL, "https://") {
+		return fmt.Errorf("base URL must start with http:// or https://")
+	}
+
+	// Generate unique source ID based on timestamp
+	sourceID := fmt.Sprintf("%s-%d", idPrefix, time.Now().Unix())
+
+	// Create source configuration
+	sourceConfig := model.NewSourceConfiguration(sourceID, name, sourceType)
+	sourceConfig.Config["base_url"] = baseURL
+	sourceConfig.Config["username"] = username
+	sourceConfig.Config["password"] = password
+	sourceConfig.Config["api_key"] = apiKey
+	sourceConfig.Config["timeout_seconds"] = float64(30)
+	sourceConfig.Config["rate_limit"] = float64(10)
+
+	// Validate credentials before persisting
+	if _, err := sourceConfig.ToAPIConfig(); err != nil {
+		return err
+	}
+
+	// Add to config service (this validates and checks for duplicates)
+	if err := c.configService.AddSource(ctx, sourceConfig); err != nil {
+		return fmt.Errorf("failed to add source to config: %w", err)
+	}
+
+	// Register with library service
+	if err := c.registerSource(sourceConfig); err != nil {
+		// Rollback config change if registration fails
+		_ = c.configService.RemoveSource(ctx, sourceID)
+		return fmt.Errorf("failed to register source: %w", err)
+	}
+
+	return nil
+}
+
 // UpdateFilesystemSource updates an existing filesystem source
 func (c *SourceController) UpdateFilesystemSource(ctx context.Context, sourceID string, name string, rootPaths []string, includeSubfolders bool, formats []string) error {
 	// Get existing source
@@ -134,7 +199,7 @@ func (c *SourceController) UpdateFilesystemSource(ctx context.Context, sourceID
 	}
 
 	// Unregister old source from library service
-	c.libraryService.UnregisterTrackRepository(sourceID)
+	c.unregisterSource(sourceID)
 
 	// Re-register with new configuration
 	if err := c.registerSource(existingSource); err != nil {
@@ -144,6 +209,91 @@ func (c *SourceController) UpdateFilesystemSource(ctx context.Context, sourceID
 	return nil
 }
 
+// SetNamingPatterns sets the patterns a filesystem source infers missing tags from
+// The source's tracks stay listed with their current values until the next scan
+// re-reads them with the new patterns
+func (c *SourceController) SetNamingPatterns(ctx context.Context, sourceID string, patterns []string) error {
+	existingSource, err := c.configService.GetSource(sourceID)
+	if err != nil {
+		return fmt.Errorf("source not found: %w", err)
+	}
+	if existingSource.Type != model.SourceTypeFilesystem {
+		return fmt.Errorf("naming patterns only apply to filesystem sources")
+	}
+
+	// Validate patterns
+	cleaned := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if err := filesystem.ValidateNamingPattern(pattern); err != nil {
+			return err
+		}
+		cleaned = append(cleaned, pattern)
+	}
+	existingSource.Config["naming_patterns"] = convertToInterfaceSlice(cleaned)
+
+	// Update in config service
+	if err := c.configService.UpdateSource(ctx, existingSource); err != nil {
+		return fmt.Errorf("failed to update source config: %w", err)
+	}
+
+	// Swap in an extractor that uses the new patterns
+	if err := c.reloadSource(existingSource); err != nil {
+		return fmt.Errorf("failed to reload source: %w", err)
+	}
+
+	return nil
+}
+
+// SetArtworkSettings sets the folder image patterns a filesystem source searches,
+// in order, and whether they win over embedded pictures
+// The source's tracks keep their current artwork until the next scan
+func (c *SourceController) SetArtworkSettings(ctx context.Context, sourceID string, patterns []string, preference string) error {
+	existingSource, err := c.configService.GetSource(sourceID)
+	if err != nil {
+		return fmt.Errorf("source not found: %w", err)
+	}
+	if existingSource.Type != model.SourceTypeFilesystem {
+		return fmt.Errorf("artwork settings only apply to filesystem sources")
+	}
+
+	switch model.ArtworkPreference(preference) {
+	case "", model.ArtworkPreferEmbedded, model.ArtworkPreferFolder:
+	default:
+		return fmt.Errorf("unknown artwork preference: %s", preference)
+	}
+
+	// Validate patterns
+	cleaned := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if err := filesystem.ValidateArtworkPattern(pattern); err != nil {
+			return err
+		}
+		cleaned = append(cleaned, pattern)
+	}
+	existingSource.Config["artwork_patterns"] = convertToInterfaceSlice(cleaned)
+	existingSource.Config["artwork_preference"] = preference
+
+	// Update in config service
+	if err := c.configService.UpdateSource(ctx, existingSource); err != nil {
+		return fmt.Errorf("failed to update source config: %w", err)
+	}
+
+	// Swap in an extractor that uses the new settings
+	if err := c.reloadSource(existingSource); err != nil {
+		return fmt.Errorf("failed to reload source: %w", err)
+	}
+
+	return nil
+}
+
 // RemoveSource removes a music source
 func (c *SourceController) RemoveSource(ctx context.Context, sourceID string) error {
 	// Remove from config service (this persists the change)
@@ -152,7 +302,7 @@ func (c *SourceController) RemoveSource(ctx context.Context, sourceID string) er
 	}
 
 	// Unregister from library service
-	c.libraryService.UnregisterTrackRepository(sourceID)
+	c.unregisterSource(sourceID)
 
 	return nil
 }
@@ -181,8 +331,9 @@ func (c *SourceController) registerSource(sourceConfig *model.SourceConfiguratio
 	case model.SourceTypeFilesystem:
 		return c.registerFilesystemSource(sourceConfig)
 	case model.SourceTypeAPISelfHosted:
-		// TODO: Implement API source registration
-		return fmt.Errorf("API sources not yet implemented")
+		return c.registerSubsonicSource(sourceConfig)
+	case model.SourceTypeJellyfin:
+		return c.registerJellyfinSource(sourceConfig)
 	default:
 		return fmt.Errorf("unsupported source type: %s", sourceConfig.Type)
 	}
@@ -197,10 +348,10 @@ func (c *SourceController) registerFilesystemSource(sourceConfig *model.SourceCo
 	}
 
 	// Create extractor (filesystem-specific)
-	extractor := filesystem.NewTagExtractor(sourceConfig.ID)
+	extractor := filesystem.NewTagExtractor(sourceConfig.ID, config)
 
 	// Create repository
-	repo := filesystem.NewFilesystemTrackRepository(sourceConfig.ID, config, extractor)
+	repo := filesystem.NewFilesystemTrackRepository(sourceConfig.ID, config, extractor, c.trackIdentities)
 
 	// Register with library service
 	c.libraryService.RegisterTrackRepository(sourceConfig.ID, repo)
@@ -208,6 +359,53 @@ func (c *SourceController) registerFilesystemSource(sourceConfig *model.SourceCo
 	return nil
 }
 
+// registerSubsonicSource registers a Subsonic-compatible API source
+func (c *SourceController) registerSubsonicSource(sourceConfig *model.SourceConfiguration) error {
+	config, err := sourceConfig.ToAPIConfig()
+	if err != nil {
+		return fmt.Errorf("failed to convert config: %w", err)
+	}
+
+	repo := subsonic.NewSubsonicTrackRepository(sourceConfig.ID, config)
+	c.libraryService.RegisterTrackRepository(sourceConfig.ID, repo)
+
+	return nil
+}
+
+// registerJellyfinSource registers a Jellyfin source with its track, album and artist repositories
+func (c *SourceController) registerJellyfinSource(sourceConfig *model.SourceConfiguration) error {
+	config, err := sourceConfig.ToAPIConfig()
+	if err != nil {
+		return fmt.Errorf("failed to convert config: %w", err)
+	}
+
+	trackRepo, albumRepo, artistRepo := jellyfin.NewJellyfinRepositories(sourceConfig.ID, config)
+	c.libraryService.RegisterTrackRepository(sourceConfig.ID, trackRepo)
+	c.libraryService.RegisterAlbumRepository(sourceConfig.ID, albumRepo)
+	c.libraryService.RegisterArtistRepository(sourceConfig.ID, artistRepo)
+
+	return nil
+}
+
+// reloadSource applies a source's updated configuration, in place when the
+// source supports it so its tracks stay in the library, otherwise by
+// registering it afresh
+func (c *SourceController) reloadSource(sourceConfig *model.SourceConfiguration) error {
+	repo := c.libraryService.GetRepositories()[sourceConfig.ID]
+	if reloader, ok := repo.(capability.ConfigReloader); ok {
+		return reloader.ReloadConfig(sourceConfig)
+	}
+	c.unregisterSource(sourceConfig.ID)
+	return c.registerSource(sourceConfig)
+}
+
+// unregisterSource removes all repositories of a source from the library service
+func (c *SourceController) unregisterSource(sourceID string) {
+	c.libraryService.UnregisterTrackRepository(sourceID)
+	c.libraryService.UnregisterAlbumRepository(sourceID)
+	c.libraryService.UnregisterArtistRepository(sourceID)
+}
+
 // convertToInterfaceSlice converts a string slice to interface slice for JSON marshaling
 func convertToInterfaceSlice(strings []string) []interface{} {
 	interfaces := make([]interface{}, len(strings))
@@ -0,0 +1,387 @@
+package decoder
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/go-mp3"
+	"github.com/mewkiz/flac"
+	"github.com/mewkiz/flac/frame"
+)
+
+// Format describes interleaved little-endian PCM samples
+type Format struct {
+	SampleRate    int
+	Channels      int
+	BitsPerSample int
+}
+
+// BlockAlign returns the size of one frame (one sample for every channel) in bytes
+func (f Format) BlockAlign() int {
+	return f.Channels * f.BitsPerSample / 8
+}
+
+// Decoder decodes an audio file into PCM frames
+type Decoder interface {
+	io.Reader
+	io.Closer
+
+	// Format returns the layout of the decoded samples
+	Format() Format
+
+	// Frames returns the total number of frames in the stream
+	Frames() int64
+
+	// SeekFrame positions the decoder so the next Read starts at frame
+	SeekFrame(frame int64) error
+
+	// Priming returns the frames the decoder emits before the encoder's first
+	// sample; encoder delay comes on top of this
+	Priming() int64
+}
+
+// CanDecode reports whether a file can be decoded to PCM
+func CanDecode(filePath string) bool {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".mp3", ".flac", ".wav", ".aif", ".aiff", ".aifc", ".dsf", ".dff":
+		return true
+	default:
+		return false
+	}
+}
+
+// Open opens a PCM decoder for a local audio file
+// Streams without channels or a sample rate are rejected, so callers can
+// divide by both
+func Open(filePath string) (Decoder, error) {
+	var dec Decoder
+	var err error
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".mp3":
+		dec, err = openMP3Decoder(filePath)
+	case ".flac":
+		dec, err = openFLACDecoder(filePath)
+	case ".wav", ".aif", ".aiff", ".aifc":
+		dec, err = openPCMDecoder(filePath)
+	case ".dsf":
+		dec, err = openDSDDecoder(filePath, false)
+	case ".dff":
+		dec, err = openDSDDecoder(filePath, true)
+	default:
+		return nil, fmt.Errorf("no decoder for %s", filepath.Ext(filePath))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if format := dec.Format(); format.Channels <= 0 || format.SampleRate <= 0 {
+		dec.Close()
+		return nil, fmt.Errorf("failed to decode %s: stream has %d channels at %d Hz", filePath, format.Channels, format.SampleRate)
+	}
+	return dec, nil
+}
+
+// OpenSegment opens the part of a file that starts at start and lasts length,
+// as for the tracks of a CUE sheet; a zero length runs to the end of the file
+func OpenSegment(filePath string, start, length time.Duration) (Decoder, error) {
+	dec, err := Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	rate := float64(dec.Format().SampleRate)
+	lead := dec.Priming() + int64(start.Seconds()*rate+0.5)
+	frames := dec.Frames() - lead
+	if length > 0 {
+		frames = min(frames, int64(length.Seconds()*rate+0.5))
+	}
+	if frames <= 0 {
+		dec.Close()
+		return nil, ErrSeekPastEnd
+	}
+
+	trimmed, err := NewTrimmed(dec, lead, frames)
+	if err != nil {
+		dec.Close()
+		return nil, err
+	}
+	return trimmed, nil
+}
+
+const (
+	mp3SamplesPerFrame = 1152
+	mp3PreRollFrames   = 2
+
+	// mp3DecoderDelay is the latency of the MP3 synthesis filter bank
+	mp3DecoderDelay = 529
+)
+
+// mp3Decoder wraps go-mp3, which always produces 16-bit stereo
+type mp3Decoder struct {
+	file    *os.File
+	decoder *mp3.Decoder
+	priming int64
+}
+
+func openMP3Decoder(filePath string) (*mp3Decoder, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	priming := mp3Priming(file)
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	decoder, err := mp3.NewDecoder(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to decode MP3: %w", err)
+	}
+
+	return &mp3Decoder{file: file, decoder: decoder, priming: priming}, nil
+}
+
+// mp3Priming returns the frames decoded ahead of the audio of an MP3 file
+// An info frame opening the stream decodes as a frame of silence, and the
+// filter bank delay is only compensated for when a LAME extension says how
+// the encoder delay was measured; only the file's first frame is looked at
+func mp3Priming(file *os.File) int64 {
+	start := SkipID3v2(file)
+	buf := make([]byte, 64*1024)
+	if _, err := file.Seek(start, io.SeekStart); err != nil {
+		return 0
+	}
+	n, _ := io.ReadFull(file, buf)
+	buf = buf[:n]
+
+	offset, header := FindMPEGFrame(buf)
+	if offset < 0 {
+		return 0
+	}
+	info, ok := ParseMPEGInfoFrame(header, buf[offset:])
+	if !ok {
+		return 0
+	}
+	priming := int64(header.SamplesPerFrame())
+	if info.LAME {
+		priming += mp3DecoderDelay
+	}
+	return priming
+}
+
+func (d *mp3Decoder) Read(p []byte) (int, error) {
+	return d.decoder.Read(p)
+}
+
+func (d *mp3Decoder) Close() error {
+	return d.file.Close()
+}
+
+func (d *mp3Decoder) Format() Format {
+	return Format{SampleRate: d.decoder.SampleRate(), Channels: 2, BitsPerSample: 16}
+}
+
+func (d *mp3Decoder) Frames() int64 {
+	return d.decoder.Length() / 4
+}
+
+// Priming covers the info frame and, for LAME files, the filter bank delay;
+// see mp3Priming
+func (d *mp3Decoder) Priming() int64 {
+	return d.priming
+}
+
+func (d *mp3Decoder) SeekFrame(frame int64) error {
+	if frame >= d.Frames() {
+		return ErrSeekPastEnd
+	}
+
+	// Start a few MP3 frames early so the bit reservoir and filter bank state
+	// match a continuous decode, then drop the pre-roll
+	preRoll := min(frame, mp3PreRollFrames*mp3SamplesPerFrame)
+	if _, err := d.decoder.Seek((frame-preRoll)*4, io.SeekStart); err != nil {
+		return err
+	}
+	_, err := io.CopyN(io.Discard, d.decoder, preRoll*4)
+	return err
+}
+
+// flacDecoder converts FLAC frames into interleaved PCM
+// Samples are written at 16 or 24 bits, whichever holds the source depth
+type flacDecoder struct {
+	file   *os.File
+	stream *flac.Stream
+	format Format
+
+	buf  []byte // decoded bytes of the current frame
+	skip int64  // frames to drop from the next decoded frame after a seek
+}
+
+func openFLACDecoder(filePath string) (*flacDecoder, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := flac.NewSeek(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to decode FLAC: %w", err)
+	}
+
+	bits := 16
+	if stream.Info.BitsPerSample > 16 {
+		bits = 24
+	}
+
+	return &flacDecoder{
+		file:   file,
+		stream: stream,
+		format: Format{
+			SampleRate:    int(stream.Info.SampleRate),
+			Channels:      int(stream.Info.NChannels),
+			BitsPerSample: bits,
+		},
+	}, nil
+}
+
+func (d *flacDecoder) Read(p []byte) (int, error) {
+	for len(d.buf) == 0 {
+		f, err := d.stream.ParseNext()
+		if err != nil {
+			return 0, err
+		}
+		d.fill(f)
+	}
+
+	n := copy(p, d.buf)
+	d.buf = d.buf[n:]
+	return n, nil
+}
+
+// fill encodes a decoded frame into the byte buffer
+func (d *flacDecoder) fill(f *frame.Frame) {
+	shift := d.format.BitsPerSample - int(f.BitsPerSample)
+
+	start := int(min(d.skip, int64(f.BlockSize)))
+	d.skip -= int64(start)
+
+	d.buf = d.buf[:0]
+	for i := start; i < int(f.BlockSize); i++ {
+		for ch := 0; ch < d.format.Channels; ch++ {
+			sample := f.Subframes[ch].Samples[i]
+			if shift > 0 {
+				sample <<= shift
+			} else {
+				sample >>= -shift
+			}
+			if d.format.BitsPerSample == 16 {
+				d.buf = binary.LittleEndian.AppendUint16(d.buf, uint16(int16(sample)))
+			} else {
+				d.buf = append(d.buf, byte(sample), byte(sample>>8), byte(sample>>16))
+			}
+		}
+	}
+}
+
+func (d *flacDecoder) Close() error {
+	return d.file.Close()
+}
+
+func (d *flacDecoder) Format() Format {
+	return d.format
+}
+
+func (d *flacDecoder) Frames() int64 {
+	return int64(d.stream.Info.NSamples)
+}
+
+func (d *flacDecoder) Priming() int64 {
+	return 0
+}
+
+func (d *flacDecoder) SeekFrame(frame int64) error {
+	d.buf = d.buf[:0]
+	d.skip = 0
+	if frame >= d.Frames() {
+		return ErrSeekPastEnd
+	}
+
+	// Seek lands on the start of the containing FLAC frame
+	// The library fails to seek into the final frame, so retry from the
+	// previous block and skip forward to the target instead
+	target := frame
+	for {
+		actual, err := d.stream.Seek(uint64(target))
+		if err == nil {
+			d.skip = frame - int64(actual)
+			return nil
+		}
+		if target == 0 {
+			return err
+		}
+		target = max(target-int64(d.stream.Info.BlockSizeMax), 0)
+	}
+}
+
+// trimmedDecoder exposes a window of another decoder's frames
+// Used for gapless playback to drop encoder delay and padding
+type trimmedDecoder struct {
+	Decoder
+	lead   int64 // frames skipped at the start
+	frames int64 // frames in the window
+
+	remaining int64 // bytes left in the window from the current position
+}
+
+// NewTrimmed drops lead frames and limits the stream to frames
+func NewTrimmed(decoder Decoder, lead, frames int64) (Decoder, error) {
+	d := &trimmedDecoder{Decoder: decoder, lead: lead, frames: frames}
+	if err := d.SeekFrame(0); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *trimmedDecoder) Read(p []byte) (int, error) {
+	if d.remaining <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > d.remaining {
+		p = p[:d.remaining]
+	}
+	n, err := d.Decoder.Read(p)
+	d.remaining -= int64(n)
+	return n, err
+}
+
+func (d *trimmedDecoder) Frames() int64 {
+	return d.frames
+}
+
+func (d *trimmedDecoder) SeekFrame(frame int64) error {
+	if frame >= d.frames {
+		return ErrSeekPastEnd
+	}
+	if err := d.Decoder.SeekFrame(d.lead + frame); err != nil {
+		return err
+	}
+	d.remaining = (d.frames - frame) * int64(d.Format().BlockAlign())
+	return nil
+}
+
+func (d *trimmedDecoder) Priming() int64 {
+	return 0
+}
+
+// ErrSeekPastEnd is returned when seeking at or beyond the end of the stream
+var ErrSeekPastEnd = errors.New("seek past end of stream")
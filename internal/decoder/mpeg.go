@@ -0,0 +1,213 @@
+package decoder
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// mpegSampleRates indexes sample rates by MPEG version (2.5, reserved, 2, 1) and rate index
+var mpegSampleRates = [4][3]int{
+	{11025, 12000, 8000},
+	{},
+	{22050, 24000, 16000},
+	{44100, 48000, 32000},
+}
+
+// mpegBitRates indexes bit rates in kbps by MPEG-1 / MPEG-2 (and 2.5), layer and rate index
+var mpegBitRates = [2][3][15]int{
+	{
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448}, // Layer I
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},    // Layer II
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},     // Layer III
+	},
+	{
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+	},
+}
+
+// MPEGFrameHeader is a parsed 4-byte MPEG audio frame header
+type MPEGFrameHeader struct {
+	Version     byte // 0: MPEG-2.5, 2: MPEG-2, 3: MPEG-1
+	Layer       int  // 1, 2 or 3
+	BitRate     int  // kbps
+	SampleRate  int
+	Padding     int
+	ChannelMode byte // 3 is mono
+}
+
+// ParseMPEGFrameHeader parses a frame header, rejecting reserved and free-format values
+func ParseMPEGFrameHeader(b []byte) (MPEGFrameHeader, bool) {
+	if len(b) < 4 || b[0] != 0xFF || b[1]&0xE0 != 0xE0 {
+		return MPEGFrameHeader{}, false
+	}
+
+	h := MPEGFrameHeader{
+		Version:     (b[1] >> 3) & 0x03,
+		Layer:       4 - int((b[1]>>1)&0x03),
+		Padding:     int((b[2] >> 1) & 0x01),
+		ChannelMode: b[3] >> 6,
+	}
+	rateIndex := (b[2] >> 2) & 0x03
+	bitRateIndex := b[2] >> 4
+	if h.Version == 1 || h.Layer == 4 || rateIndex == 3 || bitRateIndex == 0 || bitRateIndex == 15 {
+		return MPEGFrameHeader{}, false
+	}
+
+	h.SampleRate = mpegSampleRates[h.Version][rateIndex]
+	table := 0
+	if h.Version != 3 {
+		table = 1
+	}
+	h.BitRate = mpegBitRates[table][h.Layer-1][bitRateIndex]
+
+	return h, true
+}
+
+// SamplesPerFrame returns the number of samples per channel in a frame
+func (h MPEGFrameHeader) SamplesPerFrame() int {
+	switch {
+	case h.Layer == 1:
+		return 384
+	case h.Layer == 3 && h.Version != 3:
+		return 576
+	default:
+		return 1152
+	}
+}
+
+// FrameLength returns the size of the frame in bytes, including the header
+func (h MPEGFrameHeader) FrameLength() int {
+	if h.Layer == 1 {
+		return (12*h.BitRate*1000/h.SampleRate + h.Padding) * 4
+	}
+	return h.SamplesPerFrame()/8*h.BitRate*1000/h.SampleRate + h.Padding
+}
+
+// sideInfoSize returns the Layer III side information size that precedes a Xing header
+func (h MPEGFrameHeader) sideInfoSize() int {
+	mono := h.ChannelMode == 3
+	switch {
+	case h.Version == 3 && mono:
+		return 17
+	case h.Version == 3:
+		return 32
+	case mono:
+		return 9
+	default:
+		return 17
+	}
+}
+
+// FindMPEGFrame returns the offset of the first frame in buf, confirmed by a
+// second header right after it, or -1 when there is none
+func FindMPEGFrame(buf []byte) (int, MPEGFrameHeader) {
+	for i := 0; i+4 <= len(buf); i++ {
+		h, ok := ParseMPEGFrameHeader(buf[i:])
+		if !ok {
+			continue
+		}
+		next := i + h.FrameLength()
+		if next+4 <= len(buf) {
+			if following, ok := ParseMPEGFrameHeader(buf[next:]); !ok || following.SampleRate != h.SampleRate {
+				continue
+			}
+		}
+		return i, h
+	}
+	return -1, MPEGFrameHeader{}
+}
+
+// MPEGInfoFrame is the Xing/Info or VBRI header an encoder writes into the
+// first frame of an MP3, which decodes as a frame of silence
+type MPEGInfoFrame struct {
+	VBR        bool
+	Frames     int64 // audio frames, excluding the info frame; 0 when missing
+	AudioBytes int64 // audio bytes, excluding the info frame; 0 when missing
+
+	// LAME reports whether a LAME extension follows the Xing/Info header, with
+	// the encoder delay and padding it carries
+	LAME           bool
+	EncoderDelay   int
+	EncoderPadding int
+}
+
+// lameEncoders are the encoder strings that open a LAME extension; FFmpeg
+// writes the same layout
+var lameEncoders = []string{"LAME", "Lavc", "Lavf"}
+
+// ParseMPEGInfoFrame reads the Xing/Info or VBRI header of a first frame
+// Returns false when the frame is an audio frame
+func ParseMPEGInfoFrame(header MPEGFrameHeader, frame []byte) (*MPEGInfoFrame, bool) {
+	// VBRI (Fraunhofer) sits at a fixed offset after the header
+	if len(frame) >= 36+18 && string(frame[36:40]) == "VBRI" {
+		vbri := frame[36:]
+		return &MPEGInfoFrame{
+			VBR:        true,
+			AudioBytes: int64(binary.BigEndian.Uint32(vbri[10:14])),
+			Frames:     int64(binary.BigEndian.Uint32(vbri[14:18])),
+		}, true
+	}
+
+	if header.Layer != 3 {
+		return nil, false
+	}
+	xing := frame[min(4+header.sideInfoSize(), len(frame)):]
+	if len(xing) < 8 || (string(xing[:4]) != "Xing" && string(xing[:4]) != "Info") {
+		return nil, false
+	}
+
+	// LAME writes "Info" for CBR and "Xing" for VBR and ABR
+	info := &MPEGInfoFrame{VBR: string(xing[:4]) == "Xing"}
+	flags := binary.BigEndian.Uint32(xing[4:8])
+	pos := 8
+	if flags&0x1 != 0 && len(xing) >= pos+4 {
+		info.Frames = int64(binary.BigEndian.Uint32(xing[pos:]))
+		pos += 4
+	}
+	if flags&0x2 != 0 && len(xing) >= pos+4 {
+		// The byte count includes the info frame itself
+		info.AudioBytes = max(int64(binary.BigEndian.Uint32(xing[pos:]))-int64(header.FrameLength()), 0)
+		pos += 4
+	}
+	if flags&0x4 != 0 {
+		pos += 100 // seek table of contents
+	}
+	if flags&0x8 != 0 {
+		pos += 4 // quality indicator
+	}
+
+	// LAME extension: 9-byte encoder string, then delay and padding as two 12-bit values at offset 21
+	if lame := xing[min(pos, len(xing)):]; len(lame) >= 24 {
+		for _, encoder := range lameEncoders {
+			if string(lame[:4]) == encoder {
+				info.LAME = true
+				info.EncoderDelay = int(lame[21])<<4 | int(lame[22])>>4
+				info.EncoderPadding = int(lame[22]&0x0F)<<8 | int(lame[23])
+				break
+			}
+		}
+	}
+
+	return info, true
+}
+
+// SkipID3v2 returns the offset just past a leading ID3v2 tag, or 0 when there is none
+func SkipID3v2(r io.ReadSeeker) int64 {
+	header := make([]byte, 10)
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return 0
+	}
+	if _, err := io.ReadFull(r, header); err != nil || string(header[:3]) != "ID3" {
+		return 0
+	}
+
+	// Syncsafe size excludes the header and optional footer
+	size := int64(header[6])<<21 | int64(header[7])<<14 | int64(header[8])<<7 | int64(header[9])
+	size += 10
+	if header[5]&0x10 != 0 {
+		size += 10
+	}
+	return size
+}
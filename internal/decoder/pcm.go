@@ -0,0 +1,174 @@
+package decoder
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+var errUnsupportedPCM = errors.New("unsupported WAV/AIFF encoding")
+
+// pcmDecoder converts uncompressed WAV and AIFF data to little-endian PCM
+// Samples are written at 16 or 24 bits, whichever holds the source depth;
+// float samples are clipped to 24-bit integers
+type pcmDecoder struct {
+	file   *os.File
+	layout *PCMInfo
+	format Format
+	frames int64
+	pos    int64 // next frame to read; chunks after the data must not be decoded
+
+	src []byte // raw frames read from the file
+}
+
+func openPCMDecoder(filePath string) (*pcmDecoder, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	layout, err := readPCMLayout(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to decode %s: %w", filePath, err)
+	}
+
+	bits := 16
+	if layout.SampleBytes > 2 || layout.Codec == "IEEE float" {
+		bits = 24
+	}
+
+	d := &pcmDecoder{
+		file:   file,
+		layout: layout,
+		format: Format{SampleRate: layout.SampleRate, Channels: layout.Channels, BitsPerSample: bits},
+		frames: layout.DataBytes / int64(layout.SampleBytes*layout.Channels),
+	}
+	if err := d.SeekFrame(0); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *pcmDecoder) Read(p []byte) (int, error) {
+	if d.pos >= d.frames {
+		return 0, io.EOF
+	}
+	srcAlign := d.layout.SampleBytes * d.layout.Channels
+	frames := int(min(int64(len(p)/d.format.BlockAlign()), d.frames-d.pos))
+	if frames == 0 {
+		return 0, nil
+	}
+
+	if cap(d.src) < frames*srcAlign {
+		d.src = make([]byte, frames*srcAlign)
+	}
+	n, err := io.ReadFull(d.file, d.src[:frames*srcAlign])
+	frames = n / srcAlign
+	d.pos += int64(frames)
+	if frames == 0 {
+		if err == nil || err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		return 0, err
+	}
+
+	out := p[:0]
+	for i := 0; i < frames*d.layout.Channels; i++ {
+		sample := d.sample(d.src[i*d.layout.SampleBytes:])
+		if d.format.BitsPerSample == 16 {
+			out = binary.LittleEndian.AppendUint16(out, uint16(sample>>16))
+		} else {
+			out = append(out, byte(sample>>8), byte(sample>>16), byte(sample>>24))
+		}
+	}
+	return len(out), nil
+}
+
+// sample reads one source sample as a left-justified 32-bit integer
+func (d *pcmDecoder) sample(b []byte) int32 {
+	l := d.layout
+	if l.Codec == "IEEE float" {
+		var v float64
+		switch {
+		case l.SampleBytes == 8 && l.BigEndian:
+			v = math.Float64frombits(binary.BigEndian.Uint64(b))
+		case l.SampleBytes == 8:
+			v = math.Float64frombits(binary.LittleEndian.Uint64(b))
+		case l.BigEndian:
+			v = float64(math.Float32frombits(binary.BigEndian.Uint32(b)))
+		default:
+			v = float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+		}
+		return int32(max(min(v, 1-1.0/(1<<23)), -1) * (1 << 31))
+	}
+
+	var v uint32
+	for i := 0; i < l.SampleBytes; i++ {
+		if l.BigEndian {
+			v = v<<8 | uint32(b[i])
+		} else {
+			v |= uint32(b[i]) << (8 * i)
+		}
+	}
+	v <<= 32 - 8*l.SampleBytes
+	if l.Unsigned {
+		v ^= 0x80000000
+	}
+	return int32(v)
+}
+
+func (d *pcmDecoder) Close() error {
+	return d.file.Close()
+}
+
+func (d *pcmDecoder) Format() Format {
+	return d.format
+}
+
+func (d *pcmDecoder) Frames() int64 {
+	return d.frames
+}
+
+func (d *pcmDecoder) Priming() int64 {
+	return 0
+}
+
+func (d *pcmDecoder) SeekFrame(frame int64) error {
+	if frame > 0 && frame >= d.frames {
+		return ErrSeekPastEnd
+	}
+	offset := d.layout.DataOffset + frame*int64(d.layout.SampleBytes*d.layout.Channels)
+	if _, err := d.file.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	d.pos = frame
+	return nil
+}
+
+// readPCMLayout reads the chunks of a WAV or AIFF file and checks the samples
+// are uncompressed integers of up to 32 bits or IEEE floats
+func readPCMLayout(file *os.File) (*PCMInfo, error) {
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	info, err := ReadPCMInfo(file, size)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case info.DataOffset == 0:
+		return nil, errUnsupportedPCM
+	case info.Codec == "PCM" && info.SampleBytes >= 1 && info.SampleBytes <= 4:
+	case info.Codec == "IEEE float" && (info.SampleBytes == 4 || info.SampleBytes == 8):
+	default:
+		return nil, errUnsupportedPCM
+	}
+	return info, nil
+}
@@ -0,0 +1,255 @@
+package decoder
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"time"
+)
+
+// WAVE format tags, from the fmt chunk
+const (
+	waveFormatPCM        = 0x0001
+	waveFormatFloat      = 0x0003
+	waveFormatALaw       = 0x0006
+	waveFormatMuLaw      = 0x0007
+	waveFormatExtensible = 0xfffe
+)
+
+// ErrNotPCMContainer is returned for files that are not WAV or AIFF
+var ErrNotPCMContainer = errors.New("not a WAV or AIFF file")
+
+// PCMInfo describes a WAV (RIFF, RF64) or AIFF/AIFC file
+// Shared by the decoder and the tag and property readers, so both agree on
+// where the samples are and how they are stored
+type PCMInfo struct {
+	Container  string // wav or aiff
+	Codec      string // PCM, IEEE float, A-law or mu-law; empty for other codecs
+	Lossless   bool
+	SampleRate int
+	Channels   int
+	BitDepth   int   // valid bits per sample
+	Frames     int64 // samples per channel
+	DataOffset int64
+	DataBytes  int64
+
+	SampleBytes int  // container bytes per sample
+	BigEndian   bool // AIFF, except little-endian "sowt" AIFC
+	Unsigned    bool // offset binary: 8-bit WAV and "raw " AIFC
+
+	// Metadata lists the text and ID3 chunks, whose bodies are not read
+	Metadata []PCMChunk
+}
+
+// PCMChunk locates a chunk body within the file
+type PCMChunk struct {
+	ID     string
+	Offset int64
+	Size   int64
+}
+
+// Duration returns the playing time from the frame count
+func (i *PCMInfo) Duration() time.Duration {
+	if i.SampleRate == 0 {
+		return 0
+	}
+	return time.Duration(float64(i.Frames) / float64(i.SampleRate) * float64(time.Second))
+}
+
+// BitRate returns the average bit rate of the audio data in kbps
+func (i *PCMInfo) BitRate() int {
+	seconds := i.Duration().Seconds()
+	if seconds <= 0 {
+		return 0
+	}
+	return int(float64(i.DataBytes)*8/seconds/1000 + 0.5)
+}
+
+// ReadPCMInfo walks the chunks of a WAV or AIFF file
+func ReadPCMInfo(r io.ReadSeeker, size int64) (*PCMInfo, error) {
+	header := make([]byte, 12)
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+
+	info := &PCMInfo{}
+	var order binary.ByteOrder
+	riff, form := string(header[:4]), string(header[8:12])
+	switch {
+	case (riff == "RIFF" || riff == "RF64" || riff == "BW64") && form == "WAVE":
+		info.Container = "wav"
+		order = binary.LittleEndian
+	case riff == "FORM" && (form == "AIFF" || form == "AIFC"):
+		info.Container = "aiff"
+		info.BigEndian = true
+		order = binary.BigEndian
+	default:
+		return nil, ErrNotPCMContainer
+	}
+
+	var formatTag uint16
+	var blockAlign, factFrames, ds64DataSize int64
+	chunk := make([]byte, 48)
+
+	for offset := int64(12); offset+8 <= size; {
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		if _, err := io.ReadFull(r, chunk[:8]); err != nil {
+			break
+		}
+		id := string(chunk[:4])
+		chunkSize := int64(order.Uint32(chunk[4:8]))
+		body := offset + 8
+
+		// RF64 stores the real data size in ds64; an unfinished recording may
+		// leave the size at zero or claim more than the file holds
+		if id == "data" {
+			switch {
+			case chunkSize == math.MaxUint32 && ds64DataSize > 0:
+				chunkSize = ds64DataSize
+			case chunkSize == 0:
+				chunkSize = size - body
+			}
+		}
+		chunkSize = min(chunkSize, size-body)
+
+		fields := chunk[8 : 8+min(chunkSize, 40)]
+		if _, err := io.ReadFull(r, fields); err != nil {
+			break
+		}
+
+		switch id {
+		case "fmt ":
+			if len(fields) < 16 {
+				return nil, ErrNotPCMContainer
+			}
+			formatTag = order.Uint16(fields[0:2])
+			info.Channels = int(order.Uint16(fields[2:4]))
+			info.SampleRate = int(order.Uint32(fields[4:8]))
+			blockAlign = int64(order.Uint16(fields[12:14]))
+			info.BitDepth = int(order.Uint16(fields[14:16]))
+			if formatTag == waveFormatExtensible && len(fields) >= 26 {
+				// The sub-format GUID starts with the plain format tag; the
+				// container size may exceed the valid bits (24 in 32)
+				formatTag = order.Uint16(fields[24:26])
+				if valid := int(order.Uint16(fields[18:20])); valid > 0 {
+					info.BitDepth = valid
+				}
+			}
+		case "ds64":
+			if len(fields) >= 24 {
+				ds64DataSize = int64(order.Uint64(fields[8:16]))
+				factFrames = int64(order.Uint64(fields[16:24]))
+			}
+		case "fact":
+			if len(fields) >= 4 && factFrames == 0 {
+				factFrames = int64(order.Uint32(fields[:4]))
+			}
+		case "data":
+			info.DataOffset, info.DataBytes = body, chunkSize
+		case "COMM":
+			parseCOMM(info, fields)
+		case "SSND":
+			// Sample data starts after the offset and block size fields
+			if len(fields) >= 8 {
+				skip := 8 + int64(binary.BigEndian.Uint32(fields[0:4]))
+				info.DataOffset, info.DataBytes = body+skip, max(chunkSize-skip, 0)
+			}
+		case "LIST", "id3 ", "ID3 ", "NAME", "AUTH", "ANNO", "(c) ":
+			info.Metadata = append(info.Metadata, PCMChunk{ID: id, Offset: body, Size: chunkSize})
+		}
+
+		// Chunks are padded to an even length
+		offset = body + chunkSize + chunkSize&1
+	}
+
+	if info.Container == "wav" {
+		setWAVCodec(info, formatTag, blockAlign, factFrames)
+	}
+	if info.Channels == 0 || info.SampleRate == 0 {
+		return nil, ErrNotPCMContainer
+	}
+	return info, nil
+}
+
+// setWAVCodec names the fmt chunk's codec and derives the frame count
+func setWAVCodec(info *PCMInfo, formatTag uint16, blockAlign, factFrames int64) {
+	switch formatTag {
+	case waveFormatPCM:
+		info.Codec, info.Lossless = "PCM", true
+	case waveFormatFloat:
+		info.Codec, info.Lossless = "IEEE float", true
+	case waveFormatALaw:
+		info.Codec = "A-law"
+	case waveFormatMuLaw:
+		info.Codec = "mu-law"
+	}
+	if info.Channels > 0 && blockAlign%int64(info.Channels) == 0 {
+		info.SampleBytes = int(blockAlign) / info.Channels
+		info.Unsigned = info.SampleBytes == 1
+	}
+
+	switch {
+	case info.Codec != "" && blockAlign > 0:
+		info.Frames = info.DataBytes / blockAlign
+	default:
+		// Compressed WAV carries its length in the fact chunk
+		info.Frames = factFrames
+	}
+	if !info.Lossless {
+		info.BitDepth = 0
+	}
+}
+
+// parseCOMM reads the AIFF common chunk and, for AIFC, its compression type
+func parseCOMM(info *PCMInfo, comm []byte) {
+	if len(comm) < 18 {
+		return
+	}
+	info.Channels = int(binary.BigEndian.Uint16(comm[0:2]))
+	info.Frames = int64(binary.BigEndian.Uint32(comm[2:6]))
+	info.BitDepth = int(binary.BigEndian.Uint16(comm[6:8]))
+	info.SampleBytes = (info.BitDepth + 7) / 8
+	info.SampleRate = int(extendedToFloat(comm[8:18]) + 0.5)
+
+	info.Codec, info.Lossless = "PCM", true
+	if len(comm) < 22 {
+		return
+	}
+	switch string(comm[18:22]) {
+	case "NONE", "twos", "in24", "in32":
+	case "raw ":
+		info.Unsigned = true
+	case "sowt":
+		info.BigEndian = false
+	case "fl32", "FL32":
+		info.Codec, info.SampleBytes = "IEEE float", 4
+	case "fl64", "FL64":
+		info.Codec, info.SampleBytes = "IEEE float", 8
+	case "alaw", "ALAW":
+		info.Codec, info.Lossless, info.BitDepth = "A-law", false, 0
+	case "ulaw", "ULAW":
+		info.Codec, info.Lossless, info.BitDepth = "mu-law", false, 0
+	default:
+		info.Codec, info.Lossless, info.BitDepth = "", false, 0
+	}
+}
+
+// extendedToFloat converts an 80-bit IEEE 754 extended float, as used for AIFF sample rates
+func extendedToFloat(b []byte) float64 {
+	exponent := int(binary.BigEndian.Uint16(b[0:2]) & 0x7fff)
+	mantissa := binary.BigEndian.Uint64(b[2:10])
+	if exponent == 0 && mantissa == 0 {
+		return 0
+	}
+	value := math.Ldexp(float64(mantissa), exponent-16383-63)
+	if b[0]&0x80 != 0 {
+		value = -value
+	}
+	return value
+}
@@ -0,0 +1,81 @@
+package decoder
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// SampleReader decodes an audio file into normalized float samples for analysis
+type SampleReader struct {
+	decoder Decoder
+	format  Format
+	buf     []byte
+}
+
+// OpenSampleReader opens a local audio file for sample-level analysis
+func OpenSampleReader(filePath string) (*SampleReader, error) {
+	decoder, err := Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return NewSampleReader(decoder), nil
+}
+
+// NewSampleReader reads the samples of an open decoder, such as a file segment
+func NewSampleReader(decoder Decoder) *SampleReader {
+	return &SampleReader{decoder: decoder, format: decoder.Format()}
+}
+
+// Format returns the layout of the decoded samples
+func (r *SampleReader) Format() Format {
+	return r.format
+}
+
+// Frames returns the total number of frames in the stream
+func (r *SampleReader) Frames() int64 {
+	return r.decoder.Frames()
+}
+
+// Read fills dst with interleaved samples in [-1, 1) and returns how many were written
+// Only whole frames are returned, so len(dst) should be a multiple of the channel count
+func (r *SampleReader) Read(dst []float64) (int, error) {
+	bytesPerSample := r.format.BitsPerSample / 8
+	frames := len(dst) / r.format.Channels
+	if frames == 0 {
+		return 0, nil
+	}
+
+	size := frames * r.format.BlockAlign()
+	if cap(r.buf) < size {
+		r.buf = make([]byte, size)
+	}
+	buf := r.buf[:size]
+
+	n, err := io.ReadFull(r.decoder, buf)
+	n -= n % r.format.BlockAlign()
+	if err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	if n == 0 && err == nil {
+		err = io.EOF
+	}
+
+	count := n / bytesPerSample
+	for i := 0; i < count; i++ {
+		offset := i * bytesPerSample
+		switch bytesPerSample {
+		case 2:
+			dst[i] = float64(int16(binary.LittleEndian.Uint16(buf[offset:]))) / (1 << 15)
+		case 3:
+			sample := int32(buf[offset]) | int32(buf[offset+1])<<8 | int32(int8(buf[offset+2]))<<16
+			dst[i] = float64(sample) / (1 << 23)
+		}
+	}
+
+	return count, err
+}
+
+// Close releases the underlying file
+func (r *SampleReader) Close() error {
+	return r.decoder.Close()
+}
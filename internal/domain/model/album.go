@@ -16,6 +16,10 @@ type Album struct {
 	Year      int    `json:"year"`
 	Genre     string `json:"genre"`
 
+	// MusicBrainz release and release group, when the tracks are tagged with them
+	MusicBrainzAlbumID        string `json:"musicBrainzAlbumId,omitempty"`
+	MusicBrainzReleaseGroupID string `json:"musicBrainzReleaseGroupId,omitempty"`
+
 	// Artwork
 	ArtworkPath string `json:"artworkPath,omitempty"`
 
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"math"
+	"time"
+)
+
+// ReplayGainReference is the loudness ReplayGain 2.0 gains normalize to
+const ReplayGainReference = -18.0 // LUFS
+
+// LoudnessGate is the EBU R128 absolute gate; audio with no blocks above it
+// is reported at this integrated loudness
+const LoudnessGate = -70.0 // LUFS
+
+// Loudness holds EBU R128 measurements
+type Loudness struct {
+	Integrated float64 `json:"integrated"` // LUFS
+	TruePeak   float64 `json:"truePeak"`   // dBTP
+	Range      float64 `json:"range"`      // LU
+}
+
+// IsSilent reports whether gating left nothing to measure
+func (l *Loudness) IsSilent() bool {
+	return l.Integrated <= LoudnessGate
+}
+
+// Gain returns the ReplayGain 2.0 gain in dB for this loudness
+func (l *Loudness) Gain() float64 {
+	return ReplayGainReference - l.Integrated
+}
+
+// LinearPeak returns the true peak as a linear amplitude
+func (l *Loudness) LinearPeak() float64 {
+	return math.Pow(10, l.TruePeak/20)
+}
+
+// AnalysisVersion identifies the set of measurements in a TrackAnalysis
+// Bumping it makes earlier results stale so tracks are analyzed again
+const AnalysisVersion = 2
+
+// TrackAnalysis is GoMusic's stored audio analysis of a track
+// Results live in the library store rather than in the file's tags
+type TrackAnalysis struct {
+	Version    int       `json:"version"`
+	TrackID    string    `json:"trackId"`
+	FileSize   int64     `json:"fileSize"`
+	ModifiedAt time.Time `json:"modifiedAt"` // file modification time when analyzed
+	AnalyzedAt time.Time `json:"analyzedAt"`
+
+	Loudness      *Loudness `json:"loudness,omitempty"`
+	AlbumLoudness *Loudness `json:"albumLoudness,omitempty"`
+
+	BPM float64    `json:"bpm,omitempty"`
+	Key MusicalKey `json:"key,omitempty"`
+}
+
+// IsCurrent reports whether the analysis is complete and still matches the track's file
+func (a *TrackAnalysis) IsCurrent(track *Track) bool {
+	return a.Version == AnalysisVersion && a.FileSize == track.FileSize && a.ModifiedAt.Equal(track.ModifiedAt)
+}
@@ -12,6 +12,9 @@ type Artist struct {
 	// Metadata
 	Name string `json:"name"`
 
+	// MusicBrainz artist, when the tracks are tagged with it
+	MusicBrainzArtistID string `json:"musicBrainzArtistId,omitempty"`
+
 	// Image
 	ImagePath string `json:"imagePath,omitempty"`
 
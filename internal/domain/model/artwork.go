@@ -0,0 +1,25 @@
+package model
+
+// ArtworkCacheConfig holds the limits of the artwork cache
+type ArtworkCacheConfig struct {
+	// MaxSizeMB caps the cache; the least recently stored images are evicted
+	// beyond it and cached again from their source when next shown (0 = no cap)
+	MaxSizeMB int `json:"maxSizeMB"`
+}
+
+// Validate validates the artwork cache configuration
+func (c *ArtworkCacheConfig) Validate() error {
+	if c.MaxSizeMB < 0 {
+		return ErrInvalidConfig("artwork cache size cannot be negative")
+	}
+	return nil
+}
+
+// ArtworkCacheStats describes the artwork cache and what a cleanup removed
+type ArtworkCacheStats struct {
+	Files        int   `json:"files"`
+	Bytes        int64 `json:"bytes"`
+	MaxBytes     int64 `json:"maxBytes"` // 0 when uncapped
+	RemovedFiles int   `json:"removedFiles"`
+	FreedBytes   int64 `json:"freedBytes"`
+}
@@ -4,8 +4,13 @@ import "time"
 
 // AppConfig represents the complete application configuration
 type AppConfig struct {
-	Version string                `json:"version"`
-	Sources []SourceConfiguration `json:"sources"`
+	Version        string                `json:"version"`
+	Sources        []SourceConfiguration `json:"sources"`
+	Scrobbler      *ScrobblerConfig      `json:"scrobbler,omitempty"`
+	SubsonicServer *SubsonicServerConfig `json:"subsonicServer,omitempty"`
+	DLNA           *DLNAConfig           `json:"dlna,omitempty"`
+	Playback       *PlaybackConfig       `json:"playback,omitempty"`
+	ArtworkCache   *ArtworkCacheConfig   `json:"artworkCache,omitempty"`
 }
 
 // SourceConfiguration represents a configured music source
@@ -55,13 +60,61 @@ func (sc *SourceConfiguration) ToFilesystemConfig() (*FilesystemSourceConfig, er
 		}
 	}
 
+	// Extract file naming patterns
+	var namingPatterns []string
+	if patterns, ok := sc.Config["naming_patterns"].([]interface{}); ok {
+		for _, p := range patterns {
+			if pattern, ok := p.(string); ok {
+				namingPatterns = append(namingPatterns, pattern)
+			}
+		}
+	}
+
+	// Extract folder artwork settings
+	var artworkPatterns []string
+	if patterns, ok := sc.Config["artwork_patterns"].([]interface{}); ok {
+		for _, p := range patterns {
+			if pattern, ok := p.(string); ok {
+				artworkPatterns = append(artworkPatterns, pattern)
+			}
+		}
+	}
+	artworkPreference, _ := sc.Config["artwork_preference"].(string)
+
 	return &FilesystemSourceConfig{
-		RootPath:         rootPath,
-		WatchForChanges:  watchForChanges,
-		SupportedFormats: supportedFormats,
+		RootPath:          rootPath,
+		WatchForChanges:   watchForChanges,
+		SupportedFormats:  supportedFormats,
+		NamingPatterns:    namingPatterns,
+		ArtworkPatterns:   artworkPatterns,
+		ArtworkPreference: ArtworkPreference(artworkPreference),
 	}, nil
 }
 
+// ToAPIConfig converts the generic config map to APISourceConfig
+func (sc *SourceConfiguration) ToAPIConfig() (*APISourceConfig, error) {
+	config := &APISourceConfig{}
+
+	config.BaseURL, _ = sc.Config["base_url"].(string)
+	config.APIKey, _ = sc.Config["api_key"].(string)
+	config.Username, _ = sc.Config["username"].(string)
+	config.Password, _ = sc.Config["password"].(string)
+
+	// JSON numbers are decoded as float64
+	if timeout, ok := sc.Config["timeout_seconds"].(float64); ok {
+		config.Timeout = time.Duration(timeout * float64(time.Second))
+	}
+	if rateLimit, ok := sc.Config["rate_limit"].(float64); ok {
+		config.RateLimit = int(rateLimit)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
 // NewSourceConfiguration creates a new source configuration
 func NewSourceConfiguration(id, name string, sourceType SourceType) *SourceConfiguration {
 	now := time.Now()
@@ -0,0 +1,163 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MusicalKey is a track's key in standard notation, e.g. "C", "F#m", "Bb"
+// Tonics use the spelling most DJ software shows; the zero value means unknown
+type MusicalKey string
+
+// Tonic names by pitch class (0 = C) for major and minor keys
+var (
+	majorKeyNames = [12]string{"C", "Db", "D", "Eb", "E", "F", "F#", "G", "Ab", "A", "Bb", "B"}
+	minorKeyNames = [12]string{"Cm", "C#m", "Dm", "Ebm", "Em", "Fm", "F#m", "Gm", "G#m", "Am", "Bbm", "Bm"}
+)
+
+// noteOffsets maps note letters to pitch classes
+var noteOffsets = map[byte]int{'C': 0, 'D': 2, 'E': 4, 'F': 5, 'G': 7, 'A': 9, 'B': 11}
+
+// NewMusicalKey builds a key from a pitch class (0 = C) and mode
+func NewMusicalKey(pitchClass int, minor bool) MusicalKey {
+	pitchClass = ((pitchClass % 12) + 12) % 12
+	if minor {
+		return MusicalKey(minorKeyNames[pitchClass])
+	}
+	return MusicalKey(majorKeyNames[pitchClass])
+}
+
+// ParseMusicalKey parses key tags such as "Am", "A minor", "F#", "Gbmaj", "8A" or "1d"
+// Returns "" when the value is not a recognizable key
+func ParseMusicalKey(value string) MusicalKey {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return ""
+	}
+
+	if key, ok := parseWheelKey(value); ok {
+		return key
+	}
+
+	value = strings.NewReplacer("♯", "#", "♭", "b", " ", "").Replace(value)
+	pitchClass, ok := noteOffsets[strings.ToUpper(value[:1])[0]]
+	if !ok {
+		return ""
+	}
+	rest := value[1:]
+
+	switch {
+	case strings.HasPrefix(rest, "#"):
+		pitchClass++
+		rest = rest[1:]
+	case strings.HasPrefix(rest, "b"):
+		pitchClass--
+		rest = rest[1:]
+	}
+
+	switch strings.ToLower(rest) {
+	case "", "maj", "major":
+		return NewMusicalKey(pitchClass, false)
+	case "m", "min", "minor":
+		return NewMusicalKey(pitchClass, true)
+	}
+	return ""
+}
+
+// parseWheelKey parses Camelot ("8A") and Open Key ("1m") notation
+func parseWheelKey(value string) (MusicalKey, bool) {
+	suffix := strings.ToUpper(value[len(value)-1:])
+	number, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || number < 1 || number > 12 {
+		return "", false
+	}
+
+	switch suffix {
+	case "A", "B":
+		return fromCamelot(number, suffix == "A"), true
+	case "M", "D":
+		// Open Key 1 sits at Camelot 8
+		return fromCamelot((number+6)%12+1, suffix == "M"), true
+	}
+	return "", false
+}
+
+// fromCamelot converts a Camelot wheel position to a key
+func fromCamelot(number int, minor bool) MusicalKey {
+	// Camelot numbers step by fifths: 7 semitones per position, 8B = C
+	pitchClass := (number - 8) * 7
+	if minor {
+		// The relative minor sits three semitones below its major
+		pitchClass -= 3
+	}
+	return NewMusicalKey(pitchClass, minor)
+}
+
+// PitchClass returns the tonic's pitch class (0 = C) and whether the key is minor
+// ok is false for an unknown key
+func (k MusicalKey) PitchClass() (pitchClass int, minor bool, ok bool) {
+	for i := range majorKeyNames {
+		if string(k) == majorKeyNames[i] {
+			return i, false, true
+		}
+		if string(k) == minorKeyNames[i] {
+			return i, true, true
+		}
+	}
+	return 0, false, false
+}
+
+// Camelot returns the key in Camelot wheel notation, e.g. "8A" for A minor
+// Returns "" for an unknown key
+func (k MusicalKey) Camelot() string {
+	number, minor, ok := k.camelotPosition()
+	if !ok {
+		return ""
+	}
+	if minor {
+		return fmt.Sprintf("%dA", number)
+	}
+	return fmt.Sprintf("%dB", number)
+}
+
+// CamelotOrder returns a sort rank following the Camelot wheel (1A, 1B, 2A, ...)
+// Unknown keys rank last
+func (k MusicalKey) CamelotOrder() int {
+	number, minor, ok := k.camelotPosition()
+	if !ok {
+		return 100
+	}
+	if minor {
+		return number * 2
+	}
+	return number*2 + 1
+}
+
+// IsHarmonicWith reports whether two keys mix harmonically: the same key, its
+// relative major/minor, or a neighbour one step around the Camelot wheel
+func (k MusicalKey) IsHarmonicWith(other MusicalKey) bool {
+	a, aMinor, ok := k.camelotPosition()
+	b, bMinor, otherOK := other.camelotPosition()
+	if !ok || !otherOK {
+		return false
+	}
+	if aMinor != bMinor {
+		return a == b
+	}
+	distance := (a - b + 12) % 12
+	return distance == 0 || distance == 1 || distance == 11
+}
+
+func (k MusicalKey) camelotPosition() (number int, minor bool, ok bool) {
+	pitchClass, minor, ok := k.PitchClass()
+	if !ok {
+		return 0, false, false
+	}
+	if minor {
+		pitchClass += 3
+	}
+	// C major is 8B and each fifth up moves one position clockwise
+	number = (pitchClass*7+7)%12 + 1
+	return number, minor, true
+}
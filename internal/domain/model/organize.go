@@ -0,0 +1,50 @@
+package model
+
+// DefaultOrganizeTemplate is the layout used when no template is given
+const DefaultOrganizeTemplate = "%albumartist%/%album%/%disc%-%track% %title%"
+
+// FileMove is a file the organizer moves within a source
+type FileMove struct {
+	From     string   `json:"from"`
+	To       string   `json:"to"`
+	TrackIDs []string `json:"trackIds,omitempty"` // tracks stored in the file; empty for sidecar files
+}
+
+// OrganizeSkip is a track or sidecar file the organizer leaves where it is
+type OrganizeSkip struct {
+	TrackID  string `json:"trackId,omitempty"`
+	FilePath string `json:"filePath"`
+	Reason   string `json:"reason"`
+}
+
+// OrganizePlan lists the moves that arrange a source's files by a template
+type OrganizePlan struct {
+	SourceID string          `json:"sourceId"`
+	Template string          `json:"template"`
+	Moves    []*FileMove     `json:"moves"`
+	Skipped  []*OrganizeSkip `json:"skipped"`
+}
+
+// MovePhase is how far an organizer run has got in moving its files
+type MovePhase string
+
+const (
+	MovePhaseAside MovePhase = "aside" // files are renamed to temporary names beside them
+	MovePhasePlace MovePhase = "place" // files are renamed from there to their new paths
+)
+
+// JournaledMove is a file move with the temporary name it passes through
+type JournaledMove struct {
+	From string `json:"from"`
+	Temp string `json:"temp"`
+	To   string `json:"to"`
+}
+
+// MoveJournal records an organizer run while its files move, so that a run cut
+// short by a crash can be rolled back at the next start
+type MoveJournal struct {
+	SourceID string           `json:"sourceId"`
+	Root     string           `json:"root"`
+	Phase    MovePhase        `json:"phase"`
+	Moves    []*JournaledMove `json:"moves"`
+}
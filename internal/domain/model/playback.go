@@ -0,0 +1,33 @@
+package model
+
+// NormalizationMode selects which ReplayGain value the player applies
+type NormalizationMode string
+
+const (
+	NormalizationOff   NormalizationMode = "off"
+	NormalizationTrack NormalizationMode = "track"
+	NormalizationAlbum NormalizationMode = "album"
+
+	// NormalizationAuto uses album gain while an album plays in order and track gain otherwise
+	NormalizationAuto NormalizationMode = "auto"
+)
+
+// PlaybackConfig holds the user's playback preferences
+type PlaybackConfig struct {
+	NormalizationMode NormalizationMode `json:"normalizationMode"`
+
+	// PregenerateWaveforms computes seek bar waveforms after each scan instead of on first play
+	PregenerateWaveforms bool `json:"pregenerateWaveforms"`
+}
+
+// Validate validates the playback configuration and fills in defaults
+func (c *PlaybackConfig) Validate() error {
+	switch c.NormalizationMode {
+	case "":
+		c.NormalizationMode = NormalizationAuto
+	case NormalizationOff, NormalizationTrack, NormalizationAlbum, NormalizationAuto:
+	default:
+		return ErrInvalidConfig("unknown normalization mode: " + string(c.NormalizationMode))
+	}
+	return nil
+}
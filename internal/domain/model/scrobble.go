@@ -0,0 +1,95 @@
+package model
+
+import "time"
+
+// ScrobblerService identifies the scrobble API flavour spoken by the endpoint
+type ScrobblerService string
+
+const (
+	ScrobblerServiceListenBrainz ScrobblerService = "listenbrainz"
+	ScrobblerServiceLastFM       ScrobblerService = "lastfm"
+)
+
+// Default endpoints used when no base URL is configured
+const (
+	DefaultListenBrainzURL = "https://api.listenbrainz.org"
+	DefaultLastFMURL       = "https://ws.audioscrobbler.com/2.0/"
+)
+
+// ScrobblerConfig holds credentials and endpoint settings for play submission
+type ScrobblerConfig struct {
+	Enabled bool             `json:"enabled"`
+	Service ScrobblerService `json:"service"`
+	BaseURL string           `json:"baseUrl"`
+	Timeout time.Duration    `json:"timeout"`
+
+	// ListenBrainz
+	Token string `json:"token,omitempty"`
+
+	// Last.fm
+	APIKey     string `json:"apiKey,omitempty"`
+	APISecret  string `json:"apiSecret,omitempty"`
+	SessionKey string `json:"sessionKey,omitempty"`
+	Username   string `json:"username,omitempty"`
+	Password   string `json:"password,omitempty"`
+}
+
+// Validate validates the scrobbler configuration and fills in defaults
+func (c *ScrobblerConfig) Validate() error {
+	if c.Service == "" {
+		c.Service = ScrobblerServiceListenBrainz
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 10 * time.Second
+	}
+
+	switch c.Service {
+	case ScrobblerServiceListenBrainz:
+		if c.BaseURL == "" {
+			c.BaseURL = DefaultListenBrainzURL
+		}
+		if c.Enabled && c.Token == "" {
+			return ErrInvalidConfig("ListenBrainz user token is required")
+		}
+	case ScrobblerServiceLastFM:
+		if c.BaseURL == "" {
+			c.BaseURL = DefaultLastFMURL
+		}
+		if c.Enabled && (c.APIKey == "" || c.APISecret == "") {
+			return ErrInvalidConfig("Last.fm API key and secret are required")
+		}
+		if c.Enabled && c.SessionKey == "" && (c.Username == "" || c.Password == "") {
+			return ErrInvalidConfig("Last.fm session key or username/password is required")
+		}
+	default:
+		return ErrInvalidConfig("unsupported scrobbler service: " + string(c.Service))
+	}
+
+	return nil
+}
+
+// Scrobble represents a single play waiting to be submitted
+type Scrobble struct {
+	TrackID     string        `json:"trackId"`
+	Title       string        `json:"title"`
+	Artist      string        `json:"artist"`
+	Album       string        `json:"album,omitempty"`
+	AlbumArtist string        `json:"albumArtist,omitempty"`
+	TrackNumber int           `json:"trackNumber,omitempty"`
+	Duration    time.Duration `json:"duration"`
+	ListenedAt  time.Time     `json:"listenedAt"`
+}
+
+// NewScrobble creates a scrobble for a track started at the given time
+func NewScrobble(track *Track, listenedAt time.Time) *Scrobble {
+	return &Scrobble{
+		TrackID:     track.ID,
+		Title:       track.Title,
+		Artist:      track.Artist,
+		Album:       track.Album,
+		AlbumArtist: track.AlbumArtist,
+		TrackNumber: track.TrackNumber,
+		Duration:    track.Duration,
+		ListenedAt:  listenedAt,
+	}
+}
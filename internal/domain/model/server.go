@@ -0,0 +1,48 @@
+package model
+
+// DefaultSubsonicListenAddress is the port commonly used by Subsonic-compatible servers
+const DefaultSubsonicListenAddress = ":4533"
+
+// SubsonicServerConfig holds settings for the embedded Subsonic-compatible server
+type SubsonicServerConfig struct {
+	Enabled       bool   `json:"enabled"`
+	ListenAddress string `json:"listenAddress"`
+	Username      string `json:"username"`
+	Password      string `json:"password"`
+}
+
+// Validate validates the server configuration and fills in defaults
+func (c *SubsonicServerConfig) Validate() error {
+	if c.ListenAddress == "" {
+		c.ListenAddress = DefaultSubsonicListenAddress
+	}
+	if c.Enabled && (c.Username == "" || c.Password == "") {
+		return ErrInvalidConfig("username and password are required to enable the Subsonic server")
+	}
+
+	return nil
+}
+
+// DefaultDLNAListenAddress is the HTTP address used for DLNA descriptions and streams
+const DefaultDLNAListenAddress = ":8200"
+
+// DLNAConfig holds settings for the embedded UPnP AV MediaServer
+type DLNAConfig struct {
+	Enabled       bool   `json:"enabled"`
+	FriendlyName  string `json:"friendlyName"`
+	ListenAddress string `json:"listenAddress"`
+	// Interface is the network interface used for SSDP; empty uses the system default
+	Interface string `json:"interface,omitempty"`
+}
+
+// Validate validates the DLNA configuration and fills in defaults
+func (c *DLNAConfig) Validate() error {
+	if c.ListenAddress == "" {
+		c.ListenAddress = DefaultDLNAListenAddress
+	}
+	if c.FriendlyName == "" {
+		c.FriendlyName = "GoMusic"
+	}
+
+	return nil
+}
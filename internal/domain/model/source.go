@@ -1,6 +1,7 @@
 package model
 
 import (
+	"slices"
 	"time"
 )
 
@@ -10,6 +11,7 @@ type SourceType string
 const (
 	SourceTypeFilesystem    SourceType = "filesystem"
 	SourceTypeAPISelfHosted SourceType = "api-selfhosted"
+	SourceTypeJellyfin      SourceType = "api-jellyfin"
 )
 
 // Source represents a music source (filesystem or API)
@@ -37,16 +39,47 @@ type SourceConfig interface {
 type FilesystemSourceConfig struct {
 	RootPath         string   `json:"rootPath"`
 	WatchForChanges  bool     `json:"watchForChanges"`
-	SupportedFormats []string `json:"supportedFormats"` // [".mp3", ".flac", ".m4a", ".ogg"]
+	SupportedFormats []string `json:"supportedFormats"` // [".mp3", ".flac", ".m4a", ".ogg", ".opus", ".wav", ".aiff"]
+
+	// Patterns such as "%albumartist%/%year% - %album%/%track% %title%" that fill
+	// in tags missing from a file using its path below RootPath; first match wins
+	NamingPatterns []string `json:"namingPatterns,omitempty"`
+
+	// Image file patterns such as "cover.*", searched in order in a track's folder
+	// and then its parent; DefaultArtworkPatterns when empty
+	ArtworkPatterns []string `json:"artworkPatterns,omitempty"`
+
+	// Which image wins when a file has a picture embedded and one beside it
+	ArtworkPreference ArtworkPreference `json:"artworkPreference,omitempty"`
+}
+
+// ArtworkPreference chooses between a file's embedded picture and a folder image
+type ArtworkPreference string
+
+const (
+	ArtworkPreferEmbedded ArtworkPreference = "embedded" // the default
+	ArtworkPreferFolder   ArtworkPreference = "folder"
+)
+
+// SupportedFormats lists the audio file extensions a filesystem source can scan,
+// and the formats a source scans unless it names its own
+var SupportedFormats = []string{".mp3", ".flac", ".m4a", ".ogg", ".oga", ".opus", ".wav", ".aif", ".aiff", ".aifc", ".wv", ".ape", ".dsf", ".dff"}
+
+// IsSupportedFormat reports whether a file extension is in SupportedFormats
+func IsSupportedFormat(extension string) bool {
+	return slices.Contains(SupportedFormats, extension)
 }
 
+// DefaultArtworkPatterns are the folder images searched when a source names none
+var DefaultArtworkPatterns = []string{"cover.*", "folder.*", "front.*", "album.*", "albumart*.*"}
+
 // Validate validates the filesystem source configuration
 func (c *FilesystemSourceConfig) Validate() error {
 	if c.RootPath == "" {
 		return ErrInvalidConfig("root path is required")
 	}
 	if len(c.SupportedFormats) == 0 {
-		c.SupportedFormats = []string{".mp3", ".flac", ".m4a", ".ogg"}
+		c.SupportedFormats = slices.Clone(SupportedFormats)
 	}
 	return nil
 }
@@ -60,6 +93,8 @@ func (c *FilesystemSourceConfig) GetType() SourceType {
 type APISourceConfig struct {
 	BaseURL   string        `json:"baseUrl"`
 	APIKey    string        `json:"apiKey,omitempty"`
+	Username  string        `json:"username,omitempty"`
+	Password  string        `json:"password,omitempty"`
 	Timeout   time.Duration `json:"timeout"`
 	RateLimit int           `json:"rateLimit"` // Requests per second
 }
@@ -69,6 +104,9 @@ func (c *APISourceConfig) Validate() error {
 	if c.BaseURL == "" {
 		return ErrInvalidConfig("base URL is required")
 	}
+	if c.APIKey == "" && c.Username == "" {
+		return ErrInvalidConfig("API key or username is required")
+	}
 	if c.Timeout == 0 {
 		c.Timeout = 30 * time.Second
 	}
@@ -0,0 +1,93 @@
+package model
+
+import "time"
+
+// TagChanges lists tag values to write to a track's file
+// Nil fields are left unchanged; an empty string or zero removes the tag
+type TagChanges struct {
+	Title       *string `json:"title,omitempty"`
+	Artist      *string `json:"artist,omitempty"`
+	Album       *string `json:"album,omitempty"`
+	AlbumArtist *string `json:"albumArtist,omitempty"`
+	Genre       *string `json:"genre,omitempty"`
+	Year        *int    `json:"year,omitempty"`
+	TrackNumber *int    `json:"trackNumber,omitempty"`
+	DiscNumber  *int    `json:"discNumber,omitempty"`
+}
+
+// IsEmpty reports whether no field is changed
+func (c *TagChanges) IsEmpty() bool {
+	return c.Title == nil && c.Artist == nil && c.Album == nil && c.AlbumArtist == nil &&
+		c.Genre == nil && c.Year == nil && c.TrackNumber == nil && c.DiscNumber == nil
+}
+
+// TagField names a tag field that can be edited
+type TagField string
+
+const (
+	TagFieldTitle       TagField = "title"
+	TagFieldArtist      TagField = "artist"
+	TagFieldAlbum       TagField = "album"
+	TagFieldAlbumArtist TagField = "albumArtist"
+	TagFieldGenre       TagField = "genre"
+	TagFieldYear        TagField = "year"
+	TagFieldTrackNumber TagField = "trackNumber"
+	TagFieldDiscNumber  TagField = "discNumber"
+)
+
+// TagOperationType is the kind of edit a TagOperation makes
+type TagOperationType string
+
+const (
+	TagOpSet       TagOperationType = "set"       // replace the value with Value
+	TagOpClear     TagOperationType = "clear"     // remove the value
+	TagOpReplace   TagOperationType = "replace"   // replace every Find with Replace
+	TagOpRegex     TagOperationType = "regex"     // replace matches of the Find pattern; Replace may use $1
+	TagOpNumber    TagOperationType = "number"    // number tracks from Start in the order given
+	TagOpTitleCase TagOperationType = "titleCase" // capitalize words, keeping short joining words lowercase
+)
+
+// TagOperation is one edit applied to a field of every track in a batch
+type TagOperation struct {
+	Field   TagField         `json:"field"`
+	Type    TagOperationType `json:"type"`
+	Value   string           `json:"value,omitempty"`
+	Find    string           `json:"find,omitempty"`
+	Replace string           `json:"replace,omitempty"`
+	Start   int              `json:"start,omitempty"` // first number for TagOpNumber; defaults to 1
+}
+
+// TagFieldChange is the old and new value of one field
+type TagFieldChange struct {
+	Field TagField `json:"field"`
+	Old   string   `json:"old"`
+	New   string   `json:"new"`
+}
+
+// TagEditPreview is the dry-run result of a batch for one file
+// Error is set when the file cannot be edited; such files are skipped
+type TagEditPreview struct {
+	TrackID  string           `json:"trackId"`
+	FilePath string           `json:"filePath"`
+	Changes  []TagFieldChange `json:"changes"`
+	Error    string           `json:"error,omitempty"`
+}
+
+// TagEditBatch is a journal record of a batch written to files
+// An entry is marked applied once its file is written, so undo only touches files
+// that were actually changed
+type TagEditBatch struct {
+	ID        string          `json:"id"`
+	CreatedAt time.Time       `json:"createdAt"`
+	UndoneAt  *time.Time      `json:"undoneAt,omitempty"`
+	Entries   []*TagEditEntry `json:"entries"`
+}
+
+// TagEditEntry records the changes made to one file
+type TagEditEntry struct {
+	TrackID  string           `json:"trackId"`
+	FilePath string           `json:"filePath"`
+	Changes  []TagFieldChange `json:"changes"`
+	Applied  bool             `json:"applied"`
+	Error    string           `json:"error,omitempty"`
+}
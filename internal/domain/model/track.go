@@ -24,12 +24,48 @@ type Track struct {
 	DiscNumber  int           `json:"discNumber"`
 	Duration    time.Duration `json:"duration"`
 
+	// MusicBrainz identifiers written by taggers such as Picard (empty when untagged)
+	// The artist ID is left empty when the credit names several artists
+	MusicBrainzTrackID        string `json:"musicBrainzTrackId,omitempty"` // recording
+	MusicBrainzAlbumID        string `json:"musicBrainzAlbumId,omitempty"` // release
+	MusicBrainzReleaseGroupID string `json:"musicBrainzReleaseGroupId,omitempty"`
+	MusicBrainzArtistID       string `json:"musicBrainzArtistId,omitempty"`
+
+	// Fields parsed from the file's path because its tags lack them
+	InferredFields []TagField `json:"inferredFields,omitempty"`
+
 	// File-specific (for filesystem sources)
 	FilePath   string `json:"filePath,omitempty"`
 	FileSize   int64  `json:"fileSize,omitempty"`
 	Format     string `json:"format"`           // mp3, flac, m4a, ogg, etc.
 	BitRate    int    `json:"bitRate,omitempty"` // kbps
 	SampleRate int    `json:"sampleRate,omitempty"` // Hz
+	Codec      string `json:"codec,omitempty"`      // MP3, FLAC, AAC, ALAC, ...
+	BitDepth   int    `json:"bitDepth,omitempty"`   // lossless formats only
+	Lossless   bool   `json:"lossless,omitempty"`
+
+	// Set when the file is in the library but GoMusic cannot play it
+	UnplayableReason string `json:"unplayableReason,omitempty"`
+	Channels    int    `json:"channels,omitempty"`
+	ChannelMode string `json:"channelMode,omitempty"` // MP3: stereo, joint stereo, dual channel, mono
+	VBR         bool   `json:"vbr,omitempty"`
+
+	// Gapless playback (zero when unknown)
+	TotalSamples   int64 `json:"totalSamples,omitempty"`   // exact samples per channel, excluding delay and padding
+	EncoderDelay   int   `json:"encoderDelay,omitempty"`   // leading samples added by the encoder
+	EncoderPadding int   `json:"encoderPadding,omitempty"` // trailing samples added by the encoder
+
+	// CUE sheet tracks are a segment of a single-file album: Duration from SegmentStart
+	CueSheet     string        `json:"cueSheet,omitempty"` // sheet path, or the audio path for an embedded sheet
+	SegmentStart time.Duration `json:"segmentStart,omitempty"`
+
+	// Loudness normalization
+	ReplayGain *ReplayGain `json:"replayGain,omitempty"`
+	Loudness   *Loudness   `json:"loudness,omitempty"` // measured by GoMusic's analysis
+
+	// DJ metadata, from tags or GoMusic's analysis (zero when unknown)
+	BPM float64    `json:"bpm,omitempty"`
+	Key MusicalKey `json:"key,omitempty"`
 
 	// API-specific (for API sources)
 	ExternalID string `json:"externalId,omitempty"`
@@ -41,4 +77,36 @@ type Track struct {
 	// Timestamps
 	AddedAt    time.Time `json:"addedAt"`
 	ModifiedAt time.Time `json:"modifiedAt"`
-}
\ No newline at end of file
+}
+
+// IsHiRes reports whether a lossless track exceeds CD resolution in bit depth or
+// sample rate; DSD always does
+func (t *Track) IsHiRes() bool {
+	return t.Lossless && (t.BitDepth > 16 || t.SampleRate > 48000)
+}
+
+// IsInferred reports whether a field's value was parsed from the file's path
+func (t *Track) IsInferred(field TagField) bool {
+	for _, inferred := range t.InferredFields {
+		if inferred == field {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSegment reports whether the track is one CUE sheet track of a larger file
+func (t *Track) IsSegment() bool {
+	return t.CueSheet != ""
+}
+
+// ReplayGain holds loudness normalization values read from tags
+// Gains are in dB relative to the ReplayGain 2.0 reference (-18 LUFS);
+// peaks are linear sample amplitudes where 1.0 is full scale
+// Nil fields were not present in the file
+type ReplayGain struct {
+	TrackGain *float64 `json:"trackGain,omitempty"`
+	TrackPeak *float64 `json:"trackPeak,omitempty"`
+	AlbumGain *float64 `json:"albumGain,omitempty"`
+	AlbumPeak *float64 `json:"albumPeak,omitempty"`
+}
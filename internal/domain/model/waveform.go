@@ -0,0 +1,49 @@
+package model
+
+import (
+	"math"
+	"time"
+)
+
+// Waveform is a downsampled amplitude envelope of a track for drawing seek bars
+// Each bucket covers an equal share of the track; values are linear amplitudes in [0, 1]
+type Waveform struct {
+	TrackID    string    `json:"trackId"`
+	FileSize   int64     `json:"fileSize"`
+	ModifiedAt time.Time `json:"modifiedAt"` // file modification time when computed
+	Duration   float64   `json:"duration"`   // seconds
+
+	Peaks []float32 `json:"peaks"`
+	RMS   []float32 `json:"rms"`
+}
+
+// IsCurrent reports whether the waveform still matches the track's file
+func (w *Waveform) IsCurrent(track *Track) bool {
+	return w.FileSize == track.FileSize && w.ModifiedAt.Equal(track.ModifiedAt)
+}
+
+// Resample merges buckets down to the requested count
+// Peaks take the maximum and RMS values are combined by energy; a count larger
+// than the stored resolution returns the waveform unchanged
+func (w *Waveform) Resample(buckets int) *Waveform {
+	n := len(w.Peaks)
+	if buckets <= 0 || buckets >= n {
+		return w
+	}
+
+	resampled := *w
+	resampled.Peaks = make([]float32, buckets)
+	resampled.RMS = make([]float32, buckets)
+	for i := 0; i < buckets; i++ {
+		start, end := i*n/buckets, (i+1)*n/buckets
+		var peak float32
+		var energy float64
+		for j := start; j < end; j++ {
+			peak = max(peak, w.Peaks[j])
+			energy += float64(w.RMS[j]) * float64(w.RMS[j])
+		}
+		resampled.Peaks[i] = peak
+		resampled.RMS[i] = float32(math.Sqrt(energy / float64(end-start)))
+	}
+	return &resampled
+}
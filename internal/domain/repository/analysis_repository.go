@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+
+	"GoMusic/internal/domain/model"
+)
+
+// AnalysisRepository defines the interface for persisting audio analysis results
+type AnalysisRepository interface {
+	// Get returns the stored analysis of a track, or nil if it has none
+	Get(ctx context.Context, trackID string) (*model.TrackAnalysis, error)
+
+	// GetAll returns every stored analysis keyed by track ID
+	GetAll(ctx context.Context) (map[string]*model.TrackAnalysis, error)
+
+	// SaveAll stores analyses, replacing earlier results for the same tracks
+	SaveAll(ctx context.Context, analyses []*model.TrackAnalysis) error
+}
@@ -0,0 +1,16 @@
+package repository
+
+import "context"
+
+// ArtworkReferenceRepository defines the interface for the artwork cache files
+// each source uses, so files no source uses can be deleted
+type ArtworkReferenceRepository interface {
+	// GetAll returns the referenced filenames by source ID
+	GetAll(ctx context.Context) (map[string][]string, error)
+
+	// Save replaces the filenames a source references
+	Save(ctx context.Context, sourceID string, filenames []string) error
+
+	// Delete removes a source's references
+	Delete(ctx context.Context, sourceID string) error
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+
+	"GoMusic/internal/domain/model"
+)
+
+// MoveJournalRepository defines the interface for the journal of the organizer
+// run in progress
+type MoveJournalRepository interface {
+	// Get returns the journaled run, or nil when none was left unfinished
+	Get(ctx context.Context) (*model.MoveJournal, error)
+
+	// Save records a run, replacing the previous record
+	Save(ctx context.Context, journal *model.MoveJournal) error
+
+	// Clear removes the record once its run has finished or been rolled back
+	Clear(ctx context.Context) error
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+
+	"GoMusic/internal/domain/model"
+)
+
+// TagJournalRepository defines the interface for the journal of batch tag edits
+type TagJournalRepository interface {
+	// Get returns a batch by ID, or nil if it is not in the journal
+	Get(ctx context.Context, id string) (*model.TagEditBatch, error)
+
+	// GetAll returns every batch in the journal, newest first
+	GetAll(ctx context.Context) ([]*model.TagEditBatch, error)
+
+	// Save stores a batch, replacing an earlier record with the same ID
+	Save(ctx context.Context, batch *model.TagEditBatch) error
+}
@@ -0,0 +1,14 @@
+package repository
+
+import "context"
+
+// TrackIdentityRepository defines the interface for the IDs of moved tracks
+// Track IDs derive from file paths, so a moved file keeps its original ID only
+// through this record
+type TrackIdentityRepository interface {
+	// Get returns the ID recorded for a file path, or "" if there is none
+	Get(ctx context.Context, filePath string) (string, error)
+
+	// SaveAll records IDs by file path; an empty ID removes the record
+	SaveAll(ctx context.Context, ids map[string]string) error
+}
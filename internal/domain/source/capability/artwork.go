@@ -0,0 +1,11 @@
+package capability
+
+import "context"
+
+// ArtworkRestorer is a source capability for sources that can cache an image
+// again after the artwork cache evicted it
+type ArtworkRestorer interface {
+	// RestoreArtwork caches the image stored as filename again and reports
+	// whether the source uses it
+	RestoreArtwork(ctx context.Context, filename string) (bool, error)
+}
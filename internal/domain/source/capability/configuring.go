@@ -0,0 +1,12 @@
+package capability
+
+import "GoMusic/internal/domain/model"
+
+// ConfigReloader is a source capability for sources that take new settings in place
+// Sources implementing this interface keep the tracks they have loaded, which
+// pick the settings up on the next scan, where a re-registered source would
+// start out empty
+type ConfigReloader interface {
+	// ReloadConfig applies the source's updated configuration
+	ReloadConfig(config *model.SourceConfiguration) error
+}
@@ -0,0 +1,14 @@
+package capability
+
+import "context"
+
+// TrackRelocator is a source capability for sources whose files can be moved
+// Sources implementing this interface update the paths of moved tracks in place,
+// keeping their IDs so playlists, history and analysis still find them
+type TrackRelocator interface {
+	// GetRootPath returns the folder the source's files are organized under
+	GetRootPath() string
+
+	// RelocateTracks records that files have moved, by old and new path
+	RelocateTracks(ctx context.Context, moves map[string]string) error
+}
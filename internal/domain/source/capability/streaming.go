@@ -0,0 +1,16 @@
+package capability
+
+import (
+	"context"
+	"net/http"
+
+	"GoMusic/internal/domain/model"
+)
+
+// StreamProvider is a source capability for remote sources whose audio is proxied
+// Sources implementing this interface open authenticated streams for their tracks
+// The request method (GET or HEAD) and header are forwarded to the server
+// (e.g. Range for seeking)
+type StreamProvider interface {
+	OpenStream(ctx context.Context, track *model.Track, method string, header http.Header) (*http.Response, error)
+}
@@ -0,0 +1,18 @@
+package capability
+
+import (
+	"context"
+
+	"GoMusic/internal/domain/model"
+)
+
+// TagEditor is a source capability for sources whose files can be retagged
+// Sources implementing this interface write tag changes into a track's file and
+// return the tracks re-read from it
+type TagEditor interface {
+	// CanEditTags reports why a track's tags cannot be written, or nil if they can
+	CanEditTags(track *model.Track) error
+
+	// UpdateTags writes changes to the file of a track
+	UpdateTags(ctx context.Context, trackID string, changes *model.TagChanges) ([]*model.Track, error)
+}
@@ -0,0 +1,516 @@
+package media
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"GoMusic/internal/decoder"
+	"GoMusic/internal/domain/model"
+	"GoMusic/internal/domain/source/capability"
+	"GoMusic/internal/service"
+)
+
+// Headers forwarded between clients and remote sources when proxying streams
+var (
+	forwardedRequestHeaders  = []string{"Range", "If-Range", "If-None-Match", "If-Modified-Since"}
+	forwardedResponseHeaders = []string{"Content-Type", "Content-Length", "Content-Range", "Accept-Ranges", "ETag", "Last-Modified"}
+)
+
+// exposedHeaders lets cross-origin players read the seeking headers
+const exposedHeaders = "Content-Length, Content-Range, Accept-Ranges, ETag, Last-Modified"
+
+// Handler serves track audio, waveforms and cached artwork over HTTP
+// Shared by the webview middleware and the embedded servers so every client
+// gets the same streaming behaviour
+type Handler struct {
+	libraryService  *service.LibraryService
+	waveformService *service.WaveformService
+	artworkDir      string
+}
+
+// NewHandler creates a media handler reading artwork from artworkDir
+func NewHandler(libraryService *service.LibraryService, waveformService *service.WaveformService, artworkDir string) *Handler {
+	return &Handler{
+		libraryService:  libraryService,
+		waveformService: waveformService,
+		artworkDir:      artworkDir,
+	}
+}
+
+// ServeAudio streams the audio of a track by ID to the webview player
+func (h *Handler) ServeAudio(w http.ResponseWriter, r *http.Request, trackID string) {
+	if trackID == "" {
+		http.Error(w, "Missing id parameter", http.StatusBadRequest)
+		return
+	}
+
+	// Look up track in library service cache
+	track, err := h.libraryService.GetTrackByID(r.Context(), trackID)
+	if err != nil {
+		http.Error(w, "Track not found", http.StatusNotFound)
+		return
+	}
+
+	h.serveTrack(w, r, track, true)
+}
+
+// ServeTrack streams the audio of a resolved track to an external client
+// Unlike ServeAudio, codecs are only decoded when the request asks for WAV
+func (h *Handler) ServeTrack(w http.ResponseWriter, r *http.Request, track *model.Track) {
+	h.serveTrack(w, r, track, false)
+}
+
+// serveTrack streams a track, decoding it when the request or the format asks for it
+// webview enables decoding of codecs the platform webview cannot play
+func (h *Handler) serveTrack(w http.ResponseWriter, r *http.Request, track *model.Track, webview bool) {
+	// Remote sources are proxied so clients only ever talk to us
+	if track.FilePath == "" && track.StreamURL != "" {
+		h.proxyAudioStream(w, r, track)
+		return
+	}
+
+	// Get file path from track metadata
+	filePath := track.FilePath
+	if filePath == "" {
+		http.Error(w, "Track has no file path", http.StatusInternalServerError)
+		return
+	}
+
+	// Verify file exists and is readable
+	fileInfo, err := os.Stat(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			http.Error(w, "File not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Cannot access file", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if fileInfo.IsDir() {
+		http.Error(w, "Path is a directory, not a file", http.StatusBadRequest)
+		return
+	}
+
+	// Formats with no decoder would only fail in the player
+	if webview && track.UnplayableReason != "" {
+		http.Error(w, track.UnplayableReason, http.StatusUnsupportedMediaType)
+		return
+	}
+
+	// Decode to WAV when asked to, or when the webview cannot play the codec
+	// CUE sheet tracks are cut out of the album file, so they are always decoded
+	if track.IsSegment() || wantsPCM(r, filePath, webview) {
+		h.serveDecoded(w, r, track, fileInfo)
+		return
+	}
+
+	// Open the file
+	file, err := os.Open(filePath)
+	if err != nil {
+		http.Error(w, "Cannot open file", http.StatusNotFound)
+		return
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			fmt.Printf("Error closing file: %v\n", err)
+		}
+	}()
+
+	// Set response headers
+	// ServeContent handles Range, If-Range, If-None-Match, If-Modified-Since and HEAD
+	w.Header().Set("Content-Type", AudioContentType(filePath))
+	w.Header().Set("ETag", fileETag(fileInfo))
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Expose-Headers", exposedHeaders)
+
+	http.ServeContent(w, r, "", fileInfo.ModTime(), file)
+}
+
+// serveDecoded streams a local file decoded to WAV
+// The optional start parameter (seconds) makes the WAV begin at that offset;
+// byte ranges within the WAV are also honoured so the player can seek natively
+// gapless=1 trims encoder delay and padding so consecutive tracks join seamlessly
+// CUE sheet tracks only cover their segment of the file
+func (h *Handler) serveDecoded(w http.ResponseWriter, r *http.Request, track *model.Track, fileInfo os.FileInfo) {
+	dec, err := openTrackDecoder(track)
+	if err != nil {
+		http.Error(w, "Cannot decode file", http.StatusUnsupportedMediaType)
+		return
+	}
+	defer dec.Close()
+
+	gapless := r.URL.Query().Get("gapless") == "1" && track.TotalSamples > 0 && !track.IsSegment()
+	if gapless {
+		lead := dec.Priming() + int64(track.EncoderDelay)
+		dec, err = decoder.NewTrimmed(dec, lead, track.TotalSamples)
+		if err != nil {
+			http.Error(w, "Cannot decode file", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	var startFrame int64
+	if start := r.URL.Query().Get("start"); start != "" {
+		seconds, err := strconv.ParseFloat(start, 64)
+		if err != nil || seconds < 0 {
+			http.Error(w, "Invalid start parameter", http.StatusBadRequest)
+			return
+		}
+		startFrame = int64(seconds * float64(dec.Format().SampleRate))
+	}
+
+	// Set response headers
+	w.Header().Set("Content-Type", "audio/wav")
+	w.Header().Set("ETag", fmt.Sprintf(`"%x-%x-wav-%d-%d-%t"`, fileInfo.Size(), fileInfo.ModTime().UnixNano(), track.SegmentStart, startFrame, gapless))
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Expose-Headers", exposedHeaders)
+
+	http.ServeContent(w, r, "", fileInfo.ModTime(), newWAVStream(dec, startFrame))
+}
+
+// openTrackDecoder opens a track's audio, limited to its segment for CUE sheet tracks
+func openTrackDecoder(track *model.Track) (decoder.Decoder, error) {
+	if track.IsSegment() {
+		return decoder.OpenSegment(track.FilePath, track.SegmentStart, track.Duration)
+	}
+	return decoder.Open(track.FilePath)
+}
+
+// wantsPCM decides whether a request should be served as decoded WAV
+// format=wav (or gapless=1) forces decoding, format=original disables it;
+// otherwise the codec decides for webview playback
+func wantsPCM(r *http.Request, filePath string, webview bool) bool {
+	if r.URL.Query().Get("gapless") == "1" && r.URL.Query().Get("format") != "original" {
+		return decoder.CanDecode(filePath)
+	}
+
+	switch r.URL.Query().Get("format") {
+	case "wav":
+		return decoder.CanDecode(filePath)
+	case "original":
+		return false
+	default:
+		return webview && NeedsDecoding(filePath)
+	}
+}
+
+// unplayableFormats lists formats the platform webview cannot play natively
+// WebKitGTK only plays FLAC and AIFF when the matching GStreamer plugins are
+// installed; WebView2 has no AIFF support at all; no webview plays DSD
+var unplayableFormats = map[string]map[string]bool{
+	"darwin":  {".dsf": true, ".dff": true},
+	"linux":   {".flac": true, ".aif": true, ".aiff": true, ".aifc": true, ".dsf": true, ".dff": true},
+	"windows": {".aif": true, ".aiff": true, ".aifc": true, ".dsf": true, ".dff": true},
+}
+
+// NeedsDecoding reports whether a file must be decoded before the webview can play it
+func NeedsDecoding(filePath string) bool {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	return unplayableFormats[runtime.GOOS][ext] && decoder.CanDecode(filePath)
+}
+
+// proxyAudioStream streams a remote track through the source that owns it
+func (h *Handler) proxyAudioStream(w http.ResponseWriter, r *http.Request, track *model.Track) {
+	repo, ok := h.libraryService.GetRepositories()[track.SourceID]
+	if !ok {
+		http.Error(w, "Source not found", http.StatusNotFound)
+		return
+	}
+
+	streamProvider, ok := repo.(capability.StreamProvider)
+	if !ok {
+		http.Error(w, "Source does not support streaming", http.StatusNotImplemented)
+		return
+	}
+
+	// Forward headers that matter for seeking and revalidation
+	header := http.Header{}
+	for _, key := range forwardedRequestHeaders {
+		if value := r.Header.Get(key); value != "" {
+			header.Set(key, value)
+		}
+	}
+
+	// HEAD is forwarded as HEAD so no audio is downloaded; servers refusing it
+	// get a GET whose body is never read
+	method := http.MethodGet
+	if r.Method == http.MethodHead {
+		method = http.MethodHead
+	}
+	resp, err := streamProvider.OpenStream(r.Context(), track, method, header)
+	if err == nil && method == http.MethodHead && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		resp.Body.Close()
+		resp, err = streamProvider.OpenStream(r.Context(), track, http.MethodGet, header)
+	}
+	if err != nil {
+		http.Error(w, "Cannot open remote stream", http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	// Copy the relevant upstream headers
+	for _, key := range forwardedResponseHeaders {
+		if value := resp.Header.Get(key); value != "" {
+			w.Header().Set(key, value)
+		}
+	}
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", AudioContentType("."+track.Format))
+	}
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Expose-Headers", exposedHeaders)
+	if w.Header().Get("Accept-Ranges") == "" {
+		w.Header().Set("Accept-Ranges", "bytes")
+	}
+
+	// Some servers ignore Range; emulate a single range by skipping ahead
+	body := io.Reader(resp.Body)
+	status := resp.StatusCode
+	if status == http.StatusOK && r.Header.Get("Range") != "" && resp.ContentLength > 0 && ifRangeMatches(r, resp.Header) {
+		start, length, err := parseSingleRange(r.Header.Get("Range"), resp.ContentLength)
+		switch {
+		case stderrors.Is(err, errUnsatisfiableRange):
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", resp.ContentLength))
+			w.Header().Del("Content-Length")
+			http.Error(w, "Requested range not satisfiable", http.StatusRequestedRangeNotSatisfiable)
+			return
+		case err == nil:
+			if r.Method != http.MethodHead {
+				if _, err := io.CopyN(io.Discard, resp.Body, start); err != nil {
+					http.Error(w, "Cannot seek remote stream", http.StatusBadGateway)
+					return
+				}
+				body = io.LimitReader(resp.Body, length)
+			}
+			status = http.StatusPartialContent
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, start+length-1, resp.ContentLength))
+			w.Header().Set("Content-Length", strconv.FormatInt(length, 10))
+		}
+	}
+	w.WriteHeader(status)
+
+	// HEAD requests only need the headers
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	// Stream the response
+	_, _ = io.Copy(w, body)
+}
+
+// fileETag builds a strong validator from a file's size and modification time
+func fileETag(info os.FileInfo) string {
+	return fmt.Sprintf(`"%x-%x"`, info.Size(), info.ModTime().UnixNano())
+}
+
+// Reasons parseSingleRange rejects a Range header
+var (
+	// errInvalidRange marks a header that is ignored, serving the whole body
+	errInvalidRange = stderrors.New("invalid range")
+	// errUnsatisfiableRange marks a range that starts past the end of the body
+	errUnsatisfiableRange = stderrors.New("range not satisfiable")
+)
+
+// parseSingleRange parses a Range header naming exactly one byte range
+// Returns the start offset and length within a body of the given size
+func parseSingleRange(header string, size int64) (start, length int64, err error) {
+	spec, found := strings.CutPrefix(header, "bytes=")
+	if !found || strings.Contains(spec, ",") {
+		return 0, 0, errInvalidRange
+	}
+	first, last, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found {
+		return 0, 0, errInvalidRange
+	}
+
+	if first == "" {
+		// Suffix range: the final N bytes
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n < 0 {
+			return 0, 0, errInvalidRange
+		}
+		if n == 0 {
+			return 0, 0, errUnsatisfiableRange
+		}
+		if n > size {
+			n = size
+		}
+		return size - n, n, nil
+	}
+
+	start, err = strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return 0, 0, errInvalidRange
+	}
+	end := size - 1
+	if last != "" {
+		end, err = strconv.ParseInt(last, 10, 64)
+		if err != nil || end < start {
+			return 0, 0, errInvalidRange
+		}
+	}
+	if start >= size {
+		return 0, 0, errUnsatisfiableRange
+	}
+	if end >= size {
+		end = size - 1
+	}
+	return start, end - start + 1, nil
+}
+
+// ifRangeMatches reports whether a ranged request's If-Range still matches the upstream validators
+func ifRangeMatches(r *http.Request, upstream http.Header) bool {
+	ifRange := r.Header.Get("If-Range")
+	if ifRange == "" {
+		return true
+	}
+	if strings.HasPrefix(ifRange, `"`) {
+		return ifRange == upstream.Get("ETag")
+	}
+	return ifRange == upstream.Get("Last-Modified")
+}
+
+// ServeArtwork serves the cached artwork of a track by ID
+func (h *Handler) ServeArtwork(w http.ResponseWriter, r *http.Request, trackID string) {
+	if trackID == "" {
+		http.Error(w, "Missing id parameter", http.StatusBadRequest)
+		return
+	}
+
+	// Look up track in library service cache
+	track, err := h.libraryService.GetTrackByID(r.Context(), trackID)
+	if err != nil {
+		http.Error(w, "Track not found", http.StatusNotFound)
+		return
+	}
+
+	// Get artwork path from track metadata
+	if track.ArtworkPath == "" {
+		http.Error(w, "Track has no artwork", http.StatusNotFound)
+		return
+	}
+
+	h.ServeArtworkFile(w, r, track.ArtworkPath)
+}
+
+// ResolveArtwork finds the cached artwork filename for a track, album or artist ID
+// Returns "" when the object is unknown or has no artwork
+func (h *Handler) ResolveArtwork(ctx context.Context, id string) string {
+	if track, err := h.libraryService.GetTrackByID(ctx, id); err == nil {
+		return track.ArtworkPath
+	}
+
+	if album, err := h.libraryService.GetAlbumByID(ctx, id); err == nil {
+		return album.ArtworkPath
+	}
+
+	if artists, err := h.libraryService.GetAllArtists(ctx); err == nil {
+		for _, artist := range artists {
+			if artist.ID == id {
+				return artist.ImagePath
+			}
+		}
+	}
+
+	return ""
+}
+
+// ServeArtworkFile serves a file from the artwork cache by filename
+func (h *Handler) ServeArtworkFile(w http.ResponseWriter, r *http.Request, artworkFilename string) {
+	// Only plain filenames are valid; never let a request escape the cache
+	if artworkFilename == "" || filepath.Base(artworkFilename) != artworkFilename {
+		http.Error(w, "Artwork not found", http.StatusNotFound)
+		return
+	}
+	fullPath := filepath.Join(h.artworkDir, artworkFilename)
+
+	// Open the artwork file, caching it again if the cache evicted it
+	file, err := os.Open(fullPath)
+	if os.IsNotExist(err) {
+		if restored, restoreErr := h.libraryService.RestoreArtwork(r.Context(), artworkFilename); restoreErr != nil {
+			fmt.Printf("Failed to restore artwork %s: %v\n", artworkFilename, restoreErr)
+		} else if restored {
+			file, err = os.Open(fullPath)
+		}
+	}
+	if err != nil {
+		http.Error(w, "Artwork not found", http.StatusNotFound)
+		return
+	}
+	defer file.Close()
+
+	// Get file info for Content-Length
+	fileInfo, err := file.Stat()
+	if err != nil {
+		http.Error(w, "Cannot stat file", http.StatusInternalServerError)
+		return
+	}
+
+	// Set response headers
+	w.Header().Set("Content-Type", ImageContentType(fullPath))
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", fileInfo.Size()))
+	w.Header().Set("Cache-Control", "public, max-age=31536000") // Cache for 1 year
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	// Stream the image
+	if _, err := io.Copy(w, file); err != nil {
+		fmt.Printf("Error streaming artwork: %v\n", err)
+	}
+}
+
+// AudioContentType determines the MIME type for audio files
+func AudioContentType(filePath string) string {
+	ext := filepath.Ext(filePath)
+	switch ext {
+	case ".mp3":
+		return "audio/mpeg"
+	case ".m4a":
+		return "audio/mp4"
+	case ".flac":
+		return "audio/flac"
+	case ".ogg", ".oga", ".opus":
+		return "audio/ogg"
+	case ".wav":
+		return "audio/wav"
+	case ".aif", ".aiff", ".aifc":
+		return "audio/aiff"
+	case ".wv":
+		return "audio/x-wavpack"
+	case ".ape":
+		return "audio/x-ape"
+	case ".dsf":
+		return "audio/x-dsf"
+	case ".dff":
+		return "audio/x-dff"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// ImageContentType determines the MIME type for image files
+func ImageContentType(filePath string) string {
+	ext := filepath.Ext(filePath)
+	switch ext {
+	case ".jpg", ".jpeg":
+		return "image/jpeg"
+	case ".png":
+		return "image/png"
+	case ".gif":
+		return "image/gif"
+	case ".bmp":
+		return "image/bmp"
+	case ".webp":
+		return "image/webp"
+	default:
+		return "image/jpeg"
+	}
+}
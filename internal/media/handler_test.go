@@ -0,0 +1,290 @@
+package media
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"GoMusic/internal/domain/model"
+	"GoMusic/internal/domain/repository"
+	"GoMusic/internal/service"
+	"GoMusic/internal/util/errors"
+)
+
+// Upstream validators of the fake remote stream
+const (
+	upstreamBody         = "0123456789"
+	upstreamETag         = `"v1"`
+	upstreamLastModified = "Mon, 02 Jan 2006 15:04:05 GMT"
+)
+
+// fakeStreamRepository is a remote source whose server ignores Range headers
+type fakeStreamRepository struct {
+	track   *model.Track
+	methods []string // request methods sent upstream
+}
+
+func (r *fakeStreamRepository) FindByID(ctx context.Context, id string) (*model.Track, error) {
+	if id != r.track.ID {
+		return nil, errors.ErrNotFound
+	}
+	return r.track, nil
+}
+
+func (r *fakeStreamRepository) FindAll(ctx context.Context, opts *repository.QueryOptions) ([]*model.Track, error) {
+	return []*model.Track{r.track}, nil
+}
+
+func (r *fakeStreamRepository) Create(ctx context.Context, track *model.Track) error { return nil }
+func (r *fakeStreamRepository) Update(ctx context.Context, track *model.Track) error { return nil }
+func (r *fakeStreamRepository) Delete(ctx context.Context, id string) error          { return nil }
+
+func (r *fakeStreamRepository) FindByAlbum(ctx context.Context, albumID string) ([]*model.Track, error) {
+	return nil, nil
+}
+
+func (r *fakeStreamRepository) FindByArtist(ctx context.Context, artistID string) ([]*model.Track, error) {
+	return nil, nil
+}
+
+func (r *fakeStreamRepository) Search(ctx context.Context, query string, opts *repository.SearchOptions) ([]*model.Track, error) {
+	return nil, nil
+}
+
+func (r *fakeStreamRepository) GetSourceID() string             { return "remote" }
+func (r *fakeStreamRepository) GetSourceType() model.SourceType { return model.SourceTypeAPISelfHosted }
+func (r *fakeStreamRepository) Scan(ctx context.Context) error  { return nil }
+func (r *fakeStreamRepository) GetScanProgress() *repository.ScanProgress {
+	return &repository.ScanProgress{}
+}
+
+// OpenStream answers every request with the whole body, as servers without
+// Range support do
+func (r *fakeStreamRepository) OpenStream(ctx context.Context, track *model.Track, method string, header http.Header) (*http.Response, error) {
+	r.methods = append(r.methods, method)
+	body := upstreamBody
+	if method == http.MethodHead {
+		body = ""
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header: http.Header{
+			"Etag": {upstreamETag}, "Last-Modified": {upstreamLastModified},
+			"Content-Type": {"audio/mpeg"}, "Content-Length": {strconv.Itoa(len(upstreamBody))},
+		},
+		ContentLength: int64(len(upstreamBody)),
+		Body:          io.NopCloser(strings.NewReader(body)),
+	}, nil
+}
+
+func TestParseSingleRange(t *testing.T) {
+	tests := []struct {
+		header     string
+		wantStart  int64
+		wantLength int64
+		wantErr    error
+	}{
+		{header: "bytes=2-5", wantStart: 2, wantLength: 4},
+		{header: "bytes=6-", wantStart: 6, wantLength: 4},
+		{header: "bytes=-3", wantStart: 7, wantLength: 3},
+		{header: "bytes=-30", wantStart: 0, wantLength: 10},
+		{header: "bytes=4-99", wantStart: 4, wantLength: 6},
+		{header: "bytes=9-9", wantStart: 9, wantLength: 1},
+		{header: "bytes=10-", wantErr: errUnsatisfiableRange},
+		{header: "bytes=-0", wantErr: errUnsatisfiableRange},
+		{header: "bytes=0-1,4-5", wantErr: errInvalidRange},
+		{header: "bytes=5-2", wantErr: errInvalidRange},
+		{header: "bytes=x-", wantErr: errInvalidRange},
+		{header: "bytes=-x", wantErr: errInvalidRange},
+		{header: "bytes=5", wantErr: errInvalidRange},
+		{header: "items=0-1", wantErr: errInvalidRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			start, length, err := parseSingleRange(tt.header, int64(len(upstreamBody)))
+			if err != tt.wantErr {
+				t.Fatalf("parseSingleRange() error = %v, want %v", err, tt.wantErr)
+			}
+			if start != tt.wantStart || length != tt.wantLength {
+				t.Errorf("parseSingleRange() = %d, %d, want %d, %d", start, length, tt.wantStart, tt.wantLength)
+			}
+		})
+	}
+}
+
+func TestIfRangeMatches(t *testing.T) {
+	upstream := http.Header{"Etag": {upstreamETag}, "Last-Modified": {upstreamLastModified}}
+
+	tests := []struct {
+		name    string
+		ifRange string
+		want    bool
+	}{
+		{name: "absent", ifRange: "", want: true},
+		{name: "matching etag", ifRange: upstreamETag, want: true},
+		{name: "changed etag", ifRange: `"v2"`, want: false},
+		{name: "matching date", ifRange: upstreamLastModified, want: true},
+		{name: "changed date", ifRange: "Tue, 03 Jan 2006 15:04:05 GMT", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/audio", nil)
+			if tt.ifRange != "" {
+				r.Header.Set("If-Range", tt.ifRange)
+			}
+			if got := ifRangeMatches(r, upstream); got != tt.want {
+				t.Errorf("ifRangeMatches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProxyAudioStreamRange(t *testing.T) {
+	tests := []struct {
+		name             string
+		method           string
+		rangeHeader      string
+		ifRange          string
+		wantStatus       int
+		wantBody         string
+		wantContentRange string
+		wantLength       string
+	}{
+		{
+			name:       "no range",
+			wantStatus: http.StatusOK,
+			wantBody:   upstreamBody,
+			wantLength: "10",
+		},
+		{
+			name:             "closed range",
+			rangeHeader:      "bytes=2-5",
+			wantStatus:       http.StatusPartialContent,
+			wantBody:         "2345",
+			wantContentRange: "bytes 2-5/10",
+			wantLength:       "4",
+		},
+		{
+			name:             "open-ended range",
+			rangeHeader:      "bytes=6-",
+			wantStatus:       http.StatusPartialContent,
+			wantBody:         "6789",
+			wantContentRange: "bytes 6-9/10",
+			wantLength:       "4",
+		},
+		{
+			name:             "suffix range",
+			rangeHeader:      "bytes=-3",
+			wantStatus:       http.StatusPartialContent,
+			wantBody:         "789",
+			wantContentRange: "bytes 7-9/10",
+			wantLength:       "3",
+		},
+		{
+			name:        "multiple ranges get the whole body",
+			rangeHeader: "bytes=0-1,4-5",
+			wantStatus:  http.StatusOK,
+			wantBody:    upstreamBody,
+			wantLength:  "10",
+		},
+		{
+			name:             "unsatisfiable range",
+			rangeHeader:      "bytes=10-",
+			wantStatus:       http.StatusRequestedRangeNotSatisfiable,
+			wantContentRange: "bytes */10",
+		},
+		{
+			name:             "matching If-Range etag",
+			rangeHeader:      "bytes=2-5",
+			ifRange:          upstreamETag,
+			wantStatus:       http.StatusPartialContent,
+			wantBody:         "2345",
+			wantContentRange: "bytes 2-5/10",
+			wantLength:       "4",
+		},
+		{
+			name:        "changed If-Range etag",
+			rangeHeader: "bytes=2-5",
+			ifRange:     `"v2"`,
+			wantStatus:  http.StatusOK,
+			wantBody:    upstreamBody,
+			wantLength:  "10",
+		},
+		{
+			name:             "matching If-Range date",
+			rangeHeader:      "bytes=-3",
+			ifRange:          upstreamLastModified,
+			wantStatus:       http.StatusPartialContent,
+			wantBody:         "789",
+			wantContentRange: "bytes 7-9/10",
+			wantLength:       "3",
+		},
+		{
+			name:        "changed If-Range date",
+			rangeHeader: "bytes=-3",
+			ifRange:     "Tue, 03 Jan 2006 15:04:05 GMT",
+			wantStatus:  http.StatusOK,
+			wantBody:    upstreamBody,
+			wantLength:  "10",
+		},
+		{
+			name:             "HEAD",
+			method:           http.MethodHead,
+			rangeHeader:      "bytes=2-5",
+			wantStatus:       http.StatusPartialContent,
+			wantContentRange: "bytes 2-5/10",
+			wantLength:       "4",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeStreamRepository{track: &model.Track{
+				ID: "remote_1", SourceID: "remote", StreamURL: "http://music.example/stream?id=1", Format: "mp3",
+			}}
+			library := service.NewLibraryService()
+			library.RegisterTrackRepository("remote", repo)
+			h := NewHandler(library, nil, t.TempDir())
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			r := httptest.NewRequest(method, "/audio?id=remote_1", nil)
+			if tt.rangeHeader != "" {
+				r.Header.Set("Range", tt.rangeHeader)
+			}
+			if tt.ifRange != "" {
+				r.Header.Set("If-Range", tt.ifRange)
+			}
+			w := httptest.NewRecorder()
+			h.ServeAudio(w, r, "remote_1")
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Content-Range"); got != tt.wantContentRange {
+				t.Errorf("Content-Range = %q, want %q", got, tt.wantContentRange)
+			}
+			if tt.wantLength != "" {
+				if got := w.Header().Get("Content-Length"); got != tt.wantLength {
+					t.Errorf("Content-Length = %q, want %q", got, tt.wantLength)
+				}
+			}
+			if tt.wantStatus != http.StatusRequestedRangeNotSatisfiable {
+				if got := w.Body.String(); got != tt.wantBody {
+					t.Errorf("body = %q, want %q", got, tt.wantBody)
+				}
+			}
+			if len(repo.methods) != 1 || repo.methods[0] != method {
+				t.Errorf("upstream requests = %v, want one %s", repo.methods, method)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package media
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"GoMusic/internal/decoder"
+)
+
+// wavHeaderSize is the size of a canonical RIFF/WAVE header
+const wavHeaderSize = 44
+
+// wavStream presents decoded audio as a seekable WAV file
+// The size is known up front from the decoder's frame count, so byte ranges map
+// directly onto frame offsets and clients can seek as in a plain file
+type wavStream struct {
+	decoder    decoder.Decoder
+	header     []byte
+	blockAlign int64
+	startFrame int64 // first decoded frame included in the file
+	size       int64
+
+	offset    int64 // position requested by the reader
+	decodePos int64 // data offset the decoder will produce next, or -1 when unknown
+	exhausted bool  // the decoder has no audio left; the rest of the file is silence
+}
+
+// newWAVStream wraps a decoder as a WAV file starting at startFrame
+func newWAVStream(dec decoder.Decoder, startFrame int64) *wavStream {
+	format := dec.Format()
+	blockAlign := int64(format.BlockAlign())
+
+	frames := max(dec.Frames()-startFrame, 0)
+	dataSize := frames * blockAlign
+
+	return &wavStream{
+		decoder:    dec,
+		header:     wavHeader(format, dataSize),
+		blockAlign: blockAlign,
+		startFrame: startFrame,
+		size:       wavHeaderSize + dataSize,
+		decodePos:  -1,
+	}
+}
+
+// Size returns the total length of the WAV file in bytes
+func (s *wavStream) Size() int64 {
+	return s.size
+}
+
+// Seek implements io.Seeker; decoding is repositioned lazily on the next Read
+func (s *wavStream) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += s.offset
+	case io.SeekEnd:
+		offset += s.size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	s.offset = offset
+	return offset, nil
+}
+
+// Read implements io.Reader
+func (s *wavStream) Read(p []byte) (int, error) {
+	if s.offset >= s.size {
+		return 0, io.EOF
+	}
+	if remaining := s.size - s.offset; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+
+	if s.offset < wavHeaderSize {
+		n := copy(p, s.header[s.offset:])
+		s.offset += int64(n)
+		return n, nil
+	}
+
+	dataOffset := s.offset - wavHeaderSize
+	if dataOffset != s.decodePos {
+		if err := s.seekData(dataOffset); err != nil {
+			return 0, err
+		}
+	}
+
+	var n int
+	var err error
+	if !s.exhausted {
+		n, err = s.decoder.Read(p)
+		if err == io.EOF {
+			// Frame counts from headers can overstate the decodable audio; the
+			// rest of the file is padded with silence
+			s.exhausted = true
+			err = nil
+		}
+	}
+	if s.exhausted && n == 0 {
+		clear(p)
+		n = len(p)
+	}
+	s.offset += int64(n)
+	s.decodePos += int64(n)
+	return n, err
+}
+
+// seekData positions the decoder at a byte offset within the data chunk
+func (s *wavStream) seekData(dataOffset int64) error {
+	frame := dataOffset / s.blockAlign
+	s.exhausted = false
+	s.decodePos = frame * s.blockAlign
+	if err := s.decoder.SeekFrame(s.startFrame + frame); err != nil {
+		if !errors.Is(err, decoder.ErrSeekPastEnd) {
+			return err
+		}
+		s.exhausted = true
+		s.decodePos = dataOffset
+		return nil
+	}
+
+	// Ranges need not be frame aligned
+	if partial := dataOffset - s.decodePos; partial > 0 {
+		if _, err := io.CopyN(io.Discard, s.decoder, partial); err != nil {
+			if err != io.EOF {
+				return err
+			}
+			s.exhausted = true
+		}
+		s.decodePos = dataOffset
+	}
+	return nil
+}
+
+// wavHeader builds a canonical 44-byte PCM WAV header
+func wavHeader(format decoder.Format, dataSize int64) []byte {
+	blockAlign := format.BlockAlign()
+
+	header := make([]byte, 0, wavHeaderSize)
+	header = append(header, "RIFF"...)
+	header = binary.LittleEndian.AppendUint32(header, uint32(min(dataSize+wavHeaderSize-8, 0xFFFFFFFF)))
+	header = append(header, "WAVE"...)
+
+	header = append(header, "fmt "...)
+	header = binary.LittleEndian.AppendUint32(header, 16)
+	header = binary.LittleEndian.AppendUint16(header, 1) // PCM
+	header = binary.LittleEndian.AppendUint16(header, uint16(format.Channels))
+	header = binary.LittleEndian.AppendUint32(header, uint32(format.SampleRate))
+	header = binary.LittleEndian.AppendUint32(header, uint32(format.SampleRate*blockAlign))
+	header = binary.LittleEndian.AppendUint16(header, uint16(blockAlign))
+	header = binary.LittleEndian.AppendUint16(header, uint16(format.BitsPerSample))
+
+	header = append(header, "data"...)
+	header = binary.LittleEndian.AppendUint32(header, uint32(min(dataSize, 0xFFFFFFFF)))
+	return header
+}
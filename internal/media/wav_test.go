@@ -0,0 +1,181 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"GoMusic/internal/decoder"
+)
+
+// fakeDecoder serves fixed PCM data while claiming frames frames
+// Like some real decoders, its last Read returns data together with io.EOF
+type fakeDecoder struct {
+	format decoder.Format
+	data   []byte
+	frames int64
+	pos    int
+}
+
+func (d *fakeDecoder) Read(p []byte) (int, error) {
+	if d.pos >= len(d.data) {
+		return 0, io.EOF
+	}
+	n := copy(p, d.data[d.pos:])
+	d.pos += n
+	if d.pos >= len(d.data) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (d *fakeDecoder) SeekFrame(frame int64) error {
+	offset := frame * int64(d.format.BlockAlign())
+	if offset > int64(len(d.data)) {
+		return decoder.ErrSeekPastEnd
+	}
+	d.pos = int(offset)
+	return nil
+}
+
+func (d *fakeDecoder) Close() error           { return nil }
+func (d *fakeDecoder) Format() decoder.Format { return d.format }
+func (d *fakeDecoder) Frames() int64          { return d.frames }
+func (d *fakeDecoder) Priming() int64         { return 0 }
+
+// newFakeDecoder creates a 16-bit stereo decoder holding decoded frames of
+// non-zero samples and claiming declared frames
+func newFakeDecoder(decoded, declared int64) *fakeDecoder {
+	format := decoder.Format{SampleRate: 44100, Channels: 2, BitsPerSample: 16}
+	data := make([]byte, decoded*int64(format.BlockAlign()))
+	for i := range data {
+		data[i] = byte(i%251) + 1
+	}
+	return &fakeDecoder{format: format, data: data, frames: declared}
+}
+
+func TestWAVHeader(t *testing.T) {
+	header := wavHeader(decoder.Format{SampleRate: 48000, Channels: 2, BitsPerSample: 24}, 6000)
+
+	if len(header) != wavHeaderSize {
+		t.Fatalf("header is %d bytes, want %d", len(header), wavHeaderSize)
+	}
+	for offset, want := range map[int]string{0: "RIFF", 8: "WAVE", 12: "fmt ", 36: "data"} {
+		if got := string(header[offset : offset+4]); got != want {
+			t.Errorf("chunk ID at %d = %q, want %q", offset, got, want)
+		}
+	}
+
+	le := binary.LittleEndian
+	fields := []struct {
+		name   string
+		offset int
+		size   int
+		want   uint32
+	}{
+		{"RIFF size", 4, 4, 6000 + wavHeaderSize - 8},
+		{"fmt size", 16, 4, 16},
+		{"format tag", 20, 2, 1},
+		{"channels", 22, 2, 2},
+		{"sample rate", 24, 4, 48000},
+		{"byte rate", 28, 4, 48000 * 6},
+		{"block align", 32, 2, 6},
+		{"bits per sample", 34, 2, 24},
+		{"data size", 40, 4, 6000},
+	}
+	for _, field := range fields {
+		var got uint32
+		if field.size == 2 {
+			got = uint32(le.Uint16(header[field.offset:]))
+		} else {
+			got = le.Uint32(header[field.offset:])
+		}
+		if got != field.want {
+			t.Errorf("%s = %d, want %d", field.name, got, field.want)
+		}
+	}
+}
+
+func TestWAVStreamRange(t *testing.T) {
+	tests := []struct {
+		name        string
+		decoded     int64 // frames the decoder produces
+		declared    int64 // frames the decoder claims
+		startFrame  int64
+		rangeHeader string
+		wantStart   int64 // offset of the expected bytes within the file
+		wantEnd     int64 // exclusive; 0 for the end of the file
+	}{
+		{name: "whole file", decoded: 100, declared: 100},
+		{name: "header only", decoded: 100, declared: 100, rangeHeader: "bytes=0-43", wantEnd: 44},
+		{name: "across the header", decoded: 100, declared: 100, rangeHeader: "bytes=40-51", wantStart: 40, wantEnd: 52},
+		{name: "mid-stream frame aligned", decoded: 100, declared: 100, rangeHeader: "bytes=244-283", wantStart: 244, wantEnd: 284},
+		{name: "mid-stream unaligned", decoded: 100, declared: 100, rangeHeader: "bytes=203-", wantStart: 203},
+		{name: "suffix", decoded: 100, declared: 100, rangeHeader: "bytes=-7", wantStart: 437},
+		{name: "start offset", decoded: 100, declared: 100, startFrame: 25, rangeHeader: "bytes=50-", wantStart: 50},
+		{name: "overstated frame count", decoded: 60, declared: 100},
+		{name: "range into the padding", decoded: 60, declared: 100, rangeHeader: "bytes=280-299", wantStart: 280, wantEnd: 300},
+		{name: "range past the decodable audio", decoded: 60, declared: 100, rangeHeader: "bytes=400-", wantStart: 400},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec := newFakeDecoder(tt.decoded, tt.declared)
+			stream := newWAVStream(dec, tt.startFrame)
+
+			// The file is the header, the decoded frames after startFrame and
+			// silence up to the declared length
+			dataSize := (tt.declared - tt.startFrame) * 4
+			file := append([]byte(nil), wavHeader(dec.format, dataSize)...)
+			file = append(file, dec.data[min(tt.startFrame*4, int64(len(dec.data))):]...)
+			file = append(file, make([]byte, wavHeaderSize+dataSize-int64(len(file)))...)
+			if stream.Size() != int64(len(file)) {
+				t.Fatalf("Size() = %d, want %d", stream.Size(), len(file))
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "/audio", nil)
+			wantStatus := http.StatusOK
+			if tt.rangeHeader != "" {
+				r.Header.Set("Range", tt.rangeHeader)
+				wantStatus = http.StatusPartialContent
+			}
+			w := httptest.NewRecorder()
+			http.ServeContent(w, r, "", time.Time{}, stream)
+
+			if w.Code != wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, wantStatus)
+			}
+			end := tt.wantEnd
+			if end == 0 {
+				end = int64(len(file))
+			}
+			if want := file[tt.wantStart:end]; !bytes.Equal(w.Body.Bytes(), want) {
+				t.Errorf("body is %d bytes %x, want %d bytes %x", w.Body.Len(), w.Body.Bytes(), len(want), want)
+			}
+		})
+	}
+}
+
+func TestWAVStreamSeekBack(t *testing.T) {
+	dec := newFakeDecoder(60, 100)
+	stream := newWAVStream(dec, 0)
+
+	// Read past the decodable audio, then seek back into it
+	if _, err := io.ReadAll(stream); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Seek(wavHeaderSize+8, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	got := make([]byte, 4)
+	if _, err := io.ReadFull(stream, got); err != nil {
+		t.Fatal(err)
+	}
+	if want := dec.data[8:12]; !bytes.Equal(got, want) {
+		t.Errorf("Read() after seeking back = %x, want %x", got, want)
+	}
+}
@@ -0,0 +1,64 @@
+package media
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"GoMusic/internal/application/dto"
+	"GoMusic/internal/service"
+)
+
+// defaultWaveformBuckets is used when a request does not ask for a resolution
+const defaultWaveformBuckets = 512
+
+// ServeWaveform serves the peak/RMS envelope of a track as JSON
+// The optional buckets parameter sets the resolution, up to service.WaveformResolution
+func (h *Handler) ServeWaveform(w http.ResponseWriter, r *http.Request, trackID string) {
+	if trackID == "" {
+		http.Error(w, "Missing id parameter", http.StatusBadRequest)
+		return
+	}
+	if h.waveformService == nil {
+		http.Error(w, "Waveforms are unavailable", http.StatusNotFound)
+		return
+	}
+
+	buckets := defaultWaveformBuckets
+	if value := r.URL.Query().Get("buckets"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			http.Error(w, "Invalid buckets parameter", http.StatusBadRequest)
+			return
+		}
+		buckets = min(n, service.WaveformResolution)
+	}
+
+	track, err := h.libraryService.GetTrackByID(r.Context(), trackID)
+	if err != nil {
+		http.Error(w, "Track not found", http.StatusNotFound)
+		return
+	}
+
+	// The waveform only changes with the file, so its identity makes a stable ETag
+	etag := fmt.Sprintf(`"%x-%x-wf-%d"`, track.FileSize, track.ModifiedAt.UnixNano(), buckets)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	waveform, err := h.waveformService.GetWaveform(r.Context(), trackID, buckets)
+	if err != nil {
+		http.Error(w, "Cannot compute waveform", http.StatusUnprocessableEntity)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(dto.ToWaveformDTO(waveform)); err != nil {
+		fmt.Printf("Error writing waveform: %v\n", err)
+	}
+}
@@ -0,0 +1,118 @@
+package analysis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"GoMusic/internal/domain/model"
+)
+
+// JSONAnalysisRepository implements AnalysisRepository using a single JSON file
+// Results are kept in memory and the file is rewritten after each batch
+type JSONAnalysisRepository struct {
+	path     string
+	analyses map[string]*model.TrackAnalysis
+	mu       sync.RWMutex
+}
+
+// NewJSONAnalysisRepository opens (or creates) the analysis store at path
+func NewJSONAnalysisRepository(path string) (*JSONAnalysisRepository, error) {
+	r := &JSONAnalysisRepository{
+		path:     path,
+		analyses: make(map[string]*model.TrackAnalysis),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return r, nil
+		}
+		return nil, fmt.Errorf("failed to read analysis store: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &r.analyses); err != nil {
+		return nil, fmt.Errorf("failed to parse analysis store: %w", err)
+	}
+
+	return r, nil
+}
+
+// Get returns a copy of the stored analysis of a track, or nil if it has none
+func (r *JSONAnalysisRepository) Get(ctx context.Context, trackID string) (*model.TrackAnalysis, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	analysis, ok := r.analyses[trackID]
+	if !ok {
+		return nil, nil
+	}
+	analysisCopy := *analysis
+	return &analysisCopy, nil
+}
+
+// GetAll returns copies of every stored analysis keyed by track ID
+func (r *JSONAnalysisRepository) GetAll(ctx context.Context) (map[string]*model.TrackAnalysis, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make(map[string]*model.TrackAnalysis, len(r.analyses))
+	for id, analysis := range r.analyses {
+		analysisCopy := *analysis
+		result[id] = &analysisCopy
+	}
+	return result, nil
+}
+
+// SaveAll stores analyses and persists the store
+func (r *JSONAnalysisRepository) SaveAll(ctx context.Context, analyses []*model.TrackAnalysis) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous := make(map[string]*model.TrackAnalysis, len(analyses))
+	for _, analysis := range analyses {
+		previous[analysis.TrackID] = r.analyses[analysis.TrackID]
+		analysisCopy := *analysis
+		r.analyses[analysis.TrackID] = &analysisCopy
+	}
+
+	if err := r.save(); err != nil {
+		// Rollback on save failure
+		for id, analysis := range previous {
+			if analysis == nil {
+				delete(r.analyses, id)
+			} else {
+				r.analyses[id] = analysis
+			}
+		}
+		return err
+	}
+
+	return nil
+}
+
+// save writes the store atomically via a temp file and rename
+func (r *JSONAnalysisRepository) save() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("failed to create analysis directory: %w", err)
+	}
+
+	data, err := json.Marshal(r.analyses)
+	if err != nil {
+		return fmt.Errorf("failed to marshal analysis store: %w", err)
+	}
+
+	tmpPath := r.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write analysis store: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, r.path); err != nil {
+		return fmt.Errorf("failed to replace analysis store: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package artwork
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// JSONArtworkReferenceRepository implements ArtworkReferenceRepository using a single JSON file
+// References are kept in memory and the file is rewritten on each save
+type JSONArtworkReferenceRepository struct {
+	path       string
+	references map[string][]string
+	mu         sync.RWMutex
+}
+
+// NewJSONArtworkReferenceRepository opens (or creates) the reference store at path
+func NewJSONArtworkReferenceRepository(path string) (*JSONArtworkReferenceRepository, error) {
+	r := &JSONArtworkReferenceRepository{
+		path:       path,
+		references: make(map[string][]string),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return r, nil
+		}
+		return nil, fmt.Errorf("failed to read artwork references: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &r.references); err != nil {
+		return nil, fmt.Errorf("failed to parse artwork references: %w", err)
+	}
+	if r.references == nil {
+		r.references = make(map[string][]string)
+	}
+
+	return r, nil
+}
+
+// GetAll returns a copy of the referenced filenames by source ID
+func (r *JSONArtworkReferenceRepository) GetAll(ctx context.Context) (map[string][]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make(map[string][]string, len(r.references))
+	for sourceID, filenames := range r.references {
+		result[sourceID] = append([]string(nil), filenames...)
+	}
+	return result, nil
+}
+
+// Save replaces the filenames a source references and persists the store
+func (r *JSONArtworkReferenceRepository) Save(ctx context.Context, sourceID string, filenames []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous := maps.Clone(r.references)
+	r.references[sourceID] = append([]string(nil), filenames...)
+
+	if err := r.save(); err != nil {
+		// Rollback on save failure
+		r.references = previous
+		return err
+	}
+
+	return nil
+}
+
+// Delete removes a source's references and persists the store
+func (r *JSONArtworkReferenceRepository) Delete(ctx context.Context, sourceID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.references[sourceID]; !ok {
+		return nil
+	}
+	previous := maps.Clone(r.references)
+	delete(r.references, sourceID)
+
+	if err := r.save(); err != nil {
+		// Rollback on save failure
+		r.references = previous
+		return err
+	}
+
+	return nil
+}
+
+// save writes the store atomically via a temp file and rename
+func (r *JSONArtworkReferenceRepository) save() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("failed to create artwork reference directory: %w", err)
+	}
+
+	data, err := json.Marshal(r.references)
+	if err != nil {
+		return fmt.Errorf("failed to marshal artwork references: %w", err)
+	}
+
+	tmpPath := r.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write artwork references: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, r.path); err != nil {
+		return fmt.Errorf("failed to replace artwork references: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package identity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// JSONTrackIdentityRepository implements TrackIdentityRepository using a single JSON file
+// The IDs are kept in memory and the file is rewritten on each save
+type JSONTrackIdentityRepository struct {
+	path string
+	ids  map[string]string
+	mu   sync.RWMutex
+}
+
+// NewJSONTrackIdentityRepository opens (or creates) the identity store at path
+func NewJSONTrackIdentityRepository(path string) (*JSONTrackIdentityRepository, error) {
+	r := &JSONTrackIdentityRepository{
+		path: path,
+		ids:  make(map[string]string),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return r, nil
+		}
+		return nil, fmt.Errorf("failed to read track identities: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &r.ids); err != nil {
+		return nil, fmt.Errorf("failed to parse track identities: %w", err)
+	}
+	if r.ids == nil {
+		r.ids = make(map[string]string)
+	}
+
+	return r, nil
+}
+
+// Get returns the ID recorded for a file path, or "" if there is none
+func (r *JSONTrackIdentityRepository) Get(ctx context.Context, filePath string) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.ids[filePath], nil
+}
+
+// SaveAll records IDs by file path and persists the store
+func (r *JSONTrackIdentityRepository) SaveAll(ctx context.Context, ids map[string]string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous := maps.Clone(r.ids)
+	for filePath, id := range ids {
+		if id == "" {
+			delete(r.ids, filePath)
+		} else {
+			r.ids[filePath] = id
+		}
+	}
+
+	if err := r.save(); err != nil {
+		// Rollback on save failure
+		r.ids = previous
+		return err
+	}
+
+	return nil
+}
+
+// save writes the store atomically via a temp file and rename
+func (r *JSONTrackIdentityRepository) save() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("failed to create track identity directory: %w", err)
+	}
+
+	data, err := json.Marshal(r.ids)
+	if err != nil {
+		return fmt.Errorf("failed to marshal track identities: %w", err)
+	}
+
+	tmpPath := r.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write track identities: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, r.path); err != nil {
+		return fmt.Errorf("failed to replace track identities: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package movejournal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"GoMusic/internal/domain/model"
+)
+
+// JSONMoveJournalRepository implements MoveJournalRepository using a single JSON file
+// The file exists only while a run is in progress, or after one was cut short
+type JSONMoveJournalRepository struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewJSONMoveJournalRepository opens the journal at path
+func NewJSONMoveJournalRepository(path string) *JSONMoveJournalRepository {
+	return &JSONMoveJournalRepository{path: path}
+}
+
+// Get reads the journaled run, or returns nil if there is none
+func (r *JSONMoveJournalRepository) Get(ctx context.Context) (*model.MoveJournal, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read move journal: %w", err)
+	}
+
+	var journal model.MoveJournal
+	if err := json.Unmarshal(data, &journal); err != nil {
+		return nil, fmt.Errorf("failed to parse move journal: %w", err)
+	}
+	return &journal, nil
+}
+
+// Save writes the run atomically via a temp file and rename, synced to disk
+// before the files it describes are touched
+func (r *JSONMoveJournalRepository) Save(ctx context.Context, journal *model.MoveJournal) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("failed to create move journal directory: %w", err)
+	}
+
+	data, err := json.Marshal(journal)
+	if err != nil {
+		return fmt.Errorf("failed to marshal move journal: %w", err)
+	}
+
+	tmpPath := r.path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to write move journal: %w", err)
+	}
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write move journal: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, r.path); err != nil {
+		return fmt.Errorf("failed to replace move journal: %w", err)
+	}
+
+	return nil
+}
+
+// Clear deletes the journal
+func (r *JSONMoveJournalRepository) Clear(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := os.Remove(r.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to clear move journal: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package tagjournal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"GoMusic/internal/domain/model"
+)
+
+// maxBatches is how many batches the journal keeps; older ones can no longer be undone
+const maxBatches = 200
+
+// JSONTagJournalRepository implements TagJournalRepository using a single JSON file
+// Batches are kept in memory, oldest first, and the file is rewritten on each save
+type JSONTagJournalRepository struct {
+	path    string
+	batches []*model.TagEditBatch
+	mu      sync.RWMutex
+}
+
+// NewJSONTagJournalRepository opens (or creates) the journal at path
+func NewJSONTagJournalRepository(path string) (*JSONTagJournalRepository, error) {
+	r := &JSONTagJournalRepository{path: path}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return r, nil
+		}
+		return nil, fmt.Errorf("failed to read tag journal: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &r.batches); err != nil {
+		return nil, fmt.Errorf("failed to parse tag journal: %w", err)
+	}
+
+	return r, nil
+}
+
+// Get returns a copy of a batch, or nil if it is not in the journal
+func (r *JSONTagJournalRepository) Get(ctx context.Context, id string) (*model.TagEditBatch, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, batch := range r.batches {
+		if batch.ID == id {
+			return copyBatch(batch), nil
+		}
+	}
+	return nil, nil
+}
+
+// GetAll returns copies of every batch, newest first
+func (r *JSONTagJournalRepository) GetAll(ctx context.Context) ([]*model.TagEditBatch, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*model.TagEditBatch, 0, len(r.batches))
+	for i := len(r.batches) - 1; i >= 0; i-- {
+		result = append(result, copyBatch(r.batches[i]))
+	}
+	return result, nil
+}
+
+// Save stores a batch and persists the journal
+func (r *JSONTagJournalRepository) Save(ctx context.Context, batch *model.TagEditBatch) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous := r.batches
+	batches := make([]*model.TagEditBatch, 0, len(r.batches)+1)
+	for _, existing := range r.batches {
+		if existing.ID != batch.ID {
+			batches = append(batches, existing)
+		}
+	}
+	batches = append(batches, copyBatch(batch))
+	if len(batches) > maxBatches {
+		batches = batches[len(batches)-maxBatches:]
+	}
+	r.batches = batches
+
+	if err := r.save(); err != nil {
+		// Rollback on save failure
+		r.batches = previous
+		return err
+	}
+
+	return nil
+}
+
+// save writes the journal atomically via a temp file and rename
+func (r *JSONTagJournalRepository) save() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("failed to create tag journal directory: %w", err)
+	}
+
+	data, err := json.Marshal(r.batches)
+	if err != nil {
+		return fmt.Errorf("failed to marshal tag journal: %w", err)
+	}
+
+	tmpPath := r.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write tag journal: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, r.path); err != nil {
+		return fmt.Errorf("failed to replace tag journal: %w", err)
+	}
+
+	return nil
+}
+
+// copyBatch returns a deep copy of a batch so callers cannot change stored records
+func copyBatch(batch *model.TagEditBatch) *model.TagEditBatch {
+	batchCopy := *batch
+	batchCopy.Entries = make([]*model.TagEditEntry, len(batch.Entries))
+	for i, entry := range batch.Entries {
+		entryCopy := *entry
+		entryCopy.Changes = append([]model.TagFieldChange(nil), entry.Changes...)
+		batchCopy.Entries[i] = &entryCopy
+	}
+	return &batchCopy
+}
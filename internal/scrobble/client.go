@@ -0,0 +1,88 @@
+package scrobble
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"GoMusic/internal/domain/model"
+)
+
+// Scrobbling rules shared by ListenBrainz and Last.fm:
+// a track counts as listened once it played for half its length or 4 minutes,
+// whichever comes first, and tracks shorter than 30 seconds are never submitted
+const (
+	MinTrackLength   = 30 * time.Second
+	MaxRequiredPlay  = 4 * time.Minute
+	MaxBatchSize     = 50
+	clientIdentifier = "GoMusic"
+)
+
+// Client submits plays to a scrobble API
+type Client interface {
+	// Submit sends a batch of completed plays
+	Submit(ctx context.Context, scrobbles []*model.Scrobble) error
+
+	// NowPlaying announces the track that just started
+	NowPlaying(ctx context.Context, scrobble *model.Scrobble) error
+}
+
+// NewClient creates the client matching the configured service
+// onSession, which may be nil, receives the session key a Last.fm client
+// obtains by logging in
+func NewClient(config *model.ScrobblerConfig, onSession func(sessionKey string)) (Client, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	httpClient := &http.Client{Timeout: config.Timeout}
+
+	switch config.Service {
+	case model.ScrobblerServiceListenBrainz:
+		return newListenBrainzClient(config, httpClient), nil
+	case model.ScrobblerServiceLastFM:
+		return newLastFMClient(config, httpClient, onSession), nil
+	default:
+		return nil, fmt.Errorf("unsupported scrobbler service: %s", config.Service)
+	}
+}
+
+// ShouldScrobble reports whether a play of the given length qualifies for submission
+func ShouldScrobble(duration, played time.Duration) bool {
+	if duration > 0 && duration < MinTrackLength {
+		return false
+	}
+
+	required := MaxRequiredPlay
+	if duration > 0 && duration/2 < required {
+		required = duration / 2
+	}
+
+	return played >= required
+}
+
+// SubmitError describes a failed submission and whether it is worth retrying
+type SubmitError struct {
+	StatusCode int
+	Message    string
+	Retryable  bool
+	AuthFailed bool
+}
+
+func (e *SubmitError) Error() string {
+	if e.StatusCode > 0 {
+		return fmt.Sprintf("scrobble submission failed (%d): %s", e.StatusCode, e.Message)
+	}
+	return "scrobble submission failed: " + e.Message
+}
+
+// classifyStatus turns an HTTP status code into a SubmitError
+func classifyStatus(statusCode int, message string) *SubmitError {
+	return &SubmitError{
+		StatusCode: statusCode,
+		Message:    message,
+		Retryable:  statusCode == http.StatusTooManyRequests || statusCode >= 500,
+		AuthFailed: statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden,
+	}
+}
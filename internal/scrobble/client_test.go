@@ -0,0 +1,54 @@
+package scrobble
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestShouldScrobble(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration time.Duration
+		played   time.Duration
+		want     bool
+	}{
+		{"half of a short track", 3 * time.Minute, 90 * time.Second, true},
+		{"just under half", 3 * time.Minute, 89 * time.Second, false},
+		{"four minutes of a long track", 20 * time.Minute, 4 * time.Minute, true},
+		{"under four minutes of a long track", 20 * time.Minute, 4*time.Minute - time.Second, false},
+		{"track under 30 seconds played in full", 29 * time.Second, 29 * time.Second, false},
+		{"track of exactly 30 seconds", 30 * time.Second, 15 * time.Second, true},
+		{"unknown length needs four minutes", 0, 4 * time.Minute, true},
+		{"unknown length played briefly", 0, time.Minute, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ShouldScrobble(tt.duration, tt.played); got != tt.want {
+				t.Errorf("ShouldScrobble(%v, %v) = %v, want %v", tt.duration, tt.played, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifyStatus(t *testing.T) {
+	tests := []struct {
+		status        int
+		wantRetryable bool
+		wantAuth      bool
+	}{
+		{http.StatusTooManyRequests, true, false},
+		{http.StatusBadGateway, true, false},
+		{http.StatusUnauthorized, false, true},
+		{http.StatusForbidden, false, true},
+		{http.StatusBadRequest, false, false},
+	}
+
+	for _, tt := range tests {
+		err := classifyStatus(tt.status, "message")
+		if err.Retryable != tt.wantRetryable || err.AuthFailed != tt.wantAuth {
+			t.Errorf("classifyStatus(%d) = %+v, want retryable %v, auth failed %v", tt.status, err, tt.wantRetryable, tt.wantAuth)
+		}
+	}
+}
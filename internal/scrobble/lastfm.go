@@ -0,0 +1,223 @@
+package scrobble
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"GoMusic/internal/domain/model"
+)
+
+// Last.fm error codes that indicate a temporary problem
+// See https://www.last.fm/api/errorcodes
+const (
+	lastFMErrInvalidSession     = 9
+	lastFMErrServiceOffline     = 11
+	lastFMErrTemporarilyFailed  = 16
+	lastFMErrRateLimitExceeded  = 29
+	lastFMErrInvalidAPIKey      = 10
+	lastFMErrAuthenticationFail = 4
+)
+
+// lastFMClient submits scrobbles to a Last.fm-compatible API (Audioscrobbler 2.0)
+type lastFMClient struct {
+	baseURL    string
+	apiKey     string
+	apiSecret  string
+	username   string
+	password   string
+	sessionKey string
+	onSession  func(sessionKey string)
+	httpClient *http.Client
+	mu         sync.Mutex
+}
+
+func newLastFMClient(config *model.ScrobblerConfig, httpClient *http.Client, onSession func(sessionKey string)) *lastFMClient {
+	return &lastFMClient{
+		baseURL:    config.BaseURL,
+		apiKey:     config.APIKey,
+		apiSecret:  config.APISecret,
+		username:   config.Username,
+		password:   config.Password,
+		sessionKey: config.SessionKey,
+		onSession:  onSession,
+		httpClient: httpClient,
+	}
+}
+
+// Submit sends completed plays using track.scrobble
+func (c *lastFMClient) Submit(ctx context.Context, scrobbles []*model.Scrobble) error {
+	sessionKey, err := c.session(ctx)
+	if err != nil {
+		return err
+	}
+
+	params := url.Values{}
+	params.Set("method", "track.scrobble")
+	params.Set("sk", sessionKey)
+	for i, s := range scrobbles {
+		suffix := "[" + strconv.Itoa(i) + "]"
+		setTrackParams(params, s, suffix)
+		params.Set("timestamp"+suffix, strconv.FormatInt(s.ListenedAt.Unix(), 10))
+	}
+
+	_, err = c.call(ctx, params)
+	return err
+}
+
+// NowPlaying announces the current track using track.updateNowPlaying
+func (c *lastFMClient) NowPlaying(ctx context.Context, scrobble *model.Scrobble) error {
+	sessionKey, err := c.session(ctx)
+	if err != nil {
+		return err
+	}
+
+	params := url.Values{}
+	params.Set("method", "track.updateNowPlaying")
+	params.Set("sk", sessionKey)
+	setTrackParams(params, scrobble, "")
+
+	_, err = c.call(ctx, params)
+	return err
+}
+
+// session returns the session key, obtaining one via auth.getMobileSession if needed
+// A new key is handed to onSession so it can be kept across restarts
+func (c *lastFMClient) session(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.sessionKey != "" {
+		return c.sessionKey, nil
+	}
+
+	params := url.Values{}
+	params.Set("method", "auth.getMobileSession")
+	params.Set("username", c.username)
+	params.Set("password", c.password)
+
+	body, err := c.call(ctx, params)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		Session struct {
+			Key string `json:"key"`
+		} `json:"session"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil || result.Session.Key == "" {
+		return "", &SubmitError{Message: "no session key in auth response", AuthFailed: true}
+	}
+
+	c.sessionKey = result.Session.Key
+	if c.onSession != nil {
+		c.onSession(c.sessionKey)
+	}
+	return c.sessionKey, nil
+}
+
+// call signs and posts an API request, returning the raw response body
+func (c *lastFMClient) call(ctx context.Context, params url.Values) ([]byte, error) {
+	params.Set("api_key", c.apiKey)
+	params.Set("api_sig", c.sign(params))
+	params.Set("format", "json")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL, strings.NewReader(params.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		// Network errors are always worth retrying (offline, DNS, timeouts)
+		return nil, &SubmitError{Message: err.Error(), Retryable: true}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+	if err != nil {
+		return nil, &SubmitError{Message: err.Error(), Retryable: true}
+	}
+
+	var apiError struct {
+		Error   int    `json:"error"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &apiError) == nil && apiError.Error != 0 {
+		return nil, classifyLastFMError(resp.StatusCode, apiError.Error, apiError.Message)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, classifyStatus(resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	return body, nil
+}
+
+// sign computes the api_sig parameter: md5 over sorted name/value pairs plus the secret
+func (c *lastFMClient) sign(params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		if key == "format" || key == "callback" || key == "api_sig" {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for _, key := range keys {
+		sb.WriteString(key)
+		sb.WriteString(params.Get(key))
+	}
+	sb.WriteString(c.apiSecret)
+
+	hash := md5.Sum([]byte(sb.String()))
+	return hex.EncodeToString(hash[:])
+}
+
+// setTrackParams adds the per-track parameters shared by scrobble and now-playing calls
+func setTrackParams(params url.Values, s *model.Scrobble, suffix string) {
+	params.Set("artist"+suffix, s.Artist)
+	params.Set("track"+suffix, s.Title)
+	if s.Album != "" {
+		params.Set("album"+suffix, s.Album)
+	}
+	if s.AlbumArtist != "" {
+		params.Set("albumArtist"+suffix, s.AlbumArtist)
+	}
+	if s.TrackNumber > 0 {
+		params.Set("trackNumber"+suffix, strconv.Itoa(s.TrackNumber))
+	}
+	if s.Duration > 0 {
+		params.Set("duration"+suffix, strconv.Itoa(int(s.Duration.Seconds())))
+	}
+}
+
+// classifyLastFMError maps Last.fm error codes onto SubmitError semantics
+func classifyLastFMError(statusCode, code int, message string) *SubmitError {
+	err := &SubmitError{
+		StatusCode: statusCode,
+		Message:    fmt.Sprintf("%s (code %d)", message, code),
+	}
+
+	switch code {
+	case lastFMErrServiceOffline, lastFMErrTemporarilyFailed, lastFMErrRateLimitExceeded:
+		err.Retryable = true
+	case lastFMErrInvalidSession, lastFMErrInvalidAPIKey, lastFMErrAuthenticationFail:
+		err.AuthFailed = true
+	}
+
+	return err
+}
@@ -0,0 +1,129 @@
+package scrobble
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"GoMusic/internal/domain/model"
+)
+
+// listenBrainzClient submits listens to a ListenBrainz-compatible API
+type listenBrainzClient struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+func newListenBrainzClient(config *model.ScrobblerConfig, httpClient *http.Client) *listenBrainzClient {
+	return &listenBrainzClient{
+		baseURL:    strings.TrimSuffix(config.BaseURL, "/"),
+		token:      config.Token,
+		httpClient: httpClient,
+	}
+}
+
+type listenBrainzSubmission struct {
+	ListenType string               `json:"listen_type"`
+	Payload    []listenBrainzListen `json:"payload"`
+}
+
+type listenBrainzListen struct {
+	ListenedAt    int64                     `json:"listened_at,omitempty"`
+	TrackMetadata listenBrainzTrackMetadata `json:"track_metadata"`
+}
+
+type listenBrainzTrackMetadata struct {
+	ArtistName     string                 `json:"artist_name"`
+	TrackName      string                 `json:"track_name"`
+	ReleaseName    string                 `json:"release_name,omitempty"`
+	AdditionalInfo map[string]interface{} `json:"additional_info,omitempty"`
+}
+
+// Submit sends completed listens
+func (c *listenBrainzClient) Submit(ctx context.Context, scrobbles []*model.Scrobble) error {
+	listenType := "import"
+	if len(scrobbles) == 1 {
+		listenType = "single"
+	}
+
+	payload := make([]listenBrainzListen, len(scrobbles))
+	for i, s := range scrobbles {
+		payload[i] = toListenBrainzListen(s)
+		payload[i].ListenedAt = s.ListenedAt.Unix()
+	}
+
+	return c.post(ctx, listenBrainzSubmission{ListenType: listenType, Payload: payload})
+}
+
+// NowPlaying announces the currently playing track
+func (c *listenBrainzClient) NowPlaying(ctx context.Context, scrobble *model.Scrobble) error {
+	return c.post(ctx, listenBrainzSubmission{
+		ListenType: "playing_now",
+		Payload:    []listenBrainzListen{toListenBrainzListen(scrobble)},
+	})
+}
+
+// post sends a submission to the submit-listens endpoint
+func (c *listenBrainzClient) post(ctx context.Context, submission listenBrainzSubmission) error {
+	body, err := json.Marshal(submission)
+	if err != nil {
+		return fmt.Errorf("failed to marshal listens: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/1/submit-listens", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Token "+c.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		// Network errors are always worth retrying (offline, DNS, timeouts)
+		return &SubmitError{Message: err.Error(), Retryable: true}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	var apiError struct {
+		Error string `json:"error"`
+	}
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if json.Unmarshal(respBody, &apiError) != nil || apiError.Error == "" {
+		apiError.Error = http.StatusText(resp.StatusCode)
+	}
+
+	return classifyStatus(resp.StatusCode, apiError.Error)
+}
+
+// toListenBrainzListen converts a scrobble into the ListenBrainz payload format
+func toListenBrainzListen(s *model.Scrobble) listenBrainzListen {
+	info := map[string]interface{}{
+		"media_player":      clientIdentifier,
+		"submission_client": clientIdentifier,
+	}
+	if s.Duration > 0 {
+		info["duration_ms"] = s.Duration.Milliseconds()
+	}
+	if s.TrackNumber > 0 {
+		info["tracknumber"] = s.TrackNumber
+	}
+
+	return listenBrainzListen{
+		TrackMetadata: listenBrainzTrackMetadata{
+			ArtistName:     s.Artist,
+			TrackName:      s.Title,
+			ReleaseName:    s.Album,
+			AdditionalInfo: info,
+		},
+	}
+}
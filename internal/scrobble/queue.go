@@ -0,0 +1,116 @@
+package scrobble
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"GoMusic/internal/domain/model"
+)
+
+// Queue is a disk-backed FIFO of scrobbles waiting to be submitted
+// The whole queue is rewritten on every change, which is fine for the
+// few hundred entries that accumulate while offline
+type Queue struct {
+	path  string
+	items []*model.Scrobble
+	mu    sync.Mutex
+}
+
+// NewQueue opens (or creates) the queue stored at path
+func NewQueue(path string) (*Queue, error) {
+	q := &Queue{path: path}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return q, nil
+		}
+		return nil, fmt.Errorf("failed to read scrobble queue: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &q.items); err != nil {
+		return nil, fmt.Errorf("failed to parse scrobble queue: %w", err)
+	}
+
+	return q, nil
+}
+
+// Enqueue appends a scrobble and persists the queue
+func (q *Queue) Enqueue(scrobble *model.Scrobble) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.items = append(q.items, scrobble)
+	if err := q.save(); err != nil {
+		q.items = q.items[:len(q.items)-1]
+		return err
+	}
+
+	return nil
+}
+
+// Peek returns up to n scrobbles from the front of the queue
+func (q *Queue) Peek(n int) []*model.Scrobble {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if n > len(q.items) {
+		n = len(q.items)
+	}
+
+	batch := make([]*model.Scrobble, n)
+	copy(batch, q.items[:n])
+	return batch
+}
+
+// Remove drops the first n scrobbles and persists the queue
+func (q *Queue) Remove(n int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if n > len(q.items) {
+		n = len(q.items)
+	}
+
+	removed := q.items[:n]
+	q.items = q.items[n:]
+	if err := q.save(); err != nil {
+		q.items = append(removed, q.items...)
+		return err
+	}
+
+	return nil
+}
+
+// Len returns the number of queued scrobbles
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// save writes the queue atomically via a temp file and rename
+func (q *Queue) save() error {
+	if err := os.MkdirAll(filepath.Dir(q.path), 0755); err != nil {
+		return fmt.Errorf("failed to create queue directory: %w", err)
+	}
+
+	data, err := json.Marshal(q.items)
+	if err != nil {
+		return fmt.Errorf("failed to marshal scrobble queue: %w", err)
+	}
+
+	tmpPath := q.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write scrobble queue: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, q.path); err != nil {
+		return fmt.Errorf("failed to replace scrobble queue: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package scrobble
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"GoMusic/internal/domain/model"
+)
+
+func TestQueuePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scrobbles", "queue.json")
+	queue, err := NewQueue(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"one", "two", "three"} {
+		if err := queue.Enqueue(&model.Scrobble{TrackID: id}); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+	if err := queue.Remove(1); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+
+	// A restart finds the scrobbles still waiting, in order
+	reopened, err := NewQueue(path)
+	if err != nil {
+		t.Fatalf("NewQueue() error = %v", err)
+	}
+	if reopened.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", reopened.Len())
+	}
+	batch := reopened.Peek(10)
+	if len(batch) != 2 || batch[0].TrackID != "two" || batch[1].TrackID != "three" {
+		t.Fatalf("Peek() = %+v, want two and three", batch)
+	}
+	if err := reopened.Remove(10); err != nil || reopened.Len() != 0 {
+		t.Fatalf("Remove() past the end = %v, Len() = %d", err, reopened.Len())
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Error("the temporary file was left behind")
+	}
+}
+
+func TestNewQueueCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewQueue(path); err == nil {
+		t.Fatal("NewQueue() of a corrupt file succeeded")
+	}
+}
@@ -0,0 +1,501 @@
+package dlna
+
+import (
+	"context"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"GoMusic/internal/domain/model"
+	"GoMusic/internal/domain/source/capability"
+	"GoMusic/internal/service"
+)
+
+// Object IDs of the fixed containers
+// Other objects use "<kind>/<id>" IDs, e.g. "album/album_1a2b" or "folder/<sourceID>/Rock/Live"
+const (
+	rootID    = "0"
+	artistsID = "artists"
+	albumsID  = "albums"
+	genresID  = "genres"
+	tracksID  = "tracks"
+	foldersID = "folders"
+)
+
+// UPnP object classes
+const (
+	classStorageFolder = "object.container.storageFolder"
+	classMusicArtist   = "object.container.person.musicArtist"
+	classMusicAlbum    = "object.container.album.musicAlbum"
+	classMusicGenre    = "object.container.genre.musicGenre"
+	classMusicTrack    = "object.item.audioItem.musicTrack"
+)
+
+// object is a node of the content tree: either a container or a track item
+type object struct {
+	id          string
+	parentID    string
+	title       string
+	class       string
+	isContainer bool
+	childCount  int // -1 when unknown
+
+	artist    string
+	album     string
+	genre     string
+	artworkID string // song, album or artist ID whose artwork represents the object
+	track     *model.Track
+}
+
+// library is a snapshot of the library used to answer a single request
+type library struct {
+	tracks     []*model.Track
+	trackByID  map[string]*model.Track
+	albums     []*model.Album
+	albumByID  map[string]*model.Album
+	artists    []*model.Artist
+	artistByID map[string]*model.Artist
+	genres     []string
+
+	// Groupings computed once per snapshot
+	tracksByAlbum  map[string][]*model.Track
+	tracksByGenre  map[string][]*model.Track
+	albumsByArtist map[string][]*model.Album
+
+	browsers  map[string]capability.DirectoryBrowser
+	sourceIDs []string // sources with a folder view, sorted
+}
+
+// loadLibrary takes a snapshot of the library and the sources' folder views
+func loadLibrary(ctx context.Context, libraryService *service.LibraryService) (*library, error) {
+	tracks, err := libraryService.GetAllTracks(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	albums, err := libraryService.GetAllAlbums(ctx)
+	if err != nil {
+		return nil, err
+	}
+	artists, err := libraryService.GetAllArtists(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	lib := &library{
+		tracks:     tracks,
+		trackByID:  make(map[string]*model.Track, len(tracks)),
+		albums:     albums,
+		albumByID:  make(map[string]*model.Album, len(albums)),
+		artists:    artists,
+		artistByID: make(map[string]*model.Artist, len(artists)),
+		browsers:   make(map[string]capability.DirectoryBrowser),
+	}
+
+	sort.SliceStable(lib.tracks, func(i, j int) bool {
+		return strings.ToLower(lib.tracks[i].Title) < strings.ToLower(lib.tracks[j].Title)
+	})
+
+	genres := make(map[string]bool)
+	for _, track := range tracks {
+		lib.trackByID[track.ID] = track
+		if track.Genre != "" && !genres[track.Genre] {
+			genres[track.Genre] = true
+			lib.genres = append(lib.genres, track.Genre)
+		}
+	}
+	sort.Strings(lib.genres)
+
+	for _, album := range albums {
+		lib.albumByID[album.ID] = album
+	}
+	for _, artist := range artists {
+		lib.artistByID[artist.ID] = artist
+	}
+
+	for sourceID, repo := range libraryService.GetRepositories() {
+		if browser, ok := repo.(capability.DirectoryBrowser); ok {
+			lib.browsers[sourceID] = browser
+			lib.sourceIDs = append(lib.sourceIDs, sourceID)
+		}
+	}
+	sort.Strings(lib.sourceIDs)
+
+	lib.groupTracks()
+
+	return lib, nil
+}
+
+// groupTracks indexes tracks by album and genre, and albums by artist
+func (l *library) groupTracks() {
+	l.tracksByAlbum = make(map[string][]*model.Track)
+	l.tracksByGenre = make(map[string][]*model.Track)
+	l.albumsByArtist = make(map[string][]*model.Album)
+
+	artistAlbums := make(map[string]map[string]bool)
+	addArtistAlbum := func(artistID string, album *model.Album) {
+		if artistAlbums[artistID] == nil {
+			artistAlbums[artistID] = make(map[string]bool)
+		}
+		if !artistAlbums[artistID][album.ID] {
+			artistAlbums[artistID][album.ID] = true
+			l.albumsByArtist[artistID] = append(l.albumsByArtist[artistID], album)
+		}
+	}
+
+	for _, album := range l.albums {
+		addArtistAlbum(album.ArtistID, album)
+	}
+	for _, track := range l.tracks {
+		l.tracksByAlbum[track.AlbumID] = append(l.tracksByAlbum[track.AlbumID], track)
+		if track.Genre != "" {
+			l.tracksByGenre[track.Genre] = append(l.tracksByGenre[track.Genre], track)
+		}
+		if album, ok := l.albumByID[track.AlbumID]; ok {
+			addArtistAlbum(track.ArtistID, album)
+		}
+	}
+
+	for _, tracks := range l.tracksByAlbum {
+		sort.SliceStable(tracks, func(i, j int) bool {
+			if tracks[i].DiscNumber != tracks[j].DiscNumber {
+				return tracks[i].DiscNumber < tracks[j].DiscNumber
+			}
+			return tracks[i].TrackNumber < tracks[j].TrackNumber
+		})
+	}
+	for _, tracks := range l.tracksByGenre {
+		sort.SliceStable(tracks, func(i, j int) bool {
+			if tracks[i].Artist != tracks[j].Artist {
+				return strings.ToLower(tracks[i].Artist) < strings.ToLower(tracks[j].Artist)
+			}
+			if tracks[i].Album != tracks[j].Album {
+				return strings.ToLower(tracks[i].Album) < strings.ToLower(tracks[j].Album)
+			}
+			return tracks[i].TrackNumber < tracks[j].TrackNumber
+		})
+	}
+	for _, albums := range l.albumsByArtist {
+		sort.SliceStable(albums, func(i, j int) bool {
+			if albums[i].Year != albums[j].Year {
+				return albums[i].Year < albums[j].Year
+			}
+			return strings.ToLower(albums[i].Title) < strings.ToLower(albums[j].Title)
+		})
+	}
+}
+
+// lookup returns the object with the given ID
+func (l *library) lookup(id string) (*object, bool) {
+	switch id {
+	case rootID:
+		return &object{id: rootID, parentID: "-1", title: "GoMusic", class: classStorageFolder, isContainer: true, childCount: len(l.rootChildren())}, true
+	case artistsID:
+		return l.fixedContainer(artistsID, "Artists", len(l.artists)), true
+	case albumsID:
+		return l.fixedContainer(albumsID, "Albums", len(l.albums)), true
+	case genresID:
+		return l.fixedContainer(genresID, "Genres", len(l.genres)), true
+	case tracksID:
+		return l.fixedContainer(tracksID, "All Tracks", len(l.tracks)), true
+	case foldersID:
+		if len(l.sourceIDs) == 0 {
+			return nil, false
+		}
+		return l.fixedContainer(foldersID, "Folders", len(l.sourceIDs)), true
+	}
+
+	kind, rest, ok := strings.Cut(id, "/")
+	if !ok {
+		return nil, false
+	}
+
+	switch kind {
+	case "artist":
+		if artist, ok := l.artistByID[rest]; ok {
+			return l.artistObject(artist), true
+		}
+	case "album":
+		if album, ok := l.albumByID[rest]; ok {
+			return l.albumObject(album, albumsID), true
+		}
+	case "genre":
+		if genre, err := url.PathUnescape(rest); err == nil {
+			for _, g := range l.genres {
+				if g == genre {
+					return l.genreObject(genre), true
+				}
+			}
+		}
+	case "track":
+		if track, ok := l.trackByID[rest]; ok {
+			return trackObject(track, "album/"+track.AlbumID), true
+		}
+	case "folder":
+		sourceID, relPath, _ := strings.Cut(rest, "/")
+		if _, ok := l.browsers[sourceID]; ok && validRelativePath(relPath) {
+			return l.folderObject(sourceID, relPath), true
+		}
+	}
+
+	return nil, false
+}
+
+// children returns the direct children of a container
+func (l *library) children(id string) ([]*object, bool) {
+	switch id {
+	case rootID:
+		return l.rootChildren(), true
+	case artistsID:
+		objects := make([]*object, 0, len(l.artists))
+		for _, artist := range l.artists {
+			objects = append(objects, l.artistObject(artist))
+		}
+		return objects, true
+	case albumsID:
+		objects := make([]*object, 0, len(l.albums))
+		for _, album := range l.albums {
+			objects = append(objects, l.albumObject(album, albumsID))
+		}
+		return objects, true
+	case genresID:
+		objects := make([]*object, 0, len(l.genres))
+		for _, genre := range l.genres {
+			objects = append(objects, l.genreObject(genre))
+		}
+		return objects, true
+	case tracksID:
+		return tracksToObjects(l.tracks, tracksID), true
+	case foldersID:
+		objects := make([]*object, 0, len(l.sourceIDs))
+		for _, sourceID := range l.sourceIDs {
+			objects = append(objects, l.folderObject(sourceID, ""))
+		}
+		return objects, true
+	}
+
+	kind, rest, ok := strings.Cut(id, "/")
+	if !ok {
+		return nil, false
+	}
+
+	switch kind {
+	case "artist":
+		if _, ok := l.artistByID[rest]; ok {
+			var objects []*object
+			for _, album := range l.albumsByArtist[rest] {
+				objects = append(objects, l.albumObject(album, id))
+			}
+			return objects, true
+		}
+	case "album":
+		if _, ok := l.albumByID[rest]; ok {
+			return tracksToObjects(l.tracksByAlbum[rest], id), true
+		}
+	case "genre":
+		if genre, err := url.PathUnescape(rest); err == nil {
+			return tracksToObjects(l.tracksByGenre[genre], id), true
+		}
+	case "track":
+		// Items have no children
+		return nil, false
+	case "folder":
+		sourceID, relPath, _ := strings.Cut(rest, "/")
+		if _, ok := l.browsers[sourceID]; ok && validRelativePath(relPath) {
+			return l.folderChildren(sourceID, relPath), true
+		}
+	}
+
+	return nil, false
+}
+
+// rootChildren returns the top-level views
+func (l *library) rootChildren() []*object {
+	objects := []*object{
+		l.fixedContainer(artistsID, "Artists", len(l.artists)),
+		l.fixedContainer(albumsID, "Albums", len(l.albums)),
+		l.fixedContainer(genresID, "Genres", len(l.genres)),
+		l.fixedContainer(tracksID, "All Tracks", len(l.tracks)),
+	}
+	if len(l.sourceIDs) > 0 {
+		objects = append(objects, l.fixedContainer(foldersID, "Folders", len(l.sourceIDs)))
+	}
+	return objects
+}
+
+// fixedContainer creates one of the top-level view containers
+func (l *library) fixedContainer(id, title string, childCount int) *object {
+	return &object{id: id, parentID: rootID, title: title, class: classStorageFolder, isContainer: true, childCount: childCount}
+}
+
+// artistObject creates the container of an artist
+func (l *library) artistObject(artist *model.Artist) *object {
+	obj := &object{
+		id:          "artist/" + artist.ID,
+		parentID:    artistsID,
+		title:       artist.Name,
+		class:       classMusicArtist,
+		isContainer: true,
+		childCount:  len(l.albumsByArtist[artist.ID]),
+		artist:      artist.Name,
+	}
+	if artist.ImagePath != "" {
+		obj.artworkID = artist.ID
+	}
+	return obj
+}
+
+// albumObject creates the container of an album under the given parent
+func (l *library) albumObject(album *model.Album, parentID string) *object {
+	obj := &object{
+		id:          "album/" + album.ID,
+		parentID:    parentID,
+		title:       album.Title,
+		class:       classMusicAlbum,
+		isContainer: true,
+		childCount:  album.TrackCount,
+		artist:      album.Artist,
+		album:       album.Title,
+		genre:       album.Genre,
+	}
+	if album.ArtworkPath != "" {
+		obj.artworkID = album.ID
+	}
+	return obj
+}
+
+// genreObject creates the container of a genre
+func (l *library) genreObject(genre string) *object {
+	return &object{
+		id:          "genre/" + url.PathEscape(genre),
+		parentID:    genresID,
+		title:       genre,
+		class:       classMusicGenre,
+		isContainer: true,
+		childCount:  len(l.tracksByGenre[genre]),
+		genre:       genre,
+	}
+}
+
+// folderObject creates the container of a directory in a source's folder view
+func (l *library) folderObject(sourceID, relPath string) *object {
+	id := "folder/" + sourceID
+	parentID := foldersID
+	title := filepath.Base(l.browsers[sourceID].GetRootPath())
+
+	if relPath != "" {
+		id += "/" + relPath
+		title = filepath.Base(relPath)
+		parentID = "folder/" + sourceID
+		if parent := filepath.Dir(relPath); parent != "." {
+			parentID += "/" + filepath.ToSlash(parent)
+		}
+	}
+
+	// Counting entries would require listing every directory, so it is left unknown
+	return &object{id: id, parentID: parentID, title: title, class: classStorageFolder, isContainer: true, childCount: -1}
+}
+
+// folderChildren lists subdirectories and known tracks of a directory
+func (l *library) folderChildren(sourceID, relPath string) []*object {
+	browser := l.browsers[sourceID]
+	parentID := "folder/" + sourceID
+	listPath := ""
+	if relPath != "" {
+		parentID += "/" + relPath
+		listPath = "/" + relPath
+	}
+
+	nodes, err := browser.ListDirectory(listPath)
+	if err != nil {
+		return nil
+	}
+
+	tracksByPath := make(map[string]*model.Track)
+	for _, track := range l.tracks {
+		if track.SourceID == sourceID && track.FilePath != "" {
+			tracksByPath[track.FilePath] = track
+		}
+	}
+
+	rootPath := browser.GetRootPath()
+	var folders, items []*object
+	for _, node := range nodes {
+		if node.IsDirectory {
+			childRel, err := filepath.Rel(rootPath, node.Path)
+			if err != nil || !validRelativePath(filepath.ToSlash(childRel)) {
+				continue
+			}
+			folders = append(folders, l.folderObject(sourceID, filepath.ToSlash(childRel)))
+			continue
+		}
+
+		// Only files the scanner recognised as tracks are playable
+		if track, ok := tracksByPath[node.Path]; ok {
+			items = append(items, trackObject(track, parentID))
+		}
+	}
+
+	sort.SliceStable(folders, func(i, j int) bool {
+		return strings.ToLower(folders[i].title) < strings.ToLower(folders[j].title)
+	})
+	sort.SliceStable(items, func(i, j int) bool {
+		return strings.ToLower(filepath.Base(items[i].track.FilePath)) < strings.ToLower(filepath.Base(items[j].track.FilePath))
+	})
+
+	return append(folders, items...)
+}
+
+// searchCandidates returns every object that Search can match
+func (l *library) searchCandidates() []*object {
+	objects := make([]*object, 0, len(l.artists)+len(l.albums)+len(l.tracks))
+	for _, artist := range l.artists {
+		objects = append(objects, l.artistObject(artist))
+	}
+	for _, album := range l.albums {
+		objects = append(objects, l.albumObject(album, albumsID))
+	}
+	for _, track := range l.tracks {
+		objects = append(objects, trackObject(track, "album/"+track.AlbumID))
+	}
+	return objects
+}
+
+// trackObject creates the item of a track under the given parent
+func trackObject(track *model.Track, parentID string) *object {
+	return &object{
+		id:       "track/" + track.ID,
+		parentID: parentID,
+		title:    track.Title,
+		class:    classMusicTrack,
+		artist:   track.Artist,
+		album:    track.Album,
+		genre:    track.Genre,
+		track:    track,
+	}
+}
+
+// tracksToObjects creates items for tracks under the given parent
+func tracksToObjects(tracks []*model.Track, parentID string) []*object {
+	objects := make([]*object, 0, len(tracks))
+	for _, track := range tracks {
+		objects = append(objects, trackObject(track, parentID))
+	}
+	return objects
+}
+
+// validRelativePath rejects folder paths that could escape the source root
+func validRelativePath(relPath string) bool {
+	if relPath == "" {
+		return true
+	}
+	if strings.HasPrefix(relPath, "/") {
+		return false
+	}
+	for _, part := range strings.Split(relPath, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
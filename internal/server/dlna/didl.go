@@ -0,0 +1,204 @@
+package dlna
+
+import (
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"GoMusic/internal/domain/model"
+	"GoMusic/internal/media"
+)
+
+// DIDL-Lite document types returned in Browse and Search results
+// Namespaced names are written literally since encoding/xml cannot emit prefixes
+
+type didlLite struct {
+	XMLName    xml.Name        `xml:"DIDL-Lite"`
+	Xmlns      string          `xml:"xmlns,attr"`
+	XmlnsDC    string          `xml:"xmlns:dc,attr"`
+	XmlnsUPnP  string          `xml:"xmlns:upnp,attr"`
+	XmlnsDLNA  string          `xml:"xmlns:dlna,attr"`
+	Containers []didlContainer `xml:"container"`
+	Items      []didlItem      `xml:"item"`
+}
+
+type didlContainer struct {
+	ID          string `xml:"id,attr"`
+	ParentID    string `xml:"parentID,attr"`
+	Restricted  int    `xml:"restricted,attr"`
+	Searchable  int    `xml:"searchable,attr"`
+	ChildCount  *int   `xml:"childCount,attr,omitempty"`
+	Title       string `xml:"dc:title"`
+	Class       string `xml:"upnp:class"`
+	Artist      string `xml:"upnp:artist,omitempty"`
+	Creator     string `xml:"dc:creator,omitempty"`
+	Genre       string `xml:"upnp:genre,omitempty"`
+	AlbumArtURI *art   `xml:"upnp:albumArtURI,omitempty"`
+}
+
+type didlItem struct {
+	ID                  string    `xml:"id,attr"`
+	ParentID            string    `xml:"parentID,attr"`
+	Restricted          int       `xml:"restricted,attr"`
+	Title               string    `xml:"dc:title"`
+	Class               string    `xml:"upnp:class"`
+	Creator             string    `xml:"dc:creator,omitempty"`
+	Artist              string    `xml:"upnp:artist,omitempty"`
+	Album               string    `xml:"upnp:album,omitempty"`
+	Genre               string    `xml:"upnp:genre,omitempty"`
+	Date                string    `xml:"dc:date,omitempty"`
+	OriginalTrackNumber int       `xml:"upnp:originalTrackNumber,omitempty"`
+	AlbumArtURI         *art      `xml:"upnp:albumArtURI,omitempty"`
+	Res                 []didlRes `xml:"res"`
+}
+
+type art struct {
+	ProfileID string `xml:"dlna:profileID,attr"`
+	URI       string `xml:",chardata"`
+}
+
+type didlRes struct {
+	ProtocolInfo    string `xml:"protocolInfo,attr"`
+	Size            int64  `xml:"size,attr,omitempty"`
+	Duration        string `xml:"duration,attr,omitempty"`
+	Bitrate         int    `xml:"bitrate,attr,omitempty"` // bytes per second
+	SampleFrequency int    `xml:"sampleFrequency,attr,omitempty"`
+	URI             string `xml:",chardata"`
+}
+
+// newDIDLLite creates an empty DIDL-Lite document
+func newDIDLLite() *didlLite {
+	return &didlLite{
+		Xmlns:     "urn:schemas-upnp-org:metadata-1-0/DIDL-Lite/",
+		XmlnsDC:   "http://purl.org/dc/elements/1.1/",
+		XmlnsUPnP: "urn:schemas-upnp-org:metadata-1-0/upnp/",
+		XmlnsDLNA: "urn:schemas-dlna-org:metadata-1-0/",
+	}
+}
+
+// add appends an object to the document
+func (d *didlLite) add(obj *object, baseURL string) {
+	if obj.isContainer {
+		container := didlContainer{
+			ID:         obj.id,
+			ParentID:   obj.parentID,
+			Restricted: 1,
+			Searchable: 1,
+			Title:      obj.title,
+			Class:      obj.class,
+			Artist:     obj.artist,
+			Creator:    obj.artist,
+			Genre:      obj.genre,
+		}
+		if obj.childCount >= 0 {
+			count := obj.childCount
+			container.ChildCount = &count
+		}
+		if obj.artworkID != "" {
+			container.AlbumArtURI = &art{ProfileID: "JPEG_TN", URI: artworkURL(baseURL, obj.artworkID)}
+		}
+		d.Containers = append(d.Containers, container)
+		return
+	}
+
+	track := obj.track
+	item := didlItem{
+		ID:                  obj.id,
+		ParentID:            obj.parentID,
+		Restricted:          1,
+		Title:               track.Title,
+		Class:               obj.class,
+		Creator:             track.Artist,
+		Artist:              track.Artist,
+		Album:               track.Album,
+		Genre:               track.Genre,
+		OriginalTrackNumber: track.TrackNumber,
+		Res: []didlRes{{
+			ProtocolInfo:    "http-get:*:" + trackContentType(track) + ":" + contentFeatures(track),
+			Size:            trackSize(track),
+			Duration:        formatDuration(track.Duration),
+			Bitrate:         track.BitRate * 1000 / 8,
+			SampleFrequency: track.SampleRate,
+			URI:             streamURL(baseURL, track.ID),
+		}},
+	}
+	if track.Year > 0 {
+		item.Date = fmt.Sprintf("%04d-01-01", track.Year)
+	}
+	if track.ArtworkPath != "" {
+		item.AlbumArtURI = &art{ProfileID: "JPEG_TN", URI: artworkURL(baseURL, track.ID)}
+	}
+	d.Items = append(d.Items, item)
+}
+
+// marshal encodes the document
+func (d *didlLite) marshal() (string, error) {
+	data, err := xml.Marshal(d)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode DIDL-Lite: %w", err)
+	}
+	return string(data), nil
+}
+
+// trackContentType returns the MIME type of a track's audio
+// CUE sheet tracks are streamed as decoded WAV
+func trackContentType(track *model.Track) string {
+	if track.IsSegment() {
+		return "audio/wav"
+	}
+	if track.FilePath != "" {
+		return media.AudioContentType(track.FilePath)
+	}
+	return media.AudioContentType("." + track.Format)
+}
+
+// trackSize returns the size of the streamed audio, or 0 when it is not known
+// in advance, as for CUE sheet tracks
+func trackSize(track *model.Track) int64 {
+	if track.IsSegment() {
+		return 0
+	}
+	return track.FileSize
+}
+
+// dlnaProfile returns the DLNA media profile for a track, or "" if it has none
+func dlnaProfile(track *model.Track) string {
+	if track.IsSegment() {
+		return ""
+	}
+	format := strings.ToLower(track.Format)
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(track.FilePath)), ".")
+	}
+
+	switch format {
+	case "mp3":
+		return "MP3"
+	case "m4a", "aac":
+		return "AAC_ISO_320"
+	default:
+		return ""
+	}
+}
+
+// contentFeatures builds the DLNA fourth field of protocolInfo
+// OP=01: byte-range seeking is supported, time seeking is not
+// FLAGS: streaming transfer mode, background transfer mode, DLNA v1.5
+func contentFeatures(track *model.Track) string {
+	features := "DLNA.ORG_OP=01;DLNA.ORG_CI=0;DLNA.ORG_FLAGS=01500000000000000000000000000000"
+	if profile := dlnaProfile(track); profile != "" {
+		features = "DLNA.ORG_PN=" + profile + ";" + features
+	}
+	return features
+}
+
+// formatDuration formats a duration as H:MM:SS.mmm
+func formatDuration(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
@@ -0,0 +1,219 @@
+package dlna
+
+import (
+	"fmt"
+	"strings"
+)
+
+// searchCapabilities lists the properties Search can match on
+const searchCapabilities = "@id,@parentID,dc:title,dc:creator,upnp:artist,upnp:album,upnp:genre,upnp:class"
+
+// matcher reports whether an object satisfies a search expression
+type matcher func(obj *object) bool
+
+// parseSearchCriteria compiles a ContentDirectory search criteria string
+// Grammar (ContentDirectory:1, 2.5.5): relExp joined by and/or with parentheses,
+// where relExp is "property op value" or "property exists true|false"
+func parseSearchCriteria(criteria string) (matcher, error) {
+	criteria = strings.TrimSpace(criteria)
+	if criteria == "" || criteria == "*" {
+		return func(*object) bool { return true }, nil
+	}
+
+	tokens, err := tokenizeCriteria(criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &criteriaParser{tokens: tokens}
+	m, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in search criteria", p.tokens[p.pos].value)
+	}
+	return m, nil
+}
+
+// criteriaToken is a word, quoted string or parenthesis
+type criteriaToken struct {
+	value  string
+	quoted bool
+}
+
+// tokenizeCriteria splits criteria into tokens, unescaping quoted strings
+func tokenizeCriteria(criteria string) ([]criteriaToken, error) {
+	var tokens []criteriaToken
+
+	for i := 0; i < len(criteria); {
+		c := criteria[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, criteriaToken{value: string(c)})
+			i++
+		case c == '"':
+			var value strings.Builder
+			i++
+			closed := false
+			for i < len(criteria) {
+				if criteria[i] == '\\' && i+1 < len(criteria) {
+					value.WriteByte(criteria[i+1])
+					i += 2
+					continue
+				}
+				if criteria[i] == '"' {
+					closed = true
+					i++
+					break
+				}
+				value.WriteByte(criteria[i])
+				i++
+			}
+			if !closed {
+				return nil, fmt.Errorf("unterminated string in search criteria")
+			}
+			tokens = append(tokens, criteriaToken{value: value.String(), quoted: true})
+		default:
+			start := i
+			for i < len(criteria) && !strings.ContainsRune(" \t\n\r()\"", rune(criteria[i])) {
+				i++
+			}
+			tokens = append(tokens, criteriaToken{value: criteria[start:i]})
+		}
+	}
+
+	return tokens, nil
+}
+
+// criteriaParser is a recursive descent parser over criteria tokens
+type criteriaParser struct {
+	tokens []criteriaToken
+	pos    int
+}
+
+// peekWord returns the next unquoted word, lowercased, without consuming it
+func (p *criteriaParser) peekWord() string {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].quoted {
+		return ""
+	}
+	return strings.ToLower(p.tokens[p.pos].value)
+}
+
+// parseOr parses expressions joined by "or"
+func (p *criteriaParser) parseOr() (matcher, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peekWord() == "or" {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l, r := left, right
+		left = func(obj *object) bool { return l(obj) || r(obj) }
+	}
+	return left, nil
+}
+
+// parseAnd parses expressions joined by "and"
+func (p *criteriaParser) parseAnd() (matcher, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peekWord() == "and" {
+		p.pos++
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		l, r := left, right
+		left = func(obj *object) bool { return l(obj) && r(obj) }
+	}
+	return left, nil
+}
+
+// parsePrimary parses a parenthesised expression or a relational expression
+func (p *criteriaParser) parsePrimary() (matcher, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of search criteria")
+	}
+
+	if token := p.tokens[p.pos]; !token.quoted && token.value == "(" {
+		p.pos++
+		m, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.tokens) || p.tokens[p.pos].value != ")" {
+			return nil, fmt.Errorf("missing ) in search criteria")
+		}
+		p.pos++
+		return m, nil
+	}
+
+	if p.pos+3 > len(p.tokens) {
+		return nil, fmt.Errorf("incomplete expression in search criteria")
+	}
+
+	property := p.tokens[p.pos].value
+	op := strings.ToLower(p.tokens[p.pos+1].value)
+	value := p.tokens[p.pos+2]
+	p.pos += 3
+
+	return relationalMatcher(property, op, value)
+}
+
+// relationalMatcher builds the matcher for "property op value"
+func relationalMatcher(property, op string, value criteriaToken) (matcher, error) {
+	get := propertyGetter(property)
+	want := strings.ToLower(value.value)
+
+	switch op {
+	case "=":
+		return func(obj *object) bool { return strings.ToLower(get(obj)) == want }, nil
+	case "!=":
+		return func(obj *object) bool { return strings.ToLower(get(obj)) != want }, nil
+	case "contains":
+		return func(obj *object) bool { return strings.Contains(strings.ToLower(get(obj)), want) }, nil
+	case "doesnotcontain":
+		return func(obj *object) bool { return !strings.Contains(strings.ToLower(get(obj)), want) }, nil
+	case "derivedfrom":
+		return func(obj *object) bool { return strings.HasPrefix(strings.ToLower(get(obj)), want) }, nil
+	case "exists":
+		exists := want == "true"
+		return func(obj *object) bool { return (get(obj) != "") == exists }, nil
+	default:
+		return nil, fmt.Errorf("unsupported operator %q in search criteria", op)
+	}
+}
+
+// propertyGetter returns an accessor for a searchable property
+func propertyGetter(property string) func(obj *object) string {
+	switch property {
+	case "@id":
+		return func(obj *object) string { return obj.id }
+	case "@parentID":
+		return func(obj *object) string { return obj.parentID }
+	case "dc:title":
+		return func(obj *object) string { return obj.title }
+	case "dc:creator", "upnp:artist", "upnp:albumArtist":
+		return func(obj *object) string { return obj.artist }
+	case "upnp:album":
+		return func(obj *object) string { return obj.album }
+	case "upnp:genre":
+		return func(obj *object) string { return obj.genre }
+	case "upnp:class":
+		return func(obj *object) string { return obj.class }
+	default:
+		// Unknown properties never have a value, so exists/!= still behave sensibly
+		return func(obj *object) string { return "" }
+	}
+}
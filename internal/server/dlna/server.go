@@ -0,0 +1,434 @@
+package dlna
+
+import (
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"GoMusic/internal/domain/model"
+	"GoMusic/internal/media"
+	"GoMusic/internal/service"
+)
+
+const (
+	mediaPathPrefix   = "/dlna/media/"
+	artworkPathPrefix = "/dlna/art/"
+
+	// sourceProtocolInfo lists the formats the server can stream
+	sourceProtocolInfo = "http-get:*:audio/mpeg:*,http-get:*:audio/flac:*,http-get:*:audio/mp4:*,http-get:*:audio/ogg:*,http-get:*:audio/wav:*,http-get:*:audio/aiff:*"
+
+	// maxRequestedCount caps the objects returned by one Browse or Search;
+	// control points page through the rest using TotalMatches
+	maxRequestedCount = 5000
+)
+
+// Server is a UPnP AV MediaServer exposing the library to TVs and AV receivers
+type Server struct {
+	config         *model.DLNAConfig
+	libraryService *service.LibraryService
+	media          *media.Handler
+	udn            string
+
+	httpServer *http.Server
+	ssdp       *ssdpAdvertiser
+	addr       string
+	mu         sync.Mutex
+}
+
+// NewServer creates a DLNA media server for the library
+// The device UUID is derived from the host name so renderers recognise it across restarts
+func NewServer(config *model.DLNAConfig, libraryService *service.LibraryService, mediaHandler *media.Handler) *Server {
+	hostname, _ := os.Hostname()
+
+	return &Server{
+		config:         config,
+		libraryService: libraryService,
+		media:          mediaHandler,
+		udn:            deviceUUID("gomusic-dlna/" + hostname),
+	}
+}
+
+// Start begins serving HTTP and advertising over SSDP
+func (s *Server) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.httpServer != nil {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", s.config.ListenAddress)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.config.ListenAddress, err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	ssdp, err := newSSDPAdvertiser(s.udn, port, s.config.Interface)
+	if err != nil {
+		listener.Close()
+		return err
+	}
+
+	httpServer := &http.Server{Handler: s.Handler()}
+	go func() {
+		if err := httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Printf("ERROR: DLNA server stopped: %v", err)
+		}
+	}()
+
+	if err := ssdp.start(); err != nil {
+		_ = httpServer.Close()
+		return err
+	}
+
+	s.httpServer = httpServer
+	s.ssdp = ssdp
+	s.addr = listener.Addr().String()
+
+	log.Printf("DLNA server %q listening on %s", s.config.FriendlyName, s.addr)
+	return nil
+}
+
+// Stop announces departure and shuts the server down
+func (s *Server) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	httpServer := s.httpServer
+	ssdp := s.ssdp
+	s.httpServer = nil
+	s.ssdp = nil
+	s.addr = ""
+	s.mu.Unlock()
+
+	if httpServer == nil {
+		return nil
+	}
+
+	ssdp.stop()
+	return httpServer.Shutdown(ctx)
+}
+
+// Addr returns the bound HTTP address, or "" when stopped
+func (s *Server) Addr() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addr
+}
+
+// Handler returns the HTTP handler for descriptions, control, events and media
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(deviceDescriptionPath, func(w http.ResponseWriter, r *http.Request) {
+		writeXML(w, deviceDescription(s.config.FriendlyName, s.udn))
+	})
+	mux.HandleFunc(contentDirectorySCPD, func(w http.ResponseWriter, r *http.Request) {
+		writeXML(w, []byte(contentDirectoryDescription))
+	})
+	mux.HandleFunc(connectionManagerSCPD, func(w http.ResponseWriter, r *http.Request) {
+		writeXML(w, []byte(connectionManagerDescription))
+	})
+	mux.HandleFunc(contentDirectoryControl, s.handleContentDirectory)
+	mux.HandleFunc(connectionManagerCtrl, s.handleConnectionManager)
+	mux.HandleFunc(contentDirectoryEvent, handleEventSubscription)
+	mux.HandleFunc(connectionManagerEvent, handleEventSubscription)
+	mux.HandleFunc(mediaPathPrefix, s.handleMedia)
+	mux.HandleFunc(artworkPathPrefix, s.handleArtwork)
+
+	return mux
+}
+
+// handleContentDirectory dispatches ContentDirectory actions
+func (s *Server) handleContentDirectory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	action, args, err := parseSOAPRequest(r)
+	if err != nil {
+		writeSOAPFault(w, &upnpError{Code: errInvalidAction, Description: err.Error()})
+		return
+	}
+
+	var result []soapArg
+	var upnpErr *upnpError
+
+	switch action {
+	case "GetSearchCapabilities":
+		result = []soapArg{{"SearchCaps", searchCapabilities}}
+	case "GetSortCapabilities":
+		result = []soapArg{{"SortCaps", ""}}
+	case "GetSystemUpdateID":
+		lib, err := loadLibrary(r.Context(), s.libraryService)
+		if err != nil {
+			upnpErr = &upnpError{Code: errActionFailed, Description: err.Error()}
+			break
+		}
+		result = []soapArg{{"Id", strconv.FormatUint(uint64(lib.updateID()), 10)}}
+	case "Browse":
+		result, upnpErr = s.browse(r, args)
+	case "Search":
+		result, upnpErr = s.search(r, args)
+	default:
+		upnpErr = &upnpError{Code: errInvalidAction, Description: "unsupported action " + action}
+	}
+
+	if upnpErr != nil {
+		writeSOAPFault(w, upnpErr)
+		return
+	}
+	writeSOAPResponse(w, contentDirectoryType, action, result)
+}
+
+// browse implements ContentDirectory Browse
+func (s *Server) browse(r *http.Request, args map[string]string) ([]soapArg, *upnpError) {
+	start, count, upnpErr := paging(args)
+	if upnpErr != nil {
+		return nil, upnpErr
+	}
+
+	lib, err := loadLibrary(r.Context(), s.libraryService)
+	if err != nil {
+		return nil, &upnpError{Code: errActionFailed, Description: err.Error()}
+	}
+
+	objectID := args["ObjectID"]
+	var objects []*object
+
+	switch args["BrowseFlag"] {
+	case "BrowseMetadata":
+		obj, ok := lib.lookup(objectID)
+		if !ok {
+			return nil, &upnpError{Code: errNoSuchObject, Description: "no such object"}
+		}
+		objects = []*object{obj}
+	case "BrowseDirectChildren":
+		children, ok := lib.children(objectID)
+		if !ok {
+			return nil, &upnpError{Code: errNoSuchObject, Description: "no such container"}
+		}
+		objects = children
+	default:
+		return nil, &upnpError{Code: errInvalidArgs, Description: "invalid BrowseFlag"}
+	}
+
+	return s.resultArgs(r, lib, objects, start, count)
+}
+
+// search implements ContentDirectory Search over artists, albums and tracks
+func (s *Server) search(r *http.Request, args map[string]string) ([]soapArg, *upnpError) {
+	start, count, upnpErr := paging(args)
+	if upnpErr != nil {
+		return nil, upnpErr
+	}
+
+	match, err := parseSearchCriteria(args["SearchCriteria"])
+	if err != nil {
+		return nil, &upnpError{Code: errUnsupportedSearchCrit, Description: err.Error()}
+	}
+
+	lib, err := loadLibrary(r.Context(), s.libraryService)
+	if err != nil {
+		return nil, &upnpError{Code: errActionFailed, Description: err.Error()}
+	}
+	if _, ok := lib.lookup(args["ContainerID"]); !ok {
+		return nil, &upnpError{Code: errNoSuchObject, Description: "no such container"}
+	}
+
+	var objects []*object
+	for _, obj := range lib.searchCandidates() {
+		if match(obj) {
+			objects = append(objects, obj)
+		}
+	}
+
+	return s.resultArgs(r, lib, objects, start, count)
+}
+
+// resultArgs pages objects and renders the Browse/Search output arguments
+func (s *Server) resultArgs(r *http.Request, lib *library, objects []*object, start, count int) ([]soapArg, *upnpError) {
+	total := len(objects)
+	start = min(start, total)
+	end := total
+	// Compared as a remainder, as start+count overflows for huge counts
+	if count < total-start {
+		end = start + count
+	}
+
+	baseURL := "http://" + r.Host
+	didl := newDIDLLite()
+	for _, obj := range objects[start:end] {
+		didl.add(obj, baseURL)
+	}
+
+	result, err := didl.marshal()
+	if err != nil {
+		return nil, &upnpError{Code: errCannotProcessRequest, Description: err.Error()}
+	}
+
+	return []soapArg{
+		{"Result", result},
+		{"NumberReturned", strconv.Itoa(end - start)},
+		{"TotalMatches", strconv.Itoa(total)},
+		{"UpdateID", strconv.FormatUint(uint64(lib.updateID()), 10)},
+	}, nil
+}
+
+// handleConnectionManager dispatches ConnectionManager actions
+func (s *Server) handleConnectionManager(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	action, args, err := parseSOAPRequest(r)
+	if err != nil {
+		writeSOAPFault(w, &upnpError{Code: errInvalidAction, Description: err.Error()})
+		return
+	}
+
+	switch action {
+	case "GetProtocolInfo":
+		writeSOAPResponse(w, connectionManagerType, action, []soapArg{{"Source", sourceProtocolInfo}, {"Sink", ""}})
+	case "GetCurrentConnectionIDs":
+		writeSOAPResponse(w, connectionManagerType, action, []soapArg{{"ConnectionIDs", "0"}})
+	case "GetCurrentConnectionInfo":
+		if args["ConnectionID"] != "0" {
+			writeSOAPFault(w, &upnpError{Code: 706, Description: "invalid connection reference"})
+			return
+		}
+		writeSOAPResponse(w, connectionManagerType, action, []soapArg{
+			{"RcsID", "-1"},
+			{"AVTransportID", "-1"},
+			{"ProtocolInfo", ""},
+			{"PeerConnectionManager", ""},
+			{"PeerConnectionID", "-1"},
+			{"Direction", "Output"},
+			{"Status", "OK"},
+		})
+	default:
+		writeSOAPFault(w, &upnpError{Code: errInvalidAction, Description: "unsupported action " + action})
+	}
+}
+
+// handleMedia streams a track with the DLNA transfer headers
+func (s *Server) handleMedia(w http.ResponseWriter, r *http.Request) {
+	trackID := strings.TrimPrefix(r.URL.Path, mediaPathPrefix)
+	track, err := s.libraryService.GetTrackByID(r.Context(), trackID)
+	if err != nil {
+		http.Error(w, "Track not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("transferMode.dlna.org", "Streaming")
+	w.Header().Set("contentFeatures.dlna.org", contentFeatures(track))
+	s.media.ServeTrack(w, r, track)
+}
+
+// handleArtwork serves artwork for a track, album or artist ID
+func (s *Server) handleArtwork(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, artworkPathPrefix)
+	artworkPath := s.media.ResolveArtwork(r.Context(), id)
+	if artworkPath == "" {
+		http.Error(w, "Artwork not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("transferMode.dlna.org", "Interactive")
+	s.media.ServeArtworkFile(w, r, artworkPath)
+}
+
+// handleEventSubscription accepts event subscriptions so strict control points proceed
+// The library is read-only from the renderer's point of view, so no events are sent
+func handleEventSubscription(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "SUBSCRIBE":
+		sid := r.Header.Get("SID")
+		if sid == "" {
+			sid = "uuid:" + deviceUUID(r.RemoteAddr+r.Header.Get("CALLBACK"))
+		}
+		w.Header().Set("SID", sid)
+		w.Header().Set("TIMEOUT", "Second-1800")
+		w.WriteHeader(http.StatusOK)
+	case "UNSUBSCRIBE":
+		w.WriteHeader(http.StatusOK)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// updateID summarises the library state so control points notice changes
+// Every track's ID, path, modification time and the tags containers are built
+// from are hashed, so tag edits, moves and rescans change it even when the
+// track count stays the same; the per-track hashes are summed, as the order of
+// tracks across sources is not stable
+func (l *library) updateID() uint32 {
+	var sum uint64
+	for _, track := range l.tracks {
+		h := fnv.New64a()
+		fmt.Fprintf(h, "%s\x00%s\x00%d\x00%s\x00%s\x00%s\x00%s\x00%s\x00%s\x00%d\x00%d",
+			track.ID, track.FilePath, track.ModifiedAt.UnixNano(), track.Title, track.Artist,
+			track.Album, track.AlbumArtist, track.AlbumID, track.Genre, track.TrackNumber, track.DiscNumber)
+		sum += h.Sum64()
+	}
+	return uint32(sum ^ sum>>32)
+}
+
+// paging reads StartingIndex and RequestedCount
+// A RequestedCount of 0 asks for every object; counts are capped at
+// maxRequestedCount
+func paging(args map[string]string) (int, int, *upnpError) {
+	start, err := strconv.Atoi(defaultString(args["StartingIndex"], "0"))
+	if err != nil || start < 0 {
+		return 0, 0, &upnpError{Code: errInvalidArgs, Description: "invalid StartingIndex"}
+	}
+	count, err := strconv.Atoi(defaultString(args["RequestedCount"], "0"))
+	if err != nil || count < 0 {
+		return 0, 0, &upnpError{Code: errInvalidArgs, Description: "invalid RequestedCount"}
+	}
+	if count == 0 || count > maxRequestedCount {
+		count = maxRequestedCount
+	}
+	return start, count, nil
+}
+
+// defaultString returns def when value is empty
+func defaultString(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
+
+// streamURL returns the media URL of a track
+func streamURL(baseURL, trackID string) string {
+	return baseURL + mediaPathPrefix + url.PathEscape(trackID)
+}
+
+// artworkURL returns the artwork URL of a track, album or artist
+func artworkURL(baseURL, id string) string {
+	return baseURL + artworkPathPrefix + url.PathEscape(id)
+}
+
+// writeXML writes a description document
+func writeXML(w http.ResponseWriter, data []byte) {
+	w.Header().Set("Content-Type", `text/xml; charset="utf-8"`)
+	_, _ = w.Write(data)
+}
+
+// deviceUUID derives a stable RFC 4122 name-based UUID from a seed
+func deviceUUID(seed string) string {
+	hash := sha1.Sum([]byte(seed))
+	hash[6] = (hash[6] & 0x0f) | 0x50 // version 5
+	hash[8] = (hash[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", hash[0:4], hash[4:6], hash[6:8], hash[8:10], hash[10:16])
+}
@@ -0,0 +1,310 @@
+package dlna
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"GoMusic/internal/domain/model"
+	"GoMusic/internal/domain/repository"
+	"GoMusic/internal/service"
+	"GoMusic/internal/util/errors"
+)
+
+// fakeTrackRepository is an in-memory source
+type fakeTrackRepository struct {
+	mu     sync.Mutex
+	tracks []*model.Track
+}
+
+func (r *fakeTrackRepository) FindByID(ctx context.Context, id string) (*model.Track, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, track := range r.tracks {
+		if track.ID == id {
+			return track, nil
+		}
+	}
+	return nil, errors.ErrNotFound
+}
+
+func (r *fakeTrackRepository) FindAll(ctx context.Context, opts *repository.QueryOptions) ([]*model.Track, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tracks := make([]*model.Track, 0, len(r.tracks))
+	for _, track := range r.tracks {
+		copied := *track
+		tracks = append(tracks, &copied)
+	}
+	return tracks, nil
+}
+
+func (r *fakeTrackRepository) Create(ctx context.Context, track *model.Track) error { return nil }
+func (r *fakeTrackRepository) Update(ctx context.Context, track *model.Track) error { return nil }
+func (r *fakeTrackRepository) Delete(ctx context.Context, id string) error          { return nil }
+
+func (r *fakeTrackRepository) FindByAlbum(ctx context.Context, albumID string) ([]*model.Track, error) {
+	return nil, nil
+}
+
+func (r *fakeTrackRepository) FindByArtist(ctx context.Context, artistID string) ([]*model.Track, error) {
+	return nil, nil
+}
+
+func (r *fakeTrackRepository) Search(ctx context.Context, query string, opts *repository.SearchOptions) ([]*model.Track, error) {
+	return nil, nil
+}
+
+func (r *fakeTrackRepository) GetSourceID() string             { return "local" }
+func (r *fakeTrackRepository) GetSourceType() model.SourceType { return model.SourceTypeFilesystem }
+func (r *fakeTrackRepository) Scan(ctx context.Context) error  { return nil }
+func (r *fakeTrackRepository) GetScanProgress() *repository.ScanProgress {
+	return &repository.ScanProgress{}
+}
+
+// newTestServer serves the ContentDirectory of a library of three tracks
+func newTestServer(t *testing.T) (*httptest.Server, *fakeTrackRepository) {
+	t.Helper()
+	repo := &fakeTrackRepository{}
+	for i, title := range []string{"Alpha", "Bravo", "Charlie"} {
+		repo.tracks = append(repo.tracks, &model.Track{
+			ID: fmt.Sprintf("t%d", i+1), SourceID: "local", FilePath: fmt.Sprintf("/music/%s.mp3", title),
+			Title: title, Artist: "Band", Album: "Record", AlbumID: "album_1", ArtistID: "artist_1", Genre: "Jazz",
+			TrackNumber: i + 1, Format: "mp3",
+		})
+	}
+	library := service.NewLibraryService()
+	library.RegisterTrackRepository("local", repo)
+
+	server := httptest.NewServer(NewServer(&model.DLNAConfig{FriendlyName: "Test"}, library, nil).Handler())
+	t.Cleanup(server.Close)
+	return server, repo
+}
+
+// soapResult is the parsed outcome of a ContentDirectory action
+type soapResult struct {
+	args      map[string]string
+	errorCode int // UPnP error code of a fault, 0 on success
+}
+
+// callAction posts a ContentDirectory action the way a control point does
+func callAction(t *testing.T, server *httptest.Server, action string, args ...string) soapResult {
+	t.Helper()
+	var body strings.Builder
+	fmt.Fprintf(&body, `<?xml version="1.0"?><s:Envelope xmlns:s="%s" s:encodingStyle="%s"><s:Body><u:%s xmlns:u="%s">`,
+		soapEnvelopeNamespace, soapEncodingStyleNamespace, action, contentDirectoryType)
+	for i := 0; i+1 < len(args); i += 2 {
+		fmt.Fprintf(&body, "<%s>", args[i])
+		xml.EscapeText(&body, []byte(args[i+1]))
+		fmt.Fprintf(&body, "</%s>", args[i])
+	}
+	fmt.Fprintf(&body, "</u:%s></s:Body></s:Envelope>", action)
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+contentDirectoryControl, strings.NewReader(body.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", `text/xml; charset="utf-8"`)
+	req.Header.Set("SOAPACTION", `"`+contentDirectoryType+"#"+action+`"`)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	// Output arguments and fault details are the leaf elements of the body
+	result := soapResult{args: make(map[string]string)}
+	decoder := xml.NewDecoder(resp.Body)
+	var current string
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			current = t.Name.Local
+		case xml.CharData:
+			if current != "" {
+				result.args[current] += string(t)
+			}
+		case xml.EndElement:
+			current = ""
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		result.errorCode, _ = strconv.Atoi(result.args["errorCode"])
+	}
+	return result
+}
+
+// resultTitles returns the titles of the objects in a DIDL-Lite result
+func resultTitles(t *testing.T, didl string) []string {
+	t.Helper()
+	var doc struct {
+		Containers []struct {
+			Title string `xml:"title"`
+		} `xml:"container"`
+		Items []struct {
+			Title string `xml:"title"`
+		} `xml:"item"`
+	}
+	if err := xml.Unmarshal([]byte(didl), &doc); err != nil {
+		t.Fatalf("invalid DIDL-Lite %q: %v", didl, err)
+	}
+	var titles []string
+	for _, c := range doc.Containers {
+		titles = append(titles, c.Title)
+	}
+	for _, item := range doc.Items {
+		titles = append(titles, item.Title)
+	}
+	return titles
+}
+
+func TestContentDirectory(t *testing.T) {
+	browse := func(objectID, flag, start, count string) []string {
+		return []string{"ObjectID", objectID, "BrowseFlag", flag, "Filter", "*",
+			"StartingIndex", start, "RequestedCount", count, "SortCriteria", ""}
+	}
+	search := func(criteria, start, count string) []string {
+		return []string{"ContainerID", rootID, "SearchCriteria", criteria, "Filter", "*",
+			"StartingIndex", start, "RequestedCount", count, "SortCriteria", ""}
+	}
+
+	tests := []struct {
+		name       string
+		action     string
+		args       []string
+		wantTitles []string
+		wantTotal  int
+		wantError  int
+	}{
+		{
+			name:       "root containers",
+			action:     "Browse",
+			args:       browse(rootID, "BrowseDirectChildren", "0", "0"),
+			wantTitles: []string{"Artists", "Albums", "Genres", "All Tracks"},
+			wantTotal:  4,
+		},
+		{
+			name:       "container metadata",
+			action:     "Browse",
+			args:       browse(tracksID, "BrowseMetadata", "0", "0"),
+			wantTitles: []string{"All Tracks"},
+			wantTotal:  1,
+		},
+		{
+			name:       "a page of tracks",
+			action:     "Browse",
+			args:       browse(tracksID, "BrowseDirectChildren", "1", "1"),
+			wantTitles: []string{"Bravo"},
+			wantTotal:  3,
+		},
+		{
+			name:       "page running past the end",
+			action:     "Browse",
+			args:       browse(tracksID, "BrowseDirectChildren", "2", "10"),
+			wantTitles: []string{"Charlie"},
+			wantTotal:  3,
+		},
+		{
+			name:      "start past the end",
+			action:    "Browse",
+			args:      browse(tracksID, "BrowseDirectChildren", "10", "5"),
+			wantTotal: 3,
+		},
+		{
+			name:       "count so large start plus count overflows",
+			action:     "Browse",
+			args:       browse(tracksID, "BrowseDirectChildren", "1", "9223372036854775807"),
+			wantTitles: []string{"Bravo", "Charlie"},
+			wantTotal:  3,
+		},
+		{name: "negative start", action: "Browse", args: browse(tracksID, "BrowseDirectChildren", "-1", "0"), wantError: errInvalidArgs},
+		{name: "count not a number", action: "Browse", args: browse(tracksID, "BrowseDirectChildren", "0", "ten"), wantError: errInvalidArgs},
+		{name: "unknown browse flag", action: "Browse", args: browse(tracksID, "BrowseEverything", "0", "0"), wantError: errInvalidArgs},
+		{name: "unknown object", action: "Browse", args: browse("album/missing", "BrowseMetadata", "0", "0"), wantError: errNoSuchObject},
+		{
+			name:       "search tracks by title",
+			action:     "Search",
+			args:       search(`upnp:class derivedfrom "object.item.audioItem" and dc:title contains "ar"`, "0", "0"),
+			wantTitles: []string{"Charlie"},
+			wantTotal:  1,
+		},
+		{
+			name:       "search paged past the end",
+			action:     "Search",
+			args:       search(`upnp:class = "object.item.audioItem.musicTrack"`, "2", "5"),
+			wantTitles: []string{"Charlie"},
+			wantTotal:  3,
+		},
+		{name: "malformed search criteria", action: "Search", args: search(`dc:title contains`, "0", "0"), wantError: errUnsupportedSearchCrit},
+		{name: "unsupported action", action: "DestroyObject", args: []string{"ObjectID", "0"}, wantError: errInvalidAction},
+	}
+
+	server, _ := newTestServer(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := callAction(t, server, tt.action, tt.args...)
+			if result.errorCode != tt.wantError {
+				t.Fatalf("%s error = %d (%s), want %d", tt.action, result.errorCode, result.args["errorDescription"], tt.wantError)
+			}
+			if tt.wantError != 0 {
+				return
+			}
+
+			titles := resultTitles(t, result.args["Result"])
+			if strings.Join(titles, ",") != strings.Join(tt.wantTitles, ",") {
+				t.Errorf("titles = %v, want %v", titles, tt.wantTitles)
+			}
+			if result.args["NumberReturned"] != strconv.Itoa(len(tt.wantTitles)) || result.args["TotalMatches"] != strconv.Itoa(tt.wantTotal) {
+				t.Errorf("NumberReturned = %s, TotalMatches = %s, want %d and %d",
+					result.args["NumberReturned"], result.args["TotalMatches"], len(tt.wantTitles), tt.wantTotal)
+			}
+			if result.args["UpdateID"] == "" {
+				t.Error("no UpdateID")
+			}
+		})
+	}
+}
+
+func TestSystemUpdateID(t *testing.T) {
+	server, repo := newTestServer(t)
+	updateID := func() string {
+		t.Helper()
+		result := callAction(t, server, "GetSystemUpdateID")
+		if result.errorCode != 0 || result.args["Id"] == "" {
+			t.Fatalf("GetSystemUpdateID failed: %v", result.args)
+		}
+		return result.args["Id"]
+	}
+
+	before := updateID()
+	if again := updateID(); again != before {
+		t.Fatalf("the update ID of an unchanged library moved from %s to %s", before, again)
+	}
+
+	// A tag edit keeps the number of tracks
+	repo.mu.Lock()
+	repo.tracks[0].Album = "Other Record"
+	repo.mu.Unlock()
+	edited := updateID()
+	if edited == before {
+		t.Error("the update ID did not change after a tag edit")
+	}
+
+	// So does a move
+	repo.mu.Lock()
+	repo.tracks[1].FilePath = "/music/Band/Bravo.mp3"
+	repo.mu.Unlock()
+	if moved := updateID(); moved == edited {
+		t.Error("the update ID did not change after a file moved")
+	}
+}
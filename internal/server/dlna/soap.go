@@ -0,0 +1,135 @@
+package dlna
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// UPnP error codes returned in SOAP faults
+const (
+	errInvalidAction           = 401
+	errInvalidArgs             = 402
+	errActionFailed            = 501
+	errNoSuchObject            = 701
+	errUnsupportedSearchCrit   = 708
+	errCannotProcessRequest    = 720
+	maxSOAPRequestSize         = 1 << 20
+	soapEnvelopeNamespace      = "http://schemas.xmlsoap.org/soap/envelope/"
+	soapEncodingStyleNamespace = "http://schemas.xmlsoap.org/soap/encoding/"
+)
+
+// soapArg is a named action argument; order matters for output arguments
+type soapArg struct {
+	Name  string
+	Value string
+}
+
+// upnpError is returned by action handlers and rendered as a SOAP fault
+type upnpError struct {
+	Code        int
+	Description string
+}
+
+func (e *upnpError) Error() string {
+	return fmt.Sprintf("UPnP error %d: %s", e.Code, e.Description)
+}
+
+// parseSOAPRequest extracts the action name and its arguments from a SOAP envelope
+func parseSOAPRequest(r *http.Request) (string, map[string]string, error) {
+	decoder := xml.NewDecoder(io.LimitReader(r.Body, maxSOAPRequestSize))
+
+	action := ""
+	args := make(map[string]string)
+	depth := 0
+	inBody := false
+	current := ""
+	var text strings.Builder
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid SOAP request: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			switch {
+			case t.Name.Local == "Body":
+				inBody = true
+			case inBody && action == "":
+				action = t.Name.Local
+			case action != "" && current == "":
+				current = t.Name.Local
+				text.Reset()
+			}
+		case xml.CharData:
+			if current != "" {
+				text.Write(t)
+			}
+		case xml.EndElement:
+			depth--
+			if current != "" && t.Name.Local == current {
+				args[current] = text.String()
+				current = ""
+			}
+		}
+	}
+
+	if action == "" {
+		return "", nil, fmt.Errorf("invalid SOAP request: no action")
+	}
+
+	// SOAPACTION is "urn:...:service:X:1#Action"; prefer it when present
+	if header := strings.Trim(r.Header.Get("SOAPACTION"), `"`); header != "" {
+		if i := strings.LastIndex(header, "#"); i >= 0 && header[i+1:] != action {
+			return "", nil, fmt.Errorf("SOAPACTION %q does not match body action %q", header, action)
+		}
+	}
+
+	return action, args, nil
+}
+
+// writeSOAPResponse writes a successful action response
+func writeSOAPResponse(w http.ResponseWriter, serviceType, action string, args []soapArg) {
+	var body bytes.Buffer
+	fmt.Fprintf(&body, `<u:%sResponse xmlns:u="%s">`, action, serviceType)
+	for _, arg := range args {
+		fmt.Fprintf(&body, "<%s>", arg.Name)
+		_ = xml.EscapeText(&body, []byte(arg.Value))
+		fmt.Fprintf(&body, "</%s>", arg.Name)
+	}
+	fmt.Fprintf(&body, `</u:%sResponse>`, action)
+
+	writeSOAPEnvelope(w, http.StatusOK, body.Bytes())
+}
+
+// writeSOAPFault writes a UPnP error as a SOAP fault
+func writeSOAPFault(w http.ResponseWriter, upnpErr *upnpError) {
+	var description bytes.Buffer
+	_ = xml.EscapeText(&description, []byte(upnpErr.Description))
+
+	body := fmt.Sprintf(`<s:Fault><faultcode>s:Client</faultcode><faultstring>UPnPError</faultstring><detail>`+
+		`<UPnPError xmlns="urn:schemas-upnp-org:control-1-0"><errorCode>%d</errorCode><errorDescription>%s</errorDescription></UPnPError>`+
+		`</detail></s:Fault>`, upnpErr.Code, description.String())
+
+	writeSOAPEnvelope(w, http.StatusInternalServerError, []byte(body))
+}
+
+// writeSOAPEnvelope wraps a body in a SOAP envelope and writes it
+func writeSOAPEnvelope(w http.ResponseWriter, status int, body []byte) {
+	w.Header().Set("Content-Type", `text/xml; charset="utf-8"`)
+	w.Header().Set("EXT", "")
+	w.WriteHeader(status)
+
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="utf-8"?>`+
+		`<s:Envelope xmlns:s="%s" s:encodingStyle="%s"><s:Body>%s</s:Body></s:Envelope>`,
+		soapEnvelopeNamespace, soapEncodingStyleNamespace, body)
+}
@@ -0,0 +1,258 @@
+package dlna
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ssdpAddress = "239.255.255.250:1900"
+
+	// ssdpMaxAge is how long control points may cache an advertisement
+	ssdpMaxAge = 1800
+
+	// notifyInterval re-announces well before the advertisement expires
+	notifyInterval = ssdpMaxAge / 2 * time.Second
+
+	// maxSearchDelay caps the random M-SEARCH response delay requested via MX
+	maxSearchDelay = 3
+)
+
+// ssdpAdvertiser announces the media server and answers M-SEARCH discovery requests
+type ssdpAdvertiser struct {
+	udn      string
+	httpPort int
+	iface    *net.Interface
+	server   string
+
+	groupAddr *net.UDPAddr
+	conn      *net.UDPConn
+	done      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// newSSDPAdvertiser creates an advertiser for the device reachable on httpPort
+// ifaceName selects the multicast interface; empty uses the system default
+func newSSDPAdvertiser(udn string, httpPort int, ifaceName string) (*ssdpAdvertiser, error) {
+	groupAddr, err := net.ResolveUDPAddr("udp4", ssdpAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &ssdpAdvertiser{
+		udn:       udn,
+		httpPort:  httpPort,
+		server:    fmt.Sprintf("%s/1.0 UPnP/1.0 GoMusic/1.0", runtime.GOOS),
+		groupAddr: groupAddr,
+		done:      make(chan struct{}),
+	}
+
+	if ifaceName != "" {
+		iface, err := net.InterfaceByName(ifaceName)
+		if err != nil {
+			return nil, fmt.Errorf("network interface %s not found: %w", ifaceName, err)
+		}
+		a.iface = iface
+	}
+
+	return a, nil
+}
+
+// start joins the SSDP multicast group and begins advertising
+func (a *ssdpAdvertiser) start() error {
+	conn, err := net.ListenMulticastUDP("udp4", a.iface, a.groupAddr)
+	if err != nil {
+		return fmt.Errorf("failed to join SSDP multicast group: %w", err)
+	}
+	a.conn = conn
+
+	a.wg.Add(2)
+	go a.readLoop()
+	go a.notifyLoop()
+
+	return nil
+}
+
+// stop announces the device's departure and closes the socket
+func (a *ssdpAdvertiser) stop() {
+	close(a.done)
+	a.notify("ssdp:byebye")
+	_ = a.conn.Close()
+	a.wg.Wait()
+}
+
+// readLoop answers M-SEARCH requests until the socket is closed
+func (a *ssdpAdvertiser) readLoop() {
+	defer a.wg.Done()
+
+	buf := make([]byte, 8192)
+	for {
+		n, from, err := a.conn.ReadFromUDP(buf)
+		if err != nil {
+			select {
+			case <-a.done:
+			default:
+				log.Printf("ERROR: SSDP read failed: %v", err)
+			}
+			return
+		}
+
+		data := make([]byte, n)
+		copy(data, buf[:n])
+		a.handleSearch(data, from)
+	}
+}
+
+// notifyLoop sends ssdp:alive announcements periodically
+func (a *ssdpAdvertiser) notifyLoop() {
+	defer a.wg.Done()
+
+	a.notify("ssdp:alive")
+
+	ticker := time.NewTicker(notifyInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.done:
+			return
+		case <-ticker.C:
+			a.notify("ssdp:alive")
+		}
+	}
+}
+
+// handleSearch replies to an M-SEARCH request for one of our targets
+func (a *ssdpAdvertiser) handleSearch(data []byte, from *net.UDPAddr) {
+	req, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(data)))
+	if err != nil || req.Method != "M-SEARCH" {
+		return
+	}
+	if req.Header.Get("MAN") != `"ssdp:discover"` {
+		return
+	}
+
+	st := req.Header.Get("ST")
+	var targets []string
+	for _, target := range a.targets() {
+		if st == "ssdp:all" || st == target {
+			targets = append(targets, target)
+		}
+	}
+	if len(targets) == 0 {
+		return
+	}
+
+	// Spread responses over MX seconds so control points are not flooded
+	delay := time.Duration(0)
+	if mx, err := strconv.Atoi(req.Header.Get("MX")); err == nil && mx > 0 {
+		if mx > maxSearchDelay {
+			mx = maxSearchDelay
+		}
+		delay = time.Duration(rand.Int63n(int64(mx) * int64(time.Second)))
+	}
+
+	location := a.location(a.localIPFor(from.IP))
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+
+		select {
+		case <-a.done:
+			return
+		case <-time.After(delay):
+		}
+
+		for _, target := range targets {
+			response := fmt.Sprintf("HTTP/1.1 200 OK\r\n"+
+				"CACHE-CONTROL: max-age=%d\r\n"+
+				"DATE: %s\r\n"+
+				"EXT:\r\n"+
+				"LOCATION: %s\r\n"+
+				"SERVER: %s\r\n"+
+				"ST: %s\r\n"+
+				"USN: %s\r\n"+
+				"Content-Length: 0\r\n\r\n",
+				ssdpMaxAge, time.Now().UTC().Format(http.TimeFormat), location, a.server, target, a.usn(target))
+			if _, err := a.conn.WriteToUDP([]byte(response), from); err != nil {
+				return
+			}
+		}
+	}()
+}
+
+// notify multicasts a NOTIFY message for every target
+func (a *ssdpAdvertiser) notify(nts string) {
+	location := a.location(a.localIPFor(a.groupAddr.IP))
+
+	for _, target := range a.targets() {
+		message := fmt.Sprintf("NOTIFY * HTTP/1.1\r\n"+
+			"HOST: %s\r\n"+
+			"CACHE-CONTROL: max-age=%d\r\n"+
+			"LOCATION: %s\r\n"+
+			"NT: %s\r\n"+
+			"NTS: %s\r\n"+
+			"SERVER: %s\r\n"+
+			"USN: %s\r\n\r\n",
+			ssdpAddress, ssdpMaxAge, location, target, nts, a.server, a.usn(target))
+		if _, err := a.conn.WriteToUDP([]byte(message), a.groupAddr); err != nil {
+			log.Printf("ERROR: SSDP notify failed: %v", err)
+			return
+		}
+	}
+}
+
+// targets returns the notification types advertised by the device
+func (a *ssdpAdvertiser) targets() []string {
+	return []string{
+		"upnp:rootdevice",
+		"uuid:" + a.udn,
+		deviceType,
+		contentDirectoryType,
+		connectionManagerType,
+	}
+}
+
+// usn returns the unique service name for a target
+func (a *ssdpAdvertiser) usn(target string) string {
+	if strings.HasPrefix(target, "uuid:") {
+		return target
+	}
+	return "uuid:" + a.udn + "::" + target
+}
+
+// location returns the device description URL on the given address
+func (a *ssdpAdvertiser) location(ip net.IP) string {
+	return fmt.Sprintf("http://%s%s", net.JoinHostPort(ip.String(), strconv.Itoa(a.httpPort)), deviceDescriptionPath)
+}
+
+// localIPFor returns our address on the interface that reaches remote
+func (a *ssdpAdvertiser) localIPFor(remote net.IP) net.IP {
+	if a.iface != nil {
+		if addrs, err := a.iface.Addrs(); err == nil {
+			for _, addr := range addrs {
+				if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() != nil {
+					return ipNet.IP
+				}
+			}
+		}
+	}
+
+	// Connecting a UDP socket sends nothing but picks the outgoing address
+	conn, err := net.DialUDP("udp4", nil, &net.UDPAddr{IP: remote, Port: 1900})
+	if err != nil {
+		return net.IPv4(127, 0, 0, 1)
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).IP
+}
@@ -0,0 +1,115 @@
+package dlna
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+// startTestAdvertiser answers M-SEARCH requests on a loopback socket instead of
+// the multicast group
+func startTestAdvertiser(t *testing.T) (*ssdpAdvertiser, *net.UDPAddr) {
+	t.Helper()
+	a, err := newSSDPAdvertiser("test-udn", 8200, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.conn = conn
+	a.wg.Add(1)
+	go a.readLoop()
+
+	t.Cleanup(func() {
+		close(a.done)
+		conn.Close()
+		a.wg.Wait()
+	})
+	return a, conn.LocalAddr().(*net.UDPAddr)
+}
+
+// mSearch sends a discovery request and collects the responses that arrive
+// within wait
+func mSearch(t *testing.T, to *net.UDPAddr, request string, wait time.Duration) []*http.Response {
+	t.Helper()
+	client, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if _, err := client.WriteToUDP([]byte(request), to); err != nil {
+		t.Fatal(err)
+	}
+
+	var responses []*http.Response
+	buf := make([]byte, 8192)
+	client.SetReadDeadline(time.Now().Add(wait))
+	for {
+		n, _, err := client.ReadFromUDP(buf)
+		if err != nil {
+			return responses
+		}
+		resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(buf[:n])), nil)
+		if err != nil {
+			t.Fatalf("invalid SSDP response %q: %v", buf[:n], err)
+		}
+		responses = append(responses, resp)
+	}
+}
+
+func TestSSDPSearch(t *testing.T) {
+	search := func(st, man string) string {
+		return "M-SEARCH * HTTP/1.1\r\nHOST: 239.255.255.250:1900\r\nMAN: " + man + "\r\nMX: 1\r\nST: " + st + "\r\n\r\n"
+	}
+
+	tests := []struct {
+		name    string
+		request string
+		wantST  []string
+	}{
+		{
+			name:    "all targets",
+			request: search("ssdp:all", `"ssdp:discover"`),
+			wantST:  []string{"upnp:rootdevice", "uuid:test-udn", deviceType, contentDirectoryType, connectionManagerType},
+		},
+		{name: "media servers", request: search(deviceType, `"ssdp:discover"`), wantST: []string{deviceType}},
+		{name: "the device itself", request: search("uuid:test-udn", `"ssdp:discover"`), wantST: []string{"uuid:test-udn"}},
+		{name: "another device type", request: search("urn:schemas-upnp-org:device:MediaRenderer:1", `"ssdp:discover"`)},
+		{name: "MAN without quotes", request: search("ssdp:all", "ssdp:discover")},
+		{name: "not a search", request: "NOTIFY * HTTP/1.1\r\nHOST: 239.255.255.250:1900\r\nNT: upnp:rootdevice\r\n\r\n"},
+		{name: "garbage", request: "\x00\x01 not http"},
+	}
+
+	a, addr := startTestAdvertiser(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Answers are spread over MX seconds
+			wait := 300 * time.Millisecond
+			if len(tt.wantST) > 0 {
+				wait = 1500 * time.Millisecond
+			}
+			responses := mSearch(t, addr, tt.request, wait)
+
+			var sts []string
+			for _, resp := range responses {
+				st := resp.Header.Get("ST")
+				sts = append(sts, st)
+				if resp.StatusCode != http.StatusOK || resp.Header.Get("USN") != a.usn(st) {
+					t.Errorf("response for %s = %d with USN %s", st, resp.StatusCode, resp.Header.Get("USN"))
+				}
+				if location := resp.Header.Get("LOCATION"); location != "http://127.0.0.1:8200"+deviceDescriptionPath {
+					t.Errorf("LOCATION = %s, want the description on the loopback address", location)
+				}
+			}
+			if !slices.Equal(sts, tt.wantST) {
+				t.Errorf("answered targets %v, want %v", sts, tt.wantST)
+			}
+		})
+	}
+}
@@ -0,0 +1,404 @@
+package subsonic
+
+import (
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"GoMusic/internal/domain/model"
+	"GoMusic/internal/domain/repository"
+	"GoMusic/internal/media"
+)
+
+// handlePing answers connectivity and credential checks
+func (s *Server) handlePing(w http.ResponseWriter, r *http.Request) (*response, *apiError) {
+	return newResponse(), nil
+}
+
+// handleGetMusicFolders lists every library source as a music folder
+func (s *Server) handleGetMusicFolders(w http.ResponseWriter, r *http.Request) (*response, *apiError) {
+	folders := &musicFolders{MusicFolder: []musicFolder{}}
+	for i, sourceID := range s.folderSourceIDs() {
+		name := sourceID
+		if source, err := s.configService.GetSource(sourceID); err == nil {
+			name = source.Name
+		}
+		folders.MusicFolder = append(folders.MusicFolder, musicFolder{ID: i + 1, Name: name})
+	}
+
+	resp := newResponse()
+	resp.MusicFolders = folders
+	return resp, nil
+}
+
+// handleGetArtists returns all artists grouped into an alphabetical index
+func (s *Server) handleGetArtists(w http.ResponseWriter, r *http.Request) (*response, *apiError) {
+	artists, err := s.libraryService.GetAllArtists(r.Context())
+	if err != nil {
+		return nil, &apiError{Code: errGeneric, Message: err.Error()}
+	}
+
+	folderSourceID := ""
+	if folderID := r.Form.Get("musicFolderId"); folderID != "" {
+		folderSourceID = s.folderSourceID(folderID)
+		if folderSourceID == "" {
+			return nil, &apiError{Code: errNotFound, Message: "music folder not found"}
+		}
+	}
+
+	indexes := make(map[string]*indexID3)
+	var names []string
+	for _, artist := range artists {
+		if folderSourceID != "" && artist.SourceID != folderSourceID {
+			continue
+		}
+
+		name := indexName(artist.Name)
+		index, ok := indexes[name]
+		if !ok {
+			index = &indexID3{Name: name}
+			indexes[name] = index
+			names = append(names, name)
+		}
+		index.Artist = append(index.Artist, toArtistID3(artist))
+	}
+	sort.Strings(names)
+
+	result := &artistsID3{IgnoredArticles: ignoredArticles, Index: []indexID3{}}
+	for _, name := range names {
+		result.Index = append(result.Index, *indexes[name])
+	}
+
+	resp := newResponse()
+	resp.Artists = result
+	return resp, nil
+}
+
+// handleGetAlbum returns an album with its songs
+func (s *Server) handleGetAlbum(w http.ResponseWriter, r *http.Request) (*response, *apiError) {
+	id := r.Form.Get("id")
+	if id == "" {
+		return nil, &apiError{Code: errMissingParameter, Message: "required parameter id is missing"}
+	}
+
+	album, err := s.libraryService.GetAlbumByID(r.Context(), id)
+	if err != nil {
+		return nil, &apiError{Code: errNotFound, Message: "album not found"}
+	}
+
+	tracks, err := s.libraryService.GetTracksByAlbum(r.Context(), id)
+	if err != nil {
+		tracks = nil
+	}
+	sortAlbumTracks(tracks)
+
+	result := toAlbumID3(album)
+	result.Song = make([]child, 0, len(tracks))
+	for _, track := range tracks {
+		result.Song = append(result.Song, toChild(track))
+	}
+
+	resp := newResponse()
+	resp.Album = &result
+	return resp, nil
+}
+
+// handleSearch3 searches artists, albums and songs
+// An empty query (or "") returns everything, which clients use to sync the whole library
+func (s *Server) handleSearch3(w http.ResponseWriter, r *http.Request) (*response, *apiError) {
+	query := strings.Trim(strings.TrimSpace(r.Form.Get("query")), `"`)
+	lowerQuery := strings.ToLower(query)
+
+	artistCount, artistOffset := intParam(r, "artistCount", 20), intParam(r, "artistOffset", 0)
+	albumCount, albumOffset := intParam(r, "albumCount", 20), intParam(r, "albumOffset", 0)
+	songCount, songOffset := intParam(r, "songCount", 20), intParam(r, "songOffset", 0)
+
+	result := &searchResult3{Artist: []artistID3{}, Album: []albumID3{}, Song: []child{}}
+
+	if artistCount > 0 {
+		artists, err := s.libraryService.GetAllArtists(r.Context())
+		if err != nil {
+			return nil, &apiError{Code: errGeneric, Message: err.Error()}
+		}
+		var matches []*model.Artist
+		for _, artist := range artists {
+			if strings.Contains(strings.ToLower(artist.Name), lowerQuery) {
+				matches = append(matches, artist)
+			}
+		}
+		for _, artist := range page(matches, artistOffset, artistCount) {
+			result.Artist = append(result.Artist, toArtistID3(artist))
+		}
+	}
+
+	if albumCount > 0 {
+		albums, err := s.libraryService.GetAllAlbums(r.Context())
+		if err != nil {
+			return nil, &apiError{Code: errGeneric, Message: err.Error()}
+		}
+		var matches []*model.Album
+		for _, album := range albums {
+			if strings.Contains(strings.ToLower(album.Title), lowerQuery) || strings.Contains(strings.ToLower(album.Artist), lowerQuery) {
+				matches = append(matches, album)
+			}
+		}
+		for _, album := range page(matches, albumOffset, albumCount) {
+			result.Album = append(result.Album, toAlbumID3(album))
+		}
+	}
+
+	if songCount > 0 {
+		var tracks []*model.Track
+		var err error
+		if query == "" {
+			tracks, err = s.libraryService.GetAllTracks(r.Context(), nil)
+		} else {
+			opts := repository.DefaultSearchOptions()
+			opts.QueryOptions = &repository.QueryOptions{}
+			tracks, err = s.libraryService.SearchTracks(r.Context(), query, opts)
+		}
+		if err != nil {
+			return nil, &apiError{Code: errGeneric, Message: err.Error()}
+		}
+
+		// Sources are merged, so order globally before paging
+		sort.SliceStable(tracks, func(i, j int) bool {
+			if tracks[i].Title != tracks[j].Title {
+				return tracks[i].Title < tracks[j].Title
+			}
+			return tracks[i].ID < tracks[j].ID
+		})
+		for _, track := range page(tracks, songOffset, songCount) {
+			result.Song = append(result.Song, toChild(track))
+		}
+	}
+
+	resp := newResponse()
+	resp.SearchResult3 = result
+	return resp, nil
+}
+
+// handleGetPlaylists lists the user's playlists
+func (s *Server) handleGetPlaylists(w http.ResponseWriter, r *http.Request) (*response, *apiError) {
+	all, err := s.libraryService.GetAllPlaylists(r.Context())
+	if err != nil {
+		return nil, &apiError{Code: errGeneric, Message: err.Error()}
+	}
+
+	result := &playlists{Playlist: []playlist{}}
+	for _, p := range all {
+		var duration time.Duration
+		for _, trackID := range p.TrackIDs {
+			if track, err := s.libraryService.GetTrackByID(r.Context(), trackID); err == nil {
+				duration += track.Duration
+			}
+		}
+
+		result.Playlist = append(result.Playlist, playlist{
+			ID:        p.ID,
+			Name:      p.Name,
+			Comment:   p.Description,
+			Owner:     s.config.Username,
+			SongCount: len(p.TrackIDs),
+			Duration:  int(duration.Seconds()),
+			Created:   formatTime(p.CreatedAt),
+			Changed:   formatTime(p.UpdatedAt),
+		})
+	}
+
+	resp := newResponse()
+	resp.Playlists = result
+	return resp, nil
+}
+
+// handleStream streams the original audio of a song
+// Transcoding parameters (maxBitRate, format) are ignored; the file is served as-is
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) (*response, *apiError) {
+	id := r.Form.Get("id")
+	if id == "" {
+		return nil, &apiError{Code: errMissingParameter, Message: "required parameter id is missing"}
+	}
+
+	track, err := s.libraryService.GetTrackByID(r.Context(), id)
+	if err != nil {
+		return nil, &apiError{Code: errNotFound, Message: "song not found"}
+	}
+
+	s.media.ServeTrack(w, r, track)
+	return nil, nil
+}
+
+// handleGetCoverArt serves artwork for a song, album or artist ID
+func (s *Server) handleGetCoverArt(w http.ResponseWriter, r *http.Request) (*response, *apiError) {
+	id := r.Form.Get("id")
+	if id == "" {
+		return nil, &apiError{Code: errMissingParameter, Message: "required parameter id is missing"}
+	}
+
+	artworkPath := s.media.ResolveArtwork(r.Context(), id)
+	if artworkPath == "" {
+		return nil, &apiError{Code: errNotFound, Message: "cover art not found"}
+	}
+
+	s.media.ServeArtworkFile(w, r, artworkPath)
+	return nil, nil
+}
+
+// folderSourceIDs returns the source IDs in music folder order
+func (s *Server) folderSourceIDs() []string {
+	sources := s.libraryService.GetSources()
+	ids := make([]string, 0, len(sources))
+	for _, source := range sources {
+		ids = append(ids, source.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// folderSourceID maps a music folder ID back to its source ID
+func (s *Server) folderSourceID(folderID string) string {
+	index, err := strconv.Atoi(folderID)
+	if err != nil {
+		return ""
+	}
+
+	ids := s.folderSourceIDs()
+	if index < 1 || index > len(ids) {
+		return ""
+	}
+	return ids[index-1]
+}
+
+// toArtistID3 converts an artist to its API representation
+func toArtistID3(artist *model.Artist) artistID3 {
+	result := artistID3{
+		ID:         artist.ID,
+		Name:       artist.Name,
+		AlbumCount: artist.AlbumCount,
+	}
+	if artist.ImagePath != "" {
+		result.CoverArt = artist.ID
+	}
+	return result
+}
+
+// toAlbumID3 converts an album to its API representation
+func toAlbumID3(album *model.Album) albumID3 {
+	result := albumID3{
+		ID:        album.ID,
+		Name:      album.Title,
+		Artist:    album.Artist,
+		ArtistID:  album.ArtistID,
+		SongCount: album.TrackCount,
+		Duration:  int(album.TotalDuration.Seconds()),
+		Created:   formatTime(album.AddedAt),
+		Year:      album.Year,
+		Genre:     album.Genre,
+	}
+	if album.ArtworkPath != "" {
+		result.CoverArt = album.ID
+	}
+	return result
+}
+
+// toChild converts a track to a song entry
+func toChild(track *model.Track) child {
+	suffix := track.Format
+	if suffix == "" && track.FilePath != "" {
+		suffix = strings.TrimPrefix(filepath.Ext(track.FilePath), ".")
+	}
+	size := track.FileSize
+	// CUE sheet tracks are streamed as decoded WAV
+	if track.IsSegment() {
+		suffix, size = "wav", 0
+	}
+
+	result := child{
+		ID:          track.ID,
+		Parent:      track.AlbumID,
+		Title:       track.Title,
+		Album:       track.Album,
+		Artist:      track.Artist,
+		Track:       track.TrackNumber,
+		DiscNumber:  track.DiscNumber,
+		Year:        track.Year,
+		Genre:       track.Genre,
+		Size:        size,
+		ContentType: media.AudioContentType("." + suffix),
+		Suffix:      suffix,
+		Duration:    int(track.Duration.Seconds()),
+		BitRate:     track.BitRate,
+		Created:     formatTime(track.AddedAt),
+		AlbumID:     track.AlbumID,
+		ArtistID:    track.ArtistID,
+		Type:        "music",
+	}
+	if track.ArtworkPath != "" {
+		result.CoverArt = track.ID
+	}
+	return result
+}
+
+// sortAlbumTracks orders tracks by disc and track number
+func sortAlbumTracks(tracks []*model.Track) {
+	sort.SliceStable(tracks, func(i, j int) bool {
+		if tracks[i].DiscNumber != tracks[j].DiscNumber {
+			return tracks[i].DiscNumber < tracks[j].DiscNumber
+		}
+		return tracks[i].TrackNumber < tracks[j].TrackNumber
+	})
+}
+
+// indexName returns the index letter for an artist name, skipping ignored articles
+func indexName(name string) string {
+	for _, article := range strings.Fields(ignoredArticles) {
+		if len(name) > len(article)+1 && strings.EqualFold(name[:len(article)+1], article+" ") {
+			name = name[len(article)+1:]
+			break
+		}
+	}
+
+	for _, r := range name {
+		if unicode.IsLetter(r) {
+			return string(unicode.ToUpper(r))
+		}
+		break
+	}
+	return "#"
+}
+
+// maxPageSize caps the items a paged request returns, as Subsonic does
+const maxPageSize = 500
+
+// intParam reads an integer parameter, falling back to def when missing or invalid
+func intParam(r *http.Request, name string, def int) int {
+	value, err := strconv.Atoi(r.Form.Get(name))
+	if err != nil || value < 0 {
+		return def
+	}
+	return value
+}
+
+// page applies offset/count paging to a slice, returning at most maxPageSize items
+func page[T any](items []T, offset, count int) []T {
+	if offset < 0 || offset >= len(items) || count <= 0 {
+		return nil
+	}
+	count = min(count, maxPageSize)
+	if count < len(items)-offset {
+		return items[offset : offset+count]
+	}
+	return items[offset:]
+}
+
+// formatTime formats a timestamp as ISO 8601, or "" for the zero time
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
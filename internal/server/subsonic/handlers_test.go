@@ -0,0 +1,46 @@
+package subsonic
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPage(t *testing.T) {
+	items := make([]int, 1200)
+	for i := range items {
+		items[i] = i
+	}
+
+	tests := []struct {
+		name      string
+		items     []int
+		offset    int
+		count     int
+		wantFirst int
+		wantLen   int
+	}{
+		{name: "first page", items: items, offset: 0, count: 20, wantFirst: 0, wantLen: 20},
+		{name: "middle page", items: items, offset: 100, count: 50, wantFirst: 100, wantLen: 50},
+		{name: "last partial page", items: items, offset: 1190, count: 20, wantFirst: 1190, wantLen: 10},
+		{name: "count capped", items: items, offset: 0, count: 10000, wantFirst: 0, wantLen: maxPageSize},
+		{name: "capped near the end", items: items, offset: 1000, count: 600, wantFirst: 1000, wantLen: 200},
+		{name: "offset at the end", items: items, offset: 1200, count: 20},
+		{name: "offset past the end", items: items, offset: 5000, count: 20},
+		{name: "negative offset", items: items, offset: -1, count: 20},
+		{name: "zero count", items: items, offset: 0, count: 0},
+		{name: "negative count", items: items, offset: 0, count: -5},
+		{name: "empty", items: nil, offset: 0, count: 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := page(tt.items, tt.offset, tt.count)
+			if len(got) != tt.wantLen {
+				t.Fatalf("page() returned %d items, want %d", len(got), tt.wantLen)
+			}
+			if tt.wantLen > 0 && !slices.Equal(got, items[tt.wantFirst:tt.wantFirst+tt.wantLen]) {
+				t.Fatalf("page() = items[%d:], want items[%d:]", got[0], tt.wantFirst)
+			}
+		})
+	}
+}
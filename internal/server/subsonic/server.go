@@ -0,0 +1,237 @@
+package subsonic
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"GoMusic/internal/domain/model"
+	"GoMusic/internal/media"
+	"GoMusic/internal/service"
+)
+
+// Server exposes the library over the Subsonic REST API
+// Any Subsonic client (DSub, Symfonium, play:Sub, ...) can browse and stream from it
+type Server struct {
+	config         *model.SubsonicServerConfig
+	libraryService *service.LibraryService
+	configService  *service.ConfigService
+	media          *media.Handler
+
+	httpServer *http.Server
+	addr       string
+	mu         sync.Mutex
+}
+
+// handlerFunc handles one API endpoint once the request is authenticated
+type handlerFunc func(w http.ResponseWriter, r *http.Request) (*response, *apiError)
+
+// NewServer creates a Subsonic server for the library
+func NewServer(config *model.SubsonicServerConfig, libraryService *service.LibraryService, configService *service.ConfigService, mediaHandler *media.Handler) *Server {
+	return &Server{
+		config:         config,
+		libraryService: libraryService,
+		configService:  configService,
+		media:          mediaHandler,
+	}
+}
+
+// Start begins listening on the configured address
+// Returns once the listener is bound; requests are served in the background
+func (s *Server) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.httpServer != nil {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", s.config.ListenAddress)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.config.ListenAddress, err)
+	}
+
+	s.httpServer = &http.Server{Handler: s.Handler()}
+	s.addr = listener.Addr().String()
+
+	go func(httpServer *http.Server) {
+		if err := httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Printf("ERROR: Subsonic server stopped: %v", err)
+		}
+	}(s.httpServer)
+
+	log.Printf("Subsonic server listening on %s", s.addr)
+	return nil
+}
+
+// Stop shuts the server down, waiting for in-flight requests until ctx expires
+func (s *Server) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	httpServer := s.httpServer
+	s.httpServer = nil
+	s.addr = ""
+	s.mu.Unlock()
+
+	if httpServer == nil {
+		return nil
+	}
+	return httpServer.Shutdown(ctx)
+}
+
+// Addr returns the bound listen address, or "" when stopped
+func (s *Server) Addr() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addr
+}
+
+// Handler returns the HTTP handler serving the /rest/ endpoints
+func (s *Server) Handler() http.Handler {
+	endpoints := map[string]handlerFunc{
+		"ping":            s.handlePing,
+		"getMusicFolders": s.handleGetMusicFolders,
+		"getArtists":      s.handleGetArtists,
+		"getAlbum":        s.handleGetAlbum,
+		"search3":         s.handleSearch3,
+		"getPlaylists":    s.handleGetPlaylists,
+	}
+
+	// Binary endpoints write their own response on success
+	binaryEndpoints := map[string]handlerFunc{
+		"stream":      s.handleStream,
+		"download":    s.handleStream,
+		"getCoverArt": s.handleGetCoverArt,
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/rest/") {
+			http.NotFound(w, r)
+			return
+		}
+
+		// Endpoints may be requested with or without the legacy .view suffix
+		name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/rest/"), ".view")
+
+		// Parameters may arrive in the query string or as a POSTed form
+		if err := r.ParseForm(); err != nil {
+			writeResponse(w, r, failed(errGeneric, "invalid request parameters"))
+			return
+		}
+
+		if apiErr := s.authenticate(r); apiErr != nil {
+			writeResponse(w, r, failed(apiErr.Code, apiErr.Message))
+			return
+		}
+
+		if handler, ok := binaryEndpoints[name]; ok {
+			if _, apiErr := handler(w, r); apiErr != nil {
+				writeResponse(w, r, failed(apiErr.Code, apiErr.Message))
+			}
+			return
+		}
+
+		handler, ok := endpoints[name]
+		if !ok {
+			writeResponse(w, r, failed(errGeneric, "unsupported endpoint: "+name))
+			return
+		}
+
+		resp, apiErr := handler(w, r)
+		if apiErr != nil {
+			writeResponse(w, r, failed(apiErr.Code, apiErr.Message))
+			return
+		}
+		writeResponse(w, r, resp)
+	})
+}
+
+// authenticate checks the request credentials against the configured user
+// Supports plain (p, optionally "enc:" hex encoded) and token (t + s) authentication
+func (s *Server) authenticate(r *http.Request) *apiError {
+	username := r.Form.Get("u")
+	if username == "" {
+		return &apiError{Code: errMissingParameter, Message: "required parameter u is missing"}
+	}
+	if !constantTimeEqual(username, s.config.Username) {
+		return &apiError{Code: errWrongCredentials, Message: "wrong username or password"}
+	}
+
+	if token := r.Form.Get("t"); token != "" {
+		salt := r.Form.Get("s")
+		if salt == "" {
+			return &apiError{Code: errMissingParameter, Message: "required parameter s is missing"}
+		}
+		expected := md5.Sum([]byte(s.config.Password + salt))
+		if !constantTimeEqual(strings.ToLower(token), hex.EncodeToString(expected[:])) {
+			return &apiError{Code: errWrongCredentials, Message: "wrong username or password"}
+		}
+		return nil
+	}
+
+	password := r.Form.Get("p")
+	if password == "" {
+		return &apiError{Code: errMissingParameter, Message: "required parameter p or t is missing"}
+	}
+	if encoded, ok := strings.CutPrefix(password, "enc:"); ok {
+		decoded, err := hex.DecodeString(encoded)
+		if err != nil {
+			return &apiError{Code: errWrongCredentials, Message: "wrong username or password"}
+		}
+		password = string(decoded)
+	}
+	if !constantTimeEqual(password, s.config.Password) {
+		return &apiError{Code: errWrongCredentials, Message: "wrong username or password"}
+	}
+
+	return nil
+}
+
+// constantTimeEqual compares secrets without leaking timing information
+func constantTimeEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// newResponse creates a successful response envelope
+func newResponse() *response {
+	return &response{
+		Xmlns:         xmlNamespace,
+		Status:        "ok",
+		Version:       apiVersion,
+		Type:          serverName,
+		ServerVersion: serverVersion,
+		OpenSubsonic:  true,
+	}
+}
+
+// failed creates an error response envelope
+func failed(code int, message string) *response {
+	resp := newResponse()
+	resp.Status = "failed"
+	resp.Error = &apiError{Code: code, Message: message}
+	return resp
+}
+
+// writeResponse encodes the response in the format requested by the f parameter
+// Subsonic always answers with HTTP 200, errors are reported in the body
+func writeResponse(w http.ResponseWriter, r *http.Request, resp *response) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	switch r.Form.Get("f") {
+	case "json":
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_ = json.NewEncoder(w).Encode(envelope{Response: resp})
+	default:
+		w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		_, _ = w.Write([]byte(xml.Header))
+		_ = xml.NewEncoder(w).Encode(resp)
+	}
+}
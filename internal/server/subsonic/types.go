@@ -0,0 +1,138 @@
+package subsonic
+
+import "encoding/xml"
+
+// Subsonic REST API response types, encoded as XML or JSON depending on the f parameter
+// See http://www.subsonic.org/pages/api.jsp and https://opensubsonic.netlify.app/
+
+const (
+	apiVersion    = "1.16.1"
+	serverName    = "gomusic"
+	serverVersion = "1.0.0"
+	xmlNamespace  = "http://subsonic.org/restapi"
+
+	// ignoredArticles are skipped when building the artist index
+	ignoredArticles = "The El La Los Las Le Les"
+)
+
+// Subsonic error codes
+const (
+	errGeneric          = 0
+	errMissingParameter = 10
+	errWrongCredentials = 40
+	errNotFound         = 70
+)
+
+// envelope wraps every JSON response
+type envelope struct {
+	Response *response `json:"subsonic-response"`
+}
+
+type response struct {
+	XMLName       xml.Name `xml:"subsonic-response" json:"-"`
+	Xmlns         string   `xml:"xmlns,attr" json:"-"`
+	Status        string   `xml:"status,attr" json:"status"`
+	Version       string   `xml:"version,attr" json:"version"`
+	Type          string   `xml:"type,attr" json:"type"`
+	ServerVersion string   `xml:"serverVersion,attr" json:"serverVersion"`
+	OpenSubsonic  bool     `xml:"openSubsonic,attr" json:"openSubsonic"`
+
+	Error         *apiError      `xml:"error,omitempty" json:"error,omitempty"`
+	MusicFolders  *musicFolders  `xml:"musicFolders,omitempty" json:"musicFolders,omitempty"`
+	Artists       *artistsID3    `xml:"artists,omitempty" json:"artists,omitempty"`
+	Album         *albumID3      `xml:"album,omitempty" json:"album,omitempty"`
+	SearchResult3 *searchResult3 `xml:"searchResult3,omitempty" json:"searchResult3,omitempty"`
+	Playlists     *playlists     `xml:"playlists,omitempty" json:"playlists,omitempty"`
+}
+
+type apiError struct {
+	Code    int    `xml:"code,attr" json:"code"`
+	Message string `xml:"message,attr" json:"message"`
+}
+
+type musicFolders struct {
+	MusicFolder []musicFolder `xml:"musicFolder" json:"musicFolder"`
+}
+
+type musicFolder struct {
+	ID   int    `xml:"id,attr" json:"id"`
+	Name string `xml:"name,attr" json:"name"`
+}
+
+type artistsID3 struct {
+	IgnoredArticles string     `xml:"ignoredArticles,attr" json:"ignoredArticles"`
+	Index           []indexID3 `xml:"index" json:"index"`
+}
+
+type indexID3 struct {
+	Name   string      `xml:"name,attr" json:"name"`
+	Artist []artistID3 `xml:"artist" json:"artist"`
+}
+
+type artistID3 struct {
+	ID         string `xml:"id,attr" json:"id"`
+	Name       string `xml:"name,attr" json:"name"`
+	CoverArt   string `xml:"coverArt,attr,omitempty" json:"coverArt,omitempty"`
+	AlbumCount int    `xml:"albumCount,attr" json:"albumCount"`
+}
+
+type albumID3 struct {
+	ID        string  `xml:"id,attr" json:"id"`
+	Name      string  `xml:"name,attr" json:"name"`
+	Artist    string  `xml:"artist,attr,omitempty" json:"artist,omitempty"`
+	ArtistID  string  `xml:"artistId,attr,omitempty" json:"artistId,omitempty"`
+	CoverArt  string  `xml:"coverArt,attr,omitempty" json:"coverArt,omitempty"`
+	SongCount int     `xml:"songCount,attr" json:"songCount"`
+	Duration  int     `xml:"duration,attr" json:"duration"` // seconds
+	Created   string  `xml:"created,attr" json:"created"`
+	Year      int     `xml:"year,attr,omitempty" json:"year,omitempty"`
+	Genre     string  `xml:"genre,attr,omitempty" json:"genre,omitempty"`
+	Song      []child `xml:"song,omitempty" json:"song,omitempty"`
+}
+
+// child is a song entry
+type child struct {
+	ID          string `xml:"id,attr" json:"id"`
+	Parent      string `xml:"parent,attr,omitempty" json:"parent,omitempty"`
+	IsDir       bool   `xml:"isDir,attr" json:"isDir"`
+	Title       string `xml:"title,attr" json:"title"`
+	Album       string `xml:"album,attr,omitempty" json:"album,omitempty"`
+	Artist      string `xml:"artist,attr,omitempty" json:"artist,omitempty"`
+	Track       int    `xml:"track,attr,omitempty" json:"track,omitempty"`
+	DiscNumber  int    `xml:"discNumber,attr,omitempty" json:"discNumber,omitempty"`
+	Year        int    `xml:"year,attr,omitempty" json:"year,omitempty"`
+	Genre       string `xml:"genre,attr,omitempty" json:"genre,omitempty"`
+	CoverArt    string `xml:"coverArt,attr,omitempty" json:"coverArt,omitempty"`
+	Size        int64  `xml:"size,attr,omitempty" json:"size,omitempty"`
+	ContentType string `xml:"contentType,attr,omitempty" json:"contentType,omitempty"`
+	Suffix      string `xml:"suffix,attr,omitempty" json:"suffix,omitempty"`
+	Duration    int    `xml:"duration,attr" json:"duration"`                   // seconds
+	BitRate     int    `xml:"bitRate,attr,omitempty" json:"bitRate,omitempty"` // kbps
+	Created     string `xml:"created,attr,omitempty" json:"created,omitempty"`
+	AlbumID     string `xml:"albumId,attr,omitempty" json:"albumId,omitempty"`
+	ArtistID    string `xml:"artistId,attr,omitempty" json:"artistId,omitempty"`
+	Type        string `xml:"type,attr" json:"type"`
+}
+
+type searchResult3 struct {
+	Artist []artistID3 `xml:"artist" json:"artist"`
+	Album  []albumID3  `xml:"album" json:"album"`
+	Song   []child     `xml:"song" json:"song"`
+}
+
+type playlists struct {
+	Playlist []playlist `xml:"playlist" json:"playlist"`
+}
+
+type playlist struct {
+	ID        string `xml:"id,attr" json:"id"`
+	Name      string `xml:"name,attr" json:"name"`
+	Comment   string `xml:"comment,attr,omitempty" json:"comment,omitempty"`
+	Owner     string `xml:"owner,attr" json:"owner"`
+	Public    bool   `xml:"public,attr" json:"public"`
+	SongCount int    `xml:"songCount,attr" json:"songCount"`
+	Duration  int    `xml:"duration,attr" json:"duration"` // seconds
+	Created   string `xml:"created,attr" json:"created"`
+	Changed   string `xml:"changed,attr" json:"changed"`
+	CoverArt  string `xml:"coverArt,attr,omitempty" json:"coverArt,omitempty"`
+}
@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"GoMusic/internal/analysis"
+	"GoMusic/internal/decoder"
+	"GoMusic/internal/domain/model"
+	"GoMusic/internal/domain/repository"
+	"GoMusic/internal/util/errors"
+)
+
+// Analysis throttling: after each chunk the worker sleeps for the time the chunk
+// took multiplied by this factor, keeping it below roughly half of one core
+const (
+	analysisChunkFrames = 48000
+	analysisThrottle    = 1.0
+)
+
+// unknownAlbum is the placeholder sources use for untagged albums
+const unknownAlbum = "Unknown Album"
+
+// AnalysisService measures EBU R128 loudness, tempo and key of tracks whose tags lack them
+// Results are stored in GoMusic's analysis store, never written to the files
+type AnalysisService struct {
+	libraryService *LibraryService
+	repo           repository.AnalysisRepository
+
+	progress *repository.ScanProgress
+	mu       sync.RWMutex
+}
+
+// NewAnalysisService creates a new analysis service
+func NewAnalysisService(libraryService *LibraryService, repo repository.AnalysisRepository) *AnalysisService {
+	return &AnalysisService{
+		libraryService: libraryService,
+		repo:           repo,
+		progress:       &repository.ScanProgress{},
+	}
+}
+
+// AnalyzeLibrary analyzes every track that needs loudness, tempo or key information
+// Tracks are grouped by album so album loudness can be derived from the same pass
+// Runs until done or ctx is cancelled; results of finished albums are kept
+func (s *AnalysisService) AnalyzeLibrary(ctx context.Context) error {
+	s.mu.Lock()
+	if s.progress.IsScanning {
+		s.mu.Unlock()
+		return errors.ErrScanInProgress
+	}
+	s.progress = &repository.ScanProgress{IsScanning: true, Errors: []string{}}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		s.progress.IsScanning = false
+		s.progress.CurrentFile = ""
+		s.mu.Unlock()
+	}()
+
+	albums, total, err := s.pendingAlbums(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.progress.TotalFiles = total
+	s.mu.Unlock()
+
+	for _, tracks := range albums {
+		if err := s.analyzeAlbum(ctx, tracks); err != nil {
+			return err
+		}
+	}
+
+	return s.ApplyStoredResults(ctx)
+}
+
+// GetProgress returns a copy of the current analysis progress
+func (s *AnalysisService) GetProgress() *repository.ScanProgress {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	progress := *s.progress
+	progress.Errors = make([]string, len(s.progress.Errors))
+	copy(progress.Errors, s.progress.Errors)
+
+	return &progress
+}
+
+// ApplyStoredResults attaches stored measurements to the library's tracks
+// Values read from tags take precedence; tracks without ReplayGain tags get
+// gains derived from the measured loudness
+func (s *AnalysisService) ApplyStoredResults(ctx context.Context) error {
+	analyses, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, repo := range s.libraryService.GetRepositories() {
+		tracks, err := repo.FindAll(ctx, &repository.QueryOptions{Limit: 0})
+		if err != nil {
+			continue
+		}
+
+		for _, track := range tracks {
+			result, ok := analyses[track.ID]
+			if !ok || result.Loudness == nil || !result.IsCurrent(track) {
+				continue
+			}
+
+			// Cached tracks are shared, so replace rather than mutate them
+			updated := *track
+			updated.Loudness = result.Loudness
+			if updated.ReplayGain == nil {
+				updated.ReplayGain = replayGainFromLoudness(result.Loudness, result.AlbumLoudness)
+			}
+			if updated.BPM == 0 {
+				updated.BPM = result.BPM
+			}
+			if updated.Key == "" {
+				updated.Key = result.Key
+			}
+			if err := repo.Update(ctx, &updated); err != nil {
+				return fmt.Errorf("failed to update track %s: %w", track.ID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// pendingAlbums returns the tracks to analyze grouped by album, and their count
+// An album is re-analyzed as a whole when any of its tracks lacks a current result
+func (s *AnalysisService) pendingAlbums(ctx context.Context) ([][]*model.Track, int, error) {
+	tracks, err := s.libraryService.GetAllTracks(ctx, &repository.QueryOptions{Limit: 0})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	analyses, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	byAlbum := make(map[string][]*model.Track)
+	var order []string
+	stale := make(map[string]bool)
+	for _, track := range tracks {
+		if !needsAnalysis(track) {
+			continue
+		}
+		if _, ok := byAlbum[track.AlbumID]; !ok {
+			order = append(order, track.AlbumID)
+		}
+		byAlbum[track.AlbumID] = append(byAlbum[track.AlbumID], track)
+
+		if result, ok := analyses[track.ID]; !ok || !result.IsCurrent(track) {
+			stale[track.AlbumID] = true
+		}
+	}
+
+	var albums [][]*model.Track
+	total := 0
+	for _, albumID := range order {
+		if stale[albumID] {
+			albums = append(albums, byAlbum[albumID])
+			total += len(byAlbum[albumID])
+		}
+	}
+
+	return albums, total, nil
+}
+
+// analyzeAlbum measures each track of an album and stores the results together
+func (s *AnalysisService) analyzeAlbum(ctx context.Context, tracks []*model.Track) error {
+	var meters []*analysis.LoudnessMeter
+	var results []*model.TrackAnalysis
+
+	for _, track := range tracks {
+		s.mu.Lock()
+		s.progress.CurrentFile = track.FilePath
+		s.mu.Unlock()
+
+		loudness, tempoKey, err := s.measure(ctx, track)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			s.mu.Lock()
+			s.progress.Errors = append(s.progress.Errors, fmt.Sprintf("%s: %v", track.FilePath, err))
+			s.progress.ProcessedFiles++
+			s.mu.Unlock()
+			continue
+		}
+
+		meters = append(meters, loudness)
+		results = append(results, &model.TrackAnalysis{
+			Version:    model.AnalysisVersion,
+			TrackID:    track.ID,
+			FileSize:   track.FileSize,
+			ModifiedAt: track.ModifiedAt,
+			AnalyzedAt: time.Now(),
+			Loudness:   loudness.Result(),
+			BPM:        tempoKey.Tempo(),
+			Key:        tempoKey.Key(),
+		})
+
+		s.mu.Lock()
+		s.progress.ProcessedFiles++
+		s.mu.Unlock()
+	}
+
+	if len(results) == 0 {
+		return nil
+	}
+
+	// Album loudness only makes sense for tracks that share a real album
+	if album := tracks[0].Album; album != "" && album != unknownAlbum {
+		albumLoudness := analysis.AlbumLoudness(meters)
+		for _, result := range results {
+			result.AlbumLoudness = albumLoudness
+		}
+	}
+
+	return s.repo.SaveAll(ctx, results)
+}
+
+// measure decodes a track once through the loudness and tempo/key meters,
+// pausing between chunks
+func (s *AnalysisService) measure(ctx context.Context, track *model.Track) (*analysis.LoudnessMeter, *analysis.TempoKeyMeter, error) {
+	reader, err := openTrackSamples(track)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer reader.Close()
+
+	format := reader.Format()
+	loudness := analysis.NewLoudnessMeter(format.SampleRate, format.Channels)
+	tempoKey := analysis.NewTempoKeyMeter(format.SampleRate, format.Channels)
+	buf := make([]float64, analysisChunkFrames*format.Channels)
+
+	for {
+		start := time.Now()
+		n, err := reader.Read(buf)
+		loudness.Process(buf[:n])
+		tempoKey.Process(buf[:n])
+		if err == io.EOF {
+			return loudness, tempoKey, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Yield so playback decoding and the UI stay responsive
+		pause := time.Duration(float64(time.Since(start)) * analysisThrottle)
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-time.After(pause):
+		}
+	}
+}
+
+// needsAnalysis reports whether a track lacks tagged loudness, tempo or key and can
+// be decoded locally
+// Tracks already filled in by an earlier analysis still count, so albums stay whole
+func needsAnalysis(track *model.Track) bool {
+	untagged := track.ReplayGain == nil || track.BPM == 0 || track.Key == "" || track.Loudness != nil
+	return track.FilePath != "" && untagged && decoder.CanDecode(track.FilePath)
+}
+
+// replayGainFromLoudness derives ReplayGain 2.0 values from measured loudness
+// Silence has no loudness to normalize, so it gets no gain rather than the
+// gate's +52 dB; nil when the track itself is silent
+func replayGainFromLoudness(track, album *model.Loudness) *model.ReplayGain {
+	if track.IsSilent() {
+		return nil
+	}
+	trackGain, trackPeak := track.Gain(), track.LinearPeak()
+	rg := &model.ReplayGain{TrackGain: &trackGain, TrackPeak: &trackPeak}
+	if album != nil && !album.IsSilent() {
+		albumGain, albumPeak := album.Gain(), album.LinearPeak()
+		rg.AlbumGain, rg.AlbumPeak = &albumGain, &albumPeak
+	}
+	return rg
+}
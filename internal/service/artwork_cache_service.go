@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"GoMusic/internal/domain/model"
+	"GoMusic/internal/domain/repository"
+	"GoMusic/internal/util/errors"
+)
+
+// artworkGracePeriod protects recently written artwork from cleanup, as a scan
+// may have cached it before any track that uses it is recorded
+const artworkGracePeriod = 10 * time.Minute
+
+// ArtworkCacheService tracks which cached artwork files each source uses and
+// deletes the files none of them use
+type ArtworkCacheService struct {
+	libraryService *LibraryService
+	configService  *ConfigService
+	references     repository.ArtworkReferenceRepository
+	artworkDir     string
+	mu             sync.Mutex
+}
+
+// NewArtworkCacheService creates a new artwork cache service for artworkDir
+func NewArtworkCacheService(libraryService *LibraryService, configService *ConfigService, references repository.ArtworkReferenceRepository, artworkDir string) *ArtworkCacheService {
+	return &ArtworkCacheService{
+		libraryService: libraryService,
+		configService:  configService,
+		references:     references,
+		artworkDir:     artworkDir,
+	}
+}
+
+// RecordReferences saves the artwork files a source currently uses
+// Call it after the source has been scanned, so its references are complete
+func (s *ArtworkCacheService) RecordReferences(ctx context.Context, sourceID string) error {
+	filenames, err := s.libraryService.artworkReferences(ctx, sourceID)
+	if err != nil {
+		return err
+	}
+	return s.references.Save(ctx, sourceID, filenames)
+}
+
+// ForgetSource drops the references of a removed source, leaving its artwork
+// to the next cleanup
+func (s *ArtworkCacheService) ForgetSource(ctx context.Context, sourceID string) error {
+	return s.references.Delete(ctx, sourceID)
+}
+
+// GetStats returns the size of the artwork cache
+func (s *ArtworkCacheService) GetStats(ctx context.Context) (*model.ArtworkCacheStats, error) {
+	files, err := s.cacheFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &model.ArtworkCacheStats{MaxBytes: s.maxBytes()}
+	for _, file := range files {
+		stats.Files++
+		stats.Bytes += file.size
+	}
+	return stats, nil
+}
+
+// CollectGarbage deletes cached artwork no source references, then evicts the
+// least recently stored files while the cache is over its size cap
+// Evicted files still in use are cached again when next served, see
+// LibraryService.RestoreArtwork
+// Sources that are configured but not loaded keep the references recorded at
+// their last scan. Files written within artworkGracePeriod are never removed
+func (s *ArtworkCacheService) CollectGarbage(ctx context.Context) (*model.ArtworkCacheStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A scan in progress caches artwork its tracks don't reference yet
+	for _, progress := range s.libraryService.GetAllScanProgress() {
+		if progress != nil && progress.IsScanning {
+			return nil, errors.ErrScanInProgress
+		}
+	}
+
+	referenced, err := s.referencedFiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	files, err := s.cacheFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &model.ArtworkCacheStats{MaxBytes: s.maxBytes()}
+	cutoff := time.Now().Add(-artworkGracePeriod)
+	remove := func(file artworkFile) bool {
+		if err := os.Remove(filepath.Join(s.artworkDir, file.name)); err != nil && !os.IsNotExist(err) {
+			fmt.Printf("Failed to remove artwork %s: %v\n", file.name, err)
+			return false
+		}
+		stats.RemovedFiles++
+		stats.FreedBytes += file.size
+		return true
+	}
+
+	kept := files[:0]
+	for _, file := range files {
+		if !referenced[file.name] && file.modTime.Before(cutoff) && remove(file) {
+			continue
+		}
+		kept = append(kept, file)
+		stats.Files++
+		stats.Bytes += file.size
+	}
+
+	if stats.MaxBytes > 0 && stats.Bytes > stats.MaxBytes {
+		sort.Slice(kept, func(i, j int) bool {
+			return kept[i].modTime.Before(kept[j].modTime)
+		})
+		for _, file := range kept {
+			if stats.Bytes <= stats.MaxBytes || !file.modTime.Before(cutoff) {
+				break
+			}
+			if remove(file) {
+				stats.Files--
+				stats.Bytes -= file.size
+			}
+		}
+	}
+
+	return stats, nil
+}
+
+// referencedFiles returns the artwork files any configured source uses
+// References of sources that are no longer configured are pruned
+func (s *ArtworkCacheService) referencedFiles(ctx context.Context) (map[string]bool, error) {
+	recorded, err := s.references.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load artwork references: %w", err)
+	}
+
+	configured := make(map[string]bool)
+	for _, source := range s.configService.GetSources() {
+		configured[source.ID] = true
+	}
+
+	referenced := make(map[string]bool)
+	for sourceID, filenames := range recorded {
+		if !configured[sourceID] {
+			if err := s.references.Delete(ctx, sourceID); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		for _, filename := range filenames {
+			referenced[filename] = true
+		}
+	}
+
+	// Loaded sources may use files cached since their references were recorded,
+	// such as the artwork of a track whose tags were edited
+	for _, source := range s.libraryService.GetSources() {
+		filenames, err := s.libraryService.artworkReferences(ctx, source.ID)
+		if err != nil {
+			if stderrors.Is(err, errors.ErrSourceNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		for _, filename := range filenames {
+			referenced[filename] = true
+		}
+	}
+
+	return referenced, nil
+}
+
+// artworkFile is a file in the artwork cache
+type artworkFile struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+// cacheFiles lists the artwork cache, skipping hidden files such as images
+// still being written
+func (s *ArtworkCacheService) cacheFiles() ([]artworkFile, error) {
+	entries, err := os.ReadDir(s.artworkDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read artwork cache: %w", err)
+	}
+
+	files := make([]artworkFile, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, artworkFile{name: entry.Name(), size: info.Size(), modTime: info.ModTime()})
+	}
+	return files, nil
+}
+
+// maxBytes returns the configured size cap in bytes, or 0 when uncapped
+func (s *ArtworkCacheService) maxBytes() int64 {
+	return int64(s.configService.GetArtworkCacheConfig().MaxSizeMB) << 20
+}
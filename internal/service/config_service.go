@@ -64,6 +64,26 @@ func (s *ConfigService) GetConfig() *model.AppConfig {
 	configCopy := *s.config
 	configCopy.Sources = make([]model.SourceConfiguration, len(s.config.Sources))
 	copy(configCopy.Sources, s.config.Sources)
+	if s.config.Scrobbler != nil {
+		scrobblerCopy := *s.config.Scrobbler
+		configCopy.Scrobbler = &scrobblerCopy
+	}
+	if s.config.SubsonicServer != nil {
+		serverCopy := *s.config.SubsonicServer
+		configCopy.SubsonicServer = &serverCopy
+	}
+	if s.config.DLNA != nil {
+		dlnaCopy := *s.config.DLNA
+		configCopy.DLNA = &dlnaCopy
+	}
+	if s.config.Playback != nil {
+		playbackCopy := *s.config.Playback
+		configCopy.Playback = &playbackCopy
+	}
+	if s.config.ArtworkCache != nil {
+		artworkCacheCopy := *s.config.ArtworkCache
+		configCopy.ArtworkCache = &artworkCacheCopy
+	}
 
 	return &configCopy
 }
@@ -228,7 +248,7 @@ func (s *ConfigService) validateSource(source *model.SourceConfiguration) error
 	switch source.Type {
 	case model.SourceTypeFilesystem:
 		return s.validateFilesystemSource(source)
-	case model.SourceTypeAPISelfHosted:
+	case model.SourceTypeAPISelfHosted, model.SourceTypeJellyfin:
 		return s.validateAPISource(source)
 	default:
 		return fmt.Errorf("unsupported source type: %s", source.Type)
@@ -282,7 +302,7 @@ func (s *ConfigService) findDuplicateSource(newSource *model.SourceConfiguration
 			if s.isFilesystemDuplicate(&existing, newSource) {
 				return &existing
 			}
-		case model.SourceTypeAPISelfHosted:
+		case model.SourceTypeAPISelfHosted, model.SourceTypeJellyfin:
 			if s.isAPIDuplicate(&existing, newSource) {
 				return &existing
 			}
@@ -355,4 +375,210 @@ func (s *ConfigService) pathsOverlap(path1, path2 string) bool {
 	}
 
 	return false
-}
\ No newline at end of file
+}
+
+// GetScrobblerConfig returns a copy of the scrobbler configuration
+// Returns a disabled default configuration if none has been saved yet
+func (s *ConfigService) GetScrobblerConfig() *model.ScrobblerConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.config == nil || s.config.Scrobbler == nil {
+		config := &model.ScrobblerConfig{}
+		_ = config.Validate()
+		return config
+	}
+
+	configCopy := *s.config.Scrobbler
+	return &configCopy
+}
+
+// UpdateScrobblerConfig validates and persists the scrobbler configuration
+// A saved Last.fm session is dropped when the account it was obtained for changes
+func (s *ConfigService) UpdateScrobblerConfig(ctx context.Context, config *model.ScrobblerConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	oldConfig := s.config.Scrobbler
+	configCopy := *config
+	if err := configCopy.Validate(); err != nil {
+		return err
+	}
+	if oldConfig != nil && configCopy.SessionKey != "" && configCopy.SessionKey == oldConfig.SessionKey && lastFMAccountChanged(oldConfig, &configCopy) {
+		configCopy.SessionKey = ""
+		if err := configCopy.Validate(); err != nil {
+			return err
+		}
+	}
+	s.config.Scrobbler = &configCopy
+
+	// Save configuration
+	if err := s.repo.Save(ctx, s.config); err != nil {
+		// Rollback on save failure
+		s.config.Scrobbler = oldConfig
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	return nil
+}
+
+// GetSubsonicServerConfig returns a copy of the embedded Subsonic server configuration
+// Returns a disabled default configuration if none has been saved yet
+func (s *ConfigService) GetSubsonicServerConfig() *model.SubsonicServerConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.config == nil || s.config.SubsonicServer == nil {
+		config := &model.SubsonicServerConfig{}
+		_ = config.Validate()
+		return config
+	}
+
+	configCopy := *s.config.SubsonicServer
+	return &configCopy
+}
+
+// UpdateSubsonicServerConfig validates and persists the embedded Subsonic server configuration
+func (s *ConfigService) UpdateSubsonicServerConfig(ctx context.Context, config *model.SubsonicServerConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	oldConfig := s.config.SubsonicServer
+	configCopy := *config
+	s.config.SubsonicServer = &configCopy
+
+	// Save configuration
+	if err := s.repo.Save(ctx, s.config); err != nil {
+		// Rollback on save failure
+		s.config.SubsonicServer = oldConfig
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	return nil
+}
+
+// GetDLNAConfig returns a copy of the DLNA media server configuration
+// Returns a disabled default configuration if none has been saved yet
+func (s *ConfigService) GetDLNAConfig() *model.DLNAConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.config == nil || s.config.DLNA == nil {
+		config := &model.DLNAConfig{}
+		_ = config.Validate()
+		return config
+	}
+
+	configCopy := *s.config.DLNA
+	return &configCopy
+}
+
+// UpdateDLNAConfig validates and persists the DLNA media server configuration
+func (s *ConfigService) UpdateDLNAConfig(ctx context.Context, config *model.DLNAConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	oldConfig := s.config.DLNA
+	configCopy := *config
+	s.config.DLNA = &configCopy
+
+	// Save configuration
+	if err := s.repo.Save(ctx, s.config); err != nil {
+		// Rollback on save failure
+		s.config.DLNA = oldConfig
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	return nil
+}
+
+// GetPlaybackConfig returns a copy of the playback preferences
+// Returns the default preferences if none have been saved yet
+func (s *ConfigService) GetPlaybackConfig() *model.PlaybackConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.config == nil || s.config.Playback == nil {
+		config := &model.PlaybackConfig{}
+		_ = config.Validate()
+		return config
+	}
+
+	configCopy := *s.config.Playback
+	return &configCopy
+}
+
+// UpdatePlaybackConfig validates and persists the playback preferences
+func (s *ConfigService) UpdatePlaybackConfig(ctx context.Context, config *model.PlaybackConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	oldConfig := s.config.Playback
+	configCopy := *config
+	s.config.Playback = &configCopy
+
+	// Save configuration
+	if err := s.repo.Save(ctx, s.config); err != nil {
+		// Rollback on save failure
+		s.config.Playback = oldConfig
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	return nil
+}
+
+// lastFMAccountChanged reports whether a scrobbler update points Last.fm at
+// another account or server
+func lastFMAccountChanged(old, updated *model.ScrobblerConfig) bool {
+	return old.Service != updated.Service || old.BaseURL != updated.BaseURL || old.APIKey != updated.APIKey ||
+		old.Username != updated.Username || old.Password != updated.Password
+}
+
+// GetArtworkCacheConfig returns a copy of the artwork cache limits
+// Returns the defaults (no cap) if none have been saved yet
+func (s *ConfigService) GetArtworkCacheConfig() *model.ArtworkCacheConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.config == nil || s.config.ArtworkCache == nil {
+		return &model.ArtworkCacheConfig{}
+	}
+
+	configCopy := *s.config.ArtworkCache
+	return &configCopy
+}
+
+// UpdateArtworkCacheConfig validates and persists the artwork cache limits
+func (s *ConfigService) UpdateArtworkCacheConfig(ctx context.Context, config *model.ArtworkCacheConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	oldConfig := s.config.ArtworkCache
+	configCopy := *config
+	s.config.ArtworkCache = &configCopy
+
+	// Save configuration
+	if err := s.repo.Save(ctx, s.config); err != nil {
+		// Rollback on save failure
+		s.config.ArtworkCache = oldConfig
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"GoMusic/internal/domain/model"
+	"GoMusic/internal/domain/repository"
+	"GoMusic/internal/util/errors"
+)
+
+// fakeTrackRepository is an in-memory source that can edit tags and move files
+type fakeTrackRepository struct {
+	sourceID string
+	root     string
+
+	mu          sync.Mutex
+	tracks      map[string]*model.Track
+	failTags    map[string]bool // tracks whose tag writes fail
+	writes      map[string]int  // tag writes by track
+	relocateErr error           // returned by RelocateTracks when set
+	relocated   map[string]string
+}
+
+// newFakeTrackRepository creates a source holding tracks, registered with a
+// new library service
+func newFakeTrackRepository(sourceID, root string, tracks ...*model.Track) (*fakeTrackRepository, *LibraryService) {
+	repo := &fakeTrackRepository{
+		sourceID:  sourceID,
+		root:      root,
+		tracks:    make(map[string]*model.Track),
+		failTags:  make(map[string]bool),
+		writes:    make(map[string]int),
+		relocated: make(map[string]string),
+	}
+	for _, track := range tracks {
+		track.SourceID = sourceID
+		repo.tracks[track.ID] = track
+	}
+
+	library := NewLibraryService()
+	library.RegisterTrackRepository(sourceID, repo)
+	return repo, library
+}
+
+// track returns a copy of a stored track
+func (r *fakeTrackRepository) track(id string) model.Track {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return *r.tracks[id]
+}
+
+func (r *fakeTrackRepository) FindByID(ctx context.Context, id string) (*model.Track, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	track, ok := r.tracks[id]
+	if !ok {
+		return nil, errors.ErrNotFound
+	}
+	copied := *track
+	return &copied, nil
+}
+
+func (r *fakeTrackRepository) FindAll(ctx context.Context, opts *repository.QueryOptions) ([]*model.Track, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tracks := make([]*model.Track, 0, len(r.tracks))
+	for _, track := range r.tracks {
+		copied := *track
+		tracks = append(tracks, &copied)
+	}
+	return tracks, nil
+}
+
+func (r *fakeTrackRepository) Create(ctx context.Context, track *model.Track) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tracks[track.ID] = track
+	return nil
+}
+
+func (r *fakeTrackRepository) Update(ctx context.Context, track *model.Track) error {
+	return r.Create(ctx, track)
+}
+
+func (r *fakeTrackRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.tracks, id)
+	return nil
+}
+
+func (r *fakeTrackRepository) FindByAlbum(ctx context.Context, albumID string) ([]*model.Track, error) {
+	return nil, nil
+}
+
+func (r *fakeTrackRepository) FindByArtist(ctx context.Context, artistID string) ([]*model.Track, error) {
+	return nil, nil
+}
+
+func (r *fakeTrackRepository) Search(ctx context.Context, query string, opts *repository.SearchOptions) ([]*model.Track, error) {
+	return nil, nil
+}
+
+func (r *fakeTrackRepository) GetSourceID() string {
+	return r.sourceID
+}
+
+func (r *fakeTrackRepository) GetSourceType() model.SourceType {
+	return model.SourceTypeFilesystem
+}
+
+func (r *fakeTrackRepository) Scan(ctx context.Context) error {
+	return nil
+}
+
+func (r *fakeTrackRepository) GetScanProgress() *repository.ScanProgress {
+	return &repository.ScanProgress{}
+}
+
+func (r *fakeTrackRepository) CanEditTags(track *model.Track) error {
+	return nil
+}
+
+// UpdateTags applies changes to the stored track, as a source re-reading the
+// written file would see them
+func (r *fakeTrackRepository) UpdateTags(ctx context.Context, trackID string, changes *model.TagChanges) ([]*model.Track, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	track, ok := r.tracks[trackID]
+	if !ok {
+		return nil, errors.ErrNotFound
+	}
+	if r.failTags[trackID] {
+		return nil, fmt.Errorf("cannot write %s", track.FilePath)
+	}
+	r.writes[trackID]++
+
+	setString := func(field *string, value *string) {
+		if value != nil {
+			*field = *value
+		}
+	}
+	setNumber := func(field *int, value *int) {
+		if value != nil {
+			*field = *value
+		}
+	}
+	setString(&track.Title, changes.Title)
+	setString(&track.Artist, changes.Artist)
+	setString(&track.Album, changes.Album)
+	setString(&track.AlbumArtist, changes.AlbumArtist)
+	setString(&track.Genre, changes.Genre)
+	setNumber(&track.Year, changes.Year)
+	setNumber(&track.TrackNumber, changes.TrackNumber)
+	setNumber(&track.DiscNumber, changes.DiscNumber)
+
+	copied := *track
+	return []*model.Track{&copied}, nil
+}
+
+func (r *fakeTrackRepository) GetRootPath() string {
+	return r.root
+}
+
+// RelocateTracks updates the paths of moved tracks, or fails with relocateErr
+func (r *fakeTrackRepository) RelocateTracks(ctx context.Context, moves map[string]string) error {
+	if r.relocateErr != nil {
+		return r.relocateErr
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, track := range r.tracks {
+		if to, ok := moves[track.FilePath]; ok {
+			r.relocated[track.FilePath] = to
+			track.FilePath = to
+		}
+	}
+	return nil
+}
@@ -2,11 +2,16 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 
 	"GoMusic/internal/domain/model"
 	"GoMusic/internal/domain/repository"
+	"GoMusic/internal/domain/source/capability"
 	"GoMusic/internal/util/errors"
 )
 
@@ -49,6 +54,13 @@ func (s *LibraryService) RegisterAlbumRepository(sourceID string, repo repositor
 	s.albumRepos[sourceID] = repo
 }
 
+// UnregisterAlbumRepository removes an album repository from the library
+func (s *LibraryService) UnregisterAlbumRepository(sourceID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.albumRepos, sourceID)
+}
+
 // RegisterArtistRepository adds an artist repository to the library
 func (s *LibraryService) RegisterArtistRepository(sourceID string, repo repository.ArtistRepository) {
 	s.mu.Lock()
@@ -56,6 +68,13 @@ func (s *LibraryService) RegisterArtistRepository(sourceID string, repo reposito
 	s.artistRepos[sourceID] = repo
 }
 
+// UnregisterArtistRepository removes an artist repository from the library
+func (s *LibraryService) UnregisterArtistRepository(sourceID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.artistRepos, sourceID)
+}
+
 // SetPlaylistRepository sets the playlist repository
 func (s *LibraryService) SetPlaylistRepository(repo repository.PlaylistRepository) {
 	s.mu.Lock()
@@ -175,6 +194,137 @@ func (s *LibraryService) ScanSource(ctx context.Context, sourceID string) error
 	return repo.Scan(ctx)
 }
 
+// UpdateTrackMetadata writes tag changes to a track's file through its source
+// Returns the tracks re-read from the file
+func (s *LibraryService) UpdateTrackMetadata(ctx context.Context, trackID string, changes *model.TagChanges) ([]*model.Track, error) {
+	track, err := s.GetTrackByID(ctx, trackID)
+	if err != nil {
+		return nil, err
+	}
+
+	editor, err := s.tagEditor(track)
+	if err != nil {
+		return nil, err
+	}
+	return editor.UpdateTags(ctx, trackID, changes)
+}
+
+// CanEditTrackMetadata reports why a track's tags cannot be written, or nil if they can
+func (s *LibraryService) CanEditTrackMetadata(track *model.Track) error {
+	editor, err := s.tagEditor(track)
+	if err != nil {
+		return err
+	}
+	return editor.CanEditTags(track)
+}
+
+// tagEditor returns the tag editing capability of a track's source
+func (s *LibraryService) tagEditor(track *model.Track) (capability.TagEditor, error) {
+	s.mu.RLock()
+	repo, exists := s.trackRepos[track.SourceID]
+	s.mu.RUnlock()
+	if !exists {
+		return nil, errors.ErrSourceNotFound
+	}
+
+	editor, ok := repo.(capability.TagEditor)
+	if !ok {
+		return nil, fmt.Errorf("source %s does not support editing tags", track.SourceID)
+	}
+	return editor, nil
+}
+
+// trackRelocator returns a source's repository with its file moving capability
+func (s *LibraryService) trackRelocator(sourceID string) (repository.TrackRepository, capability.TrackRelocator, error) {
+	s.mu.RLock()
+	repo, exists := s.trackRepos[sourceID]
+	s.mu.RUnlock()
+	if !exists {
+		return nil, nil, errors.ErrSourceNotFound
+	}
+
+	relocator, ok := repo.(capability.TrackRelocator)
+	if !ok {
+		return nil, nil, fmt.Errorf("source %s does not support organizing files", sourceID)
+	}
+	return repo, relocator, nil
+}
+
+// artworkReferences returns the artwork cache files a source's tracks, albums
+// and artists use; remote image URLs are left out
+func (s *LibraryService) artworkReferences(ctx context.Context, sourceID string) ([]string, error) {
+	s.mu.RLock()
+	trackRepo, exists := s.trackRepos[sourceID]
+	albumRepo := s.albumRepos[sourceID]
+	artistRepo := s.artistRepos[sourceID]
+	s.mu.RUnlock()
+	if !exists {
+		return nil, errors.ErrSourceNotFound
+	}
+
+	seen := make(map[string]bool)
+	var filenames []string
+	add := func(path string) {
+		if path != "" && filepath.Base(path) == path && !seen[path] {
+			seen[path] = true
+			filenames = append(filenames, path)
+		}
+	}
+
+	tracks, err := trackRepo.FindAll(ctx, &repository.QueryOptions{Limit: 0})
+	if err != nil {
+		return nil, err
+	}
+	for _, track := range tracks {
+		add(track.ArtworkPath)
+	}
+	if albumRepo != nil {
+		albums, err := albumRepo.FindAll(ctx, &repository.QueryOptions{Limit: 0})
+		if err != nil {
+			return nil, err
+		}
+		for _, album := range albums {
+			add(album.ArtworkPath)
+		}
+	}
+	if artistRepo != nil {
+		artists, err := artistRepo.FindAll(ctx, &repository.QueryOptions{Limit: 0})
+		if err != nil {
+			return nil, err
+		}
+		for _, artist := range artists {
+			add(artist.ImagePath)
+		}
+	}
+	return filenames, nil
+}
+
+// RestoreArtwork asks the sources to cache the image stored as filename again,
+// after the artwork cache evicted it, and reports whether one of them did
+func (s *LibraryService) RestoreArtwork(ctx context.Context, filename string) (bool, error) {
+	s.mu.RLock()
+	repos := make([]repository.TrackRepository, 0, len(s.trackRepos))
+	for _, repo := range s.trackRepos {
+		repos = append(repos, repo)
+	}
+	s.mu.RUnlock()
+
+	for _, repo := range repos {
+		restorer, ok := repo.(capability.ArtworkRestorer)
+		if !ok {
+			continue
+		}
+		restored, err := restorer.RestoreArtwork(ctx, filename)
+		if err != nil {
+			return false, err
+		}
+		if restored {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // ScanAllSources triggers a scan on all sources
 func (s *LibraryService) ScanAllSources(ctx context.Context) error {
 	s.mu.RLock()
@@ -267,3 +417,217 @@ func (s *LibraryService) GetRepositories() map[string]repository.TrackRepository
 
 	return repos
 }
+
+// GetAllAlbums returns the albums of all sources
+// Albums come from registered album repositories where a source provides them,
+// otherwise they are derived from the source's tracks
+func (s *LibraryService) GetAllAlbums(ctx context.Context) ([]*model.Album, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	albums := make(map[string]*model.Album)
+
+	for sourceID, repo := range s.albumRepos {
+		sourceAlbums, err := repo.FindAll(ctx, nil)
+		if err != nil {
+			log.Printf("ERROR: Failed to fetch albums from source %s: %v", sourceID, err)
+			continue
+		}
+		for _, album := range sourceAlbums {
+			albums[album.ID] = album
+		}
+	}
+
+	for sourceID, repo := range s.trackRepos {
+		if _, ok := s.albumRepos[sourceID]; ok {
+			continue
+		}
+
+		tracks, err := repo.FindAll(ctx, nil)
+		if err != nil {
+			log.Printf("ERROR: Failed to fetch tracks from source %s: %v", sourceID, err)
+			continue
+		}
+		for _, album := range albumsFromTracks(tracks) {
+			albums[album.ID] = album
+		}
+	}
+
+	result := make([]*model.Album, 0, len(albums))
+	for _, album := range albums {
+		result = append(result, album)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return strings.ToLower(result[i].Title) < strings.ToLower(result[j].Title)
+	})
+
+	return result, nil
+}
+
+// GetAlbumByID returns a single album from any source
+func (s *LibraryService) GetAlbumByID(ctx context.Context, id string) (*model.Album, error) {
+	s.mu.RLock()
+	for _, repo := range s.albumRepos {
+		if album, err := repo.FindByID(ctx, id); err == nil {
+			s.mu.RUnlock()
+			return album, nil
+		}
+	}
+	s.mu.RUnlock()
+
+	tracks, err := s.GetTracksByAlbum(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	albums := albumsFromTracks(tracks)
+	if len(albums) == 0 {
+		return nil, errors.ErrNotFound
+	}
+	return albums[0], nil
+}
+
+// GetAllArtists returns the artists of all sources
+// Artists come from registered artist repositories where a source provides them,
+// otherwise they are derived from the source's tracks
+func (s *LibraryService) GetAllArtists(ctx context.Context) ([]*model.Artist, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	artists := make(map[string]*model.Artist)
+
+	for sourceID, repo := range s.artistRepos {
+		sourceArtists, err := repo.FindAll(ctx, nil)
+		if err != nil {
+			log.Printf("ERROR: Failed to fetch artists from source %s: %v", sourceID, err)
+			continue
+		}
+		for _, artist := range sourceArtists {
+			artists[artist.ID] = artist
+		}
+	}
+
+	for sourceID, repo := range s.trackRepos {
+		if _, ok := s.artistRepos[sourceID]; ok {
+			continue
+		}
+
+		tracks, err := repo.FindAll(ctx, nil)
+		if err != nil {
+			log.Printf("ERROR: Failed to fetch tracks from source %s: %v", sourceID, err)
+			continue
+		}
+		for _, artist := range artistsFromTracks(tracks) {
+			artists[artist.ID] = artist
+		}
+	}
+
+	result := make([]*model.Artist, 0, len(artists))
+	for _, artist := range artists {
+		result = append(result, artist)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return strings.ToLower(result[i].Name) < strings.ToLower(result[j].Name)
+	})
+
+	return result, nil
+}
+
+// GetAllPlaylists returns all playlists, or none if no playlist repository is set
+func (s *LibraryService) GetAllPlaylists(ctx context.Context) ([]*model.Playlist, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.playlistRepo == nil {
+		return []*model.Playlist{}, nil
+	}
+
+	return s.playlistRepo.FindAll(ctx)
+}
+
+// albumsFromTracks groups tracks by album ID into albums
+func albumsFromTracks(tracks []*model.Track) []*model.Album {
+	albums := make(map[string]*model.Album)
+	var order []string
+
+	for _, track := range tracks {
+		album, ok := albums[track.AlbumID]
+		if !ok {
+			artist := track.AlbumArtist
+			if artist == "" {
+				artist = track.Artist
+			}
+			album = &model.Album{
+				ID:         track.AlbumID,
+				SourceID:   track.SourceID,
+				SourceType: track.SourceType,
+				Title:      track.Album,
+				Artist:     artist,
+				ArtistID:   track.ArtistID,
+				Year:       track.Year,
+				Genre:      track.Genre,
+				AddedAt:    track.AddedAt,
+
+				MusicBrainzAlbumID:        track.MusicBrainzAlbumID,
+				MusicBrainzReleaseGroupID: track.MusicBrainzReleaseGroupID,
+			}
+			albums[track.AlbumID] = album
+			order = append(order, track.AlbumID)
+		}
+
+		album.TrackCount++
+		album.TotalDuration += track.Duration
+		if album.ArtworkPath == "" {
+			album.ArtworkPath = track.ArtworkPath
+		}
+		if track.AddedAt.Before(album.AddedAt) {
+			album.AddedAt = track.AddedAt
+		}
+	}
+
+	result := make([]*model.Album, 0, len(order))
+	for _, id := range order {
+		result = append(result, albums[id])
+	}
+	return result
+}
+
+// artistsFromTracks groups tracks by artist ID into artists
+func artistsFromTracks(tracks []*model.Track) []*model.Artist {
+	artists := make(map[string]*model.Artist)
+	artistAlbums := make(map[string]map[string]bool)
+	var order []string
+
+	for _, track := range tracks {
+		artist, ok := artists[track.ArtistID]
+		if !ok {
+			artist = &model.Artist{
+				ID:         track.ArtistID,
+				SourceID:   track.SourceID,
+				SourceType: track.SourceType,
+				Name:       track.Artist,
+				AddedAt:    track.AddedAt,
+
+				MusicBrainzArtistID: track.MusicBrainzArtistID,
+			}
+			artists[track.ArtistID] = artist
+			artistAlbums[track.ArtistID] = make(map[string]bool)
+			order = append(order, track.ArtistID)
+		}
+
+		artist.TrackCount++
+		if !artistAlbums[track.ArtistID][track.AlbumID] {
+			artistAlbums[track.ArtistID][track.AlbumID] = true
+			artist.AlbumCount++
+		}
+		if artist.ImagePath == "" {
+			artist.ImagePath = track.ArtworkPath
+		}
+	}
+
+	result := make([]*model.Artist, 0, len(order))
+	for _, id := range order {
+		result = append(result, artists[id])
+	}
+	return result
+}
@@ -0,0 +1,692 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"GoMusic/internal/domain/model"
+	"GoMusic/internal/domain/repository"
+	"GoMusic/internal/domain/source/capability"
+	"GoMusic/internal/util/errors"
+)
+
+// maxPathSegmentBytes caps each folder and file name, leaving room for a
+// collision suffix and the extension within common filesystem limits
+const maxPathSegmentBytes = 200
+
+// organizePlaceholderPattern finds placeholders in an organize template
+var organizePlaceholderPattern = regexp.MustCompile(`%([A-Za-z]+)%`)
+
+// organizePlaceholders render a track's fields into an organize template
+var organizePlaceholders = map[string]func(track *model.Track) string{
+	"title": func(track *model.Track) string {
+		if track.Title != "" {
+			return track.Title
+		}
+		return strings.TrimSuffix(filepath.Base(track.FilePath), filepath.Ext(track.FilePath))
+	},
+	"artist": func(track *model.Track) string {
+		return firstNonEmpty(track.Artist, unknownArtist)
+	},
+	"albumartist": func(track *model.Track) string {
+		return firstNonEmpty(track.AlbumArtist, track.Artist, unknownArtist)
+	},
+	"album": func(track *model.Track) string {
+		return firstNonEmpty(track.Album, unknownAlbum)
+	},
+	"genre": func(track *model.Track) string {
+		return track.Genre
+	},
+	"year": func(track *model.Track) string {
+		return positiveNumber(track.Year, "%d")
+	},
+	"track": func(track *model.Track) string {
+		return positiveNumber(track.TrackNumber, "%02d")
+	},
+	"disc": func(track *model.Track) string {
+		return positiveNumber(track.DiscNumber, "%d")
+	},
+}
+
+// trackSidecarExtensions are files named after a track that move with it
+var trackSidecarExtensions = map[string]bool{".lrc": true, ".cue": true}
+
+// folderSidecarExtensions are files of an album's folder, such as cover.jpg,
+// that move when all of the folder's tracks move to one new folder
+var folderSidecarExtensions = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true, ".bmp": true,
+	".cue": true, ".lrc": true,
+}
+
+// windowsReservedNames cannot name a file on Windows, whatever the extension
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// OrganizerService renames and moves a source's files into a layout built from
+// their tags
+// A plan is computed as a dry run first; applying it moves every file or none,
+// and the source keeps the IDs of the moved tracks
+// Runs are journaled while their files move, so Recover can roll back a run
+// the app did not live to finish
+type OrganizerService struct {
+	libraryService *LibraryService
+	journal        repository.MoveJournalRepository
+	mu             sync.Mutex
+}
+
+// NewOrganizerService creates a new organizer service
+func NewOrganizerService(libraryService *LibraryService, journal repository.MoveJournalRepository) *OrganizerService {
+	return &OrganizerService{
+		libraryService: libraryService,
+		journal:        journal,
+	}
+}
+
+// Recover rolls back an organizer run left unfinished by a crash, putting its
+// files back where the library last saw them
+// The journal is kept when a file cannot be restored, to retry on the next start
+func (s *OrganizerService) Recover(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	run, err := s.journal.Get(ctx)
+	if err != nil || run == nil {
+		return err
+	}
+	if err := rollBackMoves(run); err != nil {
+		return fmt.Errorf("failed to roll back the interrupted organize run: %w", err)
+	}
+	return s.journal.Clear(ctx)
+}
+
+// Preview computes the moves that would arrange a source's files by template,
+// without touching them
+// An empty trackIDs covers every track of the source
+func (s *OrganizerService) Preview(ctx context.Context, sourceID, template string, trackIDs []string) (*model.OrganizePlan, error) {
+	plan, _, err := s.plan(ctx, sourceID, template, trackIDs)
+	return plan, err
+}
+
+// Apply recomputes the plan and carries it out
+// When a move fails, or the source cannot record the new paths, every file
+// already moved is put back
+func (s *OrganizerService) Apply(ctx context.Context, sourceID, template string, trackIDs []string) (*model.OrganizePlan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	plan, relocator, err := s.plan(ctx, sourceID, template, trackIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(plan.Moves) == 0 {
+		return plan, nil
+	}
+
+	run := newMoveJournal(sourceID, filepath.Clean(relocator.GetRootPath()), plan.Moves)
+	undo, err := moveFiles(ctx, s.journal, run)
+	if err != nil {
+		return nil, err
+	}
+
+	trackMoves := make(map[string]string)
+	for _, move := range plan.Moves {
+		if len(move.TrackIDs) > 0 {
+			trackMoves[move.From] = move.To
+		}
+	}
+	if err := relocator.RelocateTracks(ctx, trackMoves); err != nil {
+		if undoErr := undo(); undoErr != nil {
+			return nil, fmt.Errorf("%w; restoring the moved files also failed: %v", err, undoErr)
+		}
+		return nil, err
+	}
+	if err := s.journal.Clear(ctx); err != nil {
+		// A stale journal would roll back the finished run at the next start
+		log.Printf("ERROR: %v", err)
+	}
+
+	removeEmptyFolders(run.Root, plan.Moves)
+	return plan, nil
+}
+
+// organizeCandidate is a track file and the path the template gives it
+type organizeCandidate struct {
+	track *model.Track
+	to    string
+}
+
+// plan computes the moves for a source's tracks and their sidecar files
+func (s *OrganizerService) plan(ctx context.Context, sourceID, template string, trackIDs []string) (*model.OrganizePlan, capability.TrackRelocator, error) {
+	layout, err := parseOrganizeTemplate(template)
+	if err != nil {
+		return nil, nil, err
+	}
+	repo, relocator, err := s.libraryService.trackRelocator(sourceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	root := filepath.Clean(relocator.GetRootPath())
+
+	tracks, err := repo.FindAll(ctx, &repository.QueryOptions{Limit: 0})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list tracks: %w", err)
+	}
+	sort.Slice(tracks, func(i, j int) bool { return tracks[i].FilePath < tracks[j].FilePath })
+
+	plan := &model.OrganizePlan{
+		SourceID: sourceID,
+		Template: strings.Join(layout, "/"),
+		Moves:    []*model.FileMove{},
+		Skipped:  []*model.OrganizeSkip{},
+	}
+	skip := func(trackID, filePath, reason string) {
+		plan.Skipped = append(plan.Skipped, &model.OrganizeSkip{TrackID: trackID, FilePath: filePath, Reason: reason})
+	}
+
+	var selected map[string]bool
+	if len(trackIDs) > 0 {
+		selected = make(map[string]bool, len(trackIDs))
+		for _, id := range trackIDs {
+			selected[id] = true
+		}
+	}
+
+	// Folders keeping a file cannot hand their cover or CUE sheet to another folder
+	keptFolders := make(map[string]bool)
+	cueFiles := make(map[string]map[string]bool)
+	var candidates []*organizeCandidate
+	for _, track := range tracks {
+		if selected != nil && !selected[track.ID] {
+			keptFolders[filepath.Dir(track.FilePath)] = true
+			continue
+		}
+		delete(selected, track.ID)
+
+		switch {
+		case track.IsSegment():
+			skip(track.ID, track.FilePath, "tracks split by a CUE sheet are not moved")
+		case !isWithinFolder(root, track.FilePath):
+			skip(track.ID, track.FilePath, "the file is outside the source folder")
+		case isNamedByCueSheet(cueFiles, track.FilePath):
+			skip(track.ID, track.FilePath, "a CUE sheet refers to the file by name")
+		default:
+			to := filepath.Join(root, layout.render(track)) + filepath.Ext(track.FilePath)
+			candidates = append(candidates, &organizeCandidate{track: track, to: to})
+			continue
+		}
+		keptFolders[filepath.Dir(track.FilePath)] = true
+	}
+	for _, id := range trackIDs {
+		if selected[id] {
+			skip(id, "", "track not found in this source")
+			delete(selected, id)
+		}
+	}
+
+	resolver := &pathResolver{claimed: make(map[string]bool), leaving: make(map[string]bool)}
+	for _, c := range candidates {
+		if c.to == c.track.FilePath {
+			resolver.claimed[strings.ToLower(c.to)] = true
+		} else {
+			resolver.leaving[c.track.FilePath] = true
+		}
+	}
+
+	// Collisions are numbered in target order, so repeated runs agree
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].to < candidates[j].to })
+	folderTargets := make(map[string]map[string]bool)
+	var trackMoves []*model.FileMove
+	for _, c := range candidates {
+		from := c.track.FilePath
+		to := c.to
+		if to != from {
+			to = resolver.free(to, from)
+		}
+		if to == from {
+			keptFolders[filepath.Dir(from)] = true
+			continue
+		}
+		resolver.claim(to)
+
+		move := &model.FileMove{From: from, To: to, TrackIDs: []string{c.track.ID}}
+		trackMoves = append(trackMoves, move)
+		dir := filepath.Dir(from)
+		if folderTargets[dir] == nil {
+			folderTargets[dir] = make(map[string]bool)
+		}
+		folderTargets[dir][filepath.Dir(to)] = true
+	}
+	plan.Moves = append(plan.Moves, trackMoves...)
+
+	// Sidecars named after a track follow it and take its new name
+	folders := make(map[string][]string)
+	moved := make(map[string]bool)
+	for _, move := range trackMoves {
+		dir := filepath.Dir(move.From)
+		if _, ok := folders[dir]; !ok {
+			folders[dir] = listFolderFiles(dir)
+		}
+		stem := strings.TrimSuffix(filepath.Base(move.From), filepath.Ext(move.From))
+		newStem := strings.TrimSuffix(filepath.Base(move.To), filepath.Ext(move.To))
+		for _, name := range folders[dir] {
+			ext := filepath.Ext(name)
+			if !trackSidecarExtensions[strings.ToLower(ext)] || strings.TrimSuffix(name, ext) != stem {
+				continue
+			}
+			from := filepath.Join(dir, name)
+			moved[from] = true
+			addSidecar(plan, resolver, from, filepath.Join(filepath.Dir(move.To), newStem+ext))
+		}
+	}
+
+	// Folder sidecars move when the whole folder moves to one place
+	dirs := make([]string, 0, len(folderTargets))
+	for dir := range folderTargets {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		if keptFolders[dir] || len(folderTargets[dir]) != 1 {
+			continue
+		}
+		var target string
+		for target = range folderTargets[dir] {
+		}
+		for _, name := range folders[dir] {
+			from := filepath.Join(dir, name)
+			if moved[from] || !folderSidecarExtensions[strings.ToLower(filepath.Ext(name))] {
+				continue
+			}
+			addSidecar(plan, resolver, from, filepath.Join(target, name))
+		}
+	}
+
+	return plan, relocator, nil
+}
+
+// addSidecar adds a sidecar move, or skips the file when its new path is taken
+// Sidecars are never renumbered; a second cover.jpg would not be found by name
+func addSidecar(plan *model.OrganizePlan, resolver *pathResolver, from, to string) {
+	if from == to {
+		return
+	}
+	if !resolver.isFree(to, from) {
+		plan.Skipped = append(plan.Skipped, &model.OrganizeSkip{
+			FilePath: from,
+			Reason:   "a file named " + filepath.Base(to) + " already exists in the new folder",
+		})
+		return
+	}
+	resolver.claim(to)
+	plan.Moves = append(plan.Moves, &model.FileMove{From: from, To: to})
+}
+
+// pathResolver hands out target paths that no file holds or will hold
+type pathResolver struct {
+	claimed map[string]bool // lowercased, as folders may be case-insensitive
+	leaving map[string]bool // files that move away, freeing their paths
+}
+
+// isFree reports whether a file from may take path
+func (r *pathResolver) isFree(path, from string) bool {
+	if r.claimed[strings.ToLower(path)] {
+		return false
+	}
+	if strings.EqualFold(path, from) || r.leaving[path] {
+		return true
+	}
+	_, err := os.Lstat(path)
+	return os.IsNotExist(err)
+}
+
+// free returns path, or the first numbered variant such as "name (2).ext"
+// that is free
+func (r *pathResolver) free(path, from string) string {
+	ext := filepath.Ext(path)
+	stem := strings.TrimSuffix(path, ext)
+	candidate := path
+	for n := 2; !r.isFree(candidate, from); n++ {
+		candidate = stem + " (" + strconv.Itoa(n) + ")" + ext
+	}
+	return candidate
+}
+
+// claim reserves a path for a planned move
+func (r *pathResolver) claim(path string) {
+	r.claimed[strings.ToLower(path)] = true
+}
+
+// organizeTemplate is a parsed organize template, one entry per path segment
+type organizeTemplate []string
+
+// parseOrganizeTemplate parses a template such as
+// "%albumartist%/%year% - %album%/%disc%-%track% %title%"
+// The template describes a file's path below the source root, without its
+// extension; an empty template selects model.DefaultOrganizeTemplate
+func parseOrganizeTemplate(template string) (organizeTemplate, error) {
+	template = strings.Trim(strings.ReplaceAll(strings.TrimSpace(template), `\`, "/"), "/")
+	if template == "" {
+		template = model.DefaultOrganizeTemplate
+	}
+
+	segments := strings.Split(template, "/")
+	for _, segment := range segments {
+		if trimmed := strings.TrimSpace(segment); trimmed == "" || trimmed == "." || trimmed == ".." {
+			return nil, errors.ValidationError("template", "folder names cannot be empty, \".\" or \"..\"")
+		}
+		for _, match := range organizePlaceholderPattern.FindAllStringSubmatch(segment, -1) {
+			if _, ok := organizePlaceholders[strings.ToLower(match[1])]; !ok {
+				return nil, errors.ValidationError("template", fmt.Sprintf("unknown placeholder %%%s%%", match[1]))
+			}
+		}
+	}
+	if !organizePlaceholderPattern.MatchString(segments[len(segments)-1]) {
+		return nil, errors.ValidationError("template", "the file name needs at least one placeholder")
+	}
+	return segments, nil
+}
+
+// render returns a track's path relative to the source root, without an extension
+func (t organizeTemplate) render(track *model.Track) string {
+	parts := make([]string, len(t))
+	for i, segment := range t {
+		rendered := organizePlaceholderPattern.ReplaceAllStringFunc(segment, func(placeholder string) string {
+			name := strings.ToLower(strings.Trim(placeholder, "%"))
+			return organizePlaceholders[name](track)
+		})
+		parts[i] = cleanPathSegment(rendered)
+	}
+	return filepath.Join(parts...)
+}
+
+// cleanPathSegment makes text safe as one folder or file name
+// Characters filesystems reject become "_", and separators left dangling by
+// empty fields, as in "-05 Title" without a disc number, are trimmed
+func cleanPathSegment(text string) string {
+	text = strings.Map(func(r rune) rune {
+		switch {
+		case r < 0x20 || r == 0x7f:
+			return ' '
+		case strings.ContainsRune(`/\:*?"<>|`, r):
+			return '_'
+		}
+		return r
+	}, text)
+
+	text = strings.Join(strings.Fields(text), " ")
+	for _, empty := range []string{"()", "[]", "{}"} {
+		text = strings.ReplaceAll(text, empty, "")
+	}
+	text = strings.Trim(strings.Join(strings.Fields(text), " "), " .-_")
+
+	for len(text) > maxPathSegmentBytes {
+		_, size := utf8.DecodeLastRuneInString(text)
+		text = strings.TrimRight(text[:len(text)-size], " .")
+	}
+	if text == "" {
+		return "Unknown"
+	}
+
+	name, _, _ := strings.Cut(text, ".")
+	if windowsReservedNames[strings.ToUpper(name)] {
+		text = "_" + text
+	}
+	return text
+}
+
+// newMoveJournal records the moves of a run with the temporary name each file
+// takes within its folder
+func newMoveJournal(sourceID, root string, moves []*model.FileMove) *model.MoveJournal {
+	run := &model.MoveJournal{SourceID: sourceID, Root: root, Phase: model.MovePhaseAside}
+	stamp := time.Now().UnixNano()
+	for i, move := range moves {
+		run.Moves = append(run.Moves, &model.JournaledMove{
+			From: move.From,
+			Temp: filepath.Join(filepath.Dir(move.From), fmt.Sprintf(".gomusic-organize-%d-%d", stamp, i)),
+			To:   move.To,
+		})
+	}
+	return run
+}
+
+// moveFiles moves files as one transaction
+// Every file is first renamed aside within its folder, so a file may take a path
+// another is leaving; when any rename fails, the files already moved are put
+// back. The returned function undoes a completed run
+// The journal is saved before each phase and cleared once the files are back;
+// the caller clears it after a run that stands
+func moveFiles(ctx context.Context, journal repository.MoveJournalRepository, run *model.MoveJournal) (func() error, error) {
+	moves := run.Moves
+	aside, placed := 0, 0
+	var created []string
+
+	undo := func() error {
+		var errs []error
+		for i := placed - 1; i >= 0; i-- {
+			if err := os.Rename(moves[i].To, moves[i].Temp); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		for i := aside - 1; i >= 0; i-- {
+			if err := os.Rename(moves[i].Temp, moves[i].From); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		// Folders created for the moves are removed once empty again
+		for i := len(created) - 1; i >= 0; i-- {
+			os.Remove(created[i])
+		}
+		if len(errs) > 0 {
+			// The journal stays for Recover to finish the job
+			return stderrors.Join(errs...)
+		}
+		return journal.Clear(ctx)
+	}
+	fail := func(err error) (func() error, error) {
+		if undoErr := undo(); undoErr != nil {
+			return nil, fmt.Errorf("%w; restoring the moved files also failed: %v", err, undoErr)
+		}
+		return nil, err
+	}
+
+	if err := journal.Save(ctx, run); err != nil {
+		return nil, fmt.Errorf("failed to journal the moves: %w", err)
+	}
+	for _, move := range moves {
+		if err := os.Rename(move.From, move.Temp); err != nil {
+			return fail(fmt.Errorf("failed to move %s: %w", move.From, err))
+		}
+		aside++
+	}
+
+	run.Phase = model.MovePhasePlace
+	if err := journal.Save(ctx, run); err != nil {
+		return fail(fmt.Errorf("failed to journal the moves: %w", err))
+	}
+	for _, move := range moves {
+		dirs, err := makeFolders(filepath.Dir(move.To))
+		created = append(created, dirs...)
+		if err != nil {
+			return fail(fmt.Errorf("failed to create folder for %s: %w", move.To, err))
+		}
+		// Renaming over a file that appeared since the plan would destroy it
+		if _, err := os.Lstat(move.To); !os.IsNotExist(err) {
+			return fail(fmt.Errorf("cannot move %s: %s already exists", move.From, move.To))
+		}
+		if err := os.Rename(move.Temp, move.To); err != nil {
+			return fail(fmt.Errorf("failed to move %s: %w", move.From, err))
+		}
+		placed++
+	}
+
+	return undo, nil
+}
+
+// rollBackMoves puts the files of a journaled run back at their old paths
+// Files are renamed in phases and in order, so a file whose temporary name is
+// gone reached its new path only when the run was placing files; no rename
+// goes over an existing file
+func rollBackMoves(run *model.MoveJournal) error {
+	var errs []error
+	exists := func(path string) bool {
+		_, err := os.Lstat(path)
+		return err == nil
+	}
+
+	if run.Phase == model.MovePhasePlace {
+		for i := len(run.Moves) - 1; i >= 0; i-- {
+			move := run.Moves[i]
+			if exists(move.Temp) || !exists(move.To) {
+				continue
+			}
+			if err := os.Rename(move.To, move.Temp); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	for i := len(run.Moves) - 1; i >= 0; i-- {
+		move := run.Moves[i]
+		if !exists(move.Temp) {
+			continue
+		}
+		if exists(move.From) {
+			errs = append(errs, fmt.Errorf("cannot restore %s: the path is taken", move.From))
+			continue
+		}
+		if err := os.Rename(move.Temp, move.From); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// Folders made for the new paths go once empty
+	placed := make([]*model.FileMove, len(run.Moves))
+	for i, move := range run.Moves {
+		placed[i] = &model.FileMove{From: move.To}
+	}
+	removeEmptyFolders(run.Root, placed)
+
+	return stderrors.Join(errs...)
+}
+
+// makeFolders creates dir and returns the folders it had to create, parents first
+func makeFolders(dir string) ([]string, error) {
+	var missing []string
+	for current := dir; ; {
+		if _, err := os.Stat(current); err == nil {
+			break
+		}
+		missing = append([]string{current}, missing...)
+		parent := filepath.Dir(current)
+		if parent == current {
+			break
+		}
+		current = parent
+	}
+	return missing, os.MkdirAll(dir, 0755)
+}
+
+// removeEmptyFolders removes the folders files were moved out of, and their
+// parents below root, once nothing is left in them
+func removeEmptyFolders(root string, moves []*model.FileMove) {
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, move := range moves {
+		if dir := filepath.Dir(move.From); !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	// Deeper folders go first so their parents can empty out
+	sort.Slice(dirs, func(i, j int) bool { return len(dirs[i]) > len(dirs[j]) })
+
+	for _, dir := range dirs {
+		for current := dir; isWithinFolder(root, current); current = filepath.Dir(current) {
+			if os.Remove(current) != nil {
+				break
+			}
+		}
+	}
+}
+
+// cueFileCommand finds the FILE commands of a CUE sheet, quoted or not
+var cueFileCommand = regexp.MustCompile(`(?mi)^[ \t]*FILE[ \t]+(?:"([^"]+)"|(\S+))`)
+
+// isNamedByCueSheet reports whether a CUE sheet in the file's folder names it
+// Moving or renaming the file would leave the sheet's FILE line pointing at
+// nothing; sheets are read once per folder into cueFiles
+func isNamedByCueSheet(cueFiles map[string]map[string]bool, filePath string) bool {
+	dir := filepath.Dir(filePath)
+	files, ok := cueFiles[dir]
+	if !ok {
+		files = make(map[string]bool)
+		for _, name := range listFolderFiles(dir) {
+			if !strings.EqualFold(filepath.Ext(name), ".cue") {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				continue
+			}
+			for _, match := range cueFileCommand.FindAllSubmatch(data, -1) {
+				ref := string(match[1]) + string(match[2])
+				ref = filepath.FromSlash(strings.ReplaceAll(ref, `\`, "/"))
+				files[strings.ToLower(filepath.Join(dir, ref))] = true
+			}
+		}
+		cueFiles[dir] = files
+	}
+	return files[strings.ToLower(filePath)]
+}
+
+// listFolderFiles returns the names of the regular files in a folder
+func listFolderFiles(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names
+}
+
+// isWithinFolder reports whether path lies below root
+func isWithinFolder(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// firstNonEmpty returns the first value that is not empty
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// positiveNumber formats n, or returns "" when it is not set
+func positiveNumber(n int, format string) string {
+	if n <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(format, n)
+}
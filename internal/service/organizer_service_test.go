@@ -0,0 +1,334 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"GoMusic/internal/domain/model"
+	"GoMusic/internal/repository/movejournal"
+)
+
+// writeTestFiles creates files below root, each holding its own name
+func writeTestFiles(t *testing.T, root string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// listTestTree returns the files and folders below root, with a trailing
+// slash on folders, checking each file still holds the name it was created as
+func listTestTree(t *testing.T, root string, contents map[string]string) []string {
+	t.Helper()
+	var entries []string
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || path == root {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		rel = filepath.ToSlash(rel)
+		if entry.IsDir() {
+			entries = append(entries, rel+"/")
+			return nil
+		}
+		entries = append(entries, rel)
+		if want, ok := contents[rel]; ok {
+			if data, _ := os.ReadFile(path); string(data) != want {
+				t.Errorf("%s holds %q, want %q", rel, data, want)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(entries)
+	return entries
+}
+
+func TestOrganizerApply(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       []string // created below the root; tracks are in/a.mp3 and in/b.mp3
+		relocateErr error
+		wantErr     bool
+		want        []string          // the tree after the run
+		contents    map[string]string // file contents by path after the run
+		wantB       string            // the path the source records for track b
+	}{
+		{
+			name:  "tracks rendering the same path are numbered",
+			files: []string{"in/a.mp3", "in/b.mp3", "in/b.lrc"},
+			want:  []string{"Band/", "Band/Song (2).lrc", "Band/Song (2).mp3", "Band/Song.mp3"},
+			wantB: "Band/Song (2).mp3",
+			contents: map[string]string{
+				"Band/Song.mp3":     "in/a.mp3",
+				"Band/Song (2).mp3": "in/b.mp3",
+				"Band/Song (2).lrc": "in/b.lrc",
+			},
+		},
+		{
+			name:  "a file already at the path is never replaced",
+			files: []string{"in/a.mp3", "in/b.mp3", "Band/Song.mp3"},
+			want:  []string{"Band/", "Band/Song (2).mp3", "Band/Song (3).mp3", "Band/Song.mp3"},
+			wantB: "Band/Song (3).mp3",
+			contents: map[string]string{
+				"Band/Song.mp3":     "Band/Song.mp3",
+				"Band/Song (2).mp3": "in/a.mp3",
+				"Band/Song (3).mp3": "in/b.mp3",
+			},
+		},
+		{
+			name:        "source failing to record the moves rolls them back",
+			files:       []string{"in/a.mp3", "in/b.mp3", "in/b.lrc"},
+			relocateErr: fmt.Errorf("database is locked"),
+			wantErr:     true,
+			want:        []string{"in/", "in/a.mp3", "in/b.lrc", "in/b.mp3"},
+			wantB:       "in/b.mp3",
+			contents: map[string]string{
+				"in/a.mp3": "in/a.mp3",
+				"in/b.mp3": "in/b.mp3",
+				"in/b.lrc": "in/b.lrc",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			root := t.TempDir()
+			writeTestFiles(t, root, tt.files...)
+			repo, library := newFakeTrackRepository("local", root,
+				&model.Track{ID: "a", FilePath: filepath.Join(root, "in", "a.mp3"), Title: "Song", Artist: "Band"},
+				&model.Track{ID: "b", FilePath: filepath.Join(root, "in", "b.mp3"), Title: "Song", Artist: "Band"},
+			)
+			repo.relocateErr = tt.relocateErr
+			journal := movejournal.NewJSONMoveJournalRepository(filepath.Join(t.TempDir(), "organize-journal.json"))
+			s := NewOrganizerService(library, journal)
+
+			_, err := s.Apply(ctx, "local", "%artist%/%title%", nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got := listTestTree(t, root, tt.contents); !slices.Equal(got, tt.want) {
+				t.Errorf("tree = %v, want %v", got, tt.want)
+			}
+			if run, err := journal.Get(ctx); err != nil || run != nil {
+				t.Errorf("journal = %+v, %v, want it cleared", run, err)
+			}
+			if got := repo.track("b").FilePath; got != filepath.Join(root, tt.wantB) {
+				t.Errorf("track b is recorded at %s, want %s", got, tt.wantB)
+			}
+		})
+	}
+}
+
+func TestMoveFilesTargetTaken(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	writeTestFiles(t, root, "in/a.mp3", "in/b.mp3")
+	journal := movejournal.NewJSONMoveJournalRepository(filepath.Join(t.TempDir(), "organize-journal.json"))
+	run := newMoveJournal("local", root, []*model.FileMove{
+		{From: filepath.Join(root, "in", "a.mp3"), To: filepath.Join(root, "new", "a.mp3")},
+		{From: filepath.Join(root, "in", "b.mp3"), To: filepath.Join(root, "taken", "b.mp3")},
+	})
+
+	// A file appears at a target after the plan was made
+	writeTestFiles(t, root, "taken/b.mp3")
+
+	if _, err := moveFiles(ctx, journal, run); err == nil {
+		t.Fatal("moveFiles() over an existing file succeeded")
+	}
+	want := []string{"in/", "in/a.mp3", "in/b.mp3", "taken/", "taken/b.mp3"}
+	contents := map[string]string{"in/a.mp3": "in/a.mp3", "in/b.mp3": "in/b.mp3", "taken/b.mp3": "taken/b.mp3"}
+	if got := listTestTree(t, root, contents); !slices.Equal(got, want) {
+		t.Errorf("tree = %v, want %v", got, want)
+	}
+	if saved, err := journal.Get(ctx); err != nil || saved != nil {
+		t.Errorf("journal = %+v, %v, want it cleared", saved, err)
+	}
+}
+
+func TestOrganizerRecover(t *testing.T) {
+	tests := []struct {
+		name  string
+		phase model.MovePhase
+		// interrupt leaves the run as a crash would, given its moves
+		interrupt func(moves []*model.JournaledMove) error
+	}{
+		{
+			name:  "crash while moving files aside",
+			phase: model.MovePhaseAside,
+			interrupt: func(moves []*model.JournaledMove) error {
+				return os.Rename(moves[0].From, moves[0].Temp)
+			},
+		},
+		{
+			name:  "crash while placing files",
+			phase: model.MovePhasePlace,
+			interrupt: func(moves []*model.JournaledMove) error {
+				for _, move := range moves {
+					if err := os.Rename(move.From, move.Temp); err != nil {
+						return err
+					}
+				}
+				if err := os.MkdirAll(filepath.Dir(moves[0].To), 0755); err != nil {
+					return err
+				}
+				return os.Rename(moves[0].Temp, moves[0].To)
+			},
+		},
+		{
+			name:      "crash before any file moved",
+			phase:     model.MovePhaseAside,
+			interrupt: func(moves []*model.JournaledMove) error { return nil },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			root := t.TempDir()
+			writeTestFiles(t, root, "in/a.mp3", "in/b.mp3")
+			journal := movejournal.NewJSONMoveJournalRepository(filepath.Join(t.TempDir(), "organize-journal.json"))
+
+			run := newMoveJournal("local", root, []*model.FileMove{
+				{From: filepath.Join(root, "in", "a.mp3"), To: filepath.Join(root, "Band", "Album", "a.mp3")},
+				{From: filepath.Join(root, "in", "b.mp3"), To: filepath.Join(root, "Band", "Album", "b.mp3")},
+			})
+			run.Phase = tt.phase
+			if err := journal.Save(ctx, run); err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.interrupt(run.Moves); err != nil {
+				t.Fatal(err)
+			}
+
+			_, library := newFakeTrackRepository("local", root)
+			if err := NewOrganizerService(library, journal).Recover(ctx); err != nil {
+				t.Fatalf("Recover() error = %v", err)
+			}
+
+			want := []string{"in/", "in/a.mp3", "in/b.mp3"}
+			contents := map[string]string{"in/a.mp3": "in/a.mp3", "in/b.mp3": "in/b.mp3"}
+			if got := listTestTree(t, root, contents); !slices.Equal(got, want) {
+				t.Errorf("tree = %v, want %v", got, want)
+			}
+			if saved, err := journal.Get(ctx); err != nil || saved != nil {
+				t.Errorf("journal = %+v, %v, want it cleared", saved, err)
+			}
+		})
+	}
+}
+
+func TestOrganizerRecoverPathTaken(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	writeTestFiles(t, root, "in/a.mp3")
+	journal := movejournal.NewJSONMoveJournalRepository(filepath.Join(t.TempDir(), "organize-journal.json"))
+
+	run := newMoveJournal("local", root, []*model.FileMove{
+		{From: filepath.Join(root, "in", "a.mp3"), To: filepath.Join(root, "Band", "a.mp3")},
+	})
+	if err := journal.Save(ctx, run); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(run.Moves[0].From, run.Moves[0].Temp); err != nil {
+		t.Fatal(err)
+	}
+	// Another file took the old path since the crash
+	writeTestFiles(t, root, "in/a.mp3")
+
+	_, library := newFakeTrackRepository("local", root)
+	if err := NewOrganizerService(library, journal).Recover(ctx); err == nil {
+		t.Fatal("Recover() over a taken path succeeded")
+	}
+	if _, err := os.Stat(run.Moves[0].Temp); err != nil {
+		t.Errorf("the moved file was lost: %v", err)
+	}
+	if saved, err := journal.Get(ctx); err != nil || saved == nil {
+		t.Errorf("journal = %+v, %v, want it kept for the next start", saved, err)
+	}
+}
+
+func TestOrganizerCueSheetReference(t *testing.T) {
+	tests := []struct {
+		name      string
+		sheet     string
+		wantMoved []string // files the plan moves, relative to the root
+		wantSkip  []string // tracks skipped
+	}{
+		{
+			name:      "quoted file name",
+			sheet:     "TITLE \"Live\"\nFILE \"b.mp3\" MP3\n  TRACK 01 AUDIO\n    INDEX 01 00:00:00\n",
+			wantMoved: []string{"in/a.mp3"},
+			wantSkip:  []string{"b"},
+		},
+		{
+			name:      "unquoted file name in another case",
+			sheet:     "file B.MP3 MP3\r\n  TRACK 01 AUDIO\r\n",
+			wantMoved: []string{"in/a.mp3"},
+			wantSkip:  []string{"b"},
+		},
+		{
+			name:      "several files",
+			sheet:     "FILE \"a.mp3\" MP3\nFILE \"b.mp3\" MP3\n",
+			wantMoved: nil,
+			wantSkip:  []string{"a", "b"},
+		},
+		{
+			name:      "sheet naming another file",
+			sheet:     "FILE \"other.flac\" WAVE\nTITLE \"b.mp3\"\n",
+			wantMoved: []string{"in/a.mp3", "in/b.mp3", "in/live.cue"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeTestFiles(t, root, "in/a.mp3", "in/b.mp3")
+			if err := os.WriteFile(filepath.Join(root, "in", "live.cue"), []byte(tt.sheet), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, library := newFakeTrackRepository("local", root,
+				&model.Track{ID: "a", FilePath: filepath.Join(root, "in", "a.mp3"), Title: "One", Artist: "Band"},
+				&model.Track{ID: "b", FilePath: filepath.Join(root, "in", "b.mp3"), Title: "Two", Artist: "Band"},
+			)
+			journal := movejournal.NewJSONMoveJournalRepository(filepath.Join(t.TempDir(), "organize-journal.json"))
+
+			plan, err := NewOrganizerService(library, journal).Preview(context.Background(), "local", "%artist%/%title%", nil)
+			if err != nil {
+				t.Fatalf("Preview() error = %v", err)
+			}
+
+			var moved, skipped []string
+			for _, move := range plan.Moves {
+				rel, _ := filepath.Rel(root, move.From)
+				moved = append(moved, filepath.ToSlash(rel))
+			}
+			for _, skip := range plan.Skipped {
+				skipped = append(skipped, skip.TrackID)
+			}
+			slices.Sort(moved)
+			slices.Sort(skipped)
+			if !slices.Equal(moved, tt.wantMoved) {
+				t.Errorf("moved = %v, want %v", moved, tt.wantMoved)
+			}
+			if !slices.Equal(skipped, tt.wantSkip) {
+				t.Errorf("skipped = %v, want %v", skipped, tt.wantSkip)
+			}
+		})
+	}
+}
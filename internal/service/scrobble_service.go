@@ -0,0 +1,313 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"GoMusic/internal/domain/model"
+	"GoMusic/internal/scrobble"
+)
+
+// Retry backoff bounds for queued submissions
+const (
+	scrobbleInitialBackoff = 30 * time.Second
+	scrobbleMaxBackoff     = 30 * time.Minute
+)
+
+// ScrobbleStatus describes the current state of play submission
+type ScrobbleStatus struct {
+	Enabled         bool
+	Service         model.ScrobblerService
+	Queued          int
+	Submitted       int
+	LastSubmittedAt time.Time
+	LastError       string
+	NextRetryAt     time.Time
+	AuthFailed      bool
+}
+
+// ScrobbleService records plays, queues them on disk and submits them in the background
+type ScrobbleService struct {
+	configService  *ConfigService
+	libraryService *LibraryService
+	queue          *scrobble.Queue
+
+	client   scrobble.Client
+	status   ScrobbleStatus
+	backoff  time.Duration
+	wake     chan struct{}
+	onStatus func(ScrobbleStatus)
+	mu       sync.Mutex
+}
+
+// NewScrobbleService creates a new scrobble service with its queue stored at queuePath
+func NewScrobbleService(configService *ConfigService, libraryService *LibraryService, queuePath string) (*ScrobbleService, error) {
+	queue, err := scrobble.NewQueue(queuePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ScrobbleService{
+		configService:  configService,
+		libraryService: libraryService,
+		queue:          queue,
+		wake:           make(chan struct{}, 1),
+	}, nil
+}
+
+// SetStatusListener registers a callback invoked whenever the status changes
+func (s *ScrobbleService) SetStatusListener(listener func(ScrobbleStatus)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onStatus = listener
+}
+
+// Start loads the configuration and runs the submission loop until ctx is cancelled
+func (s *ScrobbleService) Start(ctx context.Context) {
+	if err := s.Reload(); err != nil {
+		log.Printf("ERROR: Failed to configure scrobbler: %v", err)
+	}
+
+	go s.run(ctx)
+}
+
+// Reload rebuilds the client from the current configuration and retries immediately
+func (s *ScrobbleService) Reload() error {
+	config := s.configService.GetScrobblerConfig()
+
+	s.mu.Lock()
+	s.client = nil
+	s.backoff = 0
+	s.status.Enabled = config.Enabled
+	s.status.Service = config.Service
+	s.status.AuthFailed = false
+	s.status.LastError = ""
+	s.status.NextRetryAt = time.Time{}
+	s.status.Queued = s.queue.Len()
+
+	var err error
+	if config.Enabled {
+		s.client, err = scrobble.NewClient(config, s.saveSessionKey)
+		if err != nil {
+			s.status.LastError = err.Error()
+		}
+	}
+	s.mu.Unlock()
+
+	s.notify()
+	s.trigger()
+	return err
+}
+
+// saveSessionKey stores the session key a Last.fm client logged in for, so the
+// next start doesn't log in again
+func (s *ScrobbleService) saveSessionKey(sessionKey string) {
+	config := s.configService.GetScrobblerConfig()
+	if config.Service != model.ScrobblerServiceLastFM || config.SessionKey == sessionKey {
+		return
+	}
+
+	config.SessionKey = sessionKey
+	if err := s.configService.UpdateScrobblerConfig(context.Background(), config); err != nil {
+		log.Printf("ERROR: Failed to save Last.fm session: %v", err)
+	}
+}
+
+// NowPlaying announces a track as currently playing
+// Failures are not queued since now-playing notifications are ephemeral
+func (s *ScrobbleService) NowPlaying(ctx context.Context, trackID string) error {
+	client := s.currentClient()
+	if client == nil {
+		return nil
+	}
+
+	track, err := s.libraryService.GetTrackByID(ctx, trackID)
+	if err != nil {
+		return fmt.Errorf("track not found: %w", err)
+	}
+
+	return client.NowPlaying(ctx, model.NewScrobble(track, time.Now()))
+}
+
+// RecordPlay queues a finished play if it satisfies the scrobbling rules
+// Returns true if the play was queued for submission
+func (s *ScrobbleService) RecordPlay(ctx context.Context, trackID string, startedAt time.Time, played time.Duration) (bool, error) {
+	if !s.configService.GetScrobblerConfig().Enabled {
+		return false, nil
+	}
+
+	track, err := s.libraryService.GetTrackByID(ctx, trackID)
+	if err != nil {
+		return false, fmt.Errorf("track not found: %w", err)
+	}
+
+	if !scrobble.ShouldScrobble(track.Duration, played) {
+		return false, nil
+	}
+
+	if err := s.queue.Enqueue(model.NewScrobble(track, startedAt)); err != nil {
+		return false, err
+	}
+
+	s.mu.Lock()
+	s.status.Queued = s.queue.Len()
+	s.mu.Unlock()
+
+	s.notify()
+	s.trigger()
+	return true, nil
+}
+
+// GetStatus returns a snapshot of the submission status
+func (s *ScrobbleService) GetStatus() ScrobbleStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := s.status
+	status.Queued = s.queue.Len()
+	return status
+}
+
+// run is the background submission loop
+func (s *ScrobbleService) run(ctx context.Context) {
+	for {
+		wait := s.flush(ctx)
+
+		var timer <-chan time.Time
+		if wait > 0 {
+			timer = time.After(wait)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-timer:
+		}
+	}
+}
+
+// flush submits queued scrobbles until the queue is empty or a submission fails
+// Returns how long to wait before the next attempt (0 means wait for a trigger)
+func (s *ScrobbleService) flush(ctx context.Context) time.Duration {
+	for {
+		client := s.currentClient()
+		if client == nil {
+			return 0
+		}
+
+		s.mu.Lock()
+		if s.status.AuthFailed {
+			// Wait for new credentials instead of hammering the endpoint
+			s.mu.Unlock()
+			return 0
+		}
+		s.mu.Unlock()
+
+		batch := s.queue.Peek(scrobble.MaxBatchSize)
+		if len(batch) == 0 {
+			return 0
+		}
+
+		err := client.Submit(ctx, batch)
+		if err == nil {
+			if err := s.queue.Remove(len(batch)); err != nil {
+				log.Printf("ERROR: Failed to update scrobble queue: %v", err)
+			}
+			s.recordSuccess(len(batch))
+			continue
+		}
+
+		if ctx.Err() != nil {
+			return 0
+		}
+
+		var submitErr *scrobble.SubmitError
+		if stderrors.As(err, &submitErr) && !submitErr.Retryable && !submitErr.AuthFailed {
+			// The server rejected the batch itself; retrying would fail forever
+			log.Printf("ERROR: Dropping %d rejected scrobbles: %v", len(batch), err)
+			if err := s.queue.Remove(len(batch)); err != nil {
+				log.Printf("ERROR: Failed to update scrobble queue: %v", err)
+			}
+			s.recordFailure(err, false)
+			continue
+		}
+
+		return s.recordFailure(err, true)
+	}
+}
+
+// recordSuccess updates the status after a successful submission
+func (s *ScrobbleService) recordSuccess(count int) {
+	s.mu.Lock()
+	s.backoff = 0
+	s.status.Submitted += count
+	s.status.LastSubmittedAt = time.Now()
+	s.status.LastError = ""
+	s.status.NextRetryAt = time.Time{}
+	s.status.Queued = s.queue.Len()
+	s.mu.Unlock()
+
+	s.notify()
+}
+
+// recordFailure updates the status after a failed submission and returns the backoff delay
+func (s *ScrobbleService) recordFailure(err error, retry bool) time.Duration {
+	s.mu.Lock()
+	s.status.LastError = err.Error()
+	s.status.Queued = s.queue.Len()
+
+	var submitErr *scrobble.SubmitError
+	if stderrors.As(err, &submitErr) && submitErr.AuthFailed {
+		s.status.AuthFailed = true
+		retry = false
+	}
+
+	var wait time.Duration
+	if retry {
+		if s.backoff == 0 {
+			s.backoff = scrobbleInitialBackoff
+		} else {
+			s.backoff *= 2
+			if s.backoff > scrobbleMaxBackoff {
+				s.backoff = scrobbleMaxBackoff
+			}
+		}
+		wait = s.backoff
+		s.status.NextRetryAt = time.Now().Add(wait)
+	}
+	s.mu.Unlock()
+
+	s.notify()
+	return wait
+}
+
+// currentClient returns the configured client, or nil if scrobbling is disabled
+func (s *ScrobbleService) currentClient() scrobble.Client {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.client
+}
+
+// trigger wakes the submission loop without blocking
+func (s *ScrobbleService) trigger() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// notify sends the current status to the registered listener
+func (s *ScrobbleService) notify() {
+	s.mu.Lock()
+	listener := s.onStatus
+	s.mu.Unlock()
+
+	if listener != nil {
+		listener(s.GetStatus())
+	}
+}
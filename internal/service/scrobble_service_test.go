@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"GoMusic/internal/domain/model"
+	"GoMusic/internal/scrobble"
+)
+
+// fakeSubmitter is a scrobble client failing with the queued errors, one per
+// submission, before it accepts everything
+type fakeSubmitter struct {
+	errs    []error
+	batches []int // size of each submission, failed or not
+}
+
+func (f *fakeSubmitter) Submit(ctx context.Context, scrobbles []*model.Scrobble) error {
+	f.batches = append(f.batches, len(scrobbles))
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return err
+	}
+	return nil
+}
+
+func (f *fakeSubmitter) NowPlaying(ctx context.Context, scrobble *model.Scrobble) error {
+	return nil
+}
+
+func TestScrobbleFlush(t *testing.T) {
+	unavailable := &scrobble.SubmitError{StatusCode: 503, Message: "unavailable", Retryable: true}
+	rejected := &scrobble.SubmitError{StatusCode: 400, Message: "bad request"}
+	unauthorized := &scrobble.SubmitError{StatusCode: 401, Message: "invalid token", AuthFailed: true}
+
+	tests := []struct {
+		name        string
+		queued      int
+		errs        []error
+		flushes     int             // flush calls, as the loop makes them after each wait
+		wantWaits   []time.Duration // returned by each flush
+		wantBatches []int
+		wantQueued  int
+		wantAuth    bool
+	}{
+		{
+			name:        "batches of at most 50",
+			queued:      120,
+			flushes:     1,
+			wantWaits:   []time.Duration{0},
+			wantBatches: []int{50, 50, 20},
+		},
+		{
+			name:        "retryable failures back off and keep the queue",
+			queued:      3,
+			errs:        []error{unavailable, unavailable, fmt.Errorf("connection refused")},
+			flushes:     4,
+			wantWaits:   []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 0},
+			wantBatches: []int{3, 3, 3, 3},
+		},
+		{
+			name:        "rejected batch is dropped",
+			queued:      60,
+			errs:        []error{rejected},
+			flushes:     1,
+			wantWaits:   []time.Duration{0},
+			wantBatches: []int{50, 10},
+		},
+		{
+			name:        "auth failure waits for new credentials",
+			queued:      3,
+			errs:        []error{unauthorized},
+			flushes:     2,
+			wantWaits:   []time.Duration{0, 0},
+			wantBatches: []int{3},
+			wantQueued:  3,
+			wantAuth:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewScrobbleService(nil, nil, filepath.Join(t.TempDir(), "scrobbles.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			submitter := &fakeSubmitter{errs: tt.errs}
+			s.client = submitter
+			for i := range tt.queued {
+				if err := s.queue.Enqueue(&model.Scrobble{TrackID: fmt.Sprint(i)}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for i := range tt.flushes {
+				if wait := s.flush(context.Background()); wait != tt.wantWaits[i] {
+					t.Errorf("flush %d waits %v, want %v", i+1, wait, tt.wantWaits[i])
+				}
+			}
+
+			if fmt.Sprint(submitter.batches) != fmt.Sprint(tt.wantBatches) {
+				t.Errorf("batches = %v, want %v", submitter.batches, tt.wantBatches)
+			}
+			status := s.GetStatus()
+			if status.Queued != tt.wantQueued || status.AuthFailed != tt.wantAuth {
+				t.Errorf("status = %+v, want %d queued, auth failed %v", status, tt.wantQueued, tt.wantAuth)
+			}
+		})
+	}
+}
+
+func TestScrobbleBackoffCap(t *testing.T) {
+	s, err := NewScrobbleService(nil, nil, filepath.Join(t.TempDir(), "scrobbles.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wait time.Duration
+	for range 10 {
+		wait = s.recordFailure(fmt.Errorf("offline"), true)
+	}
+	if wait != scrobbleMaxBackoff {
+		t.Fatalf("backoff after 10 failures = %v, want %v", wait, scrobbleMaxBackoff)
+	}
+
+	s.recordSuccess(1)
+	if wait := s.recordFailure(fmt.Errorf("offline"), true); wait != scrobbleInitialBackoff {
+		t.Fatalf("backoff after a success = %v, want %v", wait, scrobbleInitialBackoff)
+	}
+}
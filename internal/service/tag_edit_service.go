@@ -0,0 +1,482 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"GoMusic/internal/domain/model"
+	"GoMusic/internal/domain/repository"
+	"GoMusic/internal/util/errors"
+)
+
+// unknownArtist is the placeholder sources use for tracks without an artist tag
+const unknownArtist = "Unknown Artist"
+
+// tagFields lists the editable fields in the order diffs are reported
+var tagFields = []model.TagField{
+	model.TagFieldTitle,
+	model.TagFieldArtist,
+	model.TagFieldAlbum,
+	model.TagFieldAlbumArtist,
+	model.TagFieldGenre,
+	model.TagFieldYear,
+	model.TagFieldTrackNumber,
+	model.TagFieldDiscNumber,
+}
+
+// titleCaseMinorWords stay lowercase in title case unless they start or end the text
+var titleCaseMinorWords = map[string]bool{
+	"a": true, "an": true, "and": true, "as": true, "at": true, "but": true, "by": true,
+	"for": true, "from": true, "in": true, "into": true, "nor": true, "of": true, "on": true,
+	"or": true, "the": true, "to": true, "vs": true, "vs.": true, "with": true,
+}
+
+// TagEditService edits the tags of many tracks at once
+// A batch is previewed as a diff per file, then written through the tracks'
+// sources and recorded in the journal so it can be undone
+type TagEditService struct {
+	libraryService *LibraryService
+	journal        repository.TagJournalRepository
+	mu             sync.Mutex
+}
+
+// NewTagEditService creates a new tag edit service
+func NewTagEditService(libraryService *LibraryService, journal repository.TagJournalRepository) *TagEditService {
+	return &TagEditService{
+		libraryService: libraryService,
+		journal:        journal,
+	}
+}
+
+// PreviewBatch computes the changes operations would make to each track without
+// writing anything
+// Operations run in order, each seeing the result of the previous ones; tracks
+// are numbered in the order of trackIDs
+func (s *TagEditService) PreviewBatch(ctx context.Context, trackIDs []string, ops []model.TagOperation) ([]*model.TagEditPreview, error) {
+	plan, err := compileTagOperations(ops)
+	if err != nil {
+		return nil, err
+	}
+
+	previews := make([]*model.TagEditPreview, 0, len(trackIDs))
+	for i, trackID := range trackIDs {
+		preview := &model.TagEditPreview{TrackID: trackID, Changes: []model.TagFieldChange{}}
+		previews = append(previews, preview)
+
+		track, err := s.libraryService.GetTrackByID(ctx, trackID)
+		if err != nil {
+			preview.Error = "track not found"
+			continue
+		}
+		preview.FilePath = track.FilePath
+
+		changes, err := plan.apply(track, i)
+		if err != nil {
+			preview.Error = err.Error()
+			continue
+		}
+		preview.Changes = changes
+		if len(changes) > 0 {
+			if err := s.libraryService.CanEditTrackMetadata(track); err != nil {
+				preview.Error = err.Error()
+			}
+		}
+	}
+
+	return previews, nil
+}
+
+// ApplyBatch writes the changes of a batch to the files and journals them
+// Files that fail are recorded with their error and the rest are still written
+// Returns the journal record; a batch that changes nothing is not journaled
+func (s *TagEditService) ApplyBatch(ctx context.Context, trackIDs []string, ops []model.TagOperation) (*model.TagEditBatch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previews, err := s.PreviewBatch(ctx, trackIDs, ops)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := newTagEditBatch()
+	for _, preview := range previews {
+		if preview.Error == "" && len(preview.Changes) > 0 {
+			batch.Entries = append(batch.Entries, &model.TagEditEntry{
+				TrackID:  preview.TrackID,
+				FilePath: preview.FilePath,
+				Changes:  preview.Changes,
+			})
+		}
+	}
+	return s.applyBatch(ctx, batch)
+}
+
+// ApplyInferredTags writes the values inferred from the tracks' paths into their
+// files as real tags, journaled like any other batch
+func (s *TagEditService) ApplyInferredTags(ctx context.Context, trackIDs []string) (*model.TagEditBatch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	batch := newTagEditBatch()
+	for _, trackID := range trackIDs {
+		track, err := s.libraryService.GetTrackByID(ctx, trackID)
+		if err != nil || len(track.InferredFields) == 0 || s.libraryService.CanEditTrackMetadata(track) != nil {
+			continue
+		}
+
+		// The tags were missing, so writing them replaces nothing
+		entry := &model.TagEditEntry{TrackID: trackID, FilePath: track.FilePath}
+		for _, field := range track.InferredFields {
+			entry.Changes = append(entry.Changes, model.TagFieldChange{Field: field, New: trackFieldValue(track, field)})
+		}
+		batch.Entries = append(batch.Entries, entry)
+	}
+
+	return s.applyBatch(ctx, batch)
+}
+
+// newTagEditBatch creates an empty batch with a new ID
+func newTagEditBatch() *model.TagEditBatch {
+	now := time.Now()
+	return &model.TagEditBatch{
+		ID:        fmt.Sprintf("batch_%d", now.UnixNano()),
+		CreatedAt: now,
+		Entries:   []*model.TagEditEntry{},
+	}
+}
+
+// applyBatch journals a batch and writes its entries to the files
+func (s *TagEditService) applyBatch(ctx context.Context, batch *model.TagEditBatch) (*model.TagEditBatch, error) {
+	if len(batch.Entries) == 0 {
+		return batch, nil
+	}
+
+	// The batch is journaled before any file is written, so an interrupted batch
+	// can still be undone
+	if err := s.journal.Save(ctx, batch); err != nil {
+		return nil, err
+	}
+
+	for _, entry := range batch.Entries {
+		if ctx.Err() != nil {
+			entry.Error = "cancelled"
+			continue
+		}
+		changes, err := tagChangesFor(entry.Changes)
+		if err == nil {
+			_, err = s.libraryService.UpdateTrackMetadata(ctx, entry.TrackID, changes)
+		}
+		if err != nil {
+			entry.Error = err.Error()
+			continue
+		}
+		entry.Applied = true
+	}
+
+	if err := s.journal.Save(ctx, batch); err != nil {
+		return batch, err
+	}
+	return batch, nil
+}
+
+// UndoBatch restores the values a batch replaced
+// Fields edited again since the batch keep their newer values
+func (s *TagEditService) UndoBatch(ctx context.Context, batchID string) (*model.TagEditBatch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	batch, err := s.journal.Get(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	if batch == nil {
+		return nil, errors.ErrNotFound
+	}
+	if batch.UndoneAt != nil {
+		return nil, fmt.Errorf("batch %s has already been undone", batchID)
+	}
+
+	for i := len(batch.Entries) - 1; i >= 0; i-- {
+		// Entries that failed or were interrupted are checked too: a field that
+		// was never written does not hold the new value, so it is skipped
+		entry := batch.Entries[i]
+		track, err := s.libraryService.GetTrackByID(ctx, entry.TrackID)
+		if err != nil {
+			entry.Error = "track no longer in library"
+			continue
+		}
+
+		var restore []model.TagFieldChange
+		for _, change := range entry.Changes {
+			if tagFieldValue(track, change.Field) == change.New {
+				restore = append(restore, model.TagFieldChange{Field: change.Field, Old: change.New, New: change.Old})
+			}
+		}
+		if len(restore) == 0 {
+			continue
+		}
+
+		changes, err := tagChangesFor(restore)
+		if err == nil {
+			_, err = s.libraryService.UpdateTrackMetadata(ctx, entry.TrackID, changes)
+		}
+		if err != nil {
+			entry.Error = err.Error()
+			continue
+		}
+		entry.Applied = false
+	}
+
+	now := time.Now()
+	batch.UndoneAt = &now
+	if err := s.journal.Save(ctx, batch); err != nil {
+		return batch, err
+	}
+	return batch, nil
+}
+
+// GetHistory returns the journaled batches, newest first
+func (s *TagEditService) GetHistory(ctx context.Context) ([]*model.TagEditBatch, error) {
+	return s.journal.GetAll(ctx)
+}
+
+// tagEditPlan is a validated list of operations
+type tagEditPlan struct {
+	ops      []model.TagOperation
+	patterns []*regexp.Regexp // compiled Find of regex operations, by index
+}
+
+// compileTagOperations validates operations and compiles their patterns
+func compileTagOperations(ops []model.TagOperation) (*tagEditPlan, error) {
+	plan := &tagEditPlan{ops: ops, patterns: make([]*regexp.Regexp, len(ops))}
+	for i, op := range ops {
+		if !isTagField(op.Field) {
+			return nil, errors.ValidationError("field", fmt.Sprintf("unknown tag field %q", op.Field))
+		}
+		switch op.Type {
+		case model.TagOpSet, model.TagOpClear, model.TagOpReplace, model.TagOpTitleCase:
+		case model.TagOpRegex:
+			pattern, err := regexp.Compile(op.Find)
+			if err != nil {
+				return nil, errors.ValidationError("find", err.Error())
+			}
+			plan.patterns[i] = pattern
+		case model.TagOpNumber:
+			if !isNumericTagField(op.Field) {
+				return nil, errors.ValidationError("field", fmt.Sprintf("%s cannot be numbered", op.Field))
+			}
+		default:
+			return nil, errors.ValidationError("type", fmt.Sprintf("unknown operation %q", op.Type))
+		}
+	}
+	return plan, nil
+}
+
+// apply runs the operations on a track's values and returns the fields that change
+// index is the track's position in the batch, used for numbering
+func (p *tagEditPlan) apply(track *model.Track, index int) ([]model.TagFieldChange, error) {
+	values := make(map[model.TagField]string)
+	for i, op := range p.ops {
+		value, ok := values[op.Field]
+		if !ok {
+			value = tagFieldValue(track, op.Field)
+		}
+
+		switch op.Type {
+		case model.TagOpSet:
+			value = op.Value
+		case model.TagOpClear:
+			value = ""
+		case model.TagOpReplace:
+			if op.Find != "" {
+				value = strings.ReplaceAll(value, op.Find, op.Replace)
+			}
+		case model.TagOpRegex:
+			value = p.patterns[i].ReplaceAllString(value, op.Replace)
+		case model.TagOpNumber:
+			start := op.Start
+			if start <= 0 {
+				start = 1
+			}
+			value = strconv.Itoa(start + index)
+		case model.TagOpTitleCase:
+			value = titleCase(value)
+		}
+		values[op.Field] = strings.TrimSpace(value)
+	}
+
+	changes := []model.TagFieldChange{}
+	for _, field := range tagFields {
+		value, ok := values[field]
+		old := tagFieldValue(track, field)
+		if !ok || value == old {
+			continue
+		}
+		if isNumericTagField(field) && value != "" {
+			if n, err := strconv.Atoi(value); err != nil || n < 0 {
+				return nil, fmt.Errorf("%s must be a number, got %q", field, value)
+			}
+		}
+		changes = append(changes, model.TagFieldChange{Field: field, Old: old, New: value})
+	}
+	return changes, nil
+}
+
+// tagFieldValue returns a field's value as tagged in the track's file
+// Values inferred from the path, the filename standing in for a missing title
+// and the placeholders for a missing artist or album are not in the file, so
+// they read as empty
+func tagFieldValue(track *model.Track, field model.TagField) string {
+	if track.IsInferred(field) {
+		return ""
+	}
+	value := trackFieldValue(track, field)
+	switch field {
+	case model.TagFieldTitle:
+		if track.FilePath != "" && value == strings.TrimSuffix(filepath.Base(track.FilePath), filepath.Ext(track.FilePath)) {
+			return ""
+		}
+	case model.TagFieldArtist:
+		if value == unknownArtist {
+			return ""
+		}
+	case model.TagFieldAlbum:
+		if value == unknownAlbum {
+			return ""
+		}
+	}
+	return value
+}
+
+// trackFieldValue returns a track's value of a field as text, as the library
+// shows it; zero numbers read as empty
+func trackFieldValue(track *model.Track, field model.TagField) string {
+	number := func(n int) string {
+		if n <= 0 {
+			return ""
+		}
+		return strconv.Itoa(n)
+	}
+
+	switch field {
+	case model.TagFieldTitle:
+		return track.Title
+	case model.TagFieldArtist:
+		return track.Artist
+	case model.TagFieldAlbum:
+		return track.Album
+	case model.TagFieldAlbumArtist:
+		return track.AlbumArtist
+	case model.TagFieldGenre:
+		return track.Genre
+	case model.TagFieldYear:
+		return number(track.Year)
+	case model.TagFieldTrackNumber:
+		return number(track.TrackNumber)
+	case model.TagFieldDiscNumber:
+		return number(track.DiscNumber)
+	}
+	return ""
+}
+
+// tagChangesFor converts field changes to the tag changes written to a file
+func tagChangesFor(changes []model.TagFieldChange) (*model.TagChanges, error) {
+	result := &model.TagChanges{}
+	for _, change := range changes {
+		value := change.New
+		number := 0
+		if isNumericTagField(change.Field) && value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be a number, got %q", change.Field, value)
+			}
+			number = n
+		}
+
+		switch change.Field {
+		case model.TagFieldTitle:
+			result.Title = &value
+		case model.TagFieldArtist:
+			result.Artist = &value
+		case model.TagFieldAlbum:
+			result.Album = &value
+		case model.TagFieldAlbumArtist:
+			result.AlbumArtist = &value
+		case model.TagFieldGenre:
+			result.Genre = &value
+		case model.TagFieldYear:
+			result.Year = &number
+		case model.TagFieldTrackNumber:
+			result.TrackNumber = &number
+		case model.TagFieldDiscNumber:
+			result.DiscNumber = &number
+		}
+	}
+	return result, nil
+}
+
+// isTagField reports whether field is an editable tag field
+func isTagField(field model.TagField) bool {
+	for _, known := range tagFields {
+		if field == known {
+			return true
+		}
+	}
+	return false
+}
+
+// isNumericTagField reports whether a field holds a number
+func isNumericTagField(field model.TagField) bool {
+	return field == model.TagFieldYear || field == model.TagFieldTrackNumber || field == model.TagFieldDiscNumber
+}
+
+// titleCase capitalizes the first letter of each word, keeping short joining
+// words lowercase except at the start and end
+// Other letters are kept, so acronyms survive; text in capitals is lowercased first
+func titleCase(text string) string {
+	if text == strings.ToUpper(text) {
+		text = strings.ToLower(text)
+	}
+
+	words := strings.Split(text, " ")
+	first, last := -1, -1
+	for i, word := range words {
+		if word != "" {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+
+	for i, word := range words {
+		if word == "" {
+			continue
+		}
+		// A word after a colon starts a subtitle
+		afterColon := i > first && strings.HasSuffix(words[i-1], ":")
+		if i != first && i != last && !afterColon && titleCaseMinorWords[strings.ToLower(word)] {
+			words[i] = strings.ToLower(word)
+			continue
+		}
+		// Capitalize the first letter, skipping leading punctuation such as "("
+		for pos, r := range word {
+			if unicode.IsLetter(r) {
+				words[i] = word[:pos] + string(unicode.ToUpper(r)) + word[pos+utf8.RuneLen(r):]
+				break
+			}
+			if unicode.IsDigit(r) {
+				break
+			}
+		}
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"path/filepath"
+	"testing"
+
+	"GoMusic/internal/domain/model"
+	"GoMusic/internal/repository/tagjournal"
+	"GoMusic/internal/util/errors"
+)
+
+// newTagEditTest creates a tag edit service over two tracks, journaling to a
+// temporary file
+func newTagEditTest(t *testing.T) (*TagEditService, *fakeTrackRepository) {
+	t.Helper()
+	repo, library := newFakeTrackRepository("local", "/music",
+		&model.Track{ID: "one", FilePath: "/music/one.mp3", Title: "First", Artist: "Band"},
+		&model.Track{ID: "two", FilePath: "/music/two.mp3", Title: "Second", Artist: "Band", Year: 1999},
+	)
+	journal, err := tagjournal.NewJSONTagJournalRepository(filepath.Join(t.TempDir(), "tag-journal.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewTagEditService(library, journal), repo
+}
+
+func TestUndoBatch(t *testing.T) {
+	ctx := context.Background()
+	ops := []model.TagOperation{
+		{Field: model.TagFieldTitle, Type: model.TagOpSet, Value: "Renamed"},
+		{Field: model.TagFieldYear, Type: model.TagOpSet, Value: "2001"},
+	}
+
+	type want struct {
+		title string
+		year  int
+	}
+
+	tests := []struct {
+		name      string
+		setUp     func(repo *fakeTrackRepository) // before the batch
+		afterEdit func(t *testing.T, s *TagEditService, repo *fakeTrackRepository)
+		want      map[string]want
+		wantError map[string]string // entry errors after the undo, by track
+		wantWrite map[string]int    // tag writes by track, batch and undo included
+	}{
+		{
+			name:      "restores the replaced values",
+			want:      map[string]want{"one": {"First", 0}, "two": {"Second", 1999}},
+			wantWrite: map[string]int{"one": 2, "two": 2},
+		},
+		{
+			name: "keeps fields edited since",
+			afterEdit: func(t *testing.T, s *TagEditService, repo *fakeTrackRepository) {
+				title := "Edited Later"
+				if _, err := s.libraryService.UpdateTrackMetadata(ctx, "one", &model.TagChanges{Title: &title}); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want:      map[string]want{"one": {"Edited Later", 0}, "two": {"Second", 1999}},
+			wantWrite: map[string]int{"one": 3, "two": 2},
+		},
+		{
+			name:      "skips files the batch failed to write",
+			setUp:     func(repo *fakeTrackRepository) { repo.failTags["two"] = true },
+			want:      map[string]want{"one": {"First", 0}, "two": {"Second", 1999}},
+			wantError: map[string]string{"two": "cannot write /music/two.mp3"},
+			wantWrite: map[string]int{"one": 2},
+		},
+		{
+			name: "track removed from the library",
+			afterEdit: func(t *testing.T, s *TagEditService, repo *fakeTrackRepository) {
+				repo.Delete(ctx, "two")
+			},
+			want:      map[string]want{"one": {"First", 0}},
+			wantError: map[string]string{"two": "track no longer in library"},
+			wantWrite: map[string]int{"one": 2, "two": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo := newTagEditTest(t)
+			if tt.setUp != nil {
+				tt.setUp(repo)
+			}
+
+			batch, err := s.ApplyBatch(ctx, []string{"one", "two"}, ops)
+			if err != nil {
+				t.Fatalf("ApplyBatch() error = %v", err)
+			}
+			if tt.afterEdit != nil {
+				tt.afterEdit(t, s, repo)
+			}
+
+			undone, err := s.UndoBatch(ctx, batch.ID)
+			if err != nil {
+				t.Fatalf("UndoBatch() error = %v", err)
+			}
+			if undone.UndoneAt == nil {
+				t.Error("the batch is not marked undone")
+			}
+
+			for id, w := range tt.want {
+				if track := repo.track(id); track.Title != w.title || track.Year != w.year {
+					t.Errorf("track %s = %q %d, want %q %d", id, track.Title, track.Year, w.title, w.year)
+				}
+			}
+			for _, entry := range undone.Entries {
+				if entry.Error != tt.wantError[entry.TrackID] {
+					t.Errorf("entry %s error = %q, want %q", entry.TrackID, entry.Error, tt.wantError[entry.TrackID])
+				}
+			}
+			for _, id := range []string{"one", "two"} {
+				if repo.writes[id] != tt.wantWrite[id] {
+					t.Errorf("track %s written %d times, want %d", id, repo.writes[id], tt.wantWrite[id])
+				}
+			}
+
+			// The undo is journaled and cannot run twice
+			history, err := s.GetHistory(ctx)
+			if err != nil || len(history) != 1 || history[0].UndoneAt == nil {
+				t.Errorf("GetHistory() = %v, %v, want the undone batch", history, err)
+			}
+			if _, err := s.UndoBatch(ctx, batch.ID); err == nil {
+				t.Error("a second UndoBatch() succeeded")
+			}
+		})
+	}
+}
+
+func TestUndoBatchUnknown(t *testing.T) {
+	s, _ := newTagEditTest(t)
+	if _, err := s.UndoBatch(context.Background(), "batch_0"); !stderrors.Is(err, errors.ErrNotFound) {
+		t.Fatalf("UndoBatch() error = %v, want %v", err, errors.ErrNotFound)
+	}
+}
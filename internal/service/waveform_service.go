@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"GoMusic/internal/analysis"
+	"GoMusic/internal/decoder"
+	"GoMusic/internal/domain/model"
+	"GoMusic/internal/domain/repository"
+)
+
+// WaveformResolution is the number of buckets computed and cached per track
+// Requests for fewer buckets are resampled from the cached data
+const WaveformResolution = 2048
+
+// waveformTimeout bounds a waveform computation, which runs on after the
+// request that started it gives up so that other waiters still get the result
+const waveformTimeout = 10 * time.Minute
+
+// WaveformService computes and caches waveform envelopes for seek bars
+// Waveforms are computed on first request and stored as one JSON file per track
+type WaveformService struct {
+	libraryService *LibraryService
+	cacheDir       string
+
+	pending map[string]*waveformJob
+	running bool
+	mu      sync.Mutex
+}
+
+// waveformJob lets concurrent requests for the same track share one computation
+type waveformJob struct {
+	done     chan struct{}
+	waveform *model.Waveform
+	err      error
+}
+
+// NewWaveformService creates a new waveform service caching into cacheDir
+func NewWaveformService(libraryService *LibraryService, cacheDir string) *WaveformService {
+	return &WaveformService{
+		libraryService: libraryService,
+		cacheDir:       cacheDir,
+		pending:        make(map[string]*waveformJob),
+	}
+}
+
+// GetWaveform returns a track's waveform resampled to the given number of buckets
+// Computes and caches the waveform when no current cached copy exists
+func (s *WaveformService) GetWaveform(ctx context.Context, trackID string, buckets int) (*model.Waveform, error) {
+	track, err := s.libraryService.GetTrackByID(ctx, trackID)
+	if err != nil {
+		return nil, err
+	}
+
+	waveform, err := s.waveform(ctx, track, false)
+	if err != nil {
+		return nil, err
+	}
+	return waveform.Resample(buckets), nil
+}
+
+// Pregenerate computes missing waveforms for every decodable local track
+// Work is throttled like the loudness analysis; only one run happens at a time
+func (s *WaveformService) Pregenerate(ctx context.Context) {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		s.running = false
+		s.mu.Unlock()
+	}()
+
+	tracks, err := s.libraryService.GetAllTracks(ctx, &repository.QueryOptions{Limit: 0})
+	if err != nil {
+		log.Printf("ERROR: Failed to list tracks for waveforms: %v", err)
+		return
+	}
+
+	for _, track := range tracks {
+		if ctx.Err() != nil {
+			return
+		}
+		if track.FilePath == "" || !decoder.CanDecode(track.FilePath) {
+			continue
+		}
+		if _, err := s.waveform(ctx, track, true); err != nil && ctx.Err() == nil {
+			log.Printf("WARN: Failed to generate waveform for %s: %v", track.FilePath, err)
+		}
+	}
+}
+
+// waveform returns the cached waveform of a track, computing it if needed
+func (s *WaveformService) waveform(ctx context.Context, track *model.Track, throttle bool) (*model.Waveform, error) {
+	if cached := s.load(track); cached != nil {
+		return cached, nil
+	}
+
+	s.mu.Lock()
+	job, ok := s.pending[track.ID]
+	if !ok {
+		job = &waveformJob{done: make(chan struct{})}
+		s.pending[track.ID] = job
+		go s.run(ctx, job, track, throttle)
+	}
+	s.mu.Unlock()
+
+	// Each caller stops waiting on its own context; the job is shared
+	select {
+	case <-job.done:
+		return job.waveform, job.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// run computes and caches a waveform for everyone waiting on job
+// It is detached from the starting request's cancellation, so one caller
+// giving up does not fail the others
+func (s *WaveformService) run(ctx context.Context, job *waveformJob, track *model.Track, throttle bool) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), waveformTimeout)
+	defer cancel()
+
+	job.waveform, job.err = s.compute(ctx, track, throttle)
+	if job.err == nil {
+		if err := s.save(job.waveform); err != nil {
+			log.Printf("WARN: Failed to cache waveform for %s: %v", track.ID, err)
+		}
+	}
+
+	s.mu.Lock()
+	delete(s.pending, track.ID)
+	s.mu.Unlock()
+	close(job.done)
+}
+
+// compute decodes a track and measures its envelope
+func (s *WaveformService) compute(ctx context.Context, track *model.Track, throttle bool) (*model.Waveform, error) {
+	if track.FilePath == "" {
+		return nil, fmt.Errorf("waveforms are only available for local files")
+	}
+
+	reader, err := openTrackSamples(track)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	format := reader.Format()
+	meter := analysis.NewWaveformMeter(format.Channels, reader.Frames(), WaveformResolution)
+	buf := make([]float64, analysisChunkFrames*format.Channels)
+	var frames int64
+
+	for {
+		start := time.Now()
+		n, err := reader.Read(buf)
+		meter.Process(buf[:n])
+		frames += int64(n / format.Channels)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		pause := time.Duration(0)
+		if throttle {
+			pause = time.Duration(float64(time.Since(start)) * analysisThrottle)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(pause):
+		}
+	}
+
+	peaks, rms := meter.Result()
+	return &model.Waveform{
+		TrackID:    track.ID,
+		FileSize:   track.FileSize,
+		ModifiedAt: track.ModifiedAt,
+		Duration:   float64(frames) / float64(format.SampleRate),
+		Peaks:      peaks,
+		RMS:        rms,
+	}, nil
+}
+
+// openTrackSamples opens a track for analysis, limited to its segment for CUE sheet tracks
+func openTrackSamples(track *model.Track) (*decoder.SampleReader, error) {
+	if !track.IsSegment() {
+		return decoder.OpenSampleReader(track.FilePath)
+	}
+	dec, err := decoder.OpenSegment(track.FilePath, track.SegmentStart, track.Duration)
+	if err != nil {
+		return nil, err
+	}
+	return decoder.NewSampleReader(dec), nil
+}
+
+// load returns the cached waveform of a track, or nil if it is missing or stale
+func (s *WaveformService) load(track *model.Track) *model.Waveform {
+	data, err := os.ReadFile(s.cachePath(track.ID))
+	if err != nil {
+		return nil
+	}
+
+	var waveform model.Waveform
+	if err := json.Unmarshal(data, &waveform); err != nil || !waveform.IsCurrent(track) {
+		return nil
+	}
+	return &waveform
+}
+
+// save writes a waveform to the cache atomically via a temp file and rename
+func (s *WaveformService) save(waveform *model.Waveform) error {
+	if err := os.MkdirAll(s.cacheDir, 0755); err != nil {
+		return fmt.Errorf("failed to create waveform directory: %w", err)
+	}
+
+	data, err := json.Marshal(waveform)
+	if err != nil {
+		return fmt.Errorf("failed to marshal waveform: %w", err)
+	}
+
+	path := s.cachePath(waveform.TrackID)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write waveform: %w", err)
+	}
+	return os.Rename(tmpPath, path)
+}
+
+func (s *WaveformService) cachePath(trackID string) string {
+	return filepath.Join(s.cacheDir, filepath.Base(trackID)+".json")
+}
@@ -0,0 +1,217 @@
+package artwork
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// touchInterval is how stale a cached file's modification time may get before
+// storing the same image again refreshes it
+// The artwork cache evicts least recently stored files first when over its size cap
+const touchInterval = 24 * time.Hour
+
+// indexFilename names the file mapping server image keys to cache filenames;
+// hidden, so the artwork cache's cleanup leaves it alone
+const indexFilename = ".keys.json"
+
+// index maps the keys of remote images to the cache files they were stored as,
+// so an image is only downloaded once
+var index struct {
+	entries map[string]string
+	mu      sync.Mutex
+}
+
+// Dir returns the artwork cache directory, ~/.gomusic/artwork/, creating it when
+// missing
+func Dir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	artworkDir := filepath.Join(homeDir, ".gomusic", "artwork")
+	if err := os.MkdirAll(artworkDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create artwork directory: %w", err)
+	}
+	return artworkDir, nil
+}
+
+// Store writes image data into the artwork cache and returns its cache filename
+// Files are named after a hash of their content, so tracks, albums and artists
+// sharing an image share one file
+func Store(data []byte, ext string) (string, error) {
+	artworkDir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(data)
+	filename := hex.EncodeToString(hash[:16]) + ext
+	artworkPath := filepath.Join(artworkDir, filename)
+
+	if info, err := os.Stat(artworkPath); err == nil && info.Size() == int64(len(data)) {
+		if now := time.Now(); now.Sub(info.ModTime()) > touchInterval {
+			_ = os.Chtimes(artworkPath, now, now)
+		}
+		return filename, nil
+	}
+
+	if err := writeFile(artworkDir, artworkPath, data); err != nil {
+		return "", fmt.Errorf("failed to write artwork file: %w", err)
+	}
+	return filename, nil
+}
+
+// Touch reports whether a cache file exists, marking it as recently stored so
+// that it is evicted last
+func Touch(filename string) bool {
+	artworkDir, err := Dir()
+	if err != nil {
+		return false
+	}
+	artworkPath := filepath.Join(artworkDir, filename)
+	info, err := os.Stat(artworkPath)
+	if err != nil {
+		return false
+	}
+	if now := time.Now(); now.Sub(info.ModTime()) > touchInterval {
+		_ = os.Chtimes(artworkPath, now, now)
+	}
+	return true
+}
+
+// Lookup returns the cache file a remote image was stored as under key, or ""
+// when it has to be downloaded (again)
+func Lookup(key string) string {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+
+	if err := loadIndex(); err != nil {
+		return ""
+	}
+	filename := index.entries[key]
+	if filename == "" || !Touch(filename) {
+		return ""
+	}
+	return filename
+}
+
+// StoreKeyed stores a downloaded remote image like Store and remembers it under
+// key for Lookup
+func StoreKeyed(key string, data []byte, ext string) (string, error) {
+	filename, err := Store(data, ext)
+	if err != nil {
+		return "", err
+	}
+
+	index.mu.Lock()
+	defer index.mu.Unlock()
+
+	if err := loadIndex(); err != nil {
+		return "", err
+	}
+	if index.entries[key] == filename {
+		return filename, nil
+	}
+	index.entries[key] = filename
+	if err := saveIndex(); err != nil {
+		// The image is cached; it is only downloaded again next time
+		fmt.Printf("Failed to save artwork index: %v\n", err)
+	}
+	return filename, nil
+}
+
+// Extension returns the file extension for an image MIME type
+func Extension(mimeType string) string {
+	switch strings.TrimSpace(strings.Split(mimeType, ";")[0]) {
+	case "image/png":
+		return ".png"
+	case "image/gif":
+		return ".gif"
+	case "image/bmp":
+		return ".bmp"
+	case "image/webp":
+		return ".webp"
+	default:
+		return ".jpg" // Default to JPEG
+	}
+}
+
+// loadIndex reads the key index once, dropping keys whose files are gone
+// Callers hold index.mu
+func loadIndex() error {
+	if index.entries != nil {
+		return nil
+	}
+
+	artworkDir, err := Dir()
+	if err != nil {
+		return err
+	}
+
+	entries := make(map[string]string)
+	data, err := os.ReadFile(filepath.Join(artworkDir, indexFilename))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read artwork index: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &entries); err != nil {
+			// A damaged index only costs downloads
+			entries = make(map[string]string)
+		}
+	}
+	for key, filename := range entries {
+		if _, err := os.Stat(filepath.Join(artworkDir, filename)); err != nil {
+			delete(entries, key)
+		}
+	}
+
+	index.entries = entries
+	return nil
+}
+
+// saveIndex writes the key index
+// Callers hold index.mu
+func saveIndex() error {
+	artworkDir, err := Dir()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(index.entries)
+	if err != nil {
+		return fmt.Errorf("failed to marshal artwork index: %w", err)
+	}
+	return writeFile(artworkDir, filepath.Join(artworkDir, indexFilename), data)
+}
+
+// writeFile writes data beside path first and renames it into place, so a
+// reader never sees a partial file
+func writeFile(dir, path string, data []byte) error {
+	tmpFile, err := os.CreateTemp(dir, ".artwork-*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+	_, err = tmpFile.Write(data)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+	}
+	return err
+}
@@ -1,4 +1,4 @@
-package filesystem
+package cache
 
 import (
 	"sort"
@@ -45,10 +45,12 @@ func (c *TrackCache) GetAll(opts *repository.QueryOptions) []*model.Track {
 		opts = repository.DefaultQueryOptions()
 	}
 
-	// Collect all tracks
+	// Collect all tracks matching the filters
 	allTracks := make([]*model.Track, 0, len(c.tracks))
 	for _, track := range c.tracks {
-		allTracks = append(allTracks, track)
+		if matchesFilters(track, opts.Filters) {
+			allTracks = append(allTracks, track)
+		}
 	}
 
 	// Apply sorting
@@ -85,7 +87,7 @@ func (c *TrackCache) Search(query string, opts *repository.SearchOptions) []*mod
 	var results []*model.Track
 
 	for _, track := range c.tracks {
-		if c.matchesQuery(track, query, opts.Fields) {
+		if c.matchesQuery(track, query, opts.Fields) && matchesFilters(track, opts.Filters) {
 			results = append(results, track)
 		}
 	}
@@ -224,6 +226,10 @@ func (c *TrackCache) sortTracks(tracks []*model.Track, sortBy, sortOrder string)
 			less = tracks[i].Duration < tracks[j].Duration
 		case "addedAt":
 			less = tracks[i].AddedAt.Before(tracks[j].AddedAt)
+		case "bpm":
+			less = tracks[i].BPM < tracks[j].BPM
+		case "key":
+			less = tracks[i].Key.CamelotOrder() < tracks[j].Key.CamelotOrder()
 		default:
 			less = tracks[i].Title < tracks[j].Title
 		}
@@ -233,4 +239,30 @@ func (c *TrackCache) sortTracks(tracks []*model.Track, sortBy, sortOrder string)
 		}
 		return less
 	})
-}
\ No newline at end of file
+}
+// matchesFilters checks a track against QueryOptions filters
+// Supported: "bpmMin"/"bpmMax" (numbers), "key" (exact key in any notation) and
+// "harmonicKey" (keys that mix harmonically with the given one)
+func matchesFilters(track *model.Track, filters map[string]interface{}) bool {
+	for name, value := range filters {
+		switch name {
+		case "bpmMin":
+			if lower, ok := value.(float64); ok && track.BPM < lower {
+				return false
+			}
+		case "bpmMax":
+			if upper, ok := value.(float64); ok && (track.BPM == 0 || track.BPM > upper) {
+				return false
+			}
+		case "key":
+			if key, ok := value.(string); ok && track.Key != model.ParseMusicalKey(key) {
+				return false
+			}
+		case "harmonicKey":
+			if key, ok := value.(string); ok && !track.Key.IsHarmonicWith(model.ParseMusicalKey(key)) {
+				return false
+			}
+		}
+	}
+	return true
+}
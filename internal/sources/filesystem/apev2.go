@@ -0,0 +1,211 @@
+package filesystem
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/dhowden/tag"
+)
+
+// apeTagFooterSize is the size of the APEv2 header and footer
+const apeTagFooterSize = 32
+
+// maxAPETagSize caps the tag read into memory; cover art makes up most of it
+const maxAPETagSize = 32 << 20
+
+var errNoAPETag = errors.New("no APEv2 tag")
+
+// apeItem is one APEv2 tag item
+type apeItem struct {
+	Value  []byte
+	Binary bool // binary items hold cover art; text items are UTF-8
+}
+
+// readAPEv2 reads the APEv2 tag at the end of a file, before any ID3v1 tag
+// Keys are lowercased; APEv2 keys are case-insensitive
+func readAPEv2(r io.ReadSeeker, size int64) (map[string]apeItem, error) {
+	end := size
+	if end >= 128 {
+		id3v1 := make([]byte, 3)
+		if _, err := r.Seek(end-128, io.SeekStart); err == nil {
+			if _, err := io.ReadFull(r, id3v1); err == nil && string(id3v1) == "TAG" {
+				end -= 128
+			}
+		}
+	}
+	if end < apeTagFooterSize {
+		return nil, errNoAPETag
+	}
+
+	footer := make([]byte, apeTagFooterSize)
+	if _, err := r.Seek(end-apeTagFooterSize, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(r, footer); err != nil || string(footer[:8]) != "APETAGEX" {
+		return nil, errNoAPETag
+	}
+
+	// The size counts the items and footer but not the optional header
+	tagSize := int64(binary.LittleEndian.Uint32(footer[12:16]))
+	count := int(binary.LittleEndian.Uint32(footer[16:20]))
+	if tagSize < apeTagFooterSize || tagSize > end || tagSize > maxAPETagSize {
+		return nil, errNoAPETag
+	}
+
+	data := make([]byte, tagSize-apeTagFooterSize)
+	if _, err := r.Seek(end-tagSize, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+
+	items := make(map[string]apeItem, count)
+	for i := 0; i < count && len(data) >= 9; i++ {
+		valueSize := int(binary.LittleEndian.Uint32(data[0:4]))
+		flags := binary.LittleEndian.Uint32(data[4:8])
+		keyEnd := bytes.IndexByte(data[8:], 0)
+		if keyEnd < 0 || 8+keyEnd+1+valueSize > len(data) {
+			break
+		}
+		key := strings.ToLower(string(data[8 : 8+keyEnd]))
+		value := data[8+keyEnd+1 : 8+keyEnd+1+valueSize]
+		items[key] = apeItem{Value: value, Binary: flags>>1&3 == 1}
+		data = data[8+keyEnd+1+valueSize:]
+	}
+	return items, nil
+}
+
+// apeMetadata exposes an APEv2 tag as tag.Metadata
+type apeMetadata struct {
+	items    map[string]apeItem
+	format   string // container: wv or ape
+	fileType tag.FileType
+}
+
+// readAPEMetadata reads the tags of a WavPack or Monkey's Audio file: APEv2,
+// falling back to ID3v1, and an empty tag when there is neither so the file is
+// still recorded with its format
+func readAPEMetadata(r io.ReadSeeker, size int64, format string) (tag.Metadata, error) {
+	items, err := readAPEv2(r, size)
+	if err == nil {
+		return &apeMetadata{items: items, format: format, fileType: tag.FileType(strings.ToUpper(format))}, nil
+	}
+	if m, err := tag.ReadID3v1Tags(r); err == nil {
+		return m, nil
+	}
+	return &apeMetadata{format: format, fileType: tag.FileType(strings.ToUpper(format))}, nil
+}
+
+// text returns a text item, or "" for missing and binary items
+func (m *apeMetadata) text(key string) string {
+	item, ok := m.items[key]
+	if !ok || item.Binary {
+		return ""
+	}
+	// Multiple values are NUL-separated; the first is shown
+	value, _, _ := strings.Cut(string(item.Value), "\x00")
+	return strings.TrimSpace(value)
+}
+
+// number parses "n" or "n/total"
+func (m *apeMetadata) number(key string) (int, int) {
+	number, total, _ := strings.Cut(m.text(key), "/")
+	n, _ := strconv.Atoi(strings.TrimSpace(number))
+	t, _ := strconv.Atoi(strings.TrimSpace(total))
+	return n, t
+}
+
+func (m *apeMetadata) Format() tag.Format {
+	return tag.Format(m.format)
+}
+
+func (m *apeMetadata) FileType() tag.FileType {
+	return m.fileType
+}
+
+func (m *apeMetadata) Title() string {
+	return m.text("title")
+}
+
+func (m *apeMetadata) Album() string {
+	return m.text("album")
+}
+
+func (m *apeMetadata) Artist() string {
+	return m.text("artist")
+}
+
+func (m *apeMetadata) AlbumArtist() string {
+	if value := m.text("album artist"); value != "" {
+		return value
+	}
+	return m.text("albumartist")
+}
+
+func (m *apeMetadata) Composer() string {
+	return m.text("composer")
+}
+
+func (m *apeMetadata) Genre() string {
+	return m.text("genre")
+}
+
+func (m *apeMetadata) Comment() string {
+	return m.text("comment")
+}
+
+func (m *apeMetadata) Lyrics() string {
+	return m.text("lyrics")
+}
+
+// Year reads the leading digits of Year, which may be a full date
+func (m *apeMetadata) Year() int {
+	year := m.text("year")
+	if len(year) < 4 {
+		return 0
+	}
+	n, _ := strconv.Atoi(year[:4])
+	return n
+}
+
+func (m *apeMetadata) Track() (int, int) {
+	return m.number("track")
+}
+
+func (m *apeMetadata) Disc() (int, int) {
+	return m.number("disc")
+}
+
+// Picture returns the front cover, stored as a file name, NUL, then the image
+func (m *apeMetadata) Picture() *tag.Picture {
+	item, ok := m.items["cover art (front)"]
+	if !ok || !item.Binary {
+		return nil
+	}
+	_, data, found := bytes.Cut(item.Value, []byte{0})
+	if !found || len(data) == 0 {
+		return nil
+	}
+
+	// The file name is unreliable; the image signature decides the type
+	if bytes.HasPrefix(data, []byte("\x89PNG")) {
+		return &tag.Picture{Ext: "png", MIMEType: "image/png", Type: "Cover (front)", Data: data}
+	}
+	return &tag.Picture{Ext: "jpg", MIMEType: "image/jpeg", Type: "Cover (front)", Data: data}
+}
+
+// Raw returns the text items, keyed by lowercase name
+func (m *apeMetadata) Raw() map[string]interface{} {
+	raw := make(map[string]interface{}, len(m.items))
+	for key, item := range m.items {
+		if !item.Binary {
+			raw[key] = m.text(key)
+		}
+	}
+	return raw
+}
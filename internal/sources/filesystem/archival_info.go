@@ -0,0 +1,410 @@
+package filesystem
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/dhowden/tag"
+
+	"GoMusic/internal/decoder"
+)
+
+// WavPack header flags
+const (
+	wavPackBytesStored = 0x3
+	wavPackMono        = 0x4
+	wavPackHybrid      = 0x8
+	wavPackFloat       = 0x80
+	wavPackShiftLSB    = 13
+	wavPackRateLSB     = 23
+	wavPackDSD         = 0x80000000
+)
+
+// WavPack metadata sub-block IDs
+const (
+	wavPackIDLarge       = 0x80
+	wavPackIDOddSize     = 0x40
+	wavPackIDUnique      = 0x3f
+	wavPackIDChannelInfo = 0x0d
+	wavPackIDSampleRate  = 0x27
+)
+
+// wavPackSampleRates indexes the rates a WavPack header can encode; 15 means custom
+var wavPackSampleRates = [15]int{6000, 8000, 9600, 11025, 12000, 16000, 22050, 24000, 32000, 44100, 48000, 64000, 88200, 96000, 192000}
+
+// wavPackSearchLimit is how far into the file the first block is looked for
+const wavPackSearchLimit = 1 << 20
+
+var errNotArchivalFormat = errors.New("unrecognised WavPack, Monkey's Audio or DSD header")
+
+// archivalInfo describes a WavPack, Monkey's Audio, DSF or DSDIFF stream
+type archivalInfo struct {
+	Codec      string // WavPack, Monkey's Audio, DSD or DST
+	Lossless   bool
+	SampleRate int
+	Channels   int
+	BitDepth   int   // 1 for DSD
+	Frames     int64 // samples per channel
+	AudioBytes int64
+
+	// DST-compressed DSDIFF counts frames at a fixed rate instead of samples
+	DSTFrames    int64
+	DSTFrameRate int
+}
+
+// Duration returns the playing time
+func (i *archivalInfo) Duration() time.Duration {
+	if i.DSTFrameRate > 0 {
+		return time.Duration(float64(i.DSTFrames) / float64(i.DSTFrameRate) * float64(time.Second))
+	}
+	if i.SampleRate == 0 {
+		return 0
+	}
+	return time.Duration(float64(i.Frames) / float64(i.SampleRate) * float64(time.Second))
+}
+
+// BitRate returns the average bit rate in kbps
+func (i *archivalInfo) BitRate() int {
+	seconds := i.Duration().Seconds()
+	if seconds <= 0 {
+		return 0
+	}
+	return int(float64(i.AudioBytes)*8/seconds/1000 + 0.5)
+}
+
+// readWavPackInfo parses the first WavPack block header and its metadata sub-blocks
+// Hybrid files are lossy unless a .wvc correction file accompanies them, which
+// the caller checks
+func readWavPackInfo(r io.ReadSeeker, size int64) (*archivalInfo, bool, error) {
+	head := make([]byte, min(size, wavPackSearchLimit))
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, false, err
+	}
+	if _, err := io.ReadFull(r, head); err != nil {
+		return nil, false, err
+	}
+
+	start := bytes.Index(head, []byte("wvpk"))
+	if start < 0 || start+32 > len(head) {
+		return nil, false, errNotArchivalFormat
+	}
+	block := head[start:]
+	blockSize := min(int64(binary.LittleEndian.Uint32(block[4:8]))+8, int64(len(block)))
+	if blockSize < 32 {
+		return nil, false, errNotArchivalFormat
+	}
+	// 40-bit count, split as in libwavpack's GET_TOTAL_SAMPLES; all ones means unknown
+	totalSamples := int64(binary.LittleEndian.Uint32(block[12:16]))
+	if totalSamples == 0xffffffff {
+		totalSamples = 0
+	} else {
+		totalSamples += int64(block[11])<<32 - int64(block[11])
+	}
+	flags := binary.LittleEndian.Uint32(block[24:28])
+
+	info := &archivalInfo{
+		Codec:    "WavPack",
+		Lossless: true,
+		Channels: 2,
+		Frames:   totalSamples,
+		BitDepth: int(flags&wavPackBytesStored+1)*8 - int(flags>>wavPackShiftLSB&0x1f),
+	}
+	if flags&wavPackMono != 0 {
+		info.Channels = 1
+	}
+	if flags&wavPackFloat != 0 {
+		info.BitDepth = 32
+	}
+	if index := flags >> wavPackRateLSB & 0xf; index < 15 {
+		info.SampleRate = wavPackSampleRates[index]
+	}
+
+	// Sub-blocks carry the channel count of multichannel files and custom rates
+	for data := block[32:blockSize]; len(data) >= 2; {
+		id := data[0]
+		length, header := int(data[1])*2, 2
+		if id&wavPackIDLarge != 0 {
+			if len(data) < 4 {
+				break
+			}
+			length = (int(data[1]) | int(data[2])<<8 | int(data[3])<<16) * 2
+			header = 4
+		}
+		if header+length > len(data) {
+			break
+		}
+		body := data[header : header+length]
+		if id&wavPackIDOddSize != 0 && length > 0 {
+			body = body[:length-1]
+		}
+
+		switch id & wavPackIDUnique {
+		case wavPackIDChannelInfo:
+			if len(body) > 0 {
+				info.Channels = int(body[0])
+			}
+		case wavPackIDSampleRate:
+			if len(body) >= 3 {
+				info.SampleRate = int(body[0]) | int(body[1])<<8 | int(body[2])<<16
+			}
+		}
+		data = data[header+length:]
+	}
+
+	// DSD audio stores bytes of eight 1-bit samples at an eighth of the rate
+	if flags&wavPackDSD != 0 {
+		info.Codec = "WavPack DSD"
+		info.BitDepth = 1
+		info.SampleRate *= 8
+		info.Frames *= 8
+	}
+
+	info.AudioBytes = audioEnd(r, size) - int64(start)
+	return info, flags&wavPackHybrid != 0, nil
+}
+
+// readMonkeysAudioInfo parses the Monkey's Audio descriptor and header
+// Version 3.98 added a descriptor ahead of the header; older files use one combined header
+func readMonkeysAudioInfo(r io.ReadSeeker, size int64) (*archivalInfo, error) {
+	start := decoder.SkipID3v2(r)
+	head := make([]byte, 76)
+	if _, err := r.Seek(start, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if n, _ := io.ReadFull(r, head); n < 32 || string(head[:4]) != "MAC " {
+		return nil, errNotArchivalFormat
+	}
+
+	version := int(binary.LittleEndian.Uint16(head[4:6]))
+	var compression, formatFlags, channels, sampleRate, blocksPerFrame, finalFrameBlocks, totalFrames, bits int
+
+	if version >= 3980 {
+		descriptorBytes := int(binary.LittleEndian.Uint32(head[8:12]))
+		if descriptorBytes+24 > len(head) {
+			return nil, errNotArchivalFormat
+		}
+		header := head[descriptorBytes:]
+		compression = int(binary.LittleEndian.Uint16(header[0:2]))
+		formatFlags = int(binary.LittleEndian.Uint16(header[2:4]))
+		blocksPerFrame = int(binary.LittleEndian.Uint32(header[4:8]))
+		finalFrameBlocks = int(binary.LittleEndian.Uint32(header[8:12]))
+		totalFrames = int(binary.LittleEndian.Uint32(header[12:16]))
+		bits = int(binary.LittleEndian.Uint16(header[16:18]))
+		channels = int(binary.LittleEndian.Uint16(header[18:20]))
+		sampleRate = int(binary.LittleEndian.Uint32(header[20:24]))
+	} else {
+		compression = int(binary.LittleEndian.Uint16(head[6:8]))
+		formatFlags = int(binary.LittleEndian.Uint16(head[8:10]))
+		channels = int(binary.LittleEndian.Uint16(head[10:12]))
+		sampleRate = int(binary.LittleEndian.Uint32(head[12:16]))
+		totalFrames = int(binary.LittleEndian.Uint32(head[24:28]))
+		finalFrameBlocks = int(binary.LittleEndian.Uint32(head[28:32]))
+
+		switch {
+		case version >= 3950:
+			blocksPerFrame = 73728 * 4
+		case version >= 3900 || version >= 3800 && compression == 4000:
+			blocksPerFrame = 73728
+		default:
+			blocksPerFrame = 9216
+		}
+		switch {
+		case formatFlags&0x1 != 0:
+			bits = 8
+		case formatFlags&0x8 != 0:
+			bits = 24
+		default:
+			bits = 16
+		}
+	}
+	if channels == 0 || sampleRate == 0 {
+		return nil, errNotArchivalFormat
+	}
+
+	info := &archivalInfo{
+		Codec:      "Monkey's Audio",
+		Lossless:   true,
+		SampleRate: sampleRate,
+		Channels:   channels,
+		BitDepth:   bits,
+		AudioBytes: audioEnd(r, size) - start,
+	}
+	if totalFrames > 0 {
+		info.Frames = int64(totalFrames-1)*int64(blocksPerFrame) + int64(finalFrameBlocks)
+	}
+	return info, nil
+}
+
+// readDSFInfo parses the DSD and fmt chunks of a Sony DSF file
+func readDSFInfo(r io.ReadSeeker, size int64) (*archivalInfo, error) {
+	head := make([]byte, 92)
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(r, head); err != nil {
+		return nil, err
+	}
+	if string(head[0:4]) != "DSD " || string(head[28:32]) != "fmt " {
+		return nil, errNotArchivalFormat
+	}
+
+	dataSize := int64(binary.LittleEndian.Uint64(head[84:92])) - 12
+	if string(head[80:84]) != "data" {
+		dataSize = 0
+	}
+	return &archivalInfo{
+		Codec:      "DSD",
+		Lossless:   true,
+		Channels:   int(binary.LittleEndian.Uint32(head[52:56])),
+		SampleRate: int(binary.LittleEndian.Uint32(head[56:60])),
+		BitDepth:   1,
+		Frames:     int64(binary.LittleEndian.Uint64(head[64:72])),
+		AudioBytes: min(max(dataSize, 0), size),
+	}, nil
+}
+
+// dffInfo holds the DSDIFF stream properties and the metadata chunks found alongside
+type dffInfo struct {
+	archivalInfo
+	Title  string
+	Artist string
+	ID3    []byte // body of an "ID3 " chunk
+}
+
+// readDFFInfo walks the chunks of a Philips DSDIFF file
+func readDFFInfo(r io.ReadSeeker, size int64) (*dffInfo, error) {
+	head := make([]byte, 16)
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(r, head); err != nil {
+		return nil, err
+	}
+	if string(head[0:4]) != "FRM8" || string(head[12:16]) != "DSD " {
+		return nil, errNotArchivalFormat
+	}
+
+	info := &dffInfo{archivalInfo: archivalInfo{Codec: "DSD", Lossless: true, BitDepth: 1}}
+	chunkHeader := make([]byte, 12)
+	for offset := int64(16); offset+12 <= size; {
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		if _, err := io.ReadFull(r, chunkHeader); err != nil {
+			break
+		}
+		id := string(chunkHeader[:4])
+		chunkSize := int64(binary.BigEndian.Uint64(chunkHeader[4:12]))
+		if chunkSize < 0 {
+			return nil, errNotArchivalFormat
+		}
+		chunkSize = min(chunkSize, size-offset-12)
+
+		switch id {
+		case "PROP":
+			parseDFFProperties(info, readChunkBody(r, min(chunkSize, 1<<16)))
+		case "DSD ":
+			info.AudioBytes = chunkSize
+		case "DST ":
+			info.Codec = "DST"
+			info.AudioBytes = chunkSize
+			if frte := readChunkBody(r, min(chunkSize, 18)); len(frte) == 18 && string(frte[:4]) == "FRTE" {
+				info.DSTFrames = int64(binary.BigEndian.Uint32(frte[12:16]))
+				info.DSTFrameRate = int(binary.BigEndian.Uint16(frte[16:18]))
+			}
+		case "DIIN":
+			if chunkSize <= maxTextChunkSize {
+				parseDFFEditedMaster(info, readChunkBody(r, chunkSize))
+			}
+		case "ID3 ":
+			if chunkSize <= maxTextChunkSize {
+				info.ID3 = readChunkBody(r, chunkSize)
+			}
+		}
+		offset += 12 + chunkSize + chunkSize&1
+	}
+
+	if info.Channels == 0 || info.SampleRate == 0 {
+		return nil, errNotArchivalFormat
+	}
+	if info.Codec == "DSD" {
+		info.Frames = info.AudioBytes * 8 / int64(info.Channels)
+	}
+	return info, nil
+}
+
+// parseDFFProperties reads the sample rate, channels and compression from a PROP chunk
+func parseDFFProperties(info *dffInfo, prop []byte) {
+	if len(prop) < 4 || string(prop[:4]) != "SND " {
+		return
+	}
+	forEachDFFChunk(prop[4:], func(id string, body []byte) {
+		switch id {
+		case "FS  ":
+			if len(body) >= 4 {
+				info.SampleRate = int(binary.BigEndian.Uint32(body))
+			}
+		case "CHNL":
+			if len(body) >= 2 {
+				info.Channels = int(binary.BigEndian.Uint16(body))
+			}
+		case "CMPR":
+			if len(body) >= 4 && string(body[:4]) == "DST " {
+				info.Codec = "DST"
+			}
+		}
+	})
+}
+
+// parseDFFEditedMaster reads the title and artist from a DIIN chunk
+// Each text sub-chunk holds a 32-bit length and the text
+func parseDFFEditedMaster(info *dffInfo, diin []byte) {
+	forEachDFFChunk(diin, func(id string, body []byte) {
+		if len(body) < 4 {
+			return
+		}
+		length := min(int(binary.BigEndian.Uint32(body)), len(body)-4)
+		switch id {
+		case "DITI":
+			info.Title = decodeChunkText(body[4 : 4+length])
+		case "DIAR":
+			info.Artist = decodeChunkText(body[4 : 4+length])
+		}
+	})
+}
+
+// forEachDFFChunk calls fn for each chunk (ID, 64-bit big-endian size) in data
+func forEachDFFChunk(data []byte, fn func(id string, body []byte)) {
+	for len(data) >= 12 {
+		size := binary.BigEndian.Uint64(data[4:12])
+		if size > uint64(len(data)-12) {
+			return
+		}
+		fn(string(data[:4]), data[12:12+size])
+		data = data[min(12+size+size&1, uint64(len(data))):]
+	}
+}
+
+// readDFFMetadata reads the tags of a DSDIFF file: an ID3 chunk when present,
+// with the edited master title and artist filling its gaps
+func readDFFMetadata(r io.ReadSeeker, size int64) (tag.Metadata, error) {
+	info, err := readDFFInfo(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	// The AIFF-style reader already merges an ID3 tag over text chunks
+	m := &pcmMetadata{info: &pcmInfo{PCMInfo: decoder.PCMInfo{Container: "dff"}, Text: map[string]string{
+		"NAME": info.Title,
+		"AUTH": info.Artist,
+	}}}
+	if len(info.ID3) > 0 {
+		if id3, err := tag.ReadID3v2Tags(bytes.NewReader(info.ID3)); err == nil {
+			m.id3 = id3
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,176 @@
+package filesystem
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// wavPackBlock builds a block header with the given flags and sample count,
+// followed by metadata sub-blocks
+func wavPackBlock(flags uint32, totalSamples uint32, subBlocks ...[]byte) []byte {
+	body := bytes.Join(subBlocks, nil)
+	block := make([]byte, 32)
+	copy(block, "wvpk")
+	binary.LittleEndian.PutUint32(block[4:8], uint32(24+len(body)))
+	binary.LittleEndian.PutUint16(block[8:10], 0x410)
+	binary.LittleEndian.PutUint32(block[12:16], totalSamples)
+	binary.LittleEndian.PutUint32(block[24:28], flags)
+	return append(block, body...)
+}
+
+func TestReadWavPackInfo(t *testing.T) {
+	// 16-bit at 44.1 kHz (rate index 9)
+	flags := uint32(1 | 9<<wavPackRateLSB)
+
+	undersized := wavPackBlock(flags, 44100)
+	binary.LittleEndian.PutUint32(undersized[4:8], 8)
+
+	tests := []struct {
+		name       string
+		data       []byte
+		want       *archivalInfo
+		wantHybrid bool
+		wantErr    bool
+	}{
+		{
+			name: "16-bit stereo",
+			data: wavPackBlock(flags, 44100, make([]byte, 100)),
+			want: &archivalInfo{Codec: "WavPack", Lossless: true, SampleRate: 44100, Channels: 2, BitDepth: 16, Frames: 44100, AudioBytes: 132},
+		},
+		{
+			name:       "leading junk, mono hybrid",
+			data:       append([]byte("junk"), wavPackBlock(flags|wavPackMono|wavPackHybrid, 44100)...),
+			want:       &archivalInfo{Codec: "WavPack", Lossless: true, SampleRate: 44100, Channels: 1, BitDepth: 16, Frames: 44100, AudioBytes: 32},
+			wantHybrid: true,
+		},
+		{
+			name: "custom rate and channel count sub-blocks",
+			data: wavPackBlock(1|15<<wavPackRateLSB, 1000,
+				[]byte{wavPackIDSampleRate | wavPackIDOddSize, 2, 0x80, 0xbb, 0x00, 0},
+				[]byte{wavPackIDChannelInfo, 1, 6, 0}),
+			want: &archivalInfo{Codec: "WavPack", Lossless: true, SampleRate: 48000, Channels: 6, BitDepth: 16, Frames: 1000, AudioBytes: 42},
+		},
+		{
+			name: "unknown sample count",
+			data: wavPackBlock(flags, 0xffffffff),
+			want: &archivalInfo{Codec: "WavPack", Lossless: true, SampleRate: 44100, Channels: 2, BitDepth: 16, AudioBytes: 32},
+		},
+		{
+			name: "sub-block longer than the block",
+			data: wavPackBlock(flags, 44100, []byte{wavPackIDChannelInfo | wavPackIDLarge, 0xff, 0xff, 0xff}),
+			want: &archivalInfo{Codec: "WavPack", Lossless: true, SampleRate: 44100, Channels: 2, BitDepth: 16, Frames: 44100, AudioBytes: 36},
+		},
+		{name: "block size below the header", data: undersized, wantErr: true},
+		{name: "truncated header", data: wavPackBlock(flags, 44100)[:20], wantErr: true},
+		{name: "no block", data: bytes.Repeat([]byte{0}, 64), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, hybrid, err := readWavPackInfo(bytes.NewReader(tt.data), int64(len(tt.data)))
+			if tt.wantErr {
+				if !errors.Is(err, errNotArchivalFormat) {
+					t.Fatalf("readWavPackInfo() error = %v, want %v", err, errNotArchivalFormat)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readWavPackInfo() error = %v", err)
+			}
+			if *got != *tt.want || hybrid != tt.wantHybrid {
+				t.Fatalf("readWavPackInfo() = %+v, %v, want %+v, %v", got, hybrid, tt.want, tt.wantHybrid)
+			}
+		})
+	}
+}
+
+// dffChunk builds a DSDIFF chunk with a 64-bit size
+func dffChunk(id string, parts ...[]byte) []byte {
+	body := bytes.Join(parts, nil)
+	out := append([]byte(id), binary.BigEndian.AppendUint64(nil, uint64(len(body)))...)
+	out = append(out, body...)
+	if len(body)%2 == 1 {
+		out = append(out, 0)
+	}
+	return out
+}
+
+// dffFile builds a DSDIFF form from its chunks
+func dffFile(chunks ...[]byte) []byte {
+	body := append([]byte("DSD "), bytes.Join(chunks, nil)...)
+	return append(append([]byte("FRM8"), binary.BigEndian.AppendUint64(nil, uint64(len(body)))...), body...)
+}
+
+func TestReadDFFInfo(t *testing.T) {
+	prop := dffChunk("PROP", []byte("SND "),
+		dffChunk("FS  ", binary.BigEndian.AppendUint32(nil, 2822400)),
+		dffChunk("CHNL", []byte{0, 2}, []byte("SLFTSRGT")))
+	dstProp := dffChunk("PROP", []byte("SND "),
+		dffChunk("FS  ", binary.BigEndian.AppendUint32(nil, 2822400)),
+		dffChunk("CHNL", []byte{0, 2}, []byte("SLFTSRGT")),
+		dffChunk("CMPR", []byte("DST "), []byte{0}))
+	frte := append([]byte("FRTE"), make([]byte, 14)...)
+	binary.BigEndian.PutUint32(frte[12:16], 750)
+	binary.BigEndian.PutUint16(frte[16:18], 75)
+	diin := dffChunk("DIIN",
+		dffChunk("DITI", binary.BigEndian.AppendUint32(nil, 5), []byte("Title")),
+		dffChunk("DIAR", binary.BigEndian.AppendUint32(nil, 6), []byte("Artist")))
+
+	// A chunk size with the top bit set reads as negative
+	negative := dffFile(prop, []byte("DSD "), []byte{0x80, 0, 0, 0, 0, 0, 0, 0})
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    *dffInfo
+		wantErr bool
+	}{
+		{
+			name: "DSD64 stereo with edited master text",
+			data: dffFile(prop, diin, dffChunk("DSD ", make([]byte, 16))),
+			want: &dffInfo{
+				archivalInfo: archivalInfo{Codec: "DSD", Lossless: true, SampleRate: 2822400, Channels: 2, BitDepth: 1, Frames: 64, AudioBytes: 16},
+				Title:        "Title",
+				Artist:       "Artist",
+			},
+		},
+		{
+			name: "DST compressed",
+			data: dffFile(dstProp, dffChunk("DST ", frte)),
+			want: &dffInfo{archivalInfo: archivalInfo{Codec: "DST", Lossless: true, SampleRate: 2822400, Channels: 2, BitDepth: 1, AudioBytes: 18, DSTFrames: 750, DSTFrameRate: 75}},
+		},
+		{
+			name: "audio chunk cut short",
+			data: dffFile(prop, dffChunk("DSD ", make([]byte, 16)))[:len(dffFile(prop))+12+8],
+			want: &dffInfo{archivalInfo: archivalInfo{Codec: "DSD", Lossless: true, SampleRate: 2822400, Channels: 2, BitDepth: 1, Frames: 32, AudioBytes: 8}},
+		},
+		{name: "negative chunk size", data: negative, wantErr: true},
+		{name: "no properties", data: dffFile(dffChunk("DSD ", make([]byte, 16))), wantErr: true},
+		{name: "not DSDIFF", data: append([]byte("FRM8"), make([]byte, 12)...), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readDFFInfo(bytes.NewReader(tt.data), int64(len(tt.data)))
+			if tt.wantErr {
+				if !errors.Is(err, errNotArchivalFormat) {
+					t.Fatalf("readDFFInfo() error = %v, want %v", err, errNotArchivalFormat)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readDFFInfo() error = %v", err)
+			}
+			if got.archivalInfo != tt.want.archivalInfo || got.Title != tt.want.Title || got.Artist != tt.want.Artist || got.ID3 != nil {
+				t.Fatalf("readDFFInfo() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	// A header shorter than the form header is an I/O error, not a panic
+	if _, err := readDFFInfo(bytes.NewReader([]byte("FRM8")), 4); err == nil {
+		t.Fatal("readDFFInfo() of a truncated header succeeded")
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/hajimehoshi/go-mp3"
 	"github.com/mewkiz/flac"
 )
 
@@ -23,7 +22,23 @@ func NewAudioAnalyzer() *AudioAnalyzer {
 type AudioProperties struct {
 	Duration   time.Duration
 	SampleRate int
-	BitRate    int
+	BitRate    int // average kbps of the audio data
+
+	Codec    string // MP3, FLAC, AAC, HE-AAC, ALAC, ...
+	BitDepth int    // lossless formats only
+	Lossless bool
+
+	// Set when GoMusic can read the file but not play it
+	UnplayableReason string
+
+	Channels    int
+	ChannelMode string // MP3 only: stereo, joint stereo, dual channel or mono
+	VBR         bool
+
+	// Gapless playback: exact sample count after trimming, and the samples to trim
+	TotalSamples   int64
+	EncoderDelay   int
+	EncoderPadding int
 }
 
 // Analyze extracts audio properties from a file
@@ -35,14 +50,21 @@ func (a *AudioAnalyzer) Analyze(filePath string) *AudioProperties {
 		return a.analyzeMP3(filePath)
 	case ".flac":
 		return a.analyzeFLAC(filePath)
+	case ".m4a", ".m4b", ".m4p", ".mp4":
+		return a.analyzeMP4(filePath)
+	case ".ogg", ".oga", ".opus":
+		return a.analyzeOgg(filePath)
+	case ".wav", ".aif", ".aiff", ".aifc":
+		return a.analyzePCM(filePath)
+	case ".wv", ".ape", ".dsf", ".dff":
+		return a.analyzeArchival(filePath, ext)
 	default:
-		// For other formats (M4A, OGG), return empty properties
-		// Can be extended later with additional libraries
+		// Other formats return empty properties
 		return &AudioProperties{}
 	}
 }
 
-// analyzeMP3 extracts properties from MP3 file
+// analyzeMP3 extracts properties from MP3 frame headers without decoding audio
 func (a *AudioAnalyzer) analyzeMP3(filePath string) *AudioProperties {
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -50,39 +72,185 @@ func (a *AudioAnalyzer) analyzeMP3(filePath string) *AudioProperties {
 	}
 	defer file.Close()
 
-	decoder, err := mp3.NewDecoder(file)
+	fileInfo, err := file.Stat()
 	if err != nil {
 		return &AudioProperties{}
 	}
 
-	props := &AudioProperties{}
+	info := readMP3Info(file, fileInfo.Size())
+	if info == nil {
+		return &AudioProperties{}
+	}
 
-	// Sample rate
-	sampleRate := decoder.SampleRate()
-	props.SampleRate = sampleRate
-
-	// Calculate duration
-	if sampleRate > 0 {
-		length := decoder.Length()
-		// Length is in bytes (4 bytes per sample for stereo 16-bit)
-		samples := length / 4
-		durationSeconds := float64(samples) / float64(sampleRate)
-		props.Duration = time.Duration(durationSeconds * float64(time.Second))
+	props := &AudioProperties{
+		Codec:       "MP3",
+		Duration:    info.Duration(),
+		SampleRate:  info.SampleRate,
+		BitRate:     info.BitRate(),
+		Channels:    info.Channels,
+		ChannelMode: info.ChannelMode,
+		VBR:         info.VBR,
 	}
 
-	// Estimate bitrate from file size and duration
-	if props.Duration > 0 {
-		fileInfo, err := os.Stat(filePath)
-		if err == nil {
-			fileSizeBytes := fileInfo.Size()
-			durationSeconds := props.Duration.Seconds()
-			props.BitRate = int((float64(fileSizeBytes) * 8) / (durationSeconds * 1000))
-		}
+	// The LAME tag gives the exact length without encoder delay and padding
+	if samples := info.TotalSamples(); samples > 0 {
+		props.TotalSamples = samples
+		props.EncoderDelay = info.EncoderDelay
+		props.EncoderPadding = info.EncoderPadding
 	}
 
 	return props
 }
 
+// analyzeMP4 extracts properties from the MP4 atom tree (AAC or ALAC in M4A/M4B)
+func (a *AudioAnalyzer) analyzeMP4(filePath string) *AudioProperties {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return &AudioProperties{}
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return &AudioProperties{}
+	}
+
+	info := readMP4Info(file, fileInfo.Size())
+	if info == nil {
+		return &AudioProperties{}
+	}
+
+	return &AudioProperties{
+		Duration:   info.Duration,
+		SampleRate: info.SampleRate,
+		BitRate:    info.BitRate(),
+		Codec:      info.Codec,
+		BitDepth:   info.BitDepth,
+		Lossless:   info.Lossless,
+		Channels:   info.Channels,
+	}
+}
+
+// analyzeOgg extracts properties from the Ogg identification header and last page
+// (Vorbis, Opus or FLAC)
+func (a *AudioAnalyzer) analyzeOgg(filePath string) *AudioProperties {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return &AudioProperties{}
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return &AudioProperties{}
+	}
+
+	info := readOggInfo(file, fileInfo.Size())
+	if info == nil {
+		return &AudioProperties{}
+	}
+
+	return &AudioProperties{
+		Duration:     info.Duration(),
+		SampleRate:   info.SampleRate,
+		BitRate:      info.BitRate(),
+		Codec:        info.Codec,
+		BitDepth:     info.BitDepth,
+		Lossless:     info.Lossless,
+		Channels:     info.Channels,
+		VBR:          info.VBR,
+		TotalSamples: info.TotalSamples,
+		EncoderDelay: info.PreSkip,
+	}
+}
+
+// analyzePCM extracts properties from the fmt or COMM chunk of a WAV or AIFF file
+func (a *AudioAnalyzer) analyzePCM(filePath string) *AudioProperties {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return &AudioProperties{}
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return &AudioProperties{}
+	}
+
+	info, err := readPCMInfo(file, fileInfo.Size())
+	if err != nil {
+		return &AudioProperties{}
+	}
+
+	return &AudioProperties{
+		Duration:     info.Duration(),
+		SampleRate:   info.SampleRate,
+		BitRate:      info.BitRate(),
+		Codec:        info.Codec,
+		BitDepth:     info.BitDepth,
+		Lossless:     info.Lossless,
+		Channels:     info.Channels,
+		TotalSamples: info.Frames,
+	}
+}
+
+// analyzeArchival extracts properties from WavPack, Monkey's Audio and DSD headers
+// WavPack, Monkey's Audio and DST have no Go decoder, so they are marked unplayable
+func (a *AudioAnalyzer) analyzeArchival(filePath, ext string) *AudioProperties {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return &AudioProperties{}
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return &AudioProperties{}
+	}
+
+	var info *archivalInfo
+	var reason string
+	switch ext {
+	case ".wv":
+		var hybrid bool
+		info, hybrid, err = readWavPackInfo(file, fileInfo.Size())
+		if err == nil && hybrid {
+			// Hybrid mode is lossless only with its correction file
+			_, statErr := os.Stat(filePath + "c")
+			info.Lossless = statErr == nil
+		}
+		reason = "WavPack playback is not supported"
+	case ".ape":
+		info, err = readMonkeysAudioInfo(file, fileInfo.Size())
+		reason = "Monkey's Audio playback is not supported"
+	case ".dsf":
+		info, err = readDSFInfo(file, fileInfo.Size())
+	case ".dff":
+		var dff *dffInfo
+		if dff, err = readDFFInfo(file, fileInfo.Size()); err == nil {
+			info = &dff.archivalInfo
+			if info.Codec == "DST" {
+				reason = "DST-compressed DSD playback is not supported"
+			}
+		}
+	}
+	if err != nil {
+		return &AudioProperties{}
+	}
+
+	return &AudioProperties{
+		Duration:         info.Duration(),
+		SampleRate:       info.SampleRate,
+		BitRate:          info.BitRate(),
+		Codec:            info.Codec,
+		BitDepth:         info.BitDepth,
+		Lossless:         info.Lossless,
+		Channels:         info.Channels,
+		TotalSamples:     info.Frames,
+		UnplayableReason: reason,
+	}
+}
+
 // analyzeFLAC extracts properties from FLAC file
 func (a *AudioAnalyzer) analyzeFLAC(filePath string) *AudioProperties {
 	stream, err := flac.ParseFile(filePath)
@@ -91,10 +259,15 @@ func (a *AudioAnalyzer) analyzeFLAC(filePath string) *AudioProperties {
 	}
 
 	info := stream.Info
-	props := &AudioProperties{}
+	props := &AudioProperties{Codec: "FLAC", Lossless: true}
 
 	// Sample rate
 	props.SampleRate = int(info.SampleRate)
+	props.Channels = int(info.NChannels)
+	props.BitDepth = int(info.BitsPerSample)
+
+	// FLAC has no encoder delay; NSamples is already exact
+	props.TotalSamples = int64(info.NSamples)
 
 	// Duration
 	if info.SampleRate > 0 {
@@ -0,0 +1,379 @@
+package filesystem
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dhowden/tag"
+
+	"GoMusic/internal/decoder"
+	"GoMusic/internal/domain/model"
+)
+
+// cueFramesPerSecond is the resolution of CUE sheet times (CD sectors)
+const cueFramesPerSecond = 75
+
+// maxCueSheetSize caps sidecar files read as CUE sheets
+const maxCueSheetSize = 1 << 20
+
+// cueSheet is a parsed CUE sheet; album-level fields apply to every track
+type cueSheet struct {
+	Title      string
+	Performer  string
+	Genre      string
+	Date       string
+	DiscNumber int
+	AlbumGain  *float64
+	AlbumPeak  *float64
+	Files      []cueFile
+}
+
+// cueFile is a FILE entry and the tracks it holds
+type cueFile struct {
+	Name   string
+	Tracks []cueTrack
+}
+
+// cueTrack is a TRACK entry
+type cueTrack struct {
+	Number    int
+	Title     string
+	Performer string
+	Start     int64 // INDEX 01 in CD frames; the pregap before it belongs to the previous track
+	TrackGain *float64
+	TrackPeak *float64
+}
+
+// parseCueSheet parses CUE sheet text, which may be UTF-8 (with or without BOM)
+// or Latin-1
+func parseCueSheet(data []byte) *cueSheet {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	sheet := &cueSheet{}
+	var file *cueFile
+	var track *cueTrack
+
+	for _, line := range strings.Split(decodeChunkText(data), "\n") {
+		fields := cueFields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch command := strings.ToUpper(fields[0]); {
+		case command == "FILE" && len(fields) >= 2:
+			sheet.Files = append(sheet.Files, cueFile{Name: fields[1]})
+			file, track = &sheet.Files[len(sheet.Files)-1], nil
+		case command == "TRACK" && len(fields) >= 2 && file != nil:
+			number, _ := strconv.Atoi(fields[1])
+			file.Tracks = append(file.Tracks, cueTrack{Number: number, Start: -1})
+			track = &file.Tracks[len(file.Tracks)-1]
+		case command == "INDEX" && len(fields) >= 3 && track != nil:
+			if fields[1] == "01" || fields[1] == "1" {
+				track.Start = parseCueTime(fields[2])
+			}
+		case command == "TITLE" && len(fields) >= 2:
+			if track != nil {
+				track.Title = fields[1]
+			} else {
+				sheet.Title = fields[1]
+			}
+		case command == "PERFORMER" && len(fields) >= 2:
+			if track != nil {
+				track.Performer = fields[1]
+			} else {
+				sheet.Performer = fields[1]
+			}
+		case command == "REM" && len(fields) >= 3:
+			parseCueRemark(sheet, track, strings.ToUpper(fields[1]), fields[2])
+		}
+	}
+	return sheet
+}
+
+// parseCueRemark reads the REM comments written by common rippers
+func parseCueRemark(sheet *cueSheet, track *cueTrack, key, value string) {
+	switch key {
+	case "GENRE":
+		sheet.Genre = value
+	case "DATE":
+		sheet.Date = value
+	case "DISCNUMBER":
+		sheet.DiscNumber, _ = strconv.Atoi(value)
+	case "REPLAYGAIN_ALBUM_GAIN":
+		sheet.AlbumGain = parseGain(value)
+	case "REPLAYGAIN_ALBUM_PEAK":
+		sheet.AlbumPeak = parsePeak(value)
+	case "REPLAYGAIN_TRACK_GAIN":
+		if track != nil {
+			track.TrackGain = parseGain(value)
+		}
+	case "REPLAYGAIN_TRACK_PEAK":
+		if track != nil {
+			track.TrackPeak = parsePeak(value)
+		}
+	}
+}
+
+// cueFields splits a line into words, keeping quoted strings together
+func cueFields(line string) []string {
+	var fields []string
+	line = strings.TrimSpace(line)
+	for line != "" {
+		if line[0] == '"' {
+			value, rest, _ := strings.Cut(line[1:], `"`)
+			fields = append(fields, value)
+			line = strings.TrimSpace(rest)
+			continue
+		}
+		// Unquoted values run to the end of the line
+		if len(fields) == 2 && strings.EqualFold(fields[0], "REM") ||
+			len(fields) == 1 && (strings.EqualFold(fields[0], "TITLE") || strings.EqualFold(fields[0], "PERFORMER")) {
+			fields = append(fields, line)
+			break
+		}
+		word, rest, _ := strings.Cut(line, " ")
+		fields = append(fields, strings.TrimSpace(word))
+		line = strings.TrimSpace(rest)
+	}
+	return fields
+}
+
+// parseCueTime parses mm:ss:ff into CD frames, or returns -1
+func parseCueTime(value string) int64 {
+	parts := strings.Split(value, ":")
+	if len(parts) != 3 {
+		return -1
+	}
+	var frames int64
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return -1
+		}
+		switch i {
+		case 0:
+			frames = int64(n) * 60
+		case 1:
+			frames = (frames + int64(n)) * cueFramesPerSecond
+		case 2:
+			frames += int64(n)
+		}
+	}
+	return frames
+}
+
+// cueDuration converts CD frames to a duration
+func cueDuration(frames int64) time.Duration {
+	return time.Duration(frames) * time.Second / cueFramesPerSecond
+}
+
+// CueSheetCache holds the sidecar CUE sheets parsed from each directory, so a
+// scan reads a folder's sheets once rather than once per audio file in it
+// A cache lives for one scan; sheets edited later are read by the next one
+type CueSheetCache struct {
+	mu   sync.Mutex
+	dirs map[string][]*cueSidecar
+}
+
+// cueSidecar is a parsed .cue file
+type cueSidecar struct {
+	path  string
+	sheet *cueSheet
+}
+
+// NewCueSheetCache creates an empty cache for a scan
+func NewCueSheetCache() *CueSheetCache {
+	return &CueSheetCache{dirs: make(map[string][]*cueSidecar)}
+}
+
+// sidecars returns the sheets in dir, parsing them on first use
+// A nil cache parses them every time
+func (c *CueSheetCache) sidecars(dir string) []*cueSidecar {
+	if c == nil {
+		return readCueSidecars(dir)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sidecars, ok := c.dirs[dir]
+	if !ok {
+		sidecars = readCueSidecars(dir)
+		c.dirs[dir] = sidecars
+	}
+	return sidecars
+}
+
+// readCueSidecars parses the .cue files in dir
+func readCueSidecars(dir string) []*cueSidecar {
+	var sidecars []*cueSidecar
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(name), ".cue") {
+			continue
+		}
+		if info, err := entry.Info(); err != nil || info.Size() > maxCueSheetSize {
+			continue
+		}
+		cuePath := filepath.Join(dir, name)
+		data, err := os.ReadFile(cuePath)
+		if err != nil {
+			continue
+		}
+		sidecars = append(sidecars, &cueSidecar{path: cuePath, sheet: parseCueSheet(data)})
+	}
+	return sidecars
+}
+
+// findCueSheet returns the CUE sheet that splits an audio file into tracks and
+// the sheet's location: a sidecar .cue in the same directory naming the file,
+// or a sheet embedded in the file's tags
+// Sheets that give the file fewer than two tracks describe an album that is
+// already split, so they are ignored
+func findCueSheet(filePath string, metadata tag.Metadata, sheets *CueSheetCache) (*cueSheet, *cueFile, string) {
+	dir, base := filepath.Dir(filePath), filepath.Base(filePath)
+	stem := strings.TrimSuffix(base, filepath.Ext(base))
+
+	for _, sidecar := range sheets.sidecars(dir) {
+		sheet := sidecar.sheet
+
+		// Rippers often name the image differently from the audio after
+		// conversion, so a sheet named after the audio with one FILE also counts
+		name := filepath.Base(sidecar.path)
+		cueStem := strings.TrimSuffix(name, filepath.Ext(name))
+		for i := range sheet.Files {
+			file := &sheet.Files[i]
+			// Sheets written on Windows use backslashes
+			fileName := filepath.Base(strings.ReplaceAll(file.Name, `\`, "/"))
+			renamed := len(sheet.Files) == 1 && (strings.EqualFold(cueStem, stem) || strings.EqualFold(cueStem, base))
+			if (strings.EqualFold(fileName, base) || renamed) && len(file.Tracks) >= 2 {
+				return sheet, file, sidecar.path
+			}
+		}
+	}
+
+	// FLAC (CUESHEET comment) and APEv2 (Cuesheet item) can embed the sheet
+	if metadata != nil {
+		if text, ok := metadata.Raw()["cuesheet"].(string); ok {
+			sheet := parseCueSheet([]byte(text))
+			if len(sheet.Files) > 0 && len(sheet.Files[0].Tracks) >= 2 {
+				return sheet, &sheet.Files[0], filePath
+			}
+		}
+	}
+	return nil, nil, ""
+}
+
+// splitCueTracks turns a whole-file track into one track per CUE sheet entry
+// Tag values fill whatever the sheet leaves out; values that describe the whole
+// file (track ReplayGain, tempo, key, gapless info) are dropped
+func splitCueTracks(whole *model.Track, sheet *cueSheet, file *cueFile, cuePath string) []*model.Track {
+	reason := whole.UnplayableReason
+	if reason == "" && !decoder.CanDecode(whole.FilePath) {
+		reason = "CUE sheet tracks of this format cannot be played"
+	}
+
+	tracks := make([]*model.Track, 0, len(file.Tracks))
+	for i, entry := range file.Tracks {
+		if entry.Start < 0 {
+			continue
+		}
+
+		// Segments are keyed by their place in the sheet, as a sheet may repeat
+		// or leave out TRACK numbers
+		track := *whole
+		track.ID = generateTrackID(whole.FilePath + "#" + strconv.Itoa(i+1))
+		track.CueSheet = cuePath
+		track.SegmentStart = cueDuration(entry.Start)
+		track.UnplayableReason = reason
+		track.TrackNumber = entry.Number
+		track.Title = entry.Title
+		if track.Title == "" {
+			track.Title = "Track " + strconv.Itoa(entry.Number)
+		}
+		track.Artist = firstNonEmpty(entry.Performer, sheet.Performer, whole.Artist)
+		track.Album = firstNonEmpty(sheet.Title, whole.Album)
+		track.AlbumArtist = firstNonEmpty(sheet.Performer, whole.AlbumArtist)
+		track.Genre = firstNonEmpty(whole.Genre, sheet.Genre)
+		if track.Year == 0 && len(sheet.Date) >= 4 {
+			track.Year, _ = strconv.Atoi(sheet.Date[:4])
+		}
+		if sheet.DiscNumber > 0 {
+			track.DiscNumber = sheet.DiscNumber
+		}
+
+		// The segment runs to the next track's INDEX 01 or the end of the file;
+		// entries without one are skipped above, so they do not end it
+		end := int64(-1)
+		for _, next := range file.Tracks[i+1:] {
+			if next.Start >= 0 {
+				if next.Start > entry.Start {
+					end = next.Start
+				}
+				break
+			}
+		}
+		if end >= 0 {
+			track.Duration = cueDuration(end - entry.Start)
+		} else {
+			track.Duration = max(whole.Duration-track.SegmentStart, 0)
+		}
+		track.TotalSamples, track.EncoderDelay, track.EncoderPadding = 0, 0, 0
+		if track.SampleRate > 0 {
+			track.TotalSamples = int64(track.Duration.Seconds()*float64(track.SampleRate) + 0.5)
+		}
+
+		track.ReplayGain = cueReplayGain(whole.ReplayGain, sheet, &entry)
+		track.BPM, track.Key = 0, ""
+		// The file's recording ID does not name a segment, nor its artist ID a
+		// performer the sheet credits instead
+		track.MusicBrainzTrackID = ""
+		if track.Artist != whole.Artist {
+			track.MusicBrainzArtistID = ""
+		}
+		// The sheet names its tracks, and segments cannot be retagged anyway
+		track.InferredFields = nil
+
+		if track.Artist == "" {
+			track.Artist = "Unknown Artist"
+		}
+		track.AlbumID = generateAlbumID(track.Album, track.AlbumArtist, track.MusicBrainzAlbumID)
+		track.ArtistID = generateArtistID(track.Artist, track.MusicBrainzArtistID)
+		tracks = append(tracks, &track)
+	}
+	return tracks
+}
+
+// cueReplayGain combines the sheet's gains with the file's album gain
+func cueReplayGain(file *model.ReplayGain, sheet *cueSheet, entry *cueTrack) *model.ReplayGain {
+	gain := &model.ReplayGain{
+		TrackGain: entry.TrackGain,
+		TrackPeak: entry.TrackPeak,
+		AlbumGain: sheet.AlbumGain,
+		AlbumPeak: sheet.AlbumPeak,
+	}
+	// A whole-file track gain is the album gain of its segments
+	if file != nil && gain.AlbumGain == nil {
+		gain.AlbumGain, gain.AlbumPeak = file.AlbumGain, file.AlbumPeak
+		if gain.AlbumGain == nil {
+			gain.AlbumGain, gain.AlbumPeak = file.TrackGain, file.TrackPeak
+		}
+	}
+	if gain.TrackGain == nil && gain.AlbumGain == nil {
+		return nil
+	}
+	return gain
+}
+
+// firstNonEmpty returns the first non-empty value
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
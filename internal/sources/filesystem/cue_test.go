@@ -0,0 +1,241 @@
+package filesystem
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dhowden/tag"
+
+	"GoMusic/internal/domain/model"
+)
+
+// cueTestMetadata is file metadata carrying an embedded CUE sheet
+type cueTestMetadata struct {
+	tag.Metadata
+	sheet string
+}
+
+func (m cueTestMetadata) Raw() map[string]interface{} {
+	return map[string]interface{}{"cuesheet": m.sheet}
+}
+
+func TestSplitCueTracks(t *testing.T) {
+	type want struct {
+		number   int
+		title    string
+		artist   string
+		start    time.Duration
+		duration time.Duration
+	}
+
+	tests := []struct {
+		name  string
+		sheet string
+		want  []want
+	}{
+		{
+			name: "album",
+			sheet: `REM GENRE Jazz
+REM DATE 1959
+PERFORMER "Album Artist"
+TITLE "Album"
+FILE "album.flac" WAVE
+  TRACK 01 AUDIO
+    TITLE "First"
+    INDEX 01 00:00:00
+  TRACK 02 AUDIO
+    TITLE "Second"
+    PERFORMER "Guest"
+    INDEX 00 02:58:00
+    INDEX 01 03:00:00
+  TRACK 03 AUDIO
+    INDEX 01 06:30:37
+`,
+			want: []want{
+				{1, "First", "Album Artist", 0, 3 * time.Minute},
+				{2, "Second", "Guest", 3 * time.Minute, cueDuration(29287 - 13500)},
+				{3, "Track 3", "Album Artist", cueDuration(29287), 10*time.Minute - cueDuration(29287)},
+			},
+		},
+		{
+			name: "repeated track numbers",
+			sheet: `FILE "album.flac" WAVE
+  TRACK 01 AUDIO
+    INDEX 01 00:00:00
+  TRACK 01 AUDIO
+    INDEX 01 05:00:00
+`,
+			want: []want{
+				{1, "Track 1", "Whole Artist", 0, 5 * time.Minute},
+				{1, "Track 1", "Whole Artist", 5 * time.Minute, 5 * time.Minute},
+			},
+		},
+		{
+			name: "entry without INDEX 01",
+			sheet: `FILE "album.flac" WAVE
+  TRACK 01 AUDIO
+    INDEX 01 00:00:00
+  TRACK 02 AUDIO
+    INDEX 00 04:00:00
+  TRACK 03 AUDIO
+    INDEX 01 08:00:00
+`,
+			want: []want{
+				{1, "Track 1", "Whole Artist", 0, 8 * time.Minute},
+				{3, "Track 3", "Whole Artist", 8 * time.Minute, 2 * time.Minute},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sheet := parseCueSheet([]byte(tt.sheet))
+			whole := &model.Track{
+				ID:         "whole",
+				FilePath:   "/music/album.flac",
+				Artist:     "Whole Artist",
+				Duration:   10 * time.Minute,
+				SampleRate: 44100,
+			}
+			tracks := splitCueTracks(whole, sheet, &sheet.Files[0], "/music/album.cue")
+			if len(tracks) != len(tt.want) {
+				t.Fatalf("splitCueTracks() returned %d tracks, want %d", len(tracks), len(tt.want))
+			}
+
+			ids := make(map[string]bool)
+			for i, track := range tracks {
+				w := tt.want[i]
+				if track.TrackNumber != w.number || track.Title != w.title || track.Artist != w.artist ||
+					track.SegmentStart != w.start || track.Duration != w.duration {
+					t.Errorf("track %d = {%d %q %q %v %v}, want %+v", i, track.TrackNumber, track.Title,
+						track.Artist, track.SegmentStart, track.Duration, w)
+				}
+				if !track.IsSegment() || track.CueSheet != "/music/album.cue" {
+					t.Errorf("track %d is not a segment of the sheet", i)
+				}
+				if ids[track.ID] || track.ID == whole.ID {
+					t.Errorf("track %d reuses ID %s", i, track.ID)
+				}
+				ids[track.ID] = true
+			}
+		})
+	}
+}
+
+func TestSplitCueTracksAlbumFields(t *testing.T) {
+	sheet := parseCueSheet([]byte("\xef\xbb\xbfREM GENRE Jazz\r\nREM DATE 1959-08-17\r\nREM DISCNUMBER 2\r\n" +
+		"REM REPLAYGAIN_ALBUM_GAIN -7.50 dB\r\nPERFORMER \"Band\"\r\nTITLE \"Album\"\r\nFILE \"a.flac\" WAVE\r\n" +
+		"  TRACK 01 AUDIO\r\n    REM REPLAYGAIN_TRACK_GAIN -6.00 dB\r\n    INDEX 01 00:00:00\r\n" +
+		"  TRACK 02 AUDIO\r\n    INDEX 01 01:00:00\r\n"))
+	whole := &model.Track{FilePath: "/music/a.flac", Duration: 2 * time.Minute, BPM: 120, Key: "Am"}
+
+	tracks := splitCueTracks(whole, sheet, &sheet.Files[0], "/music/a.cue")
+	if len(tracks) != 2 {
+		t.Fatalf("splitCueTracks() returned %d tracks, want 2", len(tracks))
+	}
+	first := tracks[0]
+	if first.Album != "Album" || first.AlbumArtist != "Band" || first.Genre != "Jazz" || first.Year != 1959 || first.DiscNumber != 2 {
+		t.Errorf("album fields = %q %q %q %d %d", first.Album, first.AlbumArtist, first.Genre, first.Year, first.DiscNumber)
+	}
+	if first.ReplayGain == nil || *first.ReplayGain.TrackGain != -6 || *first.ReplayGain.AlbumGain != -7.5 {
+		t.Errorf("ReplayGain = %+v", first.ReplayGain)
+	}
+	if tracks[1].ReplayGain == nil || tracks[1].ReplayGain.TrackGain != nil {
+		t.Errorf("second track ReplayGain = %+v, want the album gain only", tracks[1].ReplayGain)
+	}
+	if first.BPM != 0 || first.Key != "" {
+		t.Error("whole-file tempo and key were kept")
+	}
+}
+
+func TestFindCueSheet(t *testing.T) {
+	twoTracks := "FILE \"%s\" WAVE\n TRACK 01 AUDIO\n  INDEX 01 00:00:00\n TRACK 02 AUDIO\n  INDEX 01 01:00:00\n"
+	oneTrack := "FILE \"album.flac\" WAVE\n TRACK 01 AUDIO\n  INDEX 01 00:00:00\n"
+
+	tests := []struct {
+		name     string
+		files    map[string]string
+		metadata tag.Metadata
+		wantPath string
+	}{
+		{
+			name:     "sidecar naming the file",
+			files:    map[string]string{"rip.cue": fmt.Sprintf(twoTracks, "album.flac")},
+			wantPath: "rip.cue",
+		},
+		{
+			name:     "Windows path in the sheet",
+			files:    map[string]string{"rip.cue": fmt.Sprintf(twoTracks, `C:\rips\album.flac`)},
+			wantPath: "rip.cue",
+		},
+		{
+			name:     "sheet named after the converted file",
+			files:    map[string]string{"album.cue": fmt.Sprintf(twoTracks, "album.wav")},
+			wantPath: "album.cue",
+		},
+		{
+			name:  "sheet for another file",
+			files: map[string]string{"other.cue": fmt.Sprintf(twoTracks, "other.flac")},
+		},
+		{
+			name:  "already split album",
+			files: map[string]string{"album.cue": oneTrack},
+		},
+		{
+			name:     "embedded sheet",
+			metadata: cueTestMetadata{sheet: fmt.Sprintf(twoTracks, "album.flac")},
+			wantPath: "album.flac",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			sheet, file, path := findCueSheet(filepath.Join(dir, "album.flac"), tt.metadata, NewCueSheetCache())
+			if tt.wantPath == "" {
+				if sheet != nil {
+					t.Fatalf("findCueSheet() found %s", path)
+				}
+				return
+			}
+			if sheet == nil || len(file.Tracks) != 2 || path != filepath.Join(dir, tt.wantPath) {
+				t.Fatalf("findCueSheet() = %v, %q, want %s", sheet, path, tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestCueSheetCache(t *testing.T) {
+	dir := t.TempDir()
+	cuePath := filepath.Join(dir, "album.cue")
+	sheet := "FILE \"album.flac\" WAVE\n TRACK 01 AUDIO\n  INDEX 01 00:00:00\n TRACK 02 AUDIO\n  INDEX 01 01:00:00\n"
+	if err := os.WriteFile(cuePath, []byte(sheet), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cache := NewCueSheetCache()
+	first, _, _ := findCueSheet(filepath.Join(dir, "album.flac"), nil, cache)
+	if first == nil {
+		t.Fatal("findCueSheet() found no sheet")
+	}
+	if err := os.Remove(cuePath); err != nil {
+		t.Fatal(err)
+	}
+
+	// The scan's cache still holds the sheet; outside a scan it is read afresh
+	if second, _, _ := findCueSheet(filepath.Join(dir, "album.flac"), nil, cache); second != first {
+		t.Error("the cached sheet was parsed again")
+	}
+	if uncached, _, _ := findCueSheet(filepath.Join(dir, "album.flac"), nil, nil); uncached != nil {
+		t.Error("a sheet deleted since was found without a cache")
+	}
+}
@@ -7,6 +7,11 @@ type Extractor interface {
 	// Extract reads metadata from an audio file and returns a Track
 	Extract(filePath string) (*model.Track, error)
 
+	// ExtractTracks reads an audio file and returns its tracks: one per CUE sheet
+	// entry for a single-file album, otherwise the file itself
+	// Sidecar sheets are read through sheets, which may be nil outside a scan
+	ExtractTracks(filePath string, sheets *CueSheetCache) ([]*model.Track, error)
+
 	// SupportsFormat checks if the extractor supports the given file extension
 	SupportsFormat(extension string) bool
 }
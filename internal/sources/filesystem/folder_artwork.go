@@ -0,0 +1,109 @@
+package filesystem
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"GoMusic/internal/sources/artwork"
+)
+
+// artworkImageExtensions are the files a folder artwork pattern may select
+var artworkImageExtensions = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true, ".bmp": true,
+}
+
+// ValidateArtworkPattern checks that an artwork pattern is a valid file name glob
+func ValidateArtworkPattern(pattern string) error {
+	if strings.ContainsAny(pattern, `/\`) {
+		return fmt.Errorf("artwork pattern %q must be a file name, not a path", pattern)
+	}
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid artwork pattern %q: %w", pattern, err)
+	}
+	return nil
+}
+
+// findFolderArtwork returns the image beside a track that best matches patterns,
+// or "" when there is none
+// The track's folder is searched before its parent, which holds the cover of
+// albums split into disc folders; the parent is only searched below rootPath.
+// Patterns match case-insensitively, and names matching one pattern are tried
+// in sorted order
+func findFolderArtwork(filePath, rootPath string, patterns []string) string {
+	dirs := []string{filepath.Dir(filePath)}
+	if parent := filepath.Dir(dirs[0]); parent != dirs[0] && isBelowRoot(rootPath, parent) {
+		dirs = append(dirs, parent)
+	}
+
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, pattern := range patterns {
+			pattern = strings.ToLower(pattern)
+			for _, entry := range entries {
+				name := strings.ToLower(entry.Name())
+				if entry.IsDir() || !artworkImageExtensions[filepath.Ext(name)] {
+					continue
+				}
+				if ok, _ := filepath.Match(pattern, name); ok {
+					return filepath.Join(dir, entry.Name())
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// isBelowRoot reports whether dir is rootPath or inside it; any folder is when
+// no root is set
+func isBelowRoot(rootPath, dir string) bool {
+	if rootPath == "" {
+		return true
+	}
+	rel, err := filepath.Rel(rootPath, dir)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// folderArtworkEntry remembers which cache file a folder image was stored as
+type folderArtworkEntry struct {
+	size     int64
+	modTime  time.Time
+	filename string
+}
+
+// cacheFolderArtwork stores a folder image in the artwork cache and returns its
+// cache filename
+// The image is only read again once it changes or its cache file is gone, so
+// the tracks of one folder don't each hash the same file
+func (e *TagExtractor) cacheFolderArtwork(imagePath string) (string, error) {
+	source, err := os.Stat(imagePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read folder artwork: %w", err)
+	}
+
+	e.folderArtworkMu.Lock()
+	entry, ok := e.folderArtwork[imagePath]
+	e.folderArtworkMu.Unlock()
+	if ok && entry.size == source.Size() && entry.modTime.Equal(source.ModTime()) && artwork.Touch(entry.filename) {
+		return entry.filename, nil
+	}
+
+	data, err := os.ReadFile(imagePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read folder artwork: %w", err)
+	}
+	filename, err := artwork.Store(data, strings.ToLower(filepath.Ext(imagePath)))
+	if err != nil {
+		return "", err
+	}
+
+	e.folderArtworkMu.Lock()
+	e.folderArtwork[imagePath] = folderArtworkEntry{size: source.Size(), modTime: source.ModTime(), filename: filename}
+	e.folderArtworkMu.Unlock()
+	return filename, nil
+}
@@ -0,0 +1,24 @@
+package filesystem
+
+import (
+	"strconv"
+	"strings"
+)
+
+// parseITunSMPB parses iTunes gapless info: " 00000000 DELAY PADDING SAMPLES ..." in hex
+// Returns ok=false when the value is malformed
+func parseITunSMPB(value string) (delay, padding int, samples int64, ok bool) {
+	fields := strings.Fields(value)
+	if len(fields) < 4 {
+		return 0, 0, 0, false
+	}
+
+	d, err1 := strconv.ParseInt(fields[1], 16, 64)
+	p, err2 := strconv.ParseInt(fields[2], 16, 64)
+	s, err3 := strconv.ParseInt(fields[3], 16, 64)
+	if err1 != nil || err2 != nil || err3 != nil || s <= 0 {
+		return 0, 0, 0, false
+	}
+
+	return int(d), int(p), s, true
+}
@@ -0,0 +1,374 @@
+package filesystem
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"GoMusic/internal/domain/model"
+)
+
+// id3Padding is the free space left after a rewritten ID3v2 tag so that later
+// edits rarely change its size
+const id3Padding = 1024
+
+// maxID3TagSize caps the ID3v2 tags read for rewriting
+const maxID3TagSize = 64 << 20
+
+// id3v1Size is the size of the fixed ID3v1 tag at the end of a file
+const id3v1Size = 128
+
+// id3Frame is an ID3v2 frame; frames that are not edited are written back as read
+type id3Frame struct {
+	ID    string
+	Flags [2]byte
+	Data  []byte
+}
+
+// id3Tag is an ID3v2.3 or 2.4 tag
+type id3Tag struct {
+	Version byte
+	Frames  []id3Frame
+}
+
+// rewriteMP3Tags rewrites the ID3v2 tag at the start of an MP3 file, adding one
+// when missing, and updates an existing ID3v1 tag at the end
+func rewriteMP3Tags(src *os.File, size int64, dst io.Writer, changes *model.TagChanges) error {
+	id3, audioStart, err := readID3v2Tag(src, size)
+	if err != nil {
+		return err
+	}
+	if id3 == nil {
+		id3 = &id3Tag{Version: 3}
+	}
+	id3.apply(changes)
+	if _, err := dst.Write(id3.bytes(id3Padding)); err != nil {
+		return err
+	}
+
+	audioEnd := size
+	v1 := make([]byte, id3v1Size)
+	if size-audioStart >= id3v1Size {
+		if _, err := src.ReadAt(v1, size-id3v1Size); err == nil && string(v1[:3]) == "TAG" {
+			audioEnd -= id3v1Size
+		}
+	}
+	if err := copyRange(dst, src, audioStart, audioEnd); err != nil {
+		return err
+	}
+	if audioEnd < size {
+		updateID3v1(v1, changes)
+		if _, err := dst.Write(v1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readID3v2Tag reads the ID3v2 tag at the start of a file and returns it with the
+// offset where the audio begins; the tag is nil when there is none
+// The extended header and footer are dropped and unsynchronisation undone, for
+// the whole tag in v2.3 and frame by frame in v2.4
+// Frames must run up to padding or the exact end of the tag, so that a tag
+// this code misreads is never written back truncated
+func readID3v2Tag(r io.ReaderAt, size int64) (*id3Tag, int64, error) {
+	header := make([]byte, 10)
+	if size < 10 {
+		return nil, 0, nil
+	}
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, 0, err
+	}
+	if string(header[:3]) != "ID3" {
+		return nil, 0, nil
+	}
+
+	version, flags := header[3], header[5]
+	switch version {
+	case 3, 4:
+	case 2:
+		return nil, 0, errors.New("ID3v2.2 tags cannot be edited")
+	default:
+		return nil, 0, errors.New("unknown ID3v2 version " + strconv.Itoa(int(version)))
+	}
+
+	tagSize := int64(syncsafe(header[6:10]))
+	if tagSize > maxID3TagSize || 10+tagSize > size {
+		return nil, 0, errors.New("ID3v2 tag is too large")
+	}
+	audioStart := 10 + tagSize
+	if flags&0x10 != 0 {
+		audioStart += 10
+	}
+
+	body := make([]byte, tagSize)
+	if _, err := r.ReadAt(body, 10); err != nil {
+		return nil, 0, err
+	}
+	if version == 3 && flags&0x80 != 0 {
+		body = removeUnsync(body)
+	}
+
+	pos := 0
+	if flags&0x40 != 0 && len(body) >= 4 {
+		// v2.3 counts the size field itself out, v2.4 in
+		if version == 3 {
+			pos = int(binary.BigEndian.Uint32(body[:4])) + 4
+		} else {
+			pos = int(syncsafe(body[:4]))
+		}
+	}
+
+	tag := &id3Tag{Version: version}
+	for pos < len(body) && body[pos] != 0 {
+		if pos+10 > len(body) {
+			return nil, 0, errors.New("ID3v2 tag ends inside a frame header")
+		}
+		frameSize := int(binary.BigEndian.Uint32(body[pos+4 : pos+8]))
+		if version == 4 {
+			frameSize = int(syncsafe(body[pos+4 : pos+8]))
+		}
+		if frameSize < 0 || pos+10+frameSize > len(body) {
+			return nil, 0, errors.New("ID3v2 frame " + strconv.Quote(string(body[pos:pos+4])) + " runs past the end of the tag")
+		}
+		frame := id3Frame{
+			ID:    string(body[pos : pos+4]),
+			Flags: [2]byte{body[pos+8], body[pos+9]},
+			Data:  body[pos+10 : pos+10+frameSize],
+		}
+		// v2.4 unsynchronises frame by frame; the tag flag says every frame is
+		if version == 4 && (flags&0x80 != 0 || frame.Flags[1]&0x02 != 0) {
+			frame.Data = removeUnsync(frame.Data)
+			frame.Flags[1] &^= 0x02
+		}
+		tag.Frames = append(tag.Frames, frame)
+		pos += 10 + frameSize
+	}
+	if pos > len(body) {
+		return nil, 0, errors.New("ID3v2 extended header runs past the end of the tag")
+	}
+	return tag, audioStart, nil
+}
+
+// removeUnsync undoes ID3v2 unsynchronisation, which follows every 0xff with 0x00
+func removeUnsync(data []byte) []byte {
+	return bytes.ReplaceAll(data, []byte{0xff, 0x00}, []byte{0xff})
+}
+
+// apply writes changes into the tag's text frames
+func (t *id3Tag) apply(changes *model.TagChanges) {
+	t.setTextChange("TIT2", changes.Title)
+	t.setTextChange("TPE1", changes.Artist)
+	t.setTextChange("TALB", changes.Album)
+	t.setTextChange("TPE2", changes.AlbumArtist)
+	t.setTextChange("TCON", changes.Genre)
+
+	if changes.Year != nil {
+		// v2.3 keeps the year in TYER, v2.4 a timestamp in TDRC
+		yearFrame, otherFrame := "TYER", "TDRC"
+		if t.Version == 4 {
+			yearFrame, otherFrame = "TDRC", "TYER"
+		}
+		old := t.text(yearFrame)
+		if old == "" {
+			old = t.text(otherFrame)
+		}
+		value := yearValue(old, *changes.Year)
+		if t.Version == 3 && len(value) > 4 {
+			value = value[:4]
+		}
+		t.setText(yearFrame, value)
+		t.setText(otherFrame, "")
+	}
+	if changes.TrackNumber != nil {
+		t.setText("TRCK", numberValue(t.text("TRCK"), *changes.TrackNumber))
+	}
+	if changes.DiscNumber != nil {
+		t.setText("TPOS", numberValue(t.text("TPOS"), *changes.DiscNumber))
+	}
+}
+
+// setTextChange sets a text frame when the change is present
+func (t *id3Tag) setTextChange(id string, value *string) {
+	if value != nil {
+		t.setText(id, *value)
+	}
+}
+
+// setText replaces the frames with the given ID by one holding value, in place of
+// the first of them; an empty value removes them
+func (t *id3Tag) setText(id, value string) {
+	frames := t.Frames[:0:0]
+	replaced := value == ""
+	for _, frame := range t.Frames {
+		if frame.ID != id {
+			frames = append(frames, frame)
+			continue
+		}
+		if !replaced {
+			frames = append(frames, id3Frame{ID: id, Data: encodeID3Text(t.Version, value)})
+			replaced = true
+		}
+	}
+	if !replaced {
+		frames = append(frames, id3Frame{ID: id, Data: encodeID3Text(t.Version, value)})
+	}
+	t.Frames = frames
+}
+
+// text returns the first value of a text frame, or "" when it is missing or its
+// data is compressed or encrypted
+func (t *id3Tag) text(id string) string {
+	for _, frame := range t.Frames {
+		if frame.ID != id {
+			continue
+		}
+		if t.Version == 3 && frame.Flags[1]&0xc0 != 0 || t.Version == 4 && frame.Flags[1]&0x0f != 0 {
+			return ""
+		}
+		return decodeID3Text(frame.Data)
+	}
+	return ""
+}
+
+// bytes serialises the tag followed by padding bytes of free space
+func (t *id3Tag) bytes(padding int) []byte {
+	var body bytes.Buffer
+	for _, frame := range t.Frames {
+		header := make([]byte, 10)
+		copy(header, frame.ID)
+		if t.Version == 4 {
+			putSyncsafe(header[4:8], uint32(len(frame.Data)))
+		} else {
+			binary.BigEndian.PutUint32(header[4:8], uint32(len(frame.Data)))
+		}
+		header[8], header[9] = frame.Flags[0], frame.Flags[1]
+		body.Write(header)
+		body.Write(frame.Data)
+	}
+
+	out := make([]byte, 10, 10+body.Len()+padding)
+	copy(out, "ID3")
+	out[3] = t.Version
+	putSyncsafe(out[6:10], uint32(body.Len()+padding))
+	out = append(out, body.Bytes()...)
+	return append(out, make([]byte, padding)...)
+}
+
+// decodeID3Text decodes the first string of a text frame
+func decodeID3Text(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+	encoding, text := data[0], data[1:]
+	switch encoding {
+	case 1, 2:
+		bigEndian := encoding == 2
+		if len(text) >= 2 && text[0] == 0xfe && text[1] == 0xff {
+			bigEndian, text = true, text[2:]
+		} else if len(text) >= 2 && text[0] == 0xff && text[1] == 0xfe {
+			bigEndian, text = false, text[2:]
+		}
+		units := make([]uint16, 0, len(text)/2)
+		for i := 0; i+1 < len(text); i += 2 {
+			unit := binary.LittleEndian.Uint16(text[i:])
+			if bigEndian {
+				unit = binary.BigEndian.Uint16(text[i:])
+			}
+			if unit == 0 {
+				break
+			}
+			units = append(units, unit)
+		}
+		return string(utf16.Decode(units))
+	case 3:
+		value, _, _ := strings.Cut(string(text), "\x00")
+		return value
+	default:
+		value, _, _ := bytes.Cut(text, []byte{0})
+		runes := make([]rune, len(value))
+		for i, b := range value {
+			runes[i] = rune(b)
+		}
+		return string(runes)
+	}
+}
+
+// encodeID3Text encodes a text frame: UTF-8 for v2.4; Latin-1 for v2.3 when the
+// text allows it, otherwise UTF-16 with a byte order mark
+func encodeID3Text(version byte, text string) []byte {
+	if version == 4 {
+		return append([]byte{3}, text...)
+	}
+	if latin1, ok := encodeLatin1(text); ok {
+		return append([]byte{0}, latin1...)
+	}
+	data := []byte{1, 0xff, 0xfe}
+	for _, unit := range utf16.Encode([]rune(text)) {
+		data = binary.LittleEndian.AppendUint16(data, unit)
+	}
+	return data
+}
+
+// encodeLatin1 converts text to Latin-1, reporting whether every rune fits
+func encodeLatin1(text string) ([]byte, bool) {
+	out := make([]byte, 0, len(text))
+	for _, r := range text {
+		if r > 0xff {
+			return nil, false
+		}
+		out = append(out, byte(r))
+	}
+	return out, true
+}
+
+// updateID3v1 writes changes into a 128-byte ID3v1 tag
+// ID3v1 has no album artist, and its genre is a fixed list, so those are left alone
+func updateID3v1(tag []byte, changes *model.TagChanges) {
+	setField := func(field []byte, value *string) {
+		if value == nil {
+			return
+		}
+		clear(field)
+		// Runes outside Latin-1 are replaced rather than dropping the value
+		for i, r := range []rune(*value) {
+			if i == len(field) {
+				break
+			}
+			if r > 0xff {
+				r = '?'
+			}
+			field[i] = byte(r)
+		}
+	}
+	setField(tag[3:33], changes.Title)
+	setField(tag[33:63], changes.Artist)
+	setField(tag[63:93], changes.Album)
+
+	if changes.Year != nil {
+		year := ""
+		if *changes.Year > 0 {
+			year = strconv.Itoa(*changes.Year)
+		}
+		setField(tag[93:97], &year)
+	}
+	// ID3v1.1 keeps the track in the last byte of the comment after a zero byte
+	if changes.TrackNumber != nil && tag[125] == 0 && *changes.TrackNumber >= 0 && *changes.TrackNumber <= 255 {
+		tag[126] = byte(*changes.TrackNumber)
+	}
+}
+
+// syncsafe decodes a 28-bit syncsafe integer
+func syncsafe(b []byte) uint32 {
+	return uint32(b[0]&0x7f)<<21 | uint32(b[1]&0x7f)<<14 | uint32(b[2]&0x7f)<<7 | uint32(b[3]&0x7f)
+}
+
+// putSyncsafe encodes a 28-bit syncsafe integer
+func putSyncsafe(b []byte, v uint32) {
+	b[0], b[1], b[2], b[3] = byte(v>>21&0x7f), byte(v>>14&0x7f), byte(v>>7&0x7f), byte(v&0x7f)
+}
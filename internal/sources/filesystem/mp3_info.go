@@ -0,0 +1,223 @@
+package filesystem
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"math"
+	"time"
+
+	"GoMusic/internal/decoder"
+)
+
+// MPEG audio channel modes as stored in the frame header
+var mpegChannelModes = [4]string{"stereo", "joint stereo", "dual channel", "mono"}
+
+// A file without a VBR header is treated as constant bit rate when runs of
+// cbrProbeFrames frames at cbrProbePoints places spread across the file all
+// share the first frame's bit rate
+const (
+	cbrProbeFrames = 32
+	cbrProbePoints = 8
+)
+
+// mp3Info describes an MP3 stream, read from frame headers rather than by decoding
+type mp3Info struct {
+	SampleRate      int
+	Channels        int
+	ChannelMode     string
+	VBR             bool
+	Frames          int64 // audio frames, excluding a Xing/Info/VBRI frame
+	SamplesPerFrame int
+	AudioBytes      int64 // MPEG audio data, excluding tags and the info frame
+
+	// LAME gapless info, zero when absent
+	EncoderDelay   int
+	EncoderPadding int
+}
+
+// Duration returns the playing time, trimmed by encoder delay and padding when known
+func (i *mp3Info) Duration() time.Duration {
+	samples := i.TotalSamples()
+	if samples == 0 {
+		samples = i.Frames * int64(i.SamplesPerFrame)
+	}
+	return time.Duration(float64(samples) / float64(i.SampleRate) * float64(time.Second))
+}
+
+// TotalSamples returns the exact sample count from the LAME tag, or 0 when unknown
+func (i *mp3Info) TotalSamples() int64 {
+	if i.EncoderDelay == 0 && i.EncoderPadding == 0 {
+		return 0
+	}
+	exact := i.Frames*int64(i.SamplesPerFrame) - int64(i.EncoderDelay) - int64(i.EncoderPadding)
+	return max(exact, 0)
+}
+
+// BitRate returns the average audio bit rate in kbps
+func (i *mp3Info) BitRate() int {
+	seconds := float64(i.Frames*int64(i.SamplesPerFrame)) / float64(i.SampleRate)
+	if seconds <= 0 {
+		return 0
+	}
+	return int(float64(i.AudioBytes)*8/seconds/1000 + 0.5)
+}
+
+// readMP3Info reads stream properties from the frame headers of an MP3 file
+// Uses the Xing/Info or VBRI header when present, assumes CBR when frames
+// sampled across the file share a bit rate, and otherwise walks every frame header
+// Returns nil when no MPEG audio frames are found
+func readMP3Info(r io.ReadSeeker, size int64) *mp3Info {
+	start := decoder.SkipID3v2(r)
+	end := audioEnd(r, size)
+
+	// Locate the first frame, confirmed by a second header right after it
+	buf := make([]byte, 64*1024)
+	if _, err := r.Seek(start, io.SeekStart); err != nil {
+		return nil
+	}
+	n, _ := io.ReadFull(r, buf)
+	buf = buf[:n]
+
+	offset, header := decoder.FindMPEGFrame(buf)
+	if offset < 0 {
+		return nil
+	}
+
+	info := &mp3Info{
+		SampleRate:      header.SampleRate,
+		Channels:        2,
+		ChannelMode:     mpegChannelModes[header.ChannelMode],
+		SamplesPerFrame: header.SamplesPerFrame(),
+	}
+	if header.ChannelMode == 3 {
+		info.Channels = 1
+	}
+
+	firstFrame := start + int64(offset)
+	if infoFrame, ok := decoder.ParseMPEGInfoFrame(header, buf[offset:]); ok && infoFrame.Frames > 0 {
+		info.VBR = infoFrame.VBR
+		info.Frames = infoFrame.Frames
+		info.AudioBytes = infoFrame.AudioBytes
+		info.EncoderDelay = infoFrame.EncoderDelay
+		info.EncoderPadding = infoFrame.EncoderPadding
+		if info.AudioBytes == 0 {
+			info.AudioBytes = end - firstFrame - int64(header.FrameLength())
+		}
+		return info
+	}
+
+	// No VBR header: a constant bit rate lets the size give the frame count
+	if constantBitRate(r, firstFrame, end, header.BitRate) {
+		info.AudioBytes = end - firstFrame
+		bytesPerFrame := float64(header.BitRate) * 1000 / 8 * float64(info.SamplesPerFrame) / float64(header.SampleRate)
+		info.Frames = int64(math.Round(float64(info.AudioBytes) / bytesPerFrame))
+		return info
+	}
+
+	info.VBR = true
+	info.Frames, info.AudioBytes, _ = countFrames(r, firstFrame, end, 0, 0)
+	return info
+}
+
+// constantBitRate probes runs of frames from the start to the end of the audio
+// and reports whether every frame seen is at bitRate
+// VBR files without a Xing header often open with frames at one rate, so the
+// first frames alone cannot tell
+func constantBitRate(r io.ReadSeeker, start, end int64, bitRate int) bool {
+	span := end - start
+	for i := int64(0); i < cbrProbePoints; i++ {
+		frames, _, constant := countFrames(r, start+span*i/cbrProbePoints, end, cbrProbeFrames, bitRate)
+		if !constant || (i == 0 && frames == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// countFrames walks frame headers from start to end without decoding,
+// resyncing on the next header when start is not on a frame boundary
+// Stops after limit frames when limit > 0; constant reports whether all frames
+// seen share one bit rate, which is bitRate when non-zero
+func countFrames(r io.ReadSeeker, start, end int64, limit int64, bitRate int) (frames, bytes int64, constant bool) {
+	if _, err := r.Seek(start, io.SeekStart); err != nil {
+		return 0, 0, false
+	}
+	br := bufio.NewReaderSize(r, 64*1024)
+
+	window := make([]byte, 4)
+	if _, err := io.ReadFull(br, window); err != nil {
+		return 0, 0, false
+	}
+
+	constant = true
+	pos := start
+	for pos+4 <= end && (limit == 0 || frames < limit) {
+		h, ok := decoder.ParseMPEGFrameHeader(window)
+		if !ok {
+			// Lost sync: slide the window one byte and look again
+			next, err := br.ReadByte()
+			if err != nil {
+				break
+			}
+			copy(window, window[1:])
+			window[3] = next
+			pos++
+			continue
+		}
+
+		length := int64(h.FrameLength())
+		if pos+length > end {
+			break
+		}
+		if bitRate != 0 && h.BitRate != bitRate {
+			constant = false
+		}
+		bitRate = h.BitRate
+		frames++
+		bytes += length
+		pos += length
+
+		if _, err := br.Discard(int(length) - 4); err != nil {
+			break
+		}
+		if _, err := io.ReadFull(br, window); err != nil {
+			break
+		}
+	}
+
+	return frames, bytes, constant
+}
+
+// audioEnd returns the offset where MPEG audio ends, before ID3v1 and APEv2 tags
+func audioEnd(r io.ReadSeeker, size int64) int64 {
+	end := size
+
+	tail := make([]byte, 128)
+	if end >= 128 {
+		if _, err := r.Seek(end-128, io.SeekStart); err == nil {
+			if _, err := io.ReadFull(r, tail); err == nil && string(tail[:3]) == "TAG" {
+				end -= 128
+			}
+		}
+	}
+
+	// APEv2 footer: "APETAGEX", version, tag size (excluding the header)
+	footer := make([]byte, 32)
+	if end >= 32 {
+		if _, err := r.Seek(end-32, io.SeekStart); err == nil {
+			if _, err := io.ReadFull(r, footer); err == nil && string(footer[:8]) == "APETAGEX" {
+				tagSize := int64(binary.LittleEndian.Uint32(footer[12:16]))
+				flags := binary.LittleEndian.Uint32(footer[20:24])
+				if flags&0x80000000 != 0 {
+					tagSize += 32 // header present
+				}
+				if tagSize <= end {
+					end -= tagSize
+				}
+			}
+		}
+	}
+
+	return end
+}
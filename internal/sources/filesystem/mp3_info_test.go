@@ -0,0 +1,110 @@
+package filesystem
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// MPEG-1 Layer III stereo 44.1 kHz frame headers and their frame lengths
+var (
+	mp3Header128k = []byte{0xff, 0xfb, 0x90, 0x00}
+	mp3Header192k = []byte{0xff, 0xfb, 0xb0, 0x00}
+)
+
+const (
+	mp3Frame128k = 417
+	mp3Frame192k = 626
+)
+
+// mp3Frames builds count silent frames with the given header and length
+func mp3Frames(header []byte, length, count int) []byte {
+	var out []byte
+	for range count {
+		frame := make([]byte, length)
+		copy(frame, header)
+		out = append(out, frame...)
+	}
+	return out
+}
+
+// mp3XingFrame builds a 128 kbps frame holding a Xing header with a LAME
+// extension giving the encoder delay and padding
+func mp3XingFrame(frames, audioBytes, delay, padding int) []byte {
+	frame := mp3Frames(mp3Header128k, mp3Frame128k, 1)
+	xing := frame[4+32:]
+	copy(xing, "Xing")
+	binary.BigEndian.PutUint32(xing[4:8], 0x3)
+	binary.BigEndian.PutUint32(xing[8:12], uint32(frames))
+	binary.BigEndian.PutUint32(xing[12:16], uint32(audioBytes+mp3Frame128k))
+	lame := xing[16:]
+	copy(lame, "LAME3.100")
+	lame[21] = byte(delay >> 4)
+	lame[22] = byte(delay&0xf)<<4 | byte(padding>>8)
+	lame[23] = byte(padding)
+	return frame
+}
+
+func TestReadMP3Info(t *testing.T) {
+	id3 := []byte{'I', 'D', '3', 3, 0, 0, 0, 0, 0, 20}
+	id3 = append(id3, make([]byte, 20)...)
+	id3v1 := append([]byte("TAG"), make([]byte, 125)...)
+
+	// An ID3v2 header claiming more than the file holds
+	oversizedID3 := append([]byte{'I', 'D', '3', 3, 0, 0, 0x7f, 0x7f, 0x7f, 0x7f}, mp3Frames(mp3Header128k, mp3Frame128k, 4)...)
+
+	tests := []struct {
+		name string
+		data []byte
+		want *mp3Info
+	}{
+		{
+			name: "CBR between ID3v2 and ID3v1 tags",
+			data: bytes.Join([][]byte{id3, mp3Frames(mp3Header128k, mp3Frame128k, 40), id3v1}, nil),
+			want: &mp3Info{SampleRate: 44100, Channels: 2, ChannelMode: "stereo", Frames: 40, SamplesPerFrame: 1152, AudioBytes: 40 * mp3Frame128k},
+		},
+		{
+			name: "Xing header with LAME gapless info",
+			data: append(mp3XingFrame(100, 100*mp3Frame128k, 576, 1000), mp3Frames(mp3Header128k, mp3Frame128k, 2)...),
+			want: &mp3Info{SampleRate: 44100, Channels: 2, ChannelMode: "stereo", VBR: true, Frames: 100, SamplesPerFrame: 1152, AudioBytes: 100 * mp3Frame128k, EncoderDelay: 576, EncoderPadding: 1000},
+		},
+		{
+			name: "VBR without a header, constant at the start",
+			data: append(mp3Frames(mp3Header128k, mp3Frame128k, 40), mp3Frames(mp3Header192k, mp3Frame192k, 40)...),
+			want: &mp3Info{SampleRate: 44100, Channels: 2, ChannelMode: "stereo", VBR: true, Frames: 80, SamplesPerFrame: 1152, AudioBytes: 40*mp3Frame128k + 40*mp3Frame192k},
+		},
+		{
+			name: "junk before the first frame",
+			data: append(bytes.Repeat([]byte{0xff, 0x00}, 50), mp3Frames(mp3Header128k, mp3Frame128k, 10)...),
+			want: &mp3Info{SampleRate: 44100, Channels: 2, ChannelMode: "stereo", Frames: 10, SamplesPerFrame: 1152, AudioBytes: 10 * mp3Frame128k},
+		},
+		{
+			name: "mono",
+			data: mp3Frames([]byte{0xff, 0xfb, 0x90, 0xc0}, mp3Frame128k, 10),
+			want: &mp3Info{SampleRate: 44100, Channels: 1, ChannelMode: "mono", Frames: 10, SamplesPerFrame: 1152, AudioBytes: 10 * mp3Frame128k},
+		},
+		{
+			name: "lone truncated frame",
+			data: mp3Header128k,
+			want: &mp3Info{SampleRate: 44100, Channels: 2, ChannelMode: "stereo", VBR: true, SamplesPerFrame: 1152},
+		},
+		{name: "no frames", data: bytes.Repeat([]byte("not an mp3 "), 100)},
+		{name: "reserved sample rate", data: mp3Frames([]byte{0xff, 0xfb, 0x9c, 0x00}, mp3Frame128k, 10)},
+		{name: "ID3v2 tag past the end", data: oversizedID3},
+		{name: "empty file", data: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := readMP3Info(bytes.NewReader(tt.data), int64(len(tt.data)))
+			switch {
+			case tt.want == nil && got != nil:
+				t.Fatalf("readMP3Info() = %+v, want nil", got)
+			case tt.want != nil && got == nil:
+				t.Fatalf("readMP3Info() = nil, want %+v", tt.want)
+			case tt.want != nil && *got != *tt.want:
+				t.Fatalf("readMP3Info() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,451 @@
+package filesystem
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"time"
+)
+
+// maxMoovSize caps how much of the movie atom is read into memory; audio files keep
+// their sample tables well under this, and artwork lives in udta or mdat
+const maxMoovSize = 64 << 20
+
+// mp4SampleRates indexes AAC sample rates by the AudioSpecificConfig frequency index
+var mp4SampleRates = [13]int{96000, 88200, 64000, 48000, 44100, 32000, 24000, 22050, 16000, 12000, 11025, 8000, 7350}
+
+// mp4Info describes the first audio track of an MP4/M4A container
+type mp4Info struct {
+	Codec      string // AAC, HE-AAC, HE-AACv2, ALAC, FLAC, ...
+	Lossless   bool
+	SampleRate int
+	Channels   int
+	BitDepth   int // lossless codecs only
+	Duration   time.Duration
+	AudioBytes int64 // sum of the sample sizes, 0 when unknown
+	AvgBitRate int   // bits per second as declared by the codec config, 0 when absent
+}
+
+// BitRate returns the average audio bit rate in kbps
+func (i *mp4Info) BitRate() int {
+	if i.AudioBytes > 0 && i.Duration > 0 {
+		return int(float64(i.AudioBytes)*8/i.Duration.Seconds()/1000 + 0.5)
+	}
+	return (i.AvgBitRate + 500) / 1000
+}
+
+// readMP4Info walks the top-level atoms and parses the movie atom
+// Returns nil when the file has no playable audio track
+func readMP4Info(r io.ReadSeeker, size int64) *mp4Info {
+	moov, mdatBytes := readMoov(r, size)
+	if moov == nil {
+		return nil
+	}
+
+	info := parseMoov(moov)
+	if info == nil {
+		return nil
+	}
+	// Without a sample size table fall back to the media data size
+	if info.AudioBytes == 0 && info.AvgBitRate == 0 {
+		info.AudioBytes = mdatBytes
+	}
+	return info
+}
+
+// readMoov walks the top-level atoms and returns the body of the movie atom,
+// nil when missing or too large, with the total size of the media data
+func readMoov(r io.ReadSeeker, size int64) ([]byte, int64) {
+	var moov []byte
+	var mdatBytes int64
+
+	header := make([]byte, 16)
+	for offset := int64(0); offset+8 <= size; {
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return nil, 0
+		}
+		if _, err := io.ReadFull(r, header[:8]); err != nil {
+			return nil, 0
+		}
+
+		atomSize := int64(binary.BigEndian.Uint32(header[:4]))
+		atomType := string(header[4:8])
+		headerSize := int64(8)
+		switch atomSize {
+		case 0:
+			atomSize = size - offset
+		case 1:
+			if _, err := io.ReadFull(r, header[8:16]); err != nil {
+				return nil, 0
+			}
+			atomSize = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerSize = 16
+		}
+		if atomSize < headerSize || offset+atomSize > size {
+			break
+		}
+
+		switch atomType {
+		case "moov":
+			if atomSize-headerSize > maxMoovSize {
+				return nil, 0
+			}
+			moov = make([]byte, atomSize-headerSize)
+			if _, err := io.ReadFull(r, moov); err != nil {
+				return nil, 0
+			}
+		case "mdat":
+			mdatBytes += atomSize - headerSize
+		}
+		offset += atomSize
+	}
+	return moov, mdatBytes
+}
+
+// parseMoov finds the first sound track and reads its timing and sample description
+func parseMoov(moov []byte) *mp4Info {
+	var info *mp4Info
+	var movieDuration time.Duration
+
+	forEachAtom(moov, func(atomType string, body []byte) bool {
+		switch atomType {
+		case "mvhd":
+			movieDuration = parseMediaDuration(body)
+		case "trak":
+			if mdia := findAtom(body, "mdia"); mdia != nil {
+				info = parseSoundTrack(mdia)
+			}
+		}
+		return info == nil
+	})
+	if info == nil {
+		return nil
+	}
+	if info.Duration == 0 {
+		info.Duration = movieDuration
+	}
+	return info
+}
+
+// parseSoundTrack reads a trak's mdia atom, returning nil when it is not audio
+func parseSoundTrack(mdia []byte) *mp4Info {
+	hdlr := findAtom(mdia, "hdlr")
+	if len(hdlr) < 12 || string(hdlr[8:12]) != "soun" {
+		return nil
+	}
+	stbl := findAtom(findAtom(mdia, "minf"), "stbl")
+	stsd := findAtom(stbl, "stsd")
+	if len(stsd) < 8 {
+		return nil
+	}
+
+	// Only the first sample description is used; multi-entry audio tracks are rare
+	info := &mp4Info{}
+	forEachAtom(stsd[8:], func(format string, entry []byte) bool {
+		parseAudioSampleEntry(info, format, entry)
+		return false
+	})
+	if info.Codec == "" {
+		return nil
+	}
+
+	if mdhd := findAtom(mdia, "mdhd"); mdhd != nil {
+		info.Duration = parseMediaDuration(mdhd)
+		if info.SampleRate == 0 {
+			info.SampleRate = int(mediaTimescale(mdhd))
+		}
+	}
+	info.AudioBytes = sampleTableBytes(findAtom(stbl, "stsz"))
+	return info
+}
+
+// parseAudioSampleEntry reads the QuickTime/ISO audio sample entry and its codec config
+func parseAudioSampleEntry(info *mp4Info, format string, entry []byte) {
+	if len(entry) < 28 {
+		return
+	}
+	version := binary.BigEndian.Uint16(entry[8:10])
+	info.Channels = int(binary.BigEndian.Uint16(entry[16:18]))
+	info.SampleRate = int(binary.BigEndian.Uint32(entry[24:28]) >> 16)
+
+	children := 28
+	switch version {
+	case 1:
+		children = 44
+	case 2:
+		// Version 2 moves the real rate and channel count into the extension
+		children = 64
+		if len(entry) >= 44 {
+			info.SampleRate = int(math.Float64frombits(binary.BigEndian.Uint64(entry[32:40])))
+			info.Channels = int(binary.BigEndian.Uint32(entry[40:44]))
+		}
+	}
+	if len(entry) < children {
+		children = len(entry)
+	}
+	configs := entry[children:]
+
+	switch format {
+	case "mp4a", "drms":
+		info.Codec = "AAC"
+		if esds := findAtom(configs, "esds"); len(esds) > 4 {
+			parseESDS(info, esds[4:])
+		}
+	case "alac":
+		info.Codec = "ALAC"
+		info.Lossless = true
+		if alac := findAtom(configs, "alac"); len(alac) >= 28 {
+			config := alac[4:]
+			info.BitDepth = int(config[5])
+			info.Channels = int(config[9])
+			info.AvgBitRate = int(binary.BigEndian.Uint32(config[16:20]))
+			info.SampleRate = int(binary.BigEndian.Uint32(config[20:24]))
+		}
+	case "fLaC":
+		info.Codec = "FLAC"
+		info.Lossless = true
+		// dfLa holds a STREAMINFO block after the version and block header
+		if dfla := findAtom(configs, "dfLa"); len(dfla) >= 26 {
+			packed := binary.BigEndian.Uint64(dfla[18:26])
+			info.SampleRate = int(packed >> 44)
+			info.Channels = int(packed>>41&0x7) + 1
+			info.BitDepth = int(packed>>36&0x1f) + 1
+		}
+	case "Opus":
+		info.Codec = "Opus"
+		info.SampleRate = 48000
+	case "ac-3":
+		info.Codec = "AC-3"
+	case "ec-3":
+		info.Codec = "E-AC-3"
+	}
+}
+
+// parseESDS reads the MPEG-4 elementary stream descriptor for the codec profile and bit rate
+func parseESDS(info *mp4Info, data []byte) {
+	tag, es, _ := readDescriptor(data)
+	if tag != 0x03 || len(es) < 3 {
+		return
+	}
+	flags := es[2]
+	es = es[3:]
+	if flags&0x80 != 0 && len(es) >= 2 {
+		es = es[2:]
+	}
+	if flags&0x40 != 0 && len(es) >= 1 {
+		urlLen := int(es[0])
+		if len(es) < 1+urlLen {
+			return
+		}
+		es = es[1+urlLen:]
+	}
+	if flags&0x20 != 0 && len(es) >= 2 {
+		es = es[2:]
+	}
+
+	tag, config, _ := readDescriptor(es)
+	if tag != 0x04 || len(config) < 13 {
+		return
+	}
+	switch config[0] {
+	case 0x69, 0x6b:
+		info.Codec = "MP3"
+	}
+	info.AvgBitRate = int(binary.BigEndian.Uint32(config[9:13]))
+
+	if tag, specific, _ := readDescriptor(config[13:]); tag == 0x05 && config[0] == 0x40 {
+		parseAudioSpecificConfig(info, specific)
+	}
+}
+
+// parseAudioSpecificConfig reads the AAC object type and, for explicitly
+// signalled HE-AAC, the SBR output sample rate
+func parseAudioSpecificConfig(info *mp4Info, data []byte) {
+	br := &bitReader{data: data}
+	objectType := br.read(5)
+	if objectType == 31 {
+		objectType = 32 + br.read(6)
+	}
+	rate := readASCSampleRate(br)
+	channelConfig := br.read(4)
+	if br.overrun {
+		return
+	}
+
+	switch objectType {
+	case 5, 29:
+		info.Codec = "HE-AAC"
+		if objectType == 29 {
+			info.Codec = "HE-AACv2"
+		}
+		if ext := readASCSampleRate(br); !br.overrun && ext > 0 {
+			rate = ext
+		}
+	case 23:
+		info.Codec = "AAC-LD"
+	case 39:
+		info.Codec = "AAC-ELD"
+	}
+
+	if rate > 0 {
+		info.SampleRate = rate
+	}
+	// Configuration 7 is 7.1; 1-6 map directly to channel counts
+	switch {
+	case channelConfig >= 1 && channelConfig <= 6:
+		info.Channels = channelConfig
+	case channelConfig == 7:
+		info.Channels = 8
+	}
+}
+
+// readASCSampleRate reads a frequency index, or the explicit 24-bit rate for escape value 15
+func readASCSampleRate(br *bitReader) int {
+	index := br.read(4)
+	if index == 15 {
+		return br.read(24)
+	}
+	if index < len(mp4SampleRates) {
+		return mp4SampleRates[index]
+	}
+	return 0
+}
+
+// readDescriptor splits an MPEG-4 descriptor into its tag, payload and the bytes after it
+func readDescriptor(data []byte) (tag byte, payload, rest []byte) {
+	if len(data) < 2 {
+		return 0, nil, nil
+	}
+	tag = data[0]
+	length, i := 0, 1
+	// The length uses up to four bytes, with the high bit marking continuation
+	for ; i < len(data) && i <= 4; i++ {
+		length = length<<7 | int(data[i]&0x7f)
+		if data[i]&0x80 == 0 {
+			i++
+			break
+		}
+	}
+	if i+length > len(data) {
+		length = len(data) - i
+	}
+	return tag, data[i : i+length], data[i+length:]
+}
+
+// parseMediaDuration reads the duration from an mvhd or mdhd body
+func parseMediaDuration(body []byte) time.Duration {
+	timescale := mediaTimescale(body)
+	if timescale == 0 {
+		return 0
+	}
+
+	var duration uint64
+	if body[0] == 1 {
+		duration = binary.BigEndian.Uint64(body[24:32])
+	} else {
+		duration = uint64(binary.BigEndian.Uint32(body[16:20]))
+		if duration == math.MaxUint32 {
+			return 0
+		}
+	}
+	return time.Duration(float64(duration) / float64(timescale) * float64(time.Second))
+}
+
+// mediaTimescale reads the time units per second from an mvhd or mdhd body
+func mediaTimescale(body []byte) uint32 {
+	if len(body) < 20 {
+		return 0
+	}
+	if body[0] == 1 {
+		if len(body) < 32 {
+			return 0
+		}
+		return binary.BigEndian.Uint32(body[20:24])
+	}
+	return binary.BigEndian.Uint32(body[12:16])
+}
+
+// sampleTableBytes sums the sample sizes in an stsz body
+func sampleTableBytes(stsz []byte) int64 {
+	if len(stsz) < 12 {
+		return 0
+	}
+	uniform := int64(binary.BigEndian.Uint32(stsz[4:8]))
+	count := int64(binary.BigEndian.Uint32(stsz[8:12]))
+	if uniform > 0 {
+		return uniform * count
+	}
+
+	table := stsz[12:]
+	if int64(len(table)) < count*4 {
+		return 0
+	}
+	var total int64
+	for i := int64(0); i < count; i++ {
+		total += int64(binary.BigEndian.Uint32(table[i*4:]))
+	}
+	return total
+}
+
+// findAtom returns the body of the first child atom of the given type, or nil
+func findAtom(data []byte, atomType string) []byte {
+	var found []byte
+	forEachAtom(data, func(t string, body []byte) bool {
+		if t == atomType {
+			found = body
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// forEachAtom calls fn for each atom in data until fn returns false or the data is malformed
+// Returns the bytes after the last atom visited, empty when the atoms filled data
+func forEachAtom(data []byte, fn func(atomType string, body []byte) bool) []byte {
+	for len(data) >= 8 {
+		size := uint64(binary.BigEndian.Uint32(data[:4]))
+		atomType := string(data[4:8])
+		headerSize := uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return data
+			}
+			size = binary.BigEndian.Uint64(data[8:16])
+			headerSize = 16
+		}
+		if size < headerSize || size > uint64(len(data)) {
+			return data
+		}
+		if !fn(atomType, data[headerSize:size]) {
+			return data[size:]
+		}
+		data = data[size:]
+	}
+	return data
+}
+
+// bitReader reads big-endian bit fields, recording rather than panicking on overrun
+type bitReader struct {
+	data    []byte
+	pos     int
+	overrun bool
+}
+
+// read returns the next n bits (n <= 32)
+func (b *bitReader) read(n int) int {
+	value := 0
+	for ; n > 0; n-- {
+		if b.pos >= len(b.data)*8 {
+			b.overrun = true
+			return 0
+		}
+		bit := b.data[b.pos/8] >> (7 - b.pos%8) & 1
+		value = value<<1 | int(bit)
+		b.pos++
+	}
+	return value
+}
@@ -0,0 +1,141 @@
+package filesystem
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+)
+
+// mp4Atom builds an atom from its type and body parts
+func mp4Atom(atomType string, parts ...[]byte) []byte {
+	body := bytes.Join(parts, nil)
+	out := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+	return append(append(out, atomType...), body...)
+}
+
+// mp4FullBox returns the version and flags of a full box followed by fields
+func mp4FullBox(fields ...uint32) []byte {
+	out := make([]byte, 4)
+	for _, field := range fields {
+		out = binary.BigEndian.AppendUint32(out, field)
+	}
+	return out
+}
+
+// mp4SoundTrack builds a trak holding an AAC-LC stereo 44.1 kHz track of
+// two samples (100 and 200 bytes) lasting two seconds
+func mp4SoundTrack(handler string) []byte {
+	mdhd := mp4Atom("mdhd", mp4FullBox(0, 0, 44100, 88200), make([]byte, 4))
+	hdlr := mp4Atom("hdlr", mp4FullBox(0), []byte(handler), make([]byte, 12))
+
+	entry := make([]byte, 28)
+	binary.BigEndian.PutUint16(entry[6:8], 1)
+	binary.BigEndian.PutUint16(entry[16:18], 2)
+	binary.BigEndian.PutUint16(entry[18:20], 16)
+	binary.BigEndian.PutUint32(entry[24:28], 44100<<16)
+	decoderConfig := []byte{0x40, 0x15, 0, 0, 0}
+	decoderConfig = binary.BigEndian.AppendUint32(decoderConfig, 128000)
+	decoderConfig = binary.BigEndian.AppendUint32(decoderConfig, 128000)
+	decoderConfig = append(decoderConfig, 0x05, 2, 0x12, 0x10)
+	es := append([]byte{0, 1, 0, 0x04, byte(len(decoderConfig))}, decoderConfig...)
+	esds := mp4Atom("esds", make([]byte, 4), append([]byte{0x03, byte(len(es))}, es...))
+	stsd := mp4Atom("stsd", mp4FullBox(1), mp4Atom("mp4a", entry, esds))
+	stsz := mp4Atom("stsz", mp4FullBox(0, 2, 100, 200))
+
+	return mp4Atom("trak", mp4Atom("mdia", mdhd, hdlr, mp4Atom("minf", mp4Atom("stbl", stsd, stsz))))
+}
+
+// mp4File builds a file from an ftyp atom, the given moov body and 300 bytes of media data
+func mp4File(moovParts ...[]byte) []byte {
+	ftyp := mp4Atom("ftyp", []byte("M4A "), make([]byte, 4))
+	return bytes.Join([][]byte{ftyp, mp4Atom("moov", moovParts...), mp4Atom("mdat", make([]byte, 300))}, nil)
+}
+
+func TestReadMP4Info(t *testing.T) {
+	mvhd := mp4Atom("mvhd", mp4FullBox(0, 0, 1000, 3000), make([]byte, 80))
+	valid := mp4File(mvhd, mp4SoundTrack("soun"))
+
+	// The moov atom claims more bytes than the file holds
+	overrun := bytes.Clone(valid)
+	binary.BigEndian.PutUint32(overrun[16:20], uint32(len(valid)))
+
+	// An atom shorter than its own header
+	undersized := bytes.Clone(valid)
+	binary.BigEndian.PutUint32(undersized[16:20], 4)
+
+	// A 64-bit size field cut off by the end of the file
+	largeSize := append(mp4Atom("ftyp", []byte("M4A "), make([]byte, 4)), 0, 0, 0, 1, 'm', 'o', 'o', 'v', 0, 0)
+
+	tests := []struct {
+		name string
+		data []byte
+		want *mp4Info
+	}{
+		{
+			name: "AAC track",
+			data: valid,
+			want: &mp4Info{Codec: "AAC", SampleRate: 44100, Channels: 2, Duration: 2 * time.Second, AudioBytes: 300, AvgBitRate: 128000},
+		},
+		{name: "no moov", data: mp4Atom("ftyp", []byte("M4A "), make([]byte, 4))},
+		{name: "no sound track", data: mp4File(mvhd, mp4SoundTrack("vide"))},
+		{name: "moov runs past the end", data: overrun},
+		{name: "atom shorter than its header", data: undersized},
+		{name: "truncated 64-bit size", data: largeSize},
+		{name: "truncated trak", data: mp4File(mvhd, mp4SoundTrack("soun")[:40])},
+		{name: "empty file", data: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := readMP4Info(bytes.NewReader(tt.data), int64(len(tt.data)))
+			switch {
+			case tt.want == nil && got != nil:
+				t.Fatalf("readMP4Info() = %+v, want nil", got)
+			case tt.want != nil && got == nil:
+				t.Fatalf("readMP4Info() = nil, want %+v", tt.want)
+			case tt.want != nil && *got != *tt.want:
+				t.Fatalf("readMP4Info() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMP4Box(t *testing.T) {
+	ilst := mp4Atom("ilst", mp4Atom("\xa9nam", mp4DataAtom(1, []byte("Title"))))
+	meta := mp4Atom("meta", mp4FullBox(), mp4Atom("hdlr", make([]byte, 25)), ilst)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr bool
+	}{
+		{name: "udta", data: mp4Atom("udta", meta)},
+		{name: "udta with a zero terminator", data: mp4Atom("udta", meta, make([]byte, 4))},
+		{name: "udta with trailing bytes", data: mp4Atom("udta", meta, []byte{0, 0, 0, 1}), wantErr: true},
+		{name: "ilst with a partial child", data: mp4Atom("ilst", mp4Atom("\xa9nam", []byte("x")), []byte{0, 0, 0, 9}), wantErr: true},
+		{name: "trailing atom", data: append(mp4Atom("udta", meta), mp4Atom("free")...), wantErr: true},
+		{name: "size past the end", data: mp4Atom("udta", meta)[:20], wantErr: true},
+		{name: "empty", data: nil, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			box, err := parseMP4Box(tt.data)
+			if tt.wantErr {
+				if !errors.Is(err, errMalformedMP4) {
+					t.Fatalf("parseMP4Box() error = %v, want %v", err, errMalformedMP4)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseMP4Box() error = %v", err)
+			}
+			// Whatever parses must be written back byte for byte
+			if out := box.appendTo(nil); !bytes.Equal(out, tt.data) {
+				t.Fatalf("appendTo() = %x, want %x", out, tt.data)
+			}
+		})
+	}
+}
@@ -0,0 +1,351 @@
+package filesystem
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+
+	"GoMusic/internal/domain/model"
+)
+
+// mp4Containers are the atoms on the path to the iTunes item list, which are
+// parsed into children; every other atom is kept as raw bytes
+var mp4Containers = map[string]bool{
+	"moov": true, "trak": true, "mdia": true, "minf": true, "stbl": true,
+	"udta": true, "meta": true, "ilst": true, "edts": true, "dinf": true,
+}
+
+var errMalformedMP4 = errors.New("malformed MP4 atom")
+
+// mp4Box is an atom held in memory while the movie header is rewritten
+type mp4Box struct {
+	Type     string
+	Prefix   []byte // version and flags of a full box, before its children
+	Data     []byte // body of a leaf atom
+	Children []*mp4Box
+	Trailer  []byte // zero terminator some writers end udta with
+}
+
+// rewriteMP4Tags replaces the iTunes metadata items of an MP4 file
+// Only the moov atom is rewritten; when it precedes the media data and changes
+// size, chunk offsets are shifted to match
+func rewriteMP4Tags(src *os.File, size int64, dst io.Writer, changes *model.TagChanges) error {
+	moovStart, moovEnd := int64(-1), int64(0)
+	header := make([]byte, 16)
+	for offset := int64(0); offset+8 <= size; {
+		if _, err := src.ReadAt(header[:8], offset); err != nil {
+			return err
+		}
+		atomSize := int64(binary.BigEndian.Uint32(header[:4]))
+		atomType := string(header[4:8])
+		switch atomSize {
+		case 0:
+			atomSize = size - offset
+		case 1:
+			if _, err := src.ReadAt(header[8:16], offset+8); err != nil {
+				return err
+			}
+			atomSize = int64(binary.BigEndian.Uint64(header[8:16]))
+		}
+		if atomSize < 8 || offset+atomSize > size {
+			return errMalformedMP4
+		}
+
+		switch atomType {
+		case "moov":
+			moovStart, moovEnd = offset, offset+atomSize
+		case "moof":
+			return errors.New("fragmented MP4 files cannot be tagged")
+		}
+		offset += atomSize
+	}
+	if moovStart < 0 {
+		return errors.New("MP4 file has no moov atom")
+	}
+	if moovEnd-moovStart > maxMoovSize {
+		return errors.New("MP4 moov atom is too large")
+	}
+
+	data := make([]byte, moovEnd-moovStart)
+	if _, err := src.ReadAt(data, moovStart); err != nil {
+		return err
+	}
+	moov, err := parseMP4Box(data)
+	if err != nil {
+		return err
+	}
+	if moov.Type != "moov" {
+		return errMalformedMP4
+	}
+	if moov.child("mvex") != nil {
+		return errors.New("fragmented MP4 files cannot be tagged")
+	}
+
+	applyMP4Changes(moov.itemList(), changes)
+
+	delta := moov.size() - int64(len(data))
+	if delta != 0 {
+		if err := moov.shiftChunkOffsets(moovEnd, delta); err != nil {
+			return err
+		}
+	}
+
+	if err := copyRange(dst, src, 0, moovStart); err != nil {
+		return err
+	}
+	if _, err := dst.Write(moov.appendTo(nil)); err != nil {
+		return err
+	}
+	return copyRange(dst, src, moovEnd, size)
+}
+
+// parseMP4Box parses a single atom that spans data
+func parseMP4Box(data []byte) (*mp4Box, error) {
+	var box *mp4Box
+	var err error
+	rest := forEachAtom(data, func(atomType string, body []byte) bool {
+		box, err = newMP4Box(atomType, body)
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	if box == nil || len(rest) > 0 {
+		return nil, errMalformedMP4
+	}
+	return box, nil
+}
+
+// newMP4Box builds a box from its body, parsing containers into children
+// A container's children must fill its body, or the box would lose what it
+// could not parse when written back; udta may end with a 32-bit zero terminator
+func newMP4Box(atomType string, body []byte) (*mp4Box, error) {
+	box := &mp4Box{Type: atomType}
+	if !mp4Containers[atomType] {
+		box.Data = body
+		return box, nil
+	}
+
+	// iTunes meta is a full box; QuickTime meta starts directly with children
+	if atomType == "meta" && len(body) >= 8 && string(body[4:8]) != "hdlr" {
+		box.Prefix, body = body[:4], body[4:]
+	}
+	var err error
+	rest := forEachAtom(body, func(childType string, childBody []byte) bool {
+		var child *mp4Box
+		child, err = newMP4Box(childType, childBody)
+		box.Children = append(box.Children, child)
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case len(rest) == 0:
+	case atomType == "udta" && len(rest) == 4 && binary.BigEndian.Uint32(rest) == 0:
+		box.Trailer = rest
+	default:
+		return nil, errMalformedMP4
+	}
+	return box, nil
+}
+
+// child returns the first child of the given type, or nil
+func (b *mp4Box) child(atomType string) *mp4Box {
+	for _, child := range b.Children {
+		if child.Type == atomType {
+			return child
+		}
+	}
+	return nil
+}
+
+// itemList returns moov/udta/meta/ilst, creating the missing atoms
+func (b *mp4Box) itemList() *mp4Box {
+	udta := b.child("udta")
+	if udta == nil {
+		udta = &mp4Box{Type: "udta"}
+		b.Children = append(b.Children, udta)
+	}
+	meta := udta.child("meta")
+	if meta == nil {
+		// The handler marks the atom as iTunes metadata
+		handler := make([]byte, 25)
+		copy(handler[8:], "mdirappl")
+		meta = &mp4Box{
+			Type:     "meta",
+			Prefix:   make([]byte, 4),
+			Children: []*mp4Box{{Type: "hdlr", Data: handler}},
+		}
+		udta.Children = append(udta.Children, meta)
+	}
+	ilst := meta.child("ilst")
+	if ilst == nil {
+		ilst = &mp4Box{Type: "ilst"}
+		meta.Children = append(meta.Children, ilst)
+	}
+	return ilst
+}
+
+// size returns the serialised size of the box
+func (b *mp4Box) size() int64 {
+	size := int64(8 + len(b.Prefix) + len(b.Data) + len(b.Trailer))
+	for _, child := range b.Children {
+		size += child.size()
+	}
+	if size > math.MaxUint32 {
+		size += 8
+	}
+	return size
+}
+
+// appendTo serialises the box onto out
+func (b *mp4Box) appendTo(out []byte) []byte {
+	size := b.size()
+	if size > math.MaxUint32 {
+		out = binary.BigEndian.AppendUint32(out, 1)
+		out = append(out, b.Type...)
+		out = binary.BigEndian.AppendUint64(out, uint64(size))
+	} else {
+		out = binary.BigEndian.AppendUint32(out, uint32(size))
+		out = append(out, b.Type...)
+	}
+	out = append(out, b.Prefix...)
+	out = append(out, b.Data...)
+	for _, child := range b.Children {
+		out = child.appendTo(out)
+	}
+	return append(out, b.Trailer...)
+}
+
+// shiftChunkOffsets moves every chunk offset at or past from by delta bytes
+func (b *mp4Box) shiftChunkOffsets(from, delta int64) error {
+	switch b.Type {
+	case "stco":
+		for pos := 8; pos+4 <= len(b.Data); pos += 4 {
+			offset := int64(binary.BigEndian.Uint32(b.Data[pos:]))
+			if offset < from {
+				continue
+			}
+			offset += delta
+			if offset < 0 || offset > math.MaxUint32 {
+				return errors.New("MP4 chunk offsets overflow; the file needs 64-bit offsets")
+			}
+			binary.BigEndian.PutUint32(b.Data[pos:], uint32(offset))
+		}
+	case "co64":
+		for pos := 8; pos+8 <= len(b.Data); pos += 8 {
+			offset := int64(binary.BigEndian.Uint64(b.Data[pos:]))
+			if offset >= from {
+				binary.BigEndian.PutUint64(b.Data[pos:], uint64(offset+delta))
+			}
+		}
+	}
+	for _, child := range b.Children {
+		if err := child.shiftChunkOffsets(from, delta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyMP4Changes writes changes into an iTunes item list
+func applyMP4Changes(ilst *mp4Box, changes *model.TagChanges) {
+	setChange := func(atomType string, value *string) {
+		if value != nil {
+			setMP4Text(ilst, atomType, *value)
+		}
+	}
+	setChange("\xa9nam", changes.Title)
+	setChange("\xa9ART", changes.Artist)
+	setChange("\xa9alb", changes.Album)
+	setChange("aART", changes.AlbumArtist)
+	if changes.Genre != nil {
+		// A free-text genre replaces an ID3v1 genre index
+		setMP4Text(ilst, "\xa9gen", *changes.Genre)
+		setMP4Item(ilst, "gnre", nil)
+	}
+	if changes.Year != nil {
+		setMP4Text(ilst, "\xa9day", yearValue(mp4Text(ilst, "\xa9day"), *changes.Year))
+	}
+	if changes.TrackNumber != nil {
+		setMP4Number(ilst, "trkn", *changes.TrackNumber, 8)
+	}
+	if changes.DiscNumber != nil {
+		setMP4Number(ilst, "disk", *changes.DiscNumber, 6)
+	}
+}
+
+// mp4ItemValue returns the payload of an item's data atom, or nil
+func mp4ItemValue(ilst *mp4Box, atomType string) []byte {
+	item := ilst.child(atomType)
+	if item == nil {
+		return nil
+	}
+	data := findAtom(item.Data, "data")
+	if len(data) < 8 {
+		return nil
+	}
+	return data[8:]
+}
+
+// mp4Text returns the text of an item, or ""
+func mp4Text(ilst *mp4Box, atomType string) string {
+	return string(mp4ItemValue(ilst, atomType))
+}
+
+// setMP4Text sets a UTF-8 text item; an empty value removes it
+func setMP4Text(ilst *mp4Box, atomType, value string) {
+	if value == "" {
+		setMP4Item(ilst, atomType, nil)
+		return
+	}
+	setMP4Item(ilst, atomType, mp4DataAtom(1, []byte(value)))
+}
+
+// setMP4Number sets a trkn or disk item, keeping the total of the existing value;
+// zero removes it
+func setMP4Number(ilst *mp4Box, atomType string, n, length int) {
+	if n <= 0 {
+		setMP4Item(ilst, atomType, nil)
+		return
+	}
+	value := make([]byte, length)
+	if old := mp4ItemValue(ilst, atomType); len(old) >= 6 {
+		copy(value[4:6], old[4:6])
+	}
+	binary.BigEndian.PutUint16(value[2:4], uint16(min(n, math.MaxUint16)))
+	setMP4Item(ilst, atomType, mp4DataAtom(0, value))
+}
+
+// mp4DataAtom builds a data atom with the given type indicator
+func mp4DataAtom(dataType uint32, value []byte) []byte {
+	out := binary.BigEndian.AppendUint32(nil, uint32(16+len(value)))
+	out = append(out, "data"...)
+	out = binary.BigEndian.AppendUint32(out, dataType)
+	out = binary.BigEndian.AppendUint32(out, 0)
+	return append(out, value...)
+}
+
+// setMP4Item replaces the items of the given type with one holding body, in
+// place of the first of them; a nil body removes them
+func setMP4Item(ilst *mp4Box, atomType string, body []byte) {
+	children := ilst.Children[:0:0]
+	replaced := body == nil
+	for _, child := range ilst.Children {
+		if child.Type != atomType {
+			children = append(children, child)
+			continue
+		}
+		if !replaced {
+			children = append(children, &mp4Box{Type: atomType, Data: body})
+			replaced = true
+		}
+	}
+	if !replaced {
+		children = append(children, &mp4Box{Type: atomType, Data: body})
+	}
+	ilst.Children = children
+}
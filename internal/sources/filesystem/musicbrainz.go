@@ -0,0 +1,62 @@
+package filesystem
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/dhowden/tag"
+
+	"GoMusic/internal/domain/model"
+)
+
+// musicBrainzProvider is the owner of the UFID frame holding an MP3's recording ID
+const musicBrainzProvider = "http://musicbrainz.org"
+
+// musicBrainzIDPattern matches a MusicBrainz identifier, which is a UUID
+var musicBrainzIDPattern = regexp.MustCompile(`[0-9A-Fa-f]{8}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{12}`)
+
+// Tag fields carrying MusicBrainz IDs
+// Vorbis comments and APEv2 use MUSICBRAINZ_*, ID3v2 TXXX frames and MP4 freeform
+// atoms Picard's "MusicBrainz ... Id" names
+var (
+	musicBrainzTrackFields        = []string{"musicbrainz_trackid", "musicbrainz track id"}
+	musicBrainzAlbumFields        = []string{"musicbrainz_albumid", "musicbrainz album id"}
+	musicBrainzReleaseGroupFields = []string{"musicbrainz_releasegroupid", "musicbrainz release group id"}
+	musicBrainzArtistFields       = []string{"musicbrainz_artistid", "musicbrainz artist id"}
+)
+
+// readMusicBrainzIDs sets a track's MusicBrainz IDs from its tags
+func readMusicBrainzIDs(track *model.Track, metadata tag.Metadata, values map[string]string) {
+	track.MusicBrainzTrackID = firstMusicBrainzID(values, musicBrainzTrackFields)
+	if track.MusicBrainzTrackID == "" {
+		for key, raw := range metadata.Raw() {
+			if ufid, ok := raw.(*tag.UFID); ok && strings.HasPrefix(key, "UFI") && ufid.Provider == musicBrainzProvider {
+				track.MusicBrainzTrackID = strings.ToLower(musicBrainzIDPattern.FindString(string(ufid.Identifier)))
+				break
+			}
+		}
+	}
+	track.MusicBrainzAlbumID = firstMusicBrainzID(values, musicBrainzAlbumFields)
+	track.MusicBrainzReleaseGroupID = firstMusicBrainzID(values, musicBrainzReleaseGroupFields)
+
+	// A credit such as "A feat. B" carries one ID per artist; none of them names
+	// the credit as a whole
+	for _, field := range musicBrainzArtistFields {
+		if ids := musicBrainzIDPattern.FindAllString(values[field], -1); len(ids) > 0 {
+			if len(ids) == 1 {
+				track.MusicBrainzArtistID = strings.ToLower(ids[0])
+			}
+			break
+		}
+	}
+}
+
+// firstMusicBrainzID returns the ID in the first of fields that holds one, or ""
+func firstMusicBrainzID(values map[string]string, fields []string) string {
+	for _, field := range fields {
+		if id := musicBrainzIDPattern.FindString(values[field]); id != "" {
+			return strings.ToLower(id)
+		}
+	}
+	return ""
+}
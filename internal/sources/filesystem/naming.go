@@ -0,0 +1,206 @@
+package filesystem
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"GoMusic/internal/domain/model"
+)
+
+// namingPlaceholder is a %name% placeholder of a naming pattern
+// Text placeholders match lazily within one path segment, so literal text after
+// them decides where they end
+type namingPlaceholder struct {
+	Field model.TagField // empty for %ignore%
+	Expr  string
+}
+
+// namingPlaceholders are the placeholders naming patterns may use
+var namingPlaceholders = map[string]namingPlaceholder{
+	"title":       {Field: model.TagFieldTitle, Expr: `[^/]+?`},
+	"artist":      {Field: model.TagFieldArtist, Expr: `[^/]+?`},
+	"albumartist": {Field: model.TagFieldAlbumArtist, Expr: `[^/]+?`},
+	"album":       {Field: model.TagFieldAlbum, Expr: `[^/]+?`},
+	"genre":       {Field: model.TagFieldGenre, Expr: `[^/]+?`},
+	"year":        {Field: model.TagFieldYear, Expr: `\d{4}`},
+	"track":       {Field: model.TagFieldTrackNumber, Expr: `\d{1,3}`},
+	"disc":        {Field: model.TagFieldDiscNumber, Expr: `\d{1,2}`},
+	"ignore":      {Expr: `[^/]*?`},
+}
+
+// namingPlaceholderPattern finds placeholders in a naming pattern
+var namingPlaceholderPattern = regexp.MustCompile(`%([A-Za-z]+)%`)
+
+// namingPattern is a compiled naming pattern
+type namingPattern struct {
+	regex  *regexp.Regexp
+	fields []model.TagField // field of each capture group
+}
+
+// ValidateNamingPattern checks that a naming pattern can be compiled
+func ValidateNamingPattern(pattern string) error {
+	_, err := compileNamingPattern(pattern)
+	return err
+}
+
+// compileNamingPattern compiles a pattern such as
+// "%albumartist%/%year% - %album%/%disc%-%track% %title%"
+// The pattern describes the end of a file's path without its extension; leading
+// folders it does not mention are allowed, so it need not start at the root
+func compileNamingPattern(pattern string) (*namingPattern, error) {
+	pattern = strings.Trim(strings.ReplaceAll(strings.TrimSpace(pattern), `\`, "/"), "/")
+	if pattern == "" {
+		return nil, fmt.Errorf("naming pattern is empty")
+	}
+
+	var expr strings.Builder
+	expr.WriteString(`(?:^|/)`)
+	compiled := &namingPattern{}
+	seen := make(map[string]bool)
+	last := 0
+	for _, loc := range namingPlaceholderPattern.FindAllStringSubmatchIndex(pattern, -1) {
+		name := strings.ToLower(pattern[loc[2]:loc[3]])
+		placeholder, ok := namingPlaceholders[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown placeholder %%%s%% in naming pattern", name)
+		}
+		if placeholder.Field != "" && seen[name] {
+			return nil, fmt.Errorf("placeholder %%%s%% appears twice in naming pattern", name)
+		}
+		seen[name] = true
+
+		expr.WriteString(regexp.QuoteMeta(pattern[last:loc[0]]))
+		if placeholder.Field == "" {
+			expr.WriteString("(?:" + placeholder.Expr + ")")
+		} else {
+			expr.WriteString("(" + placeholder.Expr + ")")
+			compiled.fields = append(compiled.fields, placeholder.Field)
+		}
+		last = loc[1]
+	}
+	if len(compiled.fields) == 0 {
+		return nil, fmt.Errorf("naming pattern %q has no placeholders", pattern)
+	}
+	expr.WriteString(regexp.QuoteMeta(pattern[last:]))
+	expr.WriteString(`$`)
+
+	regex, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid naming pattern: %w", err)
+	}
+	compiled.regex = regex
+	return compiled, nil
+}
+
+// compileNamingPatterns compiles patterns, skipping invalid ones
+// Patterns are validated when they are saved, so failures here come from
+// configuration edited by hand
+func compileNamingPatterns(patterns []string) []*namingPattern {
+	var compiled []*namingPattern
+	for _, pattern := range patterns {
+		if p, err := compileNamingPattern(pattern); err == nil {
+			compiled = append(compiled, p)
+		} else {
+			fmt.Printf("Ignoring naming pattern %q: %v\n", pattern, err)
+		}
+	}
+	return compiled
+}
+
+// match parses a path relative to the source root, without its extension
+// Returns the values of the pattern's fields, or nil when it does not match
+func (p *namingPattern) match(relativePath string) map[model.TagField]string {
+	groups := p.regex.FindStringSubmatch(relativePath)
+	if groups == nil {
+		return nil
+	}
+	values := make(map[model.TagField]string, len(p.fields))
+	for i, field := range p.fields {
+		if value := strings.TrimSpace(groups[i+1]); value != "" {
+			values[field] = value
+		}
+	}
+	return values
+}
+
+// inferTagsFromPath fills the fields missing from a track's tags with values
+// parsed from its path by the first matching pattern, recording which fields
+// were inferred
+func inferTagsFromPath(track *model.Track, rootPath string, patterns []*namingPattern) {
+	if len(patterns) == 0 {
+		return
+	}
+
+	relativePath, err := filepath.Rel(rootPath, track.FilePath)
+	if err != nil || strings.HasPrefix(relativePath, "..") {
+		relativePath = filepath.Base(track.FilePath)
+	}
+	relativePath = filepath.ToSlash(strings.TrimSuffix(relativePath, filepath.Ext(relativePath)))
+
+	for _, pattern := range patterns {
+		values := pattern.match(relativePath)
+		if values == nil {
+			continue
+		}
+
+		// A folder named after the album artist also names the artist of its tracks
+		if _, ok := values[model.TagFieldArtist]; !ok && track.Artist == "" {
+			if albumArtist, ok := values[model.TagFieldAlbumArtist]; ok {
+				values[model.TagFieldArtist] = albumArtist
+			}
+		}
+
+		for _, field := range []model.TagField{
+			model.TagFieldTitle, model.TagFieldArtist, model.TagFieldAlbum, model.TagFieldAlbumArtist,
+			model.TagFieldGenre, model.TagFieldYear, model.TagFieldTrackNumber, model.TagFieldDiscNumber,
+		} {
+			if value, ok := values[field]; ok && setMissingField(track, field, value) {
+				track.InferredFields = append(track.InferredFields, field)
+			}
+		}
+		return
+	}
+}
+
+// setMissingField sets a field from text when the track has no value for it,
+// reporting whether it did
+func setMissingField(track *model.Track, field model.TagField, value string) bool {
+	setText := func(target *string) bool {
+		if *target != "" {
+			return false
+		}
+		*target = value
+		return true
+	}
+	setNumber := func(target *int) bool {
+		n, err := strconv.Atoi(value)
+		if *target != 0 || err != nil || n <= 0 {
+			return false
+		}
+		*target = n
+		return true
+	}
+
+	switch field {
+	case model.TagFieldTitle:
+		return setText(&track.Title)
+	case model.TagFieldArtist:
+		return setText(&track.Artist)
+	case model.TagFieldAlbum:
+		return setText(&track.Album)
+	case model.TagFieldAlbumArtist:
+		return setText(&track.AlbumArtist)
+	case model.TagFieldGenre:
+		return setText(&track.Genre)
+	case model.TagFieldYear:
+		return setNumber(&track.Year)
+	case model.TagFieldTrackNumber:
+		return setNumber(&track.TrackNumber)
+	case model.TagFieldDiscNumber:
+		return setNumber(&track.DiscNumber)
+	}
+	return false
+}
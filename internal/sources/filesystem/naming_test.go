@@ -0,0 +1,245 @@
+package filesystem
+
+import (
+	"maps"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"GoMusic/internal/domain/model"
+)
+
+func TestCompileNamingPattern(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		path    string // relative path without extension
+		want    map[model.TagField]string
+		wantErr bool
+	}{
+		{
+			name:    "album folders with disc and track",
+			pattern: "%albumartist%/%year% - %album%/%disc%-%track% %title%",
+			path:    "Jazz/Miles Davis/1959 - Kind of Blue/1-02 Freddie Freeloader",
+			want: map[model.TagField]string{
+				model.TagFieldAlbumArtist: "Miles Davis",
+				model.TagFieldYear:        "1959",
+				model.TagFieldAlbum:       "Kind of Blue",
+				model.TagFieldDiscNumber:  "1",
+				model.TagFieldTrackNumber: "02",
+				model.TagFieldTitle:       "Freddie Freeloader",
+			},
+		},
+		{
+			name:    "path missing a folder the pattern names",
+			pattern: "%albumartist%/%year% - %album%/%disc%-%track% %title%",
+			path:    "Kind of Blue/1-02 Freddie Freeloader",
+		},
+		{
+			name:    "backslash separators",
+			pattern: `%artist%\%album%\%track% - %title%`,
+			path:    "Band/Record/03 - Song",
+			want: map[model.TagField]string{
+				model.TagFieldArtist:      "Band",
+				model.TagFieldAlbum:       "Record",
+				model.TagFieldTrackNumber: "03",
+				model.TagFieldTitle:       "Song",
+			},
+		},
+		{
+			name:    "surrounding separators and case",
+			pattern: " /%Artist%/%TITLE%/ ",
+			path:    "Band/Song",
+			want:    map[model.TagField]string{model.TagFieldArtist: "Band", model.TagFieldTitle: "Song"},
+		},
+		{
+			name:    "literal text with regexp characters",
+			pattern: "%artist% (%year%)/%title%",
+			path:    "Band (1999)/Song",
+			want: map[model.TagField]string{
+				model.TagFieldArtist: "Band",
+				model.TagFieldYear:   "1999",
+				model.TagFieldTitle:  "Song",
+			},
+		},
+		{
+			name:    "ignored segments may repeat",
+			pattern: "%ignore%/%ignore% - %title%",
+			path:    "Misc/01 - Song",
+			want:    map[model.TagField]string{model.TagFieldTitle: "Song"},
+		},
+		{
+			name:    "numbers must be digits",
+			pattern: "%track% %title%",
+			path:    "Intro Song",
+		},
+		{
+			name:    "duplicate placeholder",
+			pattern: "%title%/%title%",
+			wantErr: true,
+		},
+		{
+			name:    "duplicate placeholder in another case",
+			pattern: "%artist% - %ARTIST%",
+			wantErr: true,
+		},
+		{
+			name:    "unknown placeholder",
+			pattern: "%composer%/%title%",
+			wantErr: true,
+		},
+		{
+			name:    "no placeholders",
+			pattern: "music/files",
+			wantErr: true,
+		},
+		{
+			name:    "only ignored placeholders",
+			pattern: "%ignore%/%ignore%",
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			pattern: " / ",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compiled, err := compileNamingPattern(tt.pattern)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("compileNamingPattern() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := compiled.match(tt.path); !maps.Equal(got, tt.want) || (got == nil) != (tt.want == nil) {
+				t.Errorf("match(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInferTagsFromPath(t *testing.T) {
+	root := filepath.FromSlash("/music")
+
+	tests := []struct {
+		name         string
+		patterns     []string
+		path         string // relative to root
+		track        model.Track
+		want         model.Track
+		wantInferred []model.TagField
+	}{
+		{
+			name:     "fills every missing field",
+			patterns: []string{"%albumartist%/%year% - %album%/%disc%-%track% %title%"},
+			path:     "Miles Davis/1959 - Kind of Blue/1-02 Freddie Freeloader.flac",
+			want: model.Track{
+				Title: "Freddie Freeloader", Artist: "Miles Davis", AlbumArtist: "Miles Davis",
+				Album: "Kind of Blue", Year: 1959, TrackNumber: 2, DiscNumber: 1,
+			},
+			wantInferred: []model.TagField{
+				model.TagFieldTitle, model.TagFieldArtist, model.TagFieldAlbum, model.TagFieldAlbumArtist,
+				model.TagFieldYear, model.TagFieldTrackNumber, model.TagFieldDiscNumber,
+			},
+		},
+		{
+			name:     "tagged fields are kept",
+			patterns: []string{"%artist%/%album%/%track% %title%"},
+			path:     "Band/Record/03 Song.mp3",
+			track:    model.Track{Title: "Real Title", Artist: "Real Band", TrackNumber: 7},
+			want:     model.Track{Title: "Real Title", Artist: "Real Band", Album: "Record", TrackNumber: 7},
+			wantInferred: []model.TagField{
+				model.TagFieldAlbum,
+			},
+		},
+		{
+			name:     "album artist names the artist of untagged tracks",
+			patterns: []string{"%albumartist%/%album%/%title%"},
+			path:     "Band/Record/Song.mp3",
+			track:    model.Track{Title: "Song"},
+			want:     model.Track{Title: "Song", Artist: "Band", AlbumArtist: "Band", Album: "Record"},
+			wantInferred: []model.TagField{
+				model.TagFieldArtist, model.TagFieldAlbum, model.TagFieldAlbumArtist,
+			},
+		},
+		{
+			name:     "album artist leaves a tagged artist alone",
+			patterns: []string{"%albumartist%/%album%/%title%"},
+			path:     "Various Artists/Record/Song.mp3",
+			track:    model.Track{Title: "Song", Artist: "Guest"},
+			want:     model.Track{Title: "Song", Artist: "Guest", AlbumArtist: "Various Artists", Album: "Record"},
+			wantInferred: []model.TagField{
+				model.TagFieldAlbum, model.TagFieldAlbumArtist,
+			},
+		},
+		{
+			name:     "pattern artist wins over the album artist",
+			patterns: []string{"%albumartist%/%album%/%artist% - %title%"},
+			path:     "Various Artists/Record/Guest - Song.mp3",
+			want: model.Track{
+				Title: "Song", Artist: "Guest", AlbumArtist: "Various Artists", Album: "Record",
+			},
+			wantInferred: []model.TagField{
+				model.TagFieldTitle, model.TagFieldArtist, model.TagFieldAlbum, model.TagFieldAlbumArtist,
+			},
+		},
+		{
+			name:         "zero and blank values are not inferred",
+			patterns:     []string{"%artist% - %track% %title%"},
+			path:         "  - 00 Song.mp3",
+			want:         model.Track{Title: "Song"},
+			wantInferred: []model.TagField{model.TagFieldTitle},
+		},
+		{
+			name:         "first matching pattern wins",
+			patterns:     []string{"%artist%/%album%/%track% %title%", "%artist% - %title%", "%title%"},
+			path:         "Singles/Band - Song.mp3",
+			want:         model.Track{Title: "Song", Artist: "Band"},
+			wantInferred: []model.TagField{model.TagFieldTitle, model.TagFieldArtist},
+		},
+		{
+			name:     "no pattern matches",
+			patterns: []string{"%artist%/%album%/%track% %title%"},
+			path:     "Song.mp3",
+			track:    model.Track{Title: "Song"},
+			want:     model.Track{Title: "Song"},
+		},
+		{
+			name:         "file outside the root matches by name",
+			patterns:     []string{"%artist% - %title%"},
+			path:         "../elsewhere/Band - Song.mp3",
+			want:         model.Track{Title: "Song", Artist: "Band"},
+			wantInferred: []model.TagField{model.TagFieldTitle, model.TagFieldArtist},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patterns []*namingPattern
+			for _, pattern := range tt.patterns {
+				compiled, err := compileNamingPattern(pattern)
+				if err != nil {
+					t.Fatal(err)
+				}
+				patterns = append(patterns, compiled)
+			}
+
+			track := tt.track
+			track.FilePath = filepath.Join(root, filepath.FromSlash(tt.path))
+			inferTagsFromPath(&track, root, patterns)
+
+			if !slices.Equal(track.InferredFields, tt.wantInferred) {
+				t.Errorf("InferredFields = %v, want %v", track.InferredFields, tt.wantInferred)
+			}
+			track.FilePath, track.InferredFields = "", nil
+			if track.Title != tt.want.Title || track.Artist != tt.want.Artist || track.AlbumArtist != tt.want.AlbumArtist ||
+				track.Album != tt.want.Album || track.Genre != tt.want.Genre || track.Year != tt.want.Year ||
+				track.TrackNumber != tt.want.TrackNumber || track.DiscNumber != tt.want.DiscNumber {
+				t.Errorf("inferTagsFromPath() = %+v, want %+v", track, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package filesystem
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+// oggPageHeaderSize is the fixed part of an Ogg page header, before the segment table
+const oggPageHeaderSize = 27
+
+// oggTailWindows are the amounts read from the end of the file when looking for the
+// last page; a final page rarely exceeds 64 KiB, the larger window covers odd muxers
+var oggTailWindows = []int64{64 << 10, 1 << 20}
+
+// opusSampleRate is the rate Opus always decodes at; granule positions count at this rate
+const opusSampleRate = 48000
+
+// oggCRCTable is the CRC-32 table for Ogg's polynomial 0x04c11db7 (no reflection)
+var oggCRCTable = func() [256]uint32 {
+	var table [256]uint32
+	for i := range table {
+		crc := uint32(i) << 24
+		for range 8 {
+			if crc&0x80000000 != 0 {
+				crc = crc<<1 ^ 0x04c11db7
+			} else {
+				crc <<= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}()
+
+// oggInfo describes the first logical stream of an Ogg file
+type oggInfo struct {
+	Codec      string // Vorbis, Opus or FLAC
+	Lossless   bool
+	SampleRate int
+	Channels   int
+	BitDepth   int // FLAC only
+	VBR        bool
+
+	NominalBitRate int   // bits per second from the Vorbis header, 0 when unset
+	TotalSamples   int64 // per channel, excluding Opus pre-skip
+	PreSkip        int   // Opus only: samples to discard at the start
+	AudioBytes     int64 // file bytes after the header packets
+}
+
+// Duration returns the exact playing time from the final granule position
+func (i *oggInfo) Duration() time.Duration {
+	if i.SampleRate == 0 {
+		return 0
+	}
+	rate := i.SampleRate
+	if i.Codec == "Opus" {
+		rate = opusSampleRate
+	}
+	return time.Duration(float64(i.TotalSamples) / float64(rate) * float64(time.Second))
+}
+
+// BitRate returns the average bit rate in kbps, falling back to the nominal rate
+func (i *oggInfo) BitRate() int {
+	if seconds := i.Duration().Seconds(); seconds > 0 && i.AudioBytes > 0 {
+		return int(float64(i.AudioBytes)*8/seconds/1000 + 0.5)
+	}
+	return (i.NominalBitRate + 500) / 1000
+}
+
+// oggPage is a parsed page header; Size includes the header and segment table
+type oggPage struct {
+	Granule  int64
+	Serial   uint32
+	Segments []byte
+	Size     int64
+}
+
+// readOggInfo reads the identification header of the first stream and the granule
+// position of its last page
+// Returns nil when the file is not an Ogg stream of a known codec
+func readOggInfo(r io.ReadSeeker, size int64) *oggInfo {
+	info, serial, headerEnd := readOggHeaders(r, size)
+	if info == nil {
+		return nil
+	}
+
+	granule, ok := lastOggGranule(r, size, serial)
+	if !ok {
+		return nil
+	}
+	if info.Codec == "Opus" {
+		granule -= int64(info.PreSkip)
+	}
+	if granule > 0 {
+		info.TotalSamples = granule
+	}
+	info.AudioBytes = size - headerEnd
+	return info
+}
+
+// readOggHeaders parses the first packet of the first stream and skips its remaining
+// header packets, returning the stream serial and the offset where audio begins
+func readOggHeaders(r io.ReadSeeker, size int64) (*oggInfo, uint32, int64) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, 0, 0
+	}
+
+	var info *oggInfo
+	var serial uint32
+	var first bytes.Buffer
+	headerPackets, packets := 0, 0
+
+	for offset := int64(0); offset < size; {
+		page, err := readOggPage(r)
+		if err != nil {
+			return nil, 0, 0
+		}
+		body := make([]byte, page.Size-oggPageHeaderSize-int64(len(page.Segments)))
+		if _, err := io.ReadFull(r, body); err != nil {
+			return nil, 0, 0
+		}
+		offset += page.Size
+
+		if info == nil && offset == page.Size {
+			serial = page.Serial
+		}
+		if page.Serial != serial {
+			continue
+		}
+
+		pos := 0
+		for _, segment := range page.Segments {
+			if info == nil {
+				first.Write(body[pos : pos+int(segment)])
+			}
+			pos += int(segment)
+			if segment == 255 {
+				continue
+			}
+
+			packets++
+			if info == nil {
+				info, headerPackets = parseOggIdentification(first.Bytes())
+				if info == nil {
+					return nil, 0, 0
+				}
+			}
+		}
+
+		if info != nil && packets >= headerPackets {
+			return info, serial, offset
+		}
+	}
+	return nil, 0, 0
+}
+
+// parseOggIdentification reads a codec identification packet, returning the stream
+// properties and how many header packets precede the audio
+func parseOggIdentification(packet []byte) (*oggInfo, int) {
+	switch {
+	case len(packet) >= 30 && bytes.HasPrefix(packet, []byte("\x01vorbis")):
+		maxRate := int32(binary.LittleEndian.Uint32(packet[16:20]))
+		nominal := int32(binary.LittleEndian.Uint32(packet[20:24]))
+		minRate := int32(binary.LittleEndian.Uint32(packet[24:28]))
+		return &oggInfo{
+			Codec:          "Vorbis",
+			Channels:       int(packet[11]),
+			SampleRate:     int(binary.LittleEndian.Uint32(packet[12:16])),
+			NominalBitRate: int(max(nominal, 0)),
+			VBR:            maxRate != minRate || maxRate <= 0,
+		}, 3
+
+	case len(packet) >= 19 && bytes.HasPrefix(packet, []byte("OpusHead")):
+		// The input rate is informational; Opus always decodes at 48 kHz
+		return &oggInfo{
+			Codec:      "Opus",
+			Channels:   int(packet[9]),
+			PreSkip:    int(binary.LittleEndian.Uint16(packet[10:12])),
+			SampleRate: opusSampleRate,
+			VBR:        true,
+		}, 2
+
+	case len(packet) >= 51 && bytes.HasPrefix(packet, []byte("\x7fFLAC")) && string(packet[9:13]) == "fLaC":
+		// Mapping header, then the native STREAMINFO block
+		headers := int(binary.BigEndian.Uint16(packet[7:9]))
+		packed := binary.BigEndian.Uint64(packet[27:35])
+		return &oggInfo{
+			Codec:        "FLAC",
+			Lossless:     true,
+			SampleRate:   int(packed >> 44),
+			Channels:     int(packed>>41&0x7) + 1,
+			BitDepth:     int(packed>>36&0x1f) + 1,
+			TotalSamples: int64(packed & 0xfffffffff),
+		}, 1 + headers
+	}
+	return nil, 0
+}
+
+// lastOggGranule scans backwards from the end of the file for the last page of the
+// stream that carries a granule position
+func lastOggGranule(r io.ReadSeeker, size int64, serial uint32) (int64, bool) {
+	for _, window := range oggTailWindows {
+		window = min(window, size)
+		buf := make([]byte, window)
+		if _, err := r.Seek(size-window, io.SeekStart); err != nil {
+			return 0, false
+		}
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return 0, false
+		}
+
+		for i := bytes.LastIndex(buf, []byte("OggS")); i >= 0; i = bytes.LastIndex(buf[:i], []byte("OggS")) {
+			page, ok := parseOggPage(buf[i:])
+			if !ok || page.Serial != serial || page.Granule == -1 {
+				continue
+			}
+			return page.Granule, true
+		}
+
+		if window == size {
+			break
+		}
+	}
+	return 0, false
+}
+
+// readOggPage reads a page header and segment table, leaving r at the page body
+func readOggPage(r io.Reader) (*oggPage, error) {
+	header := make([]byte, oggPageHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	if string(header[:4]) != "OggS" {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	segments := make([]byte, header[26])
+	if _, err := io.ReadFull(r, segments); err != nil {
+		return nil, err
+	}
+	return newOggPage(header, segments), nil
+}
+
+// parseOggPage parses a complete page from buf, verifying its checksum so that an
+// "OggS" inside packet data is not mistaken for a page
+func parseOggPage(buf []byte) (*oggPage, bool) {
+	if len(buf) < oggPageHeaderSize || string(buf[:4]) != "OggS" {
+		return nil, false
+	}
+	tableEnd := oggPageHeaderSize + int(buf[26])
+	if len(buf) < tableEnd {
+		return nil, false
+	}
+
+	page := newOggPage(buf[:oggPageHeaderSize], buf[oggPageHeaderSize:tableEnd])
+	if int64(len(buf)) < page.Size {
+		return nil, false
+	}
+
+	if oggChecksum(buf[:page.Size]) != binary.LittleEndian.Uint32(buf[22:26]) {
+		return nil, false
+	}
+	return page, true
+}
+
+// oggChecksum computes the CRC of a complete page, which is defined with its own
+// field zeroed
+func oggChecksum(page []byte) uint32 {
+	crc := uint32(0)
+	for i, b := range page {
+		if i >= 22 && i < 26 {
+			b = 0
+		}
+		crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^b]
+	}
+	return crc
+}
+
+// newOggPage builds a page from its fixed header and segment table
+func newOggPage(header, segments []byte) *oggPage {
+	bodySize := 0
+	for _, segment := range segments {
+		bodySize += int(segment)
+	}
+	return &oggPage{
+		Granule:  int64(binary.LittleEndian.Uint64(header[6:14])),
+		Serial:   binary.LittleEndian.Uint32(header[14:18]),
+		Segments: segments,
+		Size:     int64(oggPageHeaderSize + len(segments) + bodySize),
+	}
+}
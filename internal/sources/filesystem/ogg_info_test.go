@@ -0,0 +1,126 @@
+package filesystem
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// oggTestPage builds a page holding whole packets
+func oggTestPage(headerType byte, granule int64, sequence uint32, packets ...[]byte) []byte {
+	var segments, body []byte
+	for _, packet := range packets {
+		for pos := 0; ; pos += 255 {
+			lace := min(len(packet)-pos, 255)
+			segments = append(segments, byte(lace))
+			if lace < 255 {
+				break
+			}
+		}
+		body = append(body, packet...)
+	}
+	var page bytes.Buffer
+	writeOggPage(&page, headerType, granule, 0x1234, sequence, segments, body)
+	return page.Bytes()
+}
+
+// vorbisIdentification builds a stereo 44.1 kHz Vorbis identification header
+func vorbisIdentification() []byte {
+	packet := append([]byte("\x01vorbis"), 0, 0, 0, 0, 2)
+	packet = binary.LittleEndian.AppendUint32(packet, 44100)
+	packet = binary.LittleEndian.AppendUint32(packet, 0)
+	packet = binary.LittleEndian.AppendUint32(packet, 128000)
+	packet = binary.LittleEndian.AppendUint32(packet, 0)
+	return append(packet, 0xb8, 1)
+}
+
+// vorbisCommentPacket builds a Vorbis comment header
+func vorbisCommentPacket(comments ...string) []byte {
+	comment := &vorbisComment{Vendor: "test", Comments: comments}
+	return append(append([]byte("\x03vorbis"), comment.bytes()...), 1)
+}
+
+// oggVorbisFile builds a Vorbis stream with one audio page ending at granule
+func oggVorbisFile(granule int64, comments ...string) []byte {
+	return bytes.Join([][]byte{
+		oggTestPage(0x02, 0, 0, vorbisIdentification()),
+		oggTestPage(0, 0, 1, vorbisCommentPacket(comments...), []byte("\x05vorbis setup")),
+		oggTestPage(0x04, granule, 2, make([]byte, 500)),
+	}, nil)
+}
+
+func TestReadOggInfo(t *testing.T) {
+	vorbis := oggVorbisFile(88200, "TITLE=Title")
+	audioPage := int64(len(oggTestPage(0x04, 88200, 2, make([]byte, 500))))
+
+	opusHead := append([]byte("OpusHead"), 1, 2)
+	opusHead = binary.LittleEndian.AppendUint16(opusHead, 312)
+	opusHead = binary.LittleEndian.AppendUint32(opusHead, 44100)
+	opusHead = append(opusHead, 0, 0, 0)
+	opus := bytes.Join([][]byte{
+		oggTestPage(0x02, 0, 0, opusHead),
+		oggTestPage(0, 0, 1, append([]byte("OpusTags"), (&vorbisComment{Vendor: "test"}).bytes()...)),
+		oggTestPage(0x04, 48000+312, 2, make([]byte, 500)),
+	}, nil)
+
+	// A comment header continued on the next page
+	longComment := vorbisCommentPacket("COMMENT=" + string(bytes.Repeat([]byte("x"), 600)))
+	setup := []byte("\x05vorbis setup")
+	var continued bytes.Buffer
+	writeOggPage(&continued, 0, 0, 0x1234, 1, []byte{255, 255}, longComment[:510])
+	writeOggPage(&continued, 0x01, 0, 0x1234, 2, []byte{byte(len(longComment) - 510), byte(len(setup))}, append(longComment[510:], setup...))
+
+	// The checksum of the last page no longer matches, so its granule is not trusted
+	badChecksum := bytes.Clone(vorbis)
+	badChecksum[len(badChecksum)-1] ^= 0xff
+
+	tests := []struct {
+		name string
+		data []byte
+		want *oggInfo
+	}{
+		{
+			name: "Vorbis",
+			data: vorbis,
+			want: &oggInfo{Codec: "Vorbis", SampleRate: 44100, Channels: 2, VBR: true, NominalBitRate: 128000, TotalSamples: 88200, AudioBytes: audioPage},
+		},
+		{
+			name: "Opus less pre-skip",
+			data: opus,
+			want: &oggInfo{Codec: "Opus", SampleRate: 48000, Channels: 2, VBR: true, TotalSamples: 48000, PreSkip: 312, AudioBytes: audioPage},
+		},
+		{
+			name: "comment header spanning pages",
+			data: bytes.Join([][]byte{
+				oggTestPage(0x02, 0, 0, vorbisIdentification()),
+				continued.Bytes(),
+				oggTestPage(0x04, 44100, 3, make([]byte, 500)),
+			}, nil),
+			want: &oggInfo{Codec: "Vorbis", SampleRate: 44100, Channels: 2, VBR: true, NominalBitRate: 128000, TotalSamples: 44100, AudioBytes: audioPage},
+		},
+		{
+			name: "last page checksum broken",
+			data: badChecksum,
+			want: &oggInfo{Codec: "Vorbis", SampleRate: 44100, Channels: 2, VBR: true, NominalBitRate: 128000, AudioBytes: audioPage},
+		},
+		{name: "unknown codec", data: oggTestPage(0x02, 0, 0, []byte("\x80theora"))},
+		{name: "truncated headers", data: vorbis[:len(vorbis)-int(audioPage)-10]},
+		{name: "identification packet too short", data: oggTestPage(0x02, 0, 0, vorbisIdentification()[:20])},
+		{name: "not Ogg", data: bytes.Repeat([]byte("RIFF"), 20)},
+		{name: "empty file", data: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := readOggInfo(bytes.NewReader(tt.data), int64(len(tt.data)))
+			switch {
+			case tt.want == nil && got != nil:
+				t.Fatalf("readOggInfo() = %+v, want nil", got)
+			case tt.want != nil && got == nil:
+				t.Fatalf("readOggInfo() = nil, want %+v", tt.want)
+			case tt.want != nil && *got != *tt.want:
+				t.Fatalf("readOggInfo() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
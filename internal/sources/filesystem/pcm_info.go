@@ -0,0 +1,101 @@
+package filesystem
+
+import (
+	"encoding/binary"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"GoMusic/internal/decoder"
+)
+
+// maxTextChunkSize caps metadata chunks read into memory; an ID3 chunk with
+// embedded artwork is the largest expected
+const maxTextChunkSize = 32 << 20
+
+// isPCMContainer reports whether a file extension is WAV or AIFF
+func isPCMContainer(ext string) bool {
+	switch strings.ToLower(ext) {
+	case ".wav", ".aif", ".aiff", ".aifc":
+		return true
+	default:
+		return false
+	}
+}
+
+// pcmInfo describes a WAV (RIFF, RF64) or AIFF/AIFC file and its metadata chunks
+type pcmInfo struct {
+	decoder.PCMInfo
+
+	Text map[string]string // RIFF INFO or AIFF text chunks by chunk ID
+	ID3  []byte            // body of an "id3 " or "ID3 " chunk
+}
+
+// readPCMInfo walks the chunks of a WAV or AIFF file and reads its metadata chunks
+func readPCMInfo(r io.ReadSeeker, size int64) (*pcmInfo, error) {
+	stream, err := decoder.ReadPCMInfo(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &pcmInfo{PCMInfo: *stream, Text: map[string]string{}}
+	for _, chunk := range stream.Metadata {
+		if chunk.Size > maxTextChunkSize {
+			continue
+		}
+		if _, err := r.Seek(chunk.Offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+		body := readChunkBody(r, chunk.Size)
+
+		switch chunk.ID {
+		case "LIST":
+			parseRIFFInfo(info, body)
+		case "id3 ", "ID3 ":
+			info.ID3 = body
+		default:
+			info.Text[chunk.ID] = decodeChunkText(body)
+		}
+	}
+	return info, nil
+}
+
+// parseRIFFInfo reads the text sub-chunks of a LIST/INFO chunk
+func parseRIFFInfo(info *pcmInfo, list []byte) {
+	if len(list) < 4 || string(list[:4]) != "INFO" {
+		return
+	}
+	for data := list[4:]; len(data) >= 8; {
+		id := string(data[:4])
+		size := int(binary.LittleEndian.Uint32(data[4:8]))
+		if size > len(data)-8 {
+			return
+		}
+		info.Text[id] = decodeChunkText(data[8 : 8+size])
+		data = data[min(8+size+size&1, len(data)):]
+	}
+}
+
+// readChunkBody reads n bytes from the current position, or returns nil
+func readChunkBody(r io.Reader, n int64) []byte {
+	body := make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil
+	}
+	return body
+}
+
+// decodeChunkText converts a NUL-terminated chunk string, treating non-UTF-8 text as Latin-1
+func decodeChunkText(b []byte) string {
+	for len(b) > 0 && (b[len(b)-1] == 0 || b[len(b)-1] == ' ') {
+		b = b[:len(b)-1]
+	}
+	if utf8.Valid(b) {
+		return string(b)
+	}
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
@@ -0,0 +1,162 @@
+package filesystem
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/dhowden/tag"
+)
+
+// Chunk IDs holding each field, RIFF INFO first and AIFF second
+var (
+	pcmTitleChunks    = []string{"INAM", "NAME"}
+	pcmArtistChunks   = []string{"IART", "AUTH"}
+	pcmAlbumChunks    = []string{"IPRD"}
+	pcmGenreChunks    = []string{"IGNR"}
+	pcmComposerChunks = []string{"IMUS"}
+	pcmCommentChunks  = []string{"ICMT", "ANNO"}
+	pcmDateChunks     = []string{"ICRD"}
+	pcmTrackChunks    = []string{"ITRK", "IPRT"}
+)
+
+// pcmMetadata exposes the tags of a WAV or AIFF file as tag.Metadata
+// An embedded ID3 chunk wins; RIFF INFO and AIFF text chunks fill the fields it leaves empty
+type pcmMetadata struct {
+	info *pcmInfo
+	id3  tag.Metadata // nil when the file has no readable ID3 chunk
+}
+
+// readPCMMetadata reads the metadata chunks of a WAV or AIFF file
+// Files without any tags still succeed, so the container and format are recorded
+func readPCMMetadata(r io.ReadSeeker, size int64) (tag.Metadata, error) {
+	info, err := readPCMInfo(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &pcmMetadata{info: info}
+	if len(info.ID3) > 0 {
+		if id3, err := tag.ReadID3v2Tags(bytes.NewReader(info.ID3)); err == nil {
+			m.id3 = id3
+		}
+	}
+	return m, nil
+}
+
+// text returns the ID3 value when set, otherwise the first non-empty chunk
+func (m *pcmMetadata) text(id3Value func(tag.Metadata) string, chunks []string) string {
+	if m.id3 != nil {
+		if value := id3Value(m.id3); value != "" {
+			return value
+		}
+	}
+	return m.chunkText(chunks)
+}
+
+// chunkText returns the first non-empty chunk value
+func (m *pcmMetadata) chunkText(chunks []string) string {
+	for _, id := range chunks {
+		if value := m.info.Text[id]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// Format returns the container (wav or aiff), which is what the library records
+func (m *pcmMetadata) Format() tag.Format {
+	return tag.Format(m.info.Container)
+}
+
+func (m *pcmMetadata) FileType() tag.FileType {
+	return tag.FileType(strings.ToUpper(m.info.Container))
+}
+
+func (m *pcmMetadata) Title() string {
+	return m.text(tag.Metadata.Title, pcmTitleChunks)
+}
+
+func (m *pcmMetadata) Album() string {
+	return m.text(tag.Metadata.Album, pcmAlbumChunks)
+}
+
+func (m *pcmMetadata) Artist() string {
+	return m.text(tag.Metadata.Artist, pcmArtistChunks)
+}
+
+func (m *pcmMetadata) AlbumArtist() string {
+	return m.text(tag.Metadata.AlbumArtist, nil)
+}
+
+func (m *pcmMetadata) Composer() string {
+	return m.text(tag.Metadata.Composer, pcmComposerChunks)
+}
+
+func (m *pcmMetadata) Genre() string {
+	return m.text(tag.Metadata.Genre, pcmGenreChunks)
+}
+
+func (m *pcmMetadata) Comment() string {
+	return m.text(tag.Metadata.Comment, pcmCommentChunks)
+}
+
+func (m *pcmMetadata) Lyrics() string {
+	return m.text(tag.Metadata.Lyrics, nil)
+}
+
+// Year reads the leading digits of ICRD, which is usually YYYY or YYYY-MM-DD
+func (m *pcmMetadata) Year() int {
+	if m.id3 != nil && m.id3.Year() != 0 {
+		return m.id3.Year()
+	}
+	date := m.chunkText(pcmDateChunks)
+	if len(date) < 4 {
+		return 0
+	}
+	year, _ := strconv.Atoi(date[:4])
+	return year
+}
+
+// Track reads ITRK/IPRT as "n" or "n/total"
+func (m *pcmMetadata) Track() (int, int) {
+	if m.id3 != nil {
+		if n, total := m.id3.Track(); n != 0 {
+			return n, total
+		}
+	}
+	value := m.chunkText(pcmTrackChunks)
+	number, total, _ := strings.Cut(value, "/")
+	n, _ := strconv.Atoi(strings.TrimSpace(number))
+	t, _ := strconv.Atoi(strings.TrimSpace(total))
+	return n, t
+}
+
+func (m *pcmMetadata) Disc() (int, int) {
+	if m.id3 == nil {
+		return 0, 0
+	}
+	return m.id3.Disc()
+}
+
+func (m *pcmMetadata) Picture() *tag.Picture {
+	if m.id3 == nil {
+		return nil
+	}
+	return m.id3.Picture()
+}
+
+// Raw returns the chunk values merged with the ID3 frames, ID3 taking precedence
+func (m *pcmMetadata) Raw() map[string]interface{} {
+	raw := make(map[string]interface{}, len(m.info.Text))
+	for id, value := range m.info.Text {
+		raw[id] = value
+	}
+	if m.id3 != nil {
+		for k, v := range m.id3.Raw() {
+			raw[k] = v
+		}
+	}
+	return raw
+}
@@ -0,0 +1,91 @@
+package filesystem
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/dhowden/tag"
+
+	"GoMusic/internal/domain/model"
+)
+
+// r128ToReplayGain converts an R128 gain (relative to -23 LUFS) to the ReplayGain
+// reference of -18 LUFS
+const r128ToReplayGain = 5.0
+
+// readReplayGain extracts ReplayGain values from ID3 TXXX frames, Vorbis comments
+// and MP4 freeform atoms, falling back to Opus R128 gains
+// Returns nil when the file carries no loudness information
+func readReplayGain(metadata tag.Metadata) *model.ReplayGain {
+	values := userTextValues(metadata)
+
+	rg := &model.ReplayGain{
+		TrackGain: parseGain(values["replaygain_track_gain"]),
+		TrackPeak: parsePeak(values["replaygain_track_peak"]),
+		AlbumGain: parseGain(values["replaygain_album_gain"]),
+		AlbumPeak: parsePeak(values["replaygain_album_peak"]),
+	}
+
+	// Opus stores gains as Q7.8 fixed point dB relative to -23 LUFS
+	if rg.TrackGain == nil {
+		rg.TrackGain = parseR128Gain(values["r128_track_gain"])
+	}
+	if rg.AlbumGain == nil {
+		rg.AlbumGain = parseR128Gain(values["r128_album_gain"])
+	}
+
+	if rg.TrackGain == nil && rg.AlbumGain == nil {
+		return nil
+	}
+	return rg
+}
+
+// userTextValues flattens free-form tag fields into a map keyed by lowercase name
+func userTextValues(metadata tag.Metadata) map[string]string {
+	values := make(map[string]string)
+	for key, raw := range metadata.Raw() {
+		switch v := raw.(type) {
+		case *tag.Comm:
+			// ID3v2 TXXX (TXX in v2.2) frames carry the field name in the description
+			if strings.HasPrefix(key, "TXX") {
+				values[strings.ToLower(v.Description)] = v.Text
+			}
+		case string:
+			values[strings.ToLower(key)] = v
+		}
+	}
+	return values
+}
+
+// parseGain parses a gain such as "-6.54 dB"
+func parseGain(value string) *float64 {
+	value = strings.TrimSpace(value)
+	value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(value, "dB"), "db"))
+	if value == "" {
+		return nil
+	}
+	gain, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil
+	}
+	return &gain
+}
+
+// parsePeak parses a linear peak amplitude such as "0.988312"
+func parsePeak(value string) *float64 {
+	peak, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || peak < 0 {
+		return nil
+	}
+	return &peak
+}
+
+// parseR128Gain parses an Opus R128 gain and converts it to a ReplayGain value
+func parseR128Gain(value string) *float64 {
+	q78, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return nil
+	}
+	gain := float64(q78)/256 + r128ToReplayGain
+	return &gain
+}
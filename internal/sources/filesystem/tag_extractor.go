@@ -7,23 +7,48 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/dhowden/tag"
 
+	"GoMusic/internal/decoder"
 	"GoMusic/internal/domain/model"
+	"GoMusic/internal/sources/artwork"
 )
 
 // TagExtractor uses github.com/dhowden/tag to extract metadata
-// Supports ID3 (MP3, M4A) and Vorbis (FLAC, OGG) formats
+// Supports ID3 (MP3, M4A, DSF) and Vorbis (FLAC, OGG) formats, plus RIFF INFO,
+// AIFF text and embedded ID3 chunks in WAV/AIFF/DSDIFF and APEv2 in WavPack
+// and Monkey's Audio
+// Tags missing from a file are inferred from its path when a naming pattern matches
+// Artwork is the embedded picture or an image file beside the track, whichever
+// the source prefers and finds first
 type TagExtractor struct {
-	sourceID string
+	sourceID          string
+	rootPath          string
+	namingPatterns    []*namingPattern
+	artworkPatterns   []string
+	artworkPreference model.ArtworkPreference
+
+	folderArtwork   map[string]folderArtworkEntry
+	folderArtworkMu sync.Mutex
 }
 
-// NewTagExtractor creates a new tag-based metadata extractor
-func NewTagExtractor(sourceID string) *TagExtractor {
+// NewTagExtractor creates a new tag-based metadata extractor for a source
+// Naming patterns parse paths relative to the root; see compileNamingPattern
+func NewTagExtractor(sourceID string, config *model.FilesystemSourceConfig) *TagExtractor {
+	artworkPatterns := config.ArtworkPatterns
+	if len(artworkPatterns) == 0 {
+		artworkPatterns = model.DefaultArtworkPatterns
+	}
 	return &TagExtractor{
-		sourceID: sourceID,
+		sourceID:          sourceID,
+		rootPath:          config.RootPath,
+		namingPatterns:    compileNamingPatterns(config.NamingPatterns),
+		artworkPatterns:   artworkPatterns,
+		artworkPreference: config.ArtworkPreference,
+		folderArtwork:     make(map[string]folderArtworkEntry),
 	}
 }
 
@@ -31,7 +56,9 @@ func NewTagExtractor(sourceID string) *TagExtractor {
 func (e *TagExtractor) SupportsFormat(extension string) bool {
 	ext := strings.ToLower(extension)
 	switch ext {
-	case ".mp3", ".m4a", ".m4b", ".m4p", ".flac", ".ogg", ".oga":
+	case ".mp3", ".m4a", ".m4b", ".m4p", ".flac", ".ogg", ".oga", ".opus",
+		".wav", ".aif", ".aiff", ".aifc",
+		".wv", ".ape", ".dsf", ".dff":
 		return true
 	default:
 		return false
@@ -40,24 +67,46 @@ func (e *TagExtractor) SupportsFormat(extension string) bool {
 
 // Extract reads metadata from an audio file
 func (e *TagExtractor) Extract(filePath string) (*model.Track, error) {
+	track, _, err := e.extract(filePath)
+	return track, err
+}
+
+// ExtractTracks reads an audio file, splitting it by its CUE sheet if it has one
+// The whole-file track is left out so the album is not listed twice
+func (e *TagExtractor) ExtractTracks(filePath string, sheets *CueSheetCache) ([]*model.Track, error) {
+	track, metadata, err := e.extract(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if sheet, file, cuePath := findCueSheet(filePath, metadata, sheets); sheet != nil {
+		if tracks := splitCueTracks(track, sheet, file, cuePath); len(tracks) > 0 {
+			return tracks, nil
+		}
+	}
+	return []*model.Track{track}, nil
+}
+
+// extract builds the whole-file track and returns the tags it was read from,
+// which are nil when the file has none
+func (e *TagExtractor) extract(filePath string) (*model.Track, tag.Metadata, error) {
 	// Open file
 	file, err := os.Open(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+		return nil, nil, fmt.Errorf("failed to open file: %w", err)
 	}
 	defer file.Close()
 
 	// Get file info
 	fileInfo, err := file.Stat()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get file info: %w", err)
+		return nil, nil, fmt.Errorf("failed to get file info: %w", err)
 	}
 
 	// Read metadata tags
-	metadata, err := tag.ReadFrom(file)
+	metadata, err := readTags(file, fileInfo.Size(), filepath.Ext(filePath))
 	if err != nil {
 		// If tag reading fails, create a track with basic info from filename
-		return e.createTrackFromFilename(filePath, fileInfo), nil
+		return e.createTrackFromFilename(filePath, fileInfo), nil, nil
 	}
 
 	// Create track from metadata
@@ -93,17 +142,9 @@ func (e *TagExtractor) Extract(filePath string) (*model.Track, error) {
 	}
 
 	// Extract and save artwork if available
-	if picture := metadata.Picture(); picture != nil {
-		artworkPath, err := e.saveArtwork(track.ID, picture)
-		if err != nil {
-			// Log error but don't fail the entire extraction
-			fmt.Printf("Failed to save artwork for %s: %v\n", track.Title, err)
-		} else {
-			track.ArtworkPath = artworkPath
-		}
-	}
+	e.setArtwork(track, metadata.Picture())
 
-	// Extract audio properties (duration, sample rate, bitrate) from file
+	// Extract audio properties (duration, sample rate, bitrate, channels) from file
 	// This is specific to filesystem sources - API sources get this from the API
 	analyzer := NewAudioAnalyzer()
 	props := analyzer.Analyze(filePath)
@@ -116,6 +157,44 @@ func (e *TagExtractor) Extract(filePath string) (*model.Track, error) {
 	if props.BitRate > 0 {
 		track.BitRate = props.BitRate
 	}
+	track.Codec = props.Codec
+	track.BitDepth = props.BitDepth
+	track.Lossless = props.Lossless
+	track.UnplayableReason = props.UnplayableReason
+	track.Channels = props.Channels
+	track.ChannelMode = props.ChannelMode
+	track.VBR = props.VBR
+	if props.TotalSamples > 0 {
+		track.TotalSamples = props.TotalSamples
+		track.EncoderDelay = props.EncoderDelay
+		track.EncoderPadding = props.EncoderPadding
+	}
+
+	// Loudness normalization values
+	track.ReplayGain = readReplayGain(metadata)
+
+	// Tempo and key for DJ-style sorting
+	values := userTextValues(metadata)
+	track.BPM = readBPM(file, fileInfo.Size(), metadata, values)
+	track.Key = readKey(values)
+
+	// Release and artist identity from MusicBrainz-tagged files
+	readMusicBrainzIDs(track, metadata, values)
+
+	// iTunes stores gapless info for AAC in a freeform atom
+	if smpb, ok := metadata.Raw()["iTunSMPB"].(string); ok && track.TotalSamples == 0 {
+		if delay, padding, samples, ok := parseITunSMPB(smpb); ok {
+			track.TotalSamples = samples
+			track.EncoderDelay = delay
+			track.EncoderPadding = padding
+			if track.SampleRate > 0 {
+				track.Duration = time.Duration(float64(samples) / float64(track.SampleRate) * float64(time.Second))
+			}
+		}
+	}
+
+	// Fill in missing tags from the file's path
+	inferTagsFromPath(track, e.rootPath, e.namingPatterns)
 
 	// Use filename as title if title is empty
 	if track.Title == "" {
@@ -133,29 +212,61 @@ func (e *TagExtractor) Extract(filePath string) (*model.Track, error) {
 	}
 
 	// Generate IDs for album and artist
-	track.AlbumID = generateAlbumID(track.Album, track.AlbumArtist)
-	track.ArtistID = generateArtistID(track.Artist)
+	track.AlbumID = generateAlbumID(track.Album, track.AlbumArtist, track.MusicBrainzAlbumID)
+	track.ArtistID = generateArtistID(track.Artist, track.MusicBrainzArtistID)
 
-	return track, nil
+	return track, metadata, nil
+}
+
+// readTags reads the tags of a file
+// The tag library does not parse WAV, AIFF, DSDIFF or APEv2, so those are read directly
+func readTags(file *os.File, size int64, ext string) (tag.Metadata, error) {
+	switch ext := strings.ToLower(ext); {
+	case isPCMContainer(ext):
+		return readPCMMetadata(file, size)
+	case ext == ".wv" || ext == ".ape":
+		return readAPEMetadata(file, size, strings.TrimPrefix(ext, "."))
+	case ext == ".dff":
+		return readDFFMetadata(file, size)
+	case ext == ".dsf":
+		// Untagged DSF files are common; keep them so their properties are still read
+		if m, err := tag.ReadFrom(file); err == nil {
+			return m, nil
+		}
+		return &pcmMetadata{info: &pcmInfo{PCMInfo: decoder.PCMInfo{Container: "dsf"}}}, nil
+	default:
+		return tag.ReadFrom(file)
+	}
 }
 
 // createTrackFromFilename creates a basic track when metadata extraction fails
+// Whatever a naming pattern finds in the path is used before the placeholders
 func (e *TagExtractor) createTrackFromFilename(filePath string, fileInfo os.FileInfo) *model.Track {
-	return &model.Track{
+	track := &model.Track{
 		ID:         generateTrackID(filePath),
 		SourceID:   e.sourceID,
 		SourceType: model.SourceTypeFilesystem,
-		Title:      getFilenameWithoutExt(filePath),
-		Artist:     "Unknown Artist",
-		Album:      "Unknown Album",
 		FilePath:   filePath,
 		FileSize:   fileInfo.Size(),
 		Format:     strings.TrimPrefix(filepath.Ext(filePath), "."),
-		ArtistID:   generateArtistID("Unknown Artist"),
-		AlbumID:    generateAlbumID("Unknown Album", ""),
 		AddedAt:    time.Now(),
 		ModifiedAt: fileInfo.ModTime(),
 	}
+	inferTagsFromPath(track, e.rootPath, e.namingPatterns)
+	e.setArtwork(track, nil)
+
+	if track.Title == "" {
+		track.Title = getFilenameWithoutExt(filePath)
+	}
+	if track.Artist == "" {
+		track.Artist = "Unknown Artist"
+	}
+	if track.Album == "" {
+		track.Album = "Unknown Album"
+	}
+	track.ArtistID = generateArtistID(track.Artist, "")
+	track.AlbumID = generateAlbumID(track.Album, track.AlbumArtist, "")
+	return track
 }
 
 // Helper functions
@@ -204,63 +315,69 @@ func generateTrackID(filePath string) string {
 	return "track_" + hex.EncodeToString(hash[:8])
 }
 
-func generateAlbumID(album, albumArtist string) string {
+// generateAlbumID derives an album ID from its MusicBrainz release when tagged,
+// so releases sharing a title stay apart, otherwise from its title and artist
+func generateAlbumID(album, albumArtist, musicBrainzID string) string {
 	key := album
 	if albumArtist != "" {
 		key = albumArtist + "_" + album
 	}
+	if musicBrainzID != "" {
+		key = "musicbrainz:" + musicBrainzID
+	}
 	hash := sha256.Sum256([]byte(key))
 	return "album_" + hex.EncodeToString(hash[:8])
 }
 
-func generateArtistID(artist string) string {
-	hash := sha256.Sum256([]byte(artist))
+// generateArtistID derives an artist ID from its MusicBrainz artist when tagged,
+// so an artist's tracks stay together across renames, otherwise from its name
+func generateArtistID(artist, musicBrainzID string) string {
+	key := artist
+	if musicBrainzID != "" {
+		key = "musicbrainz:" + musicBrainzID
+	}
+	hash := sha256.Sum256([]byte(key))
 	return "artist_" + hex.EncodeToString(hash[:8])
 }
 
-// saveArtwork saves album artwork to the cache directory
-func (e *TagExtractor) saveArtwork(trackID string, picture *tag.Picture) (string, error) {
-	// Get user's home directory
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("failed to get home directory: %w", err)
+// setArtwork caches a track's artwork, trying the embedded picture (nil when
+// there is none) and the folder image in the source's preferred order
+// Failures are logged but don't fail the entire extraction
+func (e *TagExtractor) setArtwork(track *model.Track, picture *tag.Picture) {
+	embedded := func() string {
+		if picture == nil {
+			return ""
+		}
+		artworkPath, err := e.saveArtwork(picture)
+		if err != nil {
+			fmt.Printf("Failed to save artwork for %s: %v\n", track.Title, err)
+			return ""
+		}
+		return artworkPath
 	}
-
-	// Create artwork cache directory: ~/.gomusic/artwork/
-	artworkDir := filepath.Join(homeDir, ".gomusic", "artwork")
-	if err := os.MkdirAll(artworkDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create artwork directory: %w", err)
+	folder := func() string {
+		imagePath := findFolderArtwork(track.FilePath, e.rootPath, e.artworkPatterns)
+		if imagePath == "" {
+			return ""
+		}
+		artworkPath, err := e.cacheFolderArtwork(imagePath)
+		if err != nil {
+			fmt.Printf("Failed to cache folder artwork %s: %v\n", imagePath, err)
+			return ""
+		}
+		return artworkPath
 	}
 
-	// Determine file extension from MIME type
-	ext := getImageExtension(picture.MIMEType)
-
-	// Generate filename: <trackID>.<ext>
-	filename := trackID + ext
-	artworkPath := filepath.Join(artworkDir, filename)
-
-	// Write artwork data to file
-	if err := os.WriteFile(artworkPath, picture.Data, 0644); err != nil {
-		return "", fmt.Errorf("failed to write artwork file: %w", err)
+	first, second := embedded, folder
+	if e.artworkPreference == model.ArtworkPreferFolder {
+		first, second = folder, embedded
+	}
+	if track.ArtworkPath = first(); track.ArtworkPath == "" {
+		track.ArtworkPath = second()
 	}
-
-	return filename, nil
 }
 
-// getImageExtension returns the file extension for a given MIME type
-func getImageExtension(mimeType string) string {
-	switch mimeType {
-	case "image/jpeg", "image/jpg":
-		return ".jpg"
-	case "image/png":
-		return ".png"
-	case "image/gif":
-		return ".gif"
-	case "image/bmp":
-		return ".bmp"
-	case "image/webp":
-		return ".webp"
-	default:
-		return ".jpg" // Default to JPEG
-	}
-}
\ No newline at end of file
+// saveArtwork saves an embedded picture to the artwork cache
+func (e *TagExtractor) saveArtwork(picture *tag.Picture) (string, error) {
+	return artwork.Store(picture.Data, artwork.Extension(picture.MIMEType))
+}
@@ -0,0 +1,149 @@
+package filesystem
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"GoMusic/internal/domain/model"
+)
+
+var errUnsupportedTagFormat = errors.New("tags cannot be written to this format")
+
+// tagRewriter copies an audio file to dst with its tags changed
+type tagRewriter func(src *os.File, size int64, dst io.Writer, changes *model.TagChanges) error
+
+// TagWriter writes tag changes into audio files: ID3v2.3/2.4 in MP3, Vorbis
+// comments in FLAC and Ogg, and iTunes atoms in MP4
+// Frames, comments and atoms that are not changed are kept as they are,
+// including embedded artwork
+type TagWriter struct{}
+
+// NewTagWriter creates a new tag writer
+func NewTagWriter() *TagWriter {
+	return &TagWriter{}
+}
+
+// SupportsFormat checks if tags can be written to the file format
+func (w *TagWriter) SupportsFormat(extension string) bool {
+	return tagRewriterFor(extension) != nil
+}
+
+// tagRewriterFor returns the rewriter for a file extension, or nil
+func tagRewriterFor(extension string) tagRewriter {
+	switch strings.ToLower(extension) {
+	case ".mp3":
+		return rewriteMP3Tags
+	case ".flac":
+		return rewriteFLACTags
+	case ".ogg", ".oga", ".opus":
+		return rewriteOggTags
+	case ".m4a", ".m4b", ".mp4":
+		return rewriteMP4Tags
+	default:
+		return nil
+	}
+}
+
+// Write applies changes to a file
+// The new file is written next to the original and renamed over it, so a failed
+// write never leaves a half-written file behind
+func (w *TagWriter) Write(filePath string, changes *model.TagChanges) error {
+	rewrite := tagRewriterFor(filepath.Ext(filePath))
+	if rewrite == nil {
+		return errUnsupportedTagFormat
+	}
+
+	src, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	info, err := src.Stat()
+	if err != nil {
+		src.Close()
+		return fmt.Errorf("failed to get file info: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+".*.tmp")
+	if err != nil {
+		src.Close()
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+
+	out := bufio.NewWriterSize(tmp, 1<<20)
+	err = rewrite(src, info.Size(), out, changes)
+	if err == nil {
+		err = out.Flush()
+	}
+	if err == nil {
+		err = tmp.Chmod(info.Mode().Perm())
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	// Both files must be closed before the rename on Windows
+	src.Close()
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write tags: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), filePath); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to replace file: %w", err)
+	}
+	return nil
+}
+
+// copyRange copies bytes [start, end) of src to dst
+func copyRange(dst io.Writer, src io.ReaderAt, start, end int64) error {
+	if end <= start {
+		return nil
+	}
+	_, err := io.Copy(dst, io.NewSectionReader(src, start, end-start))
+	return err
+}
+
+// yearValue returns the date to store for year, keeping the month and day of an
+// existing full date; zero clears it
+func yearValue(old string, year int) string {
+	if year <= 0 {
+		return ""
+	}
+	value := fmt.Sprintf("%04d", year)
+	if len(old) > 4 && isDigits(old[:4]) {
+		value += old[4:]
+	}
+	return value
+}
+
+// numberValue returns the "n" or "n/total" text to store for a track or disc
+// number, keeping the total of the existing value; zero clears it
+func numberValue(old string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	value := strconv.Itoa(n)
+	if _, total, ok := strings.Cut(old, "/"); ok && strings.TrimSpace(total) != "" {
+		value += "/" + strings.TrimSpace(total)
+	}
+	return value
+}
+
+// isDigits reports whether s is non-empty and all ASCII digits
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,341 @@
+package filesystem
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dhowden/tag"
+
+	"GoMusic/internal/domain/model"
+)
+
+// testJPEG stands in for embedded artwork; only its bytes are compared
+var testJPEG = []byte("\xff\xd8\xff\xe0 not really a JPEG \xff\xd9")
+
+// id3v23Frame builds an ID3v2.3 frame
+func id3v23Frame(id string, data []byte) []byte {
+	frame := append([]byte(id), binary.BigEndian.AppendUint32(nil, uint32(len(data)))...)
+	return append(append(frame, 0, 0), data...)
+}
+
+// id3v23Tag builds an ID3v2.3 tag from frames
+func id3v23Tag(frames ...[]byte) []byte {
+	body := bytes.Join(frames, nil)
+	header := []byte{'I', 'D', '3', 3, 0, 0, 0, 0, 0, 0}
+	putSyncsafe(header[6:10], uint32(len(body)))
+	return append(header, body...)
+}
+
+// flacPicture builds a FLAC picture block body holding a front cover
+func flacPicture(data []byte) []byte {
+	out := binary.BigEndian.AppendUint32(nil, 3)
+	out = binary.BigEndian.AppendUint32(out, uint32(len("image/jpeg")))
+	out = append(out, "image/jpeg"...)
+	out = append(out, make([]byte, 4*5)...)
+	out = binary.BigEndian.AppendUint32(out, uint32(len(data)))
+	return append(out, data...)
+}
+
+// flacMetadataBlock builds a metadata block with its header
+func flacMetadataBlock(blockType byte, last bool, data []byte) []byte {
+	if last {
+		blockType |= 0x80
+	}
+	return append([]byte{blockType, byte(len(data) >> 16), byte(len(data) >> 8), byte(len(data))}, data...)
+}
+
+// readFLACBlocks lists the metadata blocks of a FLAC file and returns the offset
+// where its audio begins
+func readFLACBlocks(t *testing.T, data []byte) ([]flacBlock, int) {
+	t.Helper()
+	if !bytes.HasPrefix(data, []byte("fLaC")) {
+		t.Fatal("missing fLaC marker")
+	}
+	var blocks []flacBlock
+	pos := 4
+	for {
+		if pos+4 > len(data) {
+			t.Fatal("truncated FLAC metadata")
+		}
+		length := int(data[pos+1])<<16 | int(data[pos+2])<<8 | int(data[pos+3])
+		blocks = append(blocks, flacBlock{Type: data[pos] & 0x7f, Data: data[pos+4 : pos+4+length]})
+		last := data[pos]&0x80 != 0
+		pos += 4 + length
+		if last {
+			return blocks, pos
+		}
+	}
+}
+
+// findFLACBlock returns the first block of a type, or nil
+func findFLACBlock(blocks []flacBlock, blockType byte) []byte {
+	for _, block := range blocks {
+		if block.Type == blockType {
+			return block.Data
+		}
+	}
+	return nil
+}
+
+func TestTagWriterRoundTrip(t *testing.T) {
+	const oldTitle, newTitle = "Old Title", "New Title"
+	audio := mp3Frames(mp3Header128k, mp3Frame128k, 3)
+
+	// MP3: ID3v2.3 with artwork, a private frame and a user text frame, plus ID3v1
+	apic := append([]byte("\x00image/jpeg\x00\x03\x00"), testJPEG...)
+	priv := []byte("owner@example.com\x00\x01\x02\x03")
+	txxx := []byte("\x00MY_KEY\x00kept")
+	id3v1 := make([]byte, id3v1Size)
+	copy(id3v1, "TAG"+oldTitle)
+	mp3 := bytes.Join([][]byte{
+		id3v23Tag(
+			id3v23Frame("TIT2", append([]byte{0}, oldTitle...)),
+			id3v23Frame("APIC", apic),
+			id3v23Frame("PRIV", priv),
+			id3v23Frame("TXXX", txxx),
+		),
+		audio,
+		id3v1,
+	}, nil)
+
+	// FLAC: a comment block, a picture, an application block and padding
+	streamInfo := make([]byte, 34)
+	binary.BigEndian.PutUint64(streamInfo[10:18], 44100<<44|1<<41|15<<36)
+	application := []byte("test application data")
+	flacAudio := append([]byte{0xff, 0xf8}, make([]byte, 200)...)
+	flac := bytes.Join([][]byte{
+		[]byte("fLaC"),
+		flacMetadataBlock(flacStreamInfo, false, streamInfo),
+		flacMetadataBlock(flacVorbisComment, false, (&vorbisComment{Vendor: "test", Comments: []string{"TITLE=" + oldTitle, "CUSTOM=kept"}}).bytes()),
+		flacMetadataBlock(6, false, flacPicture(testJPEG)),
+		flacMetadataBlock(2, false, application),
+		flacMetadataBlock(flacPaddingBlock, true, make([]byte, 16)),
+		flacAudio,
+	}, nil)
+
+	// MP4: a title, cover art and a freeform item, with moov ahead of mdat so
+	// that chunk offsets have to move
+	freeform := bytes.Join([][]byte{
+		mp4Atom("mean", make([]byte, 4), []byte("com.apple.iTunes")),
+		mp4Atom("name", make([]byte, 4), []byte("CUSTOM")),
+		mp4DataAtom(1, []byte("kept")),
+	}, nil)
+	ilst := mp4Atom("ilst",
+		mp4Atom("\xa9nam", mp4DataAtom(1, []byte(oldTitle))),
+		mp4Atom("covr", mp4DataAtom(13, testJPEG)),
+		mp4Atom("----", freeform),
+	)
+	hdlr := make([]byte, 25)
+	copy(hdlr[8:], "mdirappl")
+	udta := mp4Atom("udta", mp4Atom("meta", make([]byte, 4), mp4Atom("hdlr", hdlr), ilst))
+	mdatPayload := []byte("media data starts here")
+	ftyp := mp4Atom("ftyp", []byte("M4A "), make([]byte, 4))
+	stcoAt := func(offset uint32) []byte {
+		return mp4Atom("trak", mp4Atom("mdia", mp4Atom("minf", mp4Atom("stbl", mp4Atom("stco", mp4FullBox(1, offset))))))
+	}
+	moovSize := len(mp4Atom("moov", stcoAt(0), udta))
+	m4a := bytes.Join([][]byte{
+		ftyp,
+		mp4Atom("moov", stcoAt(uint32(len(ftyp)+moovSize+8)), udta),
+		mp4Atom("mdat", mdatPayload),
+	}, nil)
+
+	// Ogg Vorbis: a picture and a custom field in the comment header
+	picture := "METADATA_BLOCK_PICTURE=" + base64.StdEncoding.EncodeToString(flacPicture(testJPEG))
+	ogg := oggVorbisFile(44100, "TITLE="+oldTitle, picture, "CUSTOM=kept")
+	oggAudio := oggTestPage(0x04, 44100, 2, make([]byte, 500))
+
+	tests := []struct {
+		name  string
+		ext   string
+		data  []byte
+		check func(t *testing.T, out []byte)
+	}{
+		{
+			name: "ID3v2.3",
+			ext:  ".mp3",
+			data: mp3,
+			check: func(t *testing.T, out []byte) {
+				id3, audioStart, err := readID3v2Tag(bytes.NewReader(out), int64(len(out)))
+				if err != nil {
+					t.Fatalf("readID3v2Tag() error = %v", err)
+				}
+				if got := id3.text("TIT2"); got != newTitle {
+					t.Errorf("TIT2 = %q, want %q", got, newTitle)
+				}
+				kept := map[string][]byte{"APIC": apic, "PRIV": priv, "TXXX": txxx}
+				for _, frame := range id3.Frames {
+					if want, ok := kept[frame.ID]; ok {
+						if !bytes.Equal(frame.Data, want) {
+							t.Errorf("%s = %q, want %q", frame.ID, frame.Data, want)
+						}
+						delete(kept, frame.ID)
+					}
+				}
+				for id := range kept {
+					t.Errorf("%s frame lost", id)
+				}
+				if !bytes.Equal(out[audioStart:len(out)-id3v1Size], audio) {
+					t.Error("audio changed")
+				}
+				if v1 := out[len(out)-id3v1Size:]; string(bytes.TrimRight(v1[3:33], "\x00")) != newTitle {
+					t.Errorf("ID3v1 title = %q, want %q", v1[3:33], newTitle)
+				}
+			},
+		},
+		{
+			name: "FLAC",
+			ext:  ".flac",
+			data: flac,
+			check: func(t *testing.T, out []byte) {
+				blocks, audioStart := readFLACBlocks(t, out)
+				if blocks[0].Type != flacStreamInfo || !bytes.Equal(blocks[0].Data, streamInfo) {
+					t.Error("STREAMINFO is not kept first")
+				}
+				comment, _, err := parseVorbisComment(findFLACBlock(blocks, flacVorbisComment))
+				if err != nil {
+					t.Fatalf("parseVorbisComment() error = %v", err)
+				}
+				if comment.get("TITLE") != newTitle || comment.get("CUSTOM") != "kept" {
+					t.Errorf("comments = %q", comment.Comments)
+				}
+				if !bytes.Equal(findFLACBlock(blocks, 6), flacPicture(testJPEG)) {
+					t.Error("picture block changed")
+				}
+				if !bytes.Equal(findFLACBlock(blocks, 2), application) {
+					t.Error("application block changed")
+				}
+				if !bytes.Equal(out[audioStart:], flacAudio) {
+					t.Error("audio changed")
+				}
+			},
+		},
+		{
+			name: "MP4",
+			ext:  ".m4a",
+			data: m4a,
+			check: func(t *testing.T, out []byte) {
+				body, _ := readMoov(bytes.NewReader(out), int64(len(out)))
+				moov, err := newMP4Box("moov", body)
+				if err != nil {
+					t.Fatalf("newMP4Box() error = %v", err)
+				}
+				ilst := moov.itemList()
+				if got := mp4Text(ilst, "\xa9nam"); got != newTitle {
+					t.Errorf("title = %q, want %q", got, newTitle)
+				}
+				if covr := ilst.child("covr"); covr == nil || !bytes.Equal(covr.Data, mp4DataAtom(13, testJPEG)) {
+					t.Error("cover art changed")
+				}
+				if item := ilst.child("----"); item == nil || !bytes.Equal(item.Data, freeform) {
+					t.Error("freeform item changed")
+				}
+				stco := findAtom(findAtom(findAtom(findAtom(findAtom(body, "trak"), "mdia"), "minf"), "stbl"), "stco")
+				offset := int(binary.BigEndian.Uint32(stco[8:12]))
+				if !bytes.HasPrefix(out[offset:], mdatPayload) {
+					t.Errorf("chunk offset %d does not point at the media data", offset)
+				}
+			},
+		},
+		{
+			name: "Ogg Vorbis",
+			ext:  ".ogg",
+			data: ogg,
+			check: func(t *testing.T, out []byte) {
+				packets, _, _, headerEnd, err := readOggHeaderPackets(bytes.NewReader(out), int64(len(out)))
+				if err != nil {
+					t.Fatalf("readOggHeaderPackets() error = %v", err)
+				}
+				comment, _, err := parseVorbisComment(packets[1][7:])
+				if err != nil {
+					t.Fatalf("parseVorbisComment() error = %v", err)
+				}
+				if comment.get("TITLE") != newTitle || comment.get("CUSTOM") != "kept" {
+					t.Errorf("comments = %q", comment.Comments)
+				}
+				if comment.get("METADATA_BLOCK_PICTURE") != picture[len("METADATA_BLOCK_PICTURE="):] {
+					t.Error("picture changed")
+				}
+				if string(packets[2]) != "\x05vorbis setup" {
+					t.Error("setup header changed")
+				}
+				if !bytes.Equal(out[headerEnd:], oggAudio) {
+					t.Error("audio pages changed")
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "track"+tt.ext)
+			if err := os.WriteFile(path, tt.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			title := newTitle
+			if err := NewTagWriter().Write(path, &model.TagChanges{Title: &title}); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			out, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, out)
+
+			// Other readers see the new title and the artwork
+			metadata, err := tag.ReadFrom(bytes.NewReader(out))
+			if err != nil {
+				t.Fatalf("tag.ReadFrom() error = %v", err)
+			}
+			if metadata.Title() != newTitle {
+				t.Errorf("tag.ReadFrom() title = %q, want %q", metadata.Title(), newTitle)
+			}
+			if picture := metadata.Picture(); picture == nil || !bytes.Equal(picture.Data, testJPEG) {
+				t.Error("tag.ReadFrom() lost the artwork")
+			}
+		})
+	}
+}
+
+func TestReadID3v2TagMalformed(t *testing.T) {
+	title := id3v23Frame("TIT2", append([]byte{0}, "Title"...))
+
+	// A frame whose size runs past the end of the tag
+	overrun := id3v23Tag(title)
+	binary.BigEndian.PutUint32(overrun[14:18], 1000)
+
+	// A tag cut off inside a frame header, then padding-free audio
+	cutHeader := id3v23Tag(title, []byte("TPE1\x00\x00"))
+
+	// An extended header claiming more than the tag holds
+	extended := id3v23Tag(binary.BigEndian.AppendUint32(nil, 1000), title)
+	extended[5] |= 0x40
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr bool
+	}{
+		{name: "frame past the end of the tag", data: overrun, wantErr: true},
+		{name: "tag ends inside a frame header", data: cutHeader, wantErr: true},
+		{name: "extended header past the end", data: extended, wantErr: true},
+		{name: "tag past the end of the file", data: id3v23Tag(title)[:12], wantErr: true},
+		{name: "ID3v2.2", data: []byte{'I', 'D', '3', 2, 0, 0, 0, 0, 0, 0}, wantErr: true},
+		{name: "padding after the frames", data: id3v23Tag(title, make([]byte, 20))},
+		{name: "no tag", data: mp3Frames(mp3Header128k, mp3Frame128k, 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := readID3v2Tag(bytes.NewReader(tt.data), int64(len(tt.data)))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readID3v2Tag() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package filesystem
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/dhowden/tag"
+
+	"GoMusic/internal/domain/model"
+)
+
+// Tag fields carrying tempo and key, in order of preference
+// ID3v2.3/2.4 use TBPM/TKEY, ID3v2.2 TBP/TKE, Vorbis comments BPM/INITIALKEY
+var (
+	bpmFields = []string{"tbpm", "tbp", "bpm", "tempo"}
+	keyFields = []string{"tkey", "tke", "initialkey", "key"}
+)
+
+// readBPM extracts the tempo in beats per minute, or 0 when untagged
+// r is the file the metadata was read from, for the MP4 tempo item
+func readBPM(r io.ReadSeeker, size int64, metadata tag.Metadata, values map[string]string) float64 {
+	for _, field := range bpmFields {
+		if bpm := parseBPM(values[field]); bpm > 0 {
+			return bpm
+		}
+	}
+
+	if metadata.Format() == tag.MP4 {
+		return readMP4Tempo(r, size)
+	}
+	return 0
+}
+
+// readMP4Tempo reads the tmpo item of an MP4 file, a 16-bit integer, or 0
+func readMP4Tempo(r io.ReadSeeker, size int64) float64 {
+	moov, _ := readMoov(r, size)
+	if moov == nil {
+		return 0
+	}
+	box, err := newMP4Box("moov", moov)
+	if err != nil {
+		return 0
+	}
+	for _, atomType := range []string{"udta", "meta", "ilst"} {
+		if box = box.child(atomType); box == nil {
+			return 0
+		}
+	}
+	if value := mp4ItemValue(box, "tmpo"); len(value) >= 2 {
+		return float64(binary.BigEndian.Uint16(value))
+	}
+	return 0
+}
+
+// readKey extracts the initial key, or "" when untagged or unrecognized
+func readKey(values map[string]string) model.MusicalKey {
+	for _, field := range keyFields {
+		if key := model.ParseMusicalKey(values[field]); key != "" {
+			return key
+		}
+	}
+	return ""
+}
+
+// parseBPM parses a tempo such as "128" or "127.5", rounded to 0.01
+func parseBPM(value string) float64 {
+	bpm, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || bpm <= 0 || bpm > 999 {
+		return 0
+	}
+	return math.Round(bpm*100) / 100
+}
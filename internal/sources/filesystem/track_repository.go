@@ -3,11 +3,13 @@ package filesystem
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"sync"
 
 	"GoMusic/internal/domain/model"
 	"GoMusic/internal/domain/repository"
 	"GoMusic/internal/domain/source/capability"
+	"GoMusic/internal/sources/cache"
 	"GoMusic/internal/util/errors"
 )
 
@@ -16,25 +18,31 @@ import (
 type filesystemTrackRepository struct {
 	sourceID     string
 	config       *model.FilesystemSourceConfig
-	cache        *TrackCache
+	cache        *cache.TrackCache
 	scanner      *DirectoryScanner
 	extractor    Extractor
+	tagWriter    *TagWriter
+	identities   repository.TrackIdentityRepository
 	scanProgress *repository.ScanProgress
 	mu           sync.RWMutex
 }
 
 // NewFilesystemTrackRepository creates a new filesystem track repository
+// identities may be nil, in which case moved files get new IDs on the next scan
 func NewFilesystemTrackRepository(
 	sourceID string,
 	config *model.FilesystemSourceConfig,
 	extractor Extractor,
+	identities repository.TrackIdentityRepository,
 ) repository.TrackRepository {
 	return &filesystemTrackRepository{
-		sourceID:  sourceID,
-		config:    config,
-		cache:     NewTrackCache(),
-		scanner:   NewDirectoryScanner(config.RootPath, config.SupportedFormats),
-		extractor: extractor,
+		sourceID:   sourceID,
+		config:     config,
+		cache:      cache.NewTrackCache(),
+		scanner:    NewDirectoryScanner(config.RootPath, config.SupportedFormats),
+		extractor:  extractor,
+		tagWriter:  NewTagWriter(),
+		identities: identities,
 		scanProgress: &repository.ScanProgress{
 			IsScanning: false,
 		},
@@ -132,7 +140,8 @@ func (r *filesystemTrackRepository) Scan(ctx context.Context) error {
 	r.cache.Clear()
 
 	// Scan directory for audio files
-	files, err := r.scanner.ScanDirectory(ctx, func(filePath string) {
+	_, scanner, extractor := r.current()
+	files, err := scanner.ScanDirectory(ctx, func(filePath string) {
 		r.mu.Lock()
 		r.scanProgress.CurrentFile = filePath
 		r.scanProgress.ProcessedFiles++
@@ -149,6 +158,7 @@ func (r *filesystemTrackRepository) Scan(ctx context.Context) error {
 	r.mu.Unlock()
 
 	// Extract metadata from each file
+	sheets := NewCueSheetCache()
 	for _, filePath := range files {
 		select {
 		case <-ctx.Done():
@@ -159,7 +169,7 @@ func (r *filesystemTrackRepository) Scan(ctx context.Context) error {
 			r.scanProgress.ProcessedFiles++
 			r.mu.Unlock()
 
-			track, err := r.extractor.Extract(filePath)
+			tracks, err := extractor.ExtractTracks(filePath, sheets)
 			if err != nil {
 				errMsg := fmt.Sprintf("%s: %v", filePath, err)
 				r.mu.Lock()
@@ -168,17 +178,151 @@ func (r *filesystemTrackRepository) Scan(ctx context.Context) error {
 				continue
 			}
 
-			// Ensure track has the correct source ID
-			track.SourceID = r.sourceID
-			track.SourceType = model.SourceTypeFilesystem
+			// A single-file album with a CUE sheet yields one track per sheet entry
+			for _, track := range tracks {
+				// Ensure track has the correct source ID
+				track.SourceID = r.sourceID
+				track.SourceType = model.SourceTypeFilesystem
+				r.restoreIdentity(ctx, track)
 
-			r.cache.Add(track)
+				r.cache.Add(track)
+			}
 		}
 	}
 
 	return nil
 }
 
+// CanEditTags reports whether tags can be written to a track's file
+func (r *filesystemTrackRepository) CanEditTags(track *model.Track) error {
+	// Segment titles come from the CUE sheet, not the file's tags
+	if track.IsSegment() {
+		return fmt.Errorf("tracks split by a CUE sheet cannot be retagged")
+	}
+	if !r.tagWriter.SupportsFormat(filepath.Ext(track.FilePath)) {
+		return errors.ErrUnsupportedFormat
+	}
+	return nil
+}
+
+// UpdateTags writes tag changes to a track's file and re-reads it, replacing the
+// file's cached tracks without a rescan
+func (r *filesystemTrackRepository) UpdateTags(ctx context.Context, trackID string, changes *model.TagChanges) ([]*model.Track, error) {
+	track := r.cache.Get(trackID)
+	if track == nil {
+		return nil, errors.ErrNotFound
+	}
+	if err := r.CanEditTags(track); err != nil {
+		return nil, err
+	}
+
+	if err := r.tagWriter.Write(track.FilePath, changes); err != nil {
+		return nil, err
+	}
+
+	_, _, extractor := r.current()
+	tracks, err := extractor.ExtractTracks(track.FilePath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read updated tags: %w", err)
+	}
+	for _, cached := range r.cache.GetAll(&repository.QueryOptions{Limit: 0}) {
+		if cached.FilePath == track.FilePath {
+			r.cache.Delete(cached.ID)
+		}
+	}
+	for _, updated := range tracks {
+		updated.SourceID = r.sourceID
+		updated.SourceType = model.SourceTypeFilesystem
+		r.restoreIdentity(ctx, updated)
+		r.cache.Add(updated)
+	}
+	return tracks, nil
+}
+
+// RelocateTracks points the cached tracks of moved files at their new paths,
+// keeping their IDs, and records those IDs for later scans
+func (r *filesystemTrackRepository) RelocateTracks(ctx context.Context, moves map[string]string) error {
+	ids := make(map[string]string)
+	var relocated []*model.Track
+	for _, cached := range r.cache.GetAll(&repository.QueryOptions{Limit: 0}) {
+		newPath, ok := moves[cached.FilePath]
+		if !ok {
+			continue
+		}
+		track := *cached
+		track.FilePath = newPath
+		relocated = append(relocated, &track)
+		ids[cached.FilePath] = ""
+	}
+	// Old paths are released before new ones are claimed, as a file may move
+	// to a path another file has just left
+	for _, track := range relocated {
+		ids[track.FilePath] = track.ID
+	}
+
+	if r.identities != nil && len(ids) > 0 {
+		if err := r.identities.SaveAll(ctx, ids); err != nil {
+			return fmt.Errorf("failed to record moved tracks: %w", err)
+		}
+	}
+	for _, track := range relocated {
+		r.cache.Add(track)
+	}
+	return nil
+}
+
+// RestoreArtwork caches the artwork of a track using filename again by
+// re-reading its file, after the artwork cache evicted it
+func (r *filesystemTrackRepository) RestoreArtwork(ctx context.Context, filename string) (bool, error) {
+	for _, track := range r.cache.GetAll(&repository.QueryOptions{Limit: 0}) {
+		if track.ArtworkPath != filename {
+			continue
+		}
+		_, _, extractor := r.current()
+		extracted, err := extractor.Extract(track.FilePath)
+		if err != nil {
+			return false, err
+		}
+		// The file's artwork may have changed since the last scan
+		return extracted.ArtworkPath == filename, nil
+	}
+	return false, nil
+}
+
+// ReloadConfig swaps in an extractor and scanner for the updated configuration,
+// keeping the cached tracks until the next scan re-reads them
+func (r *filesystemTrackRepository) ReloadConfig(sourceConfig *model.SourceConfiguration) error {
+	config, err := sourceConfig.ToFilesystemConfig()
+	if err != nil {
+		return fmt.Errorf("failed to convert config: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.config = config
+	r.scanner = NewDirectoryScanner(config.RootPath, config.SupportedFormats)
+	r.extractor = NewTagExtractor(r.sourceID, config)
+	return nil
+}
+
+// current returns the configuration, scanner and extractor in use
+func (r *filesystemTrackRepository) current() (*model.FilesystemSourceConfig, *DirectoryScanner, Extractor) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.config, r.scanner, r.extractor
+}
+
+// restoreIdentity gives a track the ID it had before its file was moved
+// Segment IDs derive from the sheet's entries; their files are never moved
+func (r *filesystemTrackRepository) restoreIdentity(ctx context.Context, track *model.Track) {
+	if r.identities == nil || track.IsSegment() {
+		return
+	}
+	if id, err := r.identities.Get(ctx, track.FilePath); err == nil && id != "" {
+		track.ID = id
+	}
+}
+
 // GetScanProgress returns the current scan progress
 func (r *filesystemTrackRepository) GetScanProgress() *repository.ScanProgress {
 	r.mu.RLock()
@@ -194,20 +338,23 @@ func (r *filesystemTrackRepository) GetScanProgress() *repository.ScanProgress {
 
 // GetRootPath returns the configured root path for this repository
 func (r *filesystemTrackRepository) GetRootPath() string {
-	return r.config.RootPath
+	config, _, _ := r.current()
+	return config.RootPath
 }
 
 // ListDirectory lists the contents of a directory
 // relativePath is relative to the root path ("" or "/" for root)
 func (r *filesystemTrackRepository) ListDirectory(relativePath string) ([]*capability.FileNode, error) {
+	config, scanner, _ := r.current()
+
 	// Build full path
-	fullPath := r.config.RootPath
+	fullPath := config.RootPath
 	if relativePath != "" && relativePath != "/" {
-		fullPath = fmt.Sprintf("%s%s", r.config.RootPath, relativePath)
+		fullPath = fmt.Sprintf("%s%s", config.RootPath, relativePath)
 	}
 
 	// Read directory contents
-	entries, err := r.scanner.ListDirectory(fullPath)
+	entries, err := scanner.ListDirectory(fullPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list directory: %w", err)
 	}
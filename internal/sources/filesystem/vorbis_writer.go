@@ -0,0 +1,508 @@
+package filesystem
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"GoMusic/internal/decoder"
+	"GoMusic/internal/domain/model"
+)
+
+// flacPadding is the padding block added when rewritten FLAC metadata no longer
+// fits in the space of the old one
+const flacPadding = 4096
+
+// maxFLACBlockSize is the largest FLAC metadata block (24-bit length)
+const maxFLACBlockSize = 1<<24 - 1
+
+// FLAC metadata block types
+const (
+	flacStreamInfo    = 0
+	flacPaddingBlock  = 1
+	flacVorbisComment = 4
+)
+
+// tagVendor is the vendor string of comment blocks created from scratch
+const tagVendor = "GoMusic"
+
+// vorbisComment is a Vorbis comment header: a vendor string and KEY=value fields
+type vorbisComment struct {
+	Vendor   string
+	Comments []string
+}
+
+// parseVorbisComment parses a comment header body, returning the bytes after it
+func parseVorbisComment(data []byte) (*vorbisComment, []byte, error) {
+	errMalformed := errors.New("malformed Vorbis comment")
+	readString := func() (string, bool) {
+		if len(data) < 4 {
+			return "", false
+		}
+		n := binary.LittleEndian.Uint32(data)
+		if uint64(n) > uint64(len(data)-4) {
+			return "", false
+		}
+		value := string(data[4 : 4+n])
+		data = data[4+n:]
+		return value, true
+	}
+
+	vendor, ok := readString()
+	if !ok || len(data) < 4 {
+		return nil, nil, errMalformed
+	}
+	count := binary.LittleEndian.Uint32(data)
+	data = data[4:]
+	comment := &vorbisComment{Vendor: vendor}
+	for range count {
+		value, ok := readString()
+		if !ok {
+			return nil, nil, errMalformed
+		}
+		comment.Comments = append(comment.Comments, value)
+	}
+	return comment, data, nil
+}
+
+// bytes serialises the comment header body
+func (c *vorbisComment) bytes() []byte {
+	out := binary.LittleEndian.AppendUint32(nil, uint32(len(c.Vendor)))
+	out = append(out, c.Vendor...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(c.Comments)))
+	for _, comment := range c.Comments {
+		out = binary.LittleEndian.AppendUint32(out, uint32(len(comment)))
+		out = append(out, comment...)
+	}
+	return out
+}
+
+// get returns the first value of a field; keys are case-insensitive
+func (c *vorbisComment) get(key string) string {
+	for _, comment := range c.Comments {
+		if k, value, ok := strings.Cut(comment, "="); ok && strings.EqualFold(k, key) {
+			return value
+		}
+	}
+	return ""
+}
+
+// set replaces every field named by keys with one keys[0] field, in place of the
+// first of them; an empty value removes them
+// Later keys are aliases some taggers use for the same field
+func (c *vorbisComment) set(value string, keys ...string) {
+	comments := c.Comments[:0:0]
+	replaced := value == ""
+	for _, comment := range c.Comments {
+		k, _, _ := strings.Cut(comment, "=")
+		matched := false
+		for _, key := range keys {
+			matched = matched || strings.EqualFold(k, key)
+		}
+		if !matched {
+			comments = append(comments, comment)
+			continue
+		}
+		if !replaced {
+			comments = append(comments, keys[0]+"="+value)
+			replaced = true
+		}
+	}
+	if !replaced {
+		comments = append(comments, keys[0]+"="+value)
+	}
+	c.Comments = comments
+}
+
+// apply writes changes into the comment's fields
+func (c *vorbisComment) apply(changes *model.TagChanges) {
+	setChange := func(value *string, keys ...string) {
+		if value != nil {
+			c.set(*value, keys...)
+		}
+	}
+	setChange(changes.Title, "TITLE")
+	setChange(changes.Artist, "ARTIST")
+	setChange(changes.Album, "ALBUM")
+	setChange(changes.AlbumArtist, "ALBUMARTIST", "ALBUM ARTIST", "ALBUM_ARTIST")
+	setChange(changes.Genre, "GENRE")
+
+	if changes.Year != nil {
+		old := c.get("DATE")
+		if old == "" {
+			old = c.get("YEAR")
+		}
+		c.set(yearValue(old, *changes.Year), "DATE", "YEAR")
+	}
+	if changes.TrackNumber != nil {
+		c.setNumber(*changes.TrackNumber, "TRACKNUMBER", "TRACKTOTAL")
+	}
+	if changes.DiscNumber != nil {
+		c.setNumber(*changes.DiscNumber, "DISCNUMBER", "DISCTOTAL")
+	}
+}
+
+// setNumber sets a track or disc number; zero removes it
+// Readers expect the number alone, so the total of an "n/total" value moves to
+// its own field
+func (c *vorbisComment) setNumber(n int, key, totalKey string) {
+	if _, total, ok := strings.Cut(c.get(key), "/"); ok && n > 0 && c.get(totalKey) == "" {
+		if total = strings.TrimSpace(total); total != "" {
+			c.set(total, totalKey)
+		}
+	}
+	value := ""
+	if n > 0 {
+		value = strconv.Itoa(n)
+	}
+	c.set(value, key)
+}
+
+// flacBlock is a FLAC metadata block without its header
+type flacBlock struct {
+	Type byte
+	Data []byte
+}
+
+// rewriteFLACTags replaces the Vorbis comment block of a FLAC file, adding one
+// when missing; other blocks, including pictures, are kept as they are
+// The metadata keeps its old size when the new comment fits in the padding,
+// otherwise the audio moves and fresh padding is added
+func rewriteFLACTags(src *os.File, size int64, dst io.Writer, changes *model.TagChanges) error {
+	start := decoder.SkipID3v2(src)
+	marker := make([]byte, 4)
+	if _, err := src.ReadAt(marker, start); err != nil || string(marker) != "fLaC" {
+		return errors.New("not a FLAC file")
+	}
+
+	var blocks []flacBlock
+	pos := start + 4
+	oldSize := int64(0)
+	for {
+		header := make([]byte, 4)
+		if _, err := src.ReadAt(header, pos); err != nil {
+			return err
+		}
+		last, blockType := header[0]&0x80 != 0, header[0]&0x7f
+		length := int64(header[1])<<16 | int64(header[2])<<8 | int64(header[3])
+		if pos+4+length > size {
+			return errors.New("truncated FLAC metadata")
+		}
+		// Padding is rebuilt to fit the new metadata
+		if blockType != flacPaddingBlock {
+			data := make([]byte, length)
+			if _, err := src.ReadAt(data, pos+4); err != nil {
+				return err
+			}
+			blocks = append(blocks, flacBlock{Type: blockType, Data: data})
+		}
+		pos += 4 + length
+		oldSize += 4 + length
+		if last {
+			break
+		}
+	}
+	if len(blocks) == 0 || blocks[0].Type != flacStreamInfo {
+		return errors.New("FLAC file has no STREAMINFO block")
+	}
+
+	found := false
+	for i := range blocks {
+		if blocks[i].Type != flacVorbisComment {
+			continue
+		}
+		comment, _, err := parseVorbisComment(blocks[i].Data)
+		if err != nil {
+			return err
+		}
+		comment.apply(changes)
+		blocks[i].Data = comment.bytes()
+		found = true
+		break
+	}
+	if !found {
+		comment := &vorbisComment{Vendor: tagVendor}
+		comment.apply(changes)
+		blocks = append(blocks[:1], append([]flacBlock{{Type: flacVorbisComment, Data: comment.bytes()}}, blocks[1:]...)...)
+	}
+
+	newSize := int64(0)
+	for _, block := range blocks {
+		if len(block.Data) > maxFLACBlockSize {
+			return errors.New("FLAC metadata block is too large")
+		}
+		newSize += 4 + int64(len(block.Data))
+	}
+	padding := oldSize - newSize - 4
+	if padding < 0 {
+		padding = flacPadding
+	}
+	blocks = append(blocks, flacBlock{Type: flacPaddingBlock, Data: make([]byte, padding)})
+
+	// A leading ID3v2 tag is not part of FLAC, but is kept for players that read it
+	if err := copyRange(dst, src, 0, start); err != nil {
+		return err
+	}
+	if _, err := dst.Write([]byte("fLaC")); err != nil {
+		return err
+	}
+	for i, block := range blocks {
+		header := []byte{block.Type, byte(len(block.Data) >> 16), byte(len(block.Data) >> 8), byte(len(block.Data))}
+		if i == len(blocks)-1 {
+			header[0] |= 0x80
+		}
+		if _, err := dst.Write(header); err != nil {
+			return err
+		}
+		if _, err := dst.Write(block.Data); err != nil {
+			return err
+		}
+	}
+	return copyRange(dst, src, pos, size)
+}
+
+// oggOutPage is a page built when repaginating header packets
+type oggOutPage struct {
+	Continued bool // the page starts with the rest of a packet
+	Complete  bool // a packet ends on the page
+	Segments  []byte
+	Body      []byte
+}
+
+// rewriteOggTags replaces the comment header of the first logical stream in an
+// Ogg Vorbis, Opus or FLAC file
+// The header packets are repaginated, and when the number of header pages
+// changes the stream's later pages are renumbered
+func rewriteOggTags(src *os.File, size int64, dst io.Writer, changes *model.TagChanges) error {
+	packets, serial, headerPages, headerEnd, err := readOggHeaderPackets(src, size)
+	if err != nil {
+		return err
+	}
+
+	comment, err := rewriteOggComment(packets[0], packets[1], changes)
+	if err != nil {
+		return err
+	}
+	packets[1] = comment
+
+	pages := paginateOggPackets(packets)
+	for i, page := range pages {
+		headerType := byte(0)
+		if i == 0 {
+			headerType |= 0x02
+		}
+		if page.Continued {
+			headerType |= 0x01
+		}
+		granule := int64(0)
+		if !page.Complete {
+			granule = -1
+		}
+		if err := writeOggPage(dst, headerType, granule, serial, uint32(i), page.Segments, page.Body); err != nil {
+			return err
+		}
+	}
+
+	delta := uint32(len(pages) - headerPages)
+	if delta == 0 {
+		return copyRange(dst, src, headerEnd, size)
+	}
+
+	r := bufio.NewReaderSize(io.NewSectionReader(src, headerEnd, size-headerEnd), 1<<16)
+	for offset := headerEnd; offset < size; {
+		buf, page, err := readRawOggPage(r)
+		if err != nil {
+			return errors.New("malformed Ogg page")
+		}
+		if page.Serial == serial {
+			binary.LittleEndian.PutUint32(buf[18:22], binary.LittleEndian.Uint32(buf[18:22])+delta)
+			binary.LittleEndian.PutUint32(buf[22:26], oggChecksum(buf))
+		}
+		if _, err := dst.Write(buf); err != nil {
+			return err
+		}
+		offset += page.Size
+	}
+	return nil
+}
+
+// readOggHeaderPackets reads the header packets of the first logical stream,
+// returning them with the stream serial, the number of pages they span and the
+// offset where audio begins
+func readOggHeaderPackets(src io.ReaderAt, size int64) ([][]byte, uint32, int, int64, error) {
+	r := bufio.NewReader(io.NewSectionReader(src, 0, size))
+	var packets [][]byte
+	var current []byte
+	var serial uint32
+	headerPackets := 0
+
+	for pages, offset := 0, int64(0); offset < size; pages++ {
+		buf, page, err := readRawOggPage(r)
+		if err != nil {
+			return nil, 0, 0, 0, errors.New("not an Ogg file")
+		}
+		if pages == 0 {
+			serial = page.Serial
+		} else if page.Serial != serial {
+			return nil, 0, 0, 0, errors.New("multiplexed Ogg files cannot be tagged")
+		}
+		offset += page.Size
+
+		body := buf[oggPageHeaderSize+len(page.Segments):]
+		pos := 0
+		for i, segment := range page.Segments {
+			current = append(current, body[pos:pos+int(segment)]...)
+			pos += int(segment)
+			if segment == 255 {
+				continue
+			}
+			packets = append(packets, current)
+			current = nil
+
+			if len(packets) == 1 {
+				_, headerPackets = parseOggIdentification(packets[0])
+				if headerPackets < 2 && !isOggFLAC(packets[0]) {
+					return nil, 0, 0, 0, errUnsupportedTagFormat
+				}
+			}
+			// Ogg FLAC may not count its headers; the last metadata block is flagged
+			done := len(packets) == headerPackets
+			if isOggFLAC(packets[0]) {
+				done = len(packets) >= 2 && len(packets[len(packets)-1]) > 0 && packets[len(packets)-1][0]&0x80 != 0
+			}
+			if done {
+				if i != len(page.Segments)-1 {
+					return nil, 0, 0, 0, errors.New("Ogg headers do not end on a page boundary")
+				}
+				return packets, serial, pages + 1, offset, nil
+			}
+		}
+	}
+	return nil, 0, 0, 0, errors.New("truncated Ogg headers")
+}
+
+// isOggFLAC reports whether an identification packet starts an Ogg FLAC stream
+func isOggFLAC(packet []byte) bool {
+	return bytes.HasPrefix(packet, []byte("\x7fFLAC"))
+}
+
+// rewriteOggComment applies changes to the comment packet of the stream whose
+// identification packet is given
+func rewriteOggComment(identification, packet []byte, changes *model.TagChanges) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(identification, []byte("\x01vorbis")):
+		if !bytes.HasPrefix(packet, []byte("\x03vorbis")) {
+			return nil, errors.New("missing Vorbis comment header")
+		}
+		comment, _, err := parseVorbisComment(packet[7:])
+		if err != nil {
+			return nil, err
+		}
+		comment.apply(changes)
+		// The header ends with a framing bit
+		return append(append([]byte("\x03vorbis"), comment.bytes()...), 1), nil
+
+	case bytes.HasPrefix(identification, []byte("OpusHead")):
+		if !bytes.HasPrefix(packet, []byte("OpusTags")) {
+			return nil, errors.New("missing Opus comment header")
+		}
+		comment, rest, err := parseVorbisComment(packet[8:])
+		if err != nil {
+			return nil, err
+		}
+		comment.apply(changes)
+		// Data after the comments is kept; encoders may store binary metadata there
+		return append(append([]byte("OpusTags"), comment.bytes()...), rest...), nil
+
+	case isOggFLAC(identification):
+		if len(packet) < 4 || packet[0]&0x7f != flacVorbisComment {
+			return nil, errors.New("missing FLAC comment block")
+		}
+		comment, _, err := parseVorbisComment(packet[4:])
+		if err != nil {
+			return nil, err
+		}
+		comment.apply(changes)
+		data := comment.bytes()
+		if len(data) > maxFLACBlockSize {
+			return nil, errors.New("FLAC metadata block is too large")
+		}
+		header := []byte{packet[0], byte(len(data) >> 16), byte(len(data) >> 8), byte(len(data))}
+		return append(header, data...), nil
+	}
+	return nil, errUnsupportedTagFormat
+}
+
+// paginateOggPackets lays header packets out on pages
+// The identification packet gets a page of its own and the last packet ends its
+// page, as the codec mappings require
+func paginateOggPackets(packets [][]byte) []*oggOutPage {
+	var pages []*oggOutPage
+	page := &oggOutPage{}
+	for i, packet := range packets {
+		// Lacing values: runs of 255 and a final value below 255, which may be 0
+		for pos := 0; ; {
+			if len(page.Segments) == 255 {
+				pages = append(pages, page)
+				page = &oggOutPage{Continued: pos > 0}
+			}
+			lace := min(len(packet)-pos, 255)
+			page.Segments = append(page.Segments, byte(lace))
+			page.Body = append(page.Body, packet[pos:pos+lace]...)
+			pos += lace
+			if lace < 255 {
+				break
+			}
+		}
+		page.Complete = true
+		if i == 0 || i == len(packets)-1 {
+			pages = append(pages, page)
+			page = &oggOutPage{}
+		}
+	}
+	return pages
+}
+
+// writeOggPage writes a page with a freshly computed checksum
+func writeOggPage(w io.Writer, headerType byte, granule int64, serial, sequence uint32, segments, body []byte) error {
+	page := make([]byte, oggPageHeaderSize, oggPageHeaderSize+len(segments)+len(body))
+	copy(page, "OggS")
+	page[5] = headerType
+	binary.LittleEndian.PutUint64(page[6:14], uint64(granule))
+	binary.LittleEndian.PutUint32(page[14:18], serial)
+	binary.LittleEndian.PutUint32(page[18:22], sequence)
+	page[26] = byte(len(segments))
+	page = append(append(page, segments...), body...)
+	binary.LittleEndian.PutUint32(page[22:26], oggChecksum(page))
+	_, err := w.Write(page)
+	return err
+}
+
+// readRawOggPage reads a whole page, returning its bytes and parsed header
+func readRawOggPage(r io.Reader) ([]byte, *oggPage, error) {
+	header := make([]byte, oggPageHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, nil, err
+	}
+	if string(header[:4]) != "OggS" {
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+	segments := make([]byte, header[26])
+	if _, err := io.ReadFull(r, segments); err != nil {
+		return nil, nil, err
+	}
+
+	page := newOggPage(header, segments)
+	buf := make([]byte, page.Size)
+	copy(buf, header)
+	copy(buf[oggPageHeaderSize:], segments)
+	if _, err := io.ReadFull(r, buf[oggPageHeaderSize+len(segments):]); err != nil {
+		return nil, nil, err
+	}
+	return buf, page, nil
+}
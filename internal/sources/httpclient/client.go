@@ -0,0 +1,76 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Client is an HTTP client for API sources that enforces a request timeout
+// and a maximum request rate shared by all requests to the same server
+type Client struct {
+	httpClient *http.Client
+	interval   time.Duration
+	next       time.Time
+	mu         sync.Mutex
+}
+
+// New creates a client with the given timeout and rate limit (requests per second)
+// A rate limit of 0 disables throttling
+func New(timeout time.Duration, rateLimit int) *Client {
+	var interval time.Duration
+	if rateLimit > 0 {
+		interval = time.Second / time.Duration(rateLimit)
+	}
+
+	return &Client{
+		httpClient: &http.Client{Timeout: timeout},
+		interval:   interval,
+	}
+}
+
+// NewStreaming creates a client without an overall timeout, for long-running
+// audio streams; the rate limit still applies to opening the stream
+func NewStreaming(rateLimit int) *Client {
+	return New(0, rateLimit)
+}
+
+// Do waits for a rate limit slot and sends the request
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	if err := c.wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return c.httpClient.Do(req)
+}
+
+// wait blocks until the next request slot is available or ctx is cancelled
+func (c *Client) wait(ctx context.Context) error {
+	if c.interval == 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	now := time.Now()
+	slot := c.next
+	if slot.Before(now) {
+		slot = now
+	}
+	c.next = slot.Add(c.interval)
+	c.mu.Unlock()
+
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
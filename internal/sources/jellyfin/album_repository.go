@@ -0,0 +1,145 @@
+package jellyfin
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"GoMusic/internal/domain/model"
+	"GoMusic/internal/domain/repository"
+	"GoMusic/internal/util/errors"
+)
+
+// jellyfinAlbumRepository implements AlbumRepository over the synced Jellyfin library
+// This struct is unexported to enforce usage of the constructor
+type jellyfinAlbumRepository struct {
+	lib *library
+}
+
+// FindByID finds an album by ID
+func (r *jellyfinAlbumRepository) FindByID(ctx context.Context, id string) (*model.Album, error) {
+	r.lib.mu.RLock()
+	defer r.lib.mu.RUnlock()
+
+	album, ok := r.lib.albums[id]
+	if !ok {
+		return nil, errors.ErrNotFound
+	}
+	return album, nil
+}
+
+// FindAll returns all albums sorted by title
+func (r *jellyfinAlbumRepository) FindAll(ctx context.Context, opts *repository.QueryOptions) ([]*model.Album, error) {
+	r.lib.mu.RLock()
+	defer r.lib.mu.RUnlock()
+
+	albums := make([]*model.Album, 0, len(r.lib.albums))
+	for _, album := range r.lib.albums {
+		albums = append(albums, album)
+	}
+
+	return paginateAlbums(albums, opts), nil
+}
+
+// Create adds a new album to the repository
+func (r *jellyfinAlbumRepository) Create(ctx context.Context, album *model.Album) error {
+	r.lib.mu.Lock()
+	defer r.lib.mu.Unlock()
+
+	if _, ok := r.lib.albums[album.ID]; ok {
+		return errors.ErrAlreadyExists
+	}
+	r.lib.albums[album.ID] = album
+	return nil
+}
+
+// Update updates an existing album
+func (r *jellyfinAlbumRepository) Update(ctx context.Context, album *model.Album) error {
+	r.lib.mu.Lock()
+	defer r.lib.mu.Unlock()
+
+	if _, ok := r.lib.albums[album.ID]; !ok {
+		return errors.ErrNotFound
+	}
+	r.lib.albums[album.ID] = album
+	return nil
+}
+
+// Delete removes an album from the repository
+func (r *jellyfinAlbumRepository) Delete(ctx context.Context, id string) error {
+	r.lib.mu.Lock()
+	defer r.lib.mu.Unlock()
+
+	if _, ok := r.lib.albums[id]; !ok {
+		return errors.ErrNotFound
+	}
+	delete(r.lib.albums, id)
+	return nil
+}
+
+// FindByArtist returns all albums of an artist
+func (r *jellyfinAlbumRepository) FindByArtist(ctx context.Context, artistID string) ([]*model.Album, error) {
+	r.lib.mu.RLock()
+	defer r.lib.mu.RUnlock()
+
+	var albums []*model.Album
+	for _, album := range r.lib.albums {
+		if album.ArtistID == artistID {
+			albums = append(albums, album)
+		}
+	}
+
+	return paginateAlbums(albums, nil), nil
+}
+
+// Search searches albums by title and artist
+func (r *jellyfinAlbumRepository) Search(ctx context.Context, query string, opts *repository.SearchOptions) ([]*model.Album, error) {
+	r.lib.mu.RLock()
+	defer r.lib.mu.RUnlock()
+
+	query = strings.ToLower(query)
+	var albums []*model.Album
+	for _, album := range r.lib.albums {
+		if strings.Contains(strings.ToLower(album.Title), query) || strings.Contains(strings.ToLower(album.Artist), query) {
+			albums = append(albums, album)
+		}
+	}
+
+	var queryOpts *repository.QueryOptions
+	if opts != nil {
+		queryOpts = opts.QueryOptions
+	}
+	return paginateAlbums(albums, queryOpts), nil
+}
+
+// GetSourceID returns the source ID
+func (r *jellyfinAlbumRepository) GetSourceID() string {
+	return r.lib.sourceID
+}
+
+// GetSourceType returns the source type
+func (r *jellyfinAlbumRepository) GetSourceType() model.SourceType {
+	return model.SourceTypeJellyfin
+}
+
+// paginateAlbums sorts albums by title and applies offset/limit
+func paginateAlbums(albums []*model.Album, opts *repository.QueryOptions) []*model.Album {
+	sort.Slice(albums, func(i, j int) bool {
+		return albums[i].Title < albums[j].Title
+	})
+
+	if opts == nil {
+		return albums
+	}
+
+	start := opts.Offset
+	if start > len(albums) {
+		return []*model.Album{}
+	}
+	end := len(albums)
+	if opts.Limit > 0 && start+opts.Limit < end {
+		end = start + opts.Limit
+	}
+
+	return albums[start:end]
+}
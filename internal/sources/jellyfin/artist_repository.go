@@ -0,0 +1,130 @@
+package jellyfin
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"GoMusic/internal/domain/model"
+	"GoMusic/internal/domain/repository"
+	"GoMusic/internal/util/errors"
+)
+
+// jellyfinArtistRepository implements ArtistRepository over the synced Jellyfin library
+// This struct is unexported to enforce usage of the constructor
+type jellyfinArtistRepository struct {
+	lib *library
+}
+
+// FindByID finds an artist by ID
+func (r *jellyfinArtistRepository) FindByID(ctx context.Context, id string) (*model.Artist, error) {
+	r.lib.mu.RLock()
+	defer r.lib.mu.RUnlock()
+
+	artist, ok := r.lib.artists[id]
+	if !ok {
+		return nil, errors.ErrNotFound
+	}
+	return artist, nil
+}
+
+// FindAll returns all artists sorted by name
+func (r *jellyfinArtistRepository) FindAll(ctx context.Context, opts *repository.QueryOptions) ([]*model.Artist, error) {
+	r.lib.mu.RLock()
+	defer r.lib.mu.RUnlock()
+
+	artists := make([]*model.Artist, 0, len(r.lib.artists))
+	for _, artist := range r.lib.artists {
+		artists = append(artists, artist)
+	}
+
+	return paginateArtists(artists, opts), nil
+}
+
+// Create adds a new artist to the repository
+func (r *jellyfinArtistRepository) Create(ctx context.Context, artist *model.Artist) error {
+	r.lib.mu.Lock()
+	defer r.lib.mu.Unlock()
+
+	if _, ok := r.lib.artists[artist.ID]; ok {
+		return errors.ErrAlreadyExists
+	}
+	r.lib.artists[artist.ID] = artist
+	return nil
+}
+
+// Update updates an existing artist
+func (r *jellyfinArtistRepository) Update(ctx context.Context, artist *model.Artist) error {
+	r.lib.mu.Lock()
+	defer r.lib.mu.Unlock()
+
+	if _, ok := r.lib.artists[artist.ID]; !ok {
+		return errors.ErrNotFound
+	}
+	r.lib.artists[artist.ID] = artist
+	return nil
+}
+
+// Delete removes an artist from the repository
+func (r *jellyfinArtistRepository) Delete(ctx context.Context, id string) error {
+	r.lib.mu.Lock()
+	defer r.lib.mu.Unlock()
+
+	if _, ok := r.lib.artists[id]; !ok {
+		return errors.ErrNotFound
+	}
+	delete(r.lib.artists, id)
+	return nil
+}
+
+// Search searches artists by name
+func (r *jellyfinArtistRepository) Search(ctx context.Context, query string, opts *repository.SearchOptions) ([]*model.Artist, error) {
+	r.lib.mu.RLock()
+	defer r.lib.mu.RUnlock()
+
+	query = strings.ToLower(query)
+	var artists []*model.Artist
+	for _, artist := range r.lib.artists {
+		if strings.Contains(strings.ToLower(artist.Name), query) {
+			artists = append(artists, artist)
+		}
+	}
+
+	var queryOpts *repository.QueryOptions
+	if opts != nil {
+		queryOpts = opts.QueryOptions
+	}
+	return paginateArtists(artists, queryOpts), nil
+}
+
+// GetSourceID returns the source ID
+func (r *jellyfinArtistRepository) GetSourceID() string {
+	return r.lib.sourceID
+}
+
+// GetSourceType returns the source type
+func (r *jellyfinArtistRepository) GetSourceType() model.SourceType {
+	return model.SourceTypeJellyfin
+}
+
+// paginateArtists sorts artists by name and applies offset/limit
+func paginateArtists(artists []*model.Artist, opts *repository.QueryOptions) []*model.Artist {
+	sort.Slice(artists, func(i, j int) bool {
+		return artists[i].Name < artists[j].Name
+	})
+
+	if opts == nil {
+		return artists
+	}
+
+	start := opts.Offset
+	if start > len(artists) {
+		return []*model.Artist{}
+	}
+	end := len(artists)
+	if opts.Limit > 0 && start+opts.Limit < end {
+		end = start + opts.Limit
+	}
+
+	return artists[start:end]
+}
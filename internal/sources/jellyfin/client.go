@@ -0,0 +1,306 @@
+package jellyfin
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"GoMusic/internal/domain/model"
+	"GoMusic/internal/sources/httpclient"
+)
+
+const (
+	clientName    = "GoMusic"
+	clientVersion = "1.0.0"
+	deviceName    = "GoMusic Desktop"
+
+	// streamContainers lists the containers the webview can play directly;
+	// anything else is transcoded to MP3 by the universal endpoint
+	streamContainers = "mp3,flac,m4a,aac,ogg,oga,opus,wav"
+)
+
+// Client talks to a Jellyfin server
+type Client struct {
+	baseURL  string
+	username string
+	password string
+	apiKey   string
+	deviceID string
+
+	http   *httpclient.Client
+	stream *httpclient.Client
+
+	token  string
+	userID string
+	mu     sync.Mutex
+}
+
+// NewClient creates a Jellyfin client from an API source configuration
+// The device ID is derived from the source ID so sessions stay stable across restarts
+func NewClient(sourceID string, config *model.APISourceConfig) *Client {
+	deviceHash := sha256.Sum256([]byte("gomusic/" + sourceID))
+
+	return &Client{
+		baseURL:  strings.TrimSuffix(config.BaseURL, "/"),
+		username: config.Username,
+		password: config.Password,
+		apiKey:   config.APIKey,
+		deviceID: hex.EncodeToString(deviceHash[:8]),
+		http:     httpclient.New(config.Timeout, config.RateLimit),
+		stream:   httpclient.NewStreaming(config.RateLimit),
+	}
+}
+
+// Authenticate resolves an access token and user ID
+// API keys are used directly; otherwise username/password are exchanged for a token
+func (c *Client) Authenticate(ctx context.Context) error {
+	c.mu.Lock()
+	authenticated := c.token != "" && c.userID != ""
+	c.mu.Unlock()
+	if authenticated {
+		return nil
+	}
+
+	if c.apiKey != "" {
+		return c.authenticateWithAPIKey(ctx)
+	}
+	return c.authenticateByName(ctx)
+}
+
+// authenticateByName exchanges username/password for an access token
+func (c *Client) authenticateByName(ctx context.Context) error {
+	body, err := json.Marshal(map[string]string{"Username": c.username, "Pw": c.password})
+	if err != nil {
+		return err
+	}
+
+	var auth authResponse
+	if err := c.doJSON(ctx, http.MethodPost, "/Users/AuthenticateByName", nil, bytes.NewReader(body), &auth); err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+	if auth.AccessToken == "" || auth.User.ID == "" {
+		return fmt.Errorf("authentication failed: empty token")
+	}
+
+	c.mu.Lock()
+	c.token = auth.AccessToken
+	c.userID = auth.User.ID
+	c.mu.Unlock()
+
+	return nil
+}
+
+// authenticateWithAPIKey uses an API key and picks the user whose library to import
+// Prefers the configured username, then the first administrator
+func (c *Client) authenticateWithAPIKey(ctx context.Context) error {
+	c.mu.Lock()
+	c.token = c.apiKey
+	c.mu.Unlock()
+
+	var users []user
+	if err := c.doJSON(ctx, http.MethodGet, "/Users", nil, nil, &users); err != nil {
+		return fmt.Errorf("failed to list users: %w", err)
+	}
+	if len(users) == 0 {
+		return fmt.Errorf("server has no users")
+	}
+
+	selected := users[0]
+	for _, u := range users {
+		if c.username != "" && strings.EqualFold(u.Name, c.username) {
+			selected = u
+			break
+		}
+		if c.username == "" && u.Policy.IsAdministrator {
+			selected = u
+			break
+		}
+	}
+
+	c.mu.Lock()
+	c.userID = selected.ID
+	c.mu.Unlock()
+
+	return nil
+}
+
+// GetItems returns a page of the user's items of the given type
+func (c *Client) GetItems(ctx context.Context, itemType string, startIndex, limit int) (*itemsResponse, error) {
+	params := url.Values{}
+	params.Set("IncludeItemTypes", itemType)
+	params.Set("Recursive", "true")
+	params.Set("SortBy", "SortName")
+	params.Set("Fields", "MediaSources,Genres,DateCreated,ChildCount")
+	params.Set("StartIndex", strconv.Itoa(startIndex))
+	params.Set("Limit", strconv.Itoa(limit))
+
+	var resp itemsResponse
+	if err := c.doJSON(ctx, http.MethodGet, "/Users/"+c.currentUserID()+"/Items", params, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SearchItems searches the user's audio items
+func (c *Client) SearchItems(ctx context.Context, query string, limit int) ([]item, error) {
+	params := url.Values{}
+	params.Set("SearchTerm", query)
+	params.Set("IncludeItemTypes", "Audio")
+	params.Set("Recursive", "true")
+	params.Set("Fields", "MediaSources,Genres,DateCreated")
+	params.Set("Limit", strconv.Itoa(limit))
+
+	var resp itemsResponse
+	if err := c.doJSON(ctx, http.MethodGet, "/Users/"+c.currentUserID()+"/Items", params, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Items, nil
+}
+
+// GetAlbumArtists returns a page of album artists
+func (c *Client) GetAlbumArtists(ctx context.Context, startIndex, limit int) (*itemsResponse, error) {
+	params := url.Values{}
+	params.Set("userId", c.currentUserID())
+	params.Set("StartIndex", strconv.Itoa(startIndex))
+	params.Set("Limit", strconv.Itoa(limit))
+
+	var resp itemsResponse
+	if err := c.doJSON(ctx, http.MethodGet, "/Artists/AlbumArtists", params, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetPrimaryImage downloads the primary image of an item
+func (c *Client) GetPrimaryImage(ctx context.Context, itemID, tag string) ([]byte, string, error) {
+	params := url.Values{}
+	params.Set("maxWidth", "1000")
+	if tag != "" {
+		params.Set("tag", tag)
+	}
+
+	resp, err := c.do(ctx, c.http, http.MethodGet, "/Items/"+url.PathEscape(itemID)+"/Images/Primary", params, nil, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read image: %w", err)
+	}
+
+	return data, resp.Header.Get("Content-Type"), nil
+}
+
+// StreamURL returns the universal audio endpoint for an item without credentials
+// Credentials are sent as a header when the stream is opened
+func (c *Client) StreamURL(itemID string) string {
+	params := url.Values{}
+	params.Set("UserId", c.currentUserID())
+	params.Set("DeviceId", c.deviceID)
+	params.Set("Container", streamContainers)
+	params.Set("TranscodingContainer", "mp3")
+	params.Set("TranscodingProtocol", "http")
+	params.Set("AudioCodec", "mp3")
+	return c.baseURL + "/Audio/" + url.PathEscape(itemID) + "/universal?" + params.Encode()
+}
+
+// OpenStream requests the audio stream at streamURL with method (GET or HEAD),
+// forwarding the given header
+func (c *Client) OpenStream(ctx context.Context, streamURL string, method string, header http.Header) (*http.Response, error) {
+	if err := c.Authenticate(ctx); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, streamURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stream request: %w", err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Authorization", c.authorizationHeader())
+
+	return c.stream.Do(req)
+}
+
+// doJSON performs an API request and decodes the JSON response into out
+func (c *Client) doJSON(ctx context.Context, method, path string, params url.Values, body io.Reader, out interface{}) error {
+	resp, err := c.do(ctx, c.http, method, path, params, body, map[string]string{"Content-Type": "application/json"})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse %s response: %w", path, err)
+	}
+	return nil
+}
+
+// do performs an authenticated API request and checks the status code
+func (c *Client) do(ctx context.Context, client *httpclient.Client, method, path string, params url.Values, body io.Reader, headers map[string]string) (*http.Response, error) {
+	target := c.baseURL + path
+	if len(params) > 0 {
+		target += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", c.authorizationHeader())
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to %s failed: %w", path, err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusUnauthorized {
+			// Force re-authentication on the next call
+			c.mu.Lock()
+			c.token = ""
+			c.userID = ""
+			c.mu.Unlock()
+		}
+		return nil, fmt.Errorf("request to %s failed: %s", path, resp.Status)
+	}
+
+	return resp, nil
+}
+
+// authorizationHeader builds the MediaBrowser authorization header
+func (c *Client) authorizationHeader() string {
+	c.mu.Lock()
+	token := c.token
+	c.mu.Unlock()
+
+	header := fmt.Sprintf(`MediaBrowser Client="%s", Device="%s", DeviceId="%s", Version="%s"`,
+		clientName, deviceName, c.deviceID, clientVersion)
+	if token != "" {
+		header += fmt.Sprintf(`, Token="%s"`, token)
+	}
+	return header
+}
+
+// currentUserID returns the authenticated user ID
+func (c *Client) currentUserID() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.userID
+}
@@ -0,0 +1,180 @@
+package jellyfin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"GoMusic/internal/domain/model"
+)
+
+// fakeServer is a Jellyfin server with one API key, one password and two users
+type fakeServer struct {
+	mu         sync.Mutex
+	lastStream http.Header
+}
+
+func newFakeServer(t *testing.T) (*fakeServer, *httptest.Server) {
+	t.Helper()
+	fake := &fakeServer{}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func (f *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	auth := r.Header.Get("Authorization")
+	if r.URL.Path == "/Users/AuthenticateByName" {
+		var body struct{ Username, Pw string }
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Username != "alice" || body.Pw != "secret" {
+			http.Error(w, "wrong password", http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(authResponse{AccessToken: "session-token", User: user{ID: "user-alice", Name: "alice"}})
+		return
+	}
+
+	if !strings.Contains(auth, `Token="api-key"`) && !strings.Contains(auth, `Token="session-token"`) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case r.URL.Path == "/Users":
+		users := []user{{ID: "user-bob", Name: "bob"}, {ID: "user-admin", Name: "admin"}, {ID: "user-alice", Name: "alice"}}
+		users[1].Policy.IsAdministrator = true
+		json.NewEncoder(w).Encode(users)
+	case strings.HasSuffix(r.URL.Path, "/universal"):
+		f.mu.Lock()
+		f.lastStream = r.Header.Clone()
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusPartialContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     model.APISourceConfig
+		wantUserID string
+		wantToken  string
+		wantErr    bool
+	}{
+		{name: "API key picks the first administrator", config: model.APISourceConfig{APIKey: "api-key"}, wantUserID: "user-admin", wantToken: "api-key"},
+		{name: "API key with a username", config: model.APISourceConfig{APIKey: "api-key", Username: "Alice"}, wantUserID: "user-alice", wantToken: "api-key"},
+		{name: "username and password", config: model.APISourceConfig{Username: "alice", Password: "secret"}, wantUserID: "user-alice", wantToken: "session-token"},
+		{name: "wrong password", config: model.APISourceConfig{Username: "alice", Password: "guess"}, wantErr: true},
+		{name: "unknown API key", config: model.APISourceConfig{APIKey: "other"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, server := newFakeServer(t)
+			config := tt.config
+			config.BaseURL = server.URL + "/"
+			client := NewClient("jellyfin", &config)
+
+			err := client.Authenticate(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Authenticate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if client.currentUserID() != tt.wantUserID {
+				t.Errorf("user ID = %s, want %s", client.currentUserID(), tt.wantUserID)
+			}
+			if header := client.authorizationHeader(); !strings.Contains(header, `Token="`+tt.wantToken+`"`) {
+				t.Errorf("Authorization = %s, want token %s", header, tt.wantToken)
+			}
+		})
+	}
+}
+
+func TestFetchAll(t *testing.T) {
+	tests := []struct {
+		name      string
+		total     int // TotalRecordCount the server reports
+		available int // items the server actually returns
+		wantCalls []int
+		wantItems int
+	}{
+		{name: "several pages", total: 1200, available: 1200, wantCalls: []int{0, 500, 1000}, wantItems: 1200},
+		{name: "exactly one full page", total: 500, available: 500, wantCalls: []int{0}, wantItems: 500},
+		{name: "count reached before a short page", total: 600, available: 5000, wantCalls: []int{0, 500}, wantItems: 1000},
+		{name: "empty library", total: 0, available: 0, wantCalls: []int{0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []int
+			fetch := func(ctx context.Context, startIndex, limit int) (*itemsResponse, error) {
+				calls = append(calls, startIndex)
+				resp := &itemsResponse{TotalRecordCount: tt.total}
+				for i := startIndex; i < tt.available && i < startIndex+limit; i++ {
+					resp.Items = append(resp.Items, item{ID: fmt.Sprint(i)})
+				}
+				return resp, nil
+			}
+
+			items := 0
+			if err := fetchAll(context.Background(), fetch, func(item) { items++ }); err != nil {
+				t.Fatalf("fetchAll() error = %v", err)
+			}
+			if fmt.Sprint(calls) != fmt.Sprint(tt.wantCalls) || items != tt.wantItems {
+				t.Errorf("fetchAll() made calls %v visiting %d items, want %v and %d", calls, items, tt.wantCalls, tt.wantItems)
+			}
+		})
+	}
+
+	failing := func(ctx context.Context, startIndex, limit int) (*itemsResponse, error) {
+		return nil, fmt.Errorf("server down")
+	}
+	if err := fetchAll(context.Background(), failing, func(item) {}); err == nil {
+		t.Error("fetchAll() of a failing listing succeeded")
+	}
+}
+
+func TestStreamURL(t *testing.T) {
+	fake, server := newFakeServer(t)
+	client := NewClient("jellyfin", &model.APISourceConfig{BaseURL: server.URL, Username: "alice", Password: "secret"})
+	if err := client.Authenticate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The stream URL reaches the frontend, so the token is sent as a header
+	streamURL := client.StreamURL("item 1")
+	parsed, err := url.Parse(streamURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Path != "/Audio/item 1/universal" || parsed.Query().Get("UserId") != "user-alice" {
+		t.Errorf("StreamURL() = %s", streamURL)
+	}
+	for param := range parsed.Query() {
+		if lower := strings.ToLower(param); lower == "api_key" || lower == "apikey" || lower == "token" {
+			t.Errorf("StreamURL() = %s carries %s", streamURL, param)
+		}
+	}
+	if strings.Contains(streamURL, "session-token") || strings.Contains(streamURL, "secret") {
+		t.Errorf("StreamURL() = %s carries credentials", streamURL)
+	}
+
+	resp, err := client.OpenStream(context.Background(), streamURL, http.MethodGet, http.Header{"Range": {"bytes=100-"}})
+	if err != nil {
+		t.Fatalf("OpenStream() error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent || fake.lastStream.Get("Range") != "bytes=100-" ||
+		!strings.Contains(fake.lastStream.Get("Authorization"), `Token="session-token"`) {
+		t.Errorf("OpenStream() = %s with header %v, want an authorized, ranged stream", resp.Status, fake.lastStream)
+	}
+}
@@ -0,0 +1,452 @@
+package jellyfin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"GoMusic/internal/domain/model"
+	"GoMusic/internal/domain/repository"
+	"GoMusic/internal/sources/cache"
+	"GoMusic/internal/sources/remote"
+	"GoMusic/internal/util/errors"
+)
+
+// pageSize is the number of items requested per Items call
+const pageSize = 500
+
+// library holds the synced state shared by the track, album and artist repositories
+type library struct {
+	sourceID string
+	client   *Client
+	tracks   *cache.TrackCache
+	albums   map[string]*model.Album
+	artists  map[string]*model.Artist
+	artwork  remote.Artwork
+	mu       sync.RWMutex
+}
+
+// jellyfinTrackRepository implements TrackRepository for Jellyfin music libraries
+// This struct is unexported to enforce usage of the constructor
+type jellyfinTrackRepository struct {
+	lib          *library
+	scanProgress *repository.ScanProgress
+	mu           sync.RWMutex
+}
+
+// NewJellyfinRepositories creates the track, album and artist repositories for a Jellyfin source
+// All three share one synced library; scanning the track repository refreshes them all
+func NewJellyfinRepositories(sourceID string, config *model.APISourceConfig) (repository.TrackRepository, repository.AlbumRepository, repository.ArtistRepository) {
+	lib := &library{
+		sourceID: sourceID,
+		client:   NewClient(sourceID, config),
+		tracks:   cache.NewTrackCache(),
+		albums:   make(map[string]*model.Album),
+		artists:  make(map[string]*model.Artist),
+	}
+
+	trackRepo := &jellyfinTrackRepository{
+		lib: lib,
+		scanProgress: &repository.ScanProgress{
+			IsScanning: false,
+		},
+	}
+
+	return trackRepo, &jellyfinAlbumRepository{lib: lib}, &jellyfinArtistRepository{lib: lib}
+}
+
+// FindByID finds a track by ID
+func (r *jellyfinTrackRepository) FindByID(ctx context.Context, id string) (*model.Track, error) {
+	track := r.lib.tracks.Get(id)
+	if track == nil {
+		return nil, errors.ErrNotFound
+	}
+	return track, nil
+}
+
+// FindAll returns all synced tracks with optional filtering
+func (r *jellyfinTrackRepository) FindAll(ctx context.Context, opts *repository.QueryOptions) ([]*model.Track, error) {
+	return r.lib.tracks.GetAll(opts), nil
+}
+
+// Create adds a new track to the repository
+func (r *jellyfinTrackRepository) Create(ctx context.Context, track *model.Track) error {
+	if r.lib.tracks.Get(track.ID) != nil {
+		return errors.ErrAlreadyExists
+	}
+	r.lib.tracks.Add(track)
+	return nil
+}
+
+// Update updates an existing track
+func (r *jellyfinTrackRepository) Update(ctx context.Context, track *model.Track) error {
+	if r.lib.tracks.Get(track.ID) == nil {
+		return errors.ErrNotFound
+	}
+	r.lib.tracks.Add(track)
+	return nil
+}
+
+// Delete removes a track from the repository
+func (r *jellyfinTrackRepository) Delete(ctx context.Context, id string) error {
+	if r.lib.tracks.Get(id) == nil {
+		return errors.ErrNotFound
+	}
+	r.lib.tracks.Delete(id)
+	return nil
+}
+
+// FindByAlbum returns all tracks for a given album
+func (r *jellyfinTrackRepository) FindByAlbum(ctx context.Context, albumID string) ([]*model.Track, error) {
+	return r.lib.tracks.FindByAlbum(albumID), nil
+}
+
+// FindByArtist returns all tracks for a given artist
+func (r *jellyfinTrackRepository) FindByArtist(ctx context.Context, artistID string) ([]*model.Track, error) {
+	return r.lib.tracks.FindByArtist(artistID), nil
+}
+
+// Search searches the server, falling back to the synced cache when offline
+func (r *jellyfinTrackRepository) Search(ctx context.Context, query string, opts *repository.SearchOptions) ([]*model.Track, error) {
+	limit := 100
+	if opts != nil && opts.QueryOptions != nil && opts.Limit > 0 {
+		limit = opts.Limit
+	}
+
+	if err := r.lib.client.Authenticate(ctx); err != nil {
+		return r.lib.tracks.Search(query, opts), nil
+	}
+
+	items, err := r.lib.client.SearchItems(ctx, query, limit)
+	if err != nil {
+		return r.lib.tracks.Search(query, opts), nil
+	}
+
+	tracks := make([]*model.Track, 0, len(items))
+	for _, it := range items {
+		track := r.lib.tracks.Get(remote.ScopedID("track_", r.lib.sourceID, it.ID))
+		if track == nil {
+			// Not synced yet: make it playable right away
+			track = r.lib.itemToTrack(it)
+			r.lib.tracks.Add(track)
+		}
+		tracks = append(tracks, track)
+	}
+
+	return tracks, nil
+}
+
+// GetSourceID returns the source ID
+func (r *jellyfinTrackRepository) GetSourceID() string {
+	return r.lib.sourceID
+}
+
+// GetSourceType returns the source type
+func (r *jellyfinTrackRepository) GetSourceType() model.SourceType {
+	return model.SourceTypeJellyfin
+}
+
+// Scan syncs artists, albums and tracks from the server
+func (r *jellyfinTrackRepository) Scan(ctx context.Context) error {
+	r.mu.Lock()
+	if r.scanProgress.IsScanning {
+		r.mu.Unlock()
+		return errors.ErrScanInProgress
+	}
+	r.scanProgress.IsScanning = true
+	r.scanProgress.ProcessedFiles = 0
+	r.scanProgress.TotalFiles = 0
+	r.scanProgress.CurrentFile = ""
+	r.scanProgress.Errors = []string{}
+	r.mu.Unlock()
+
+	defer func() {
+		r.mu.Lock()
+		r.scanProgress.IsScanning = false
+		r.scanProgress.CurrentFile = ""
+		r.mu.Unlock()
+	}()
+
+	client := r.lib.client
+	if err := client.Authenticate(ctx); err != nil {
+		return fmt.Errorf("failed to connect to server: %w", err)
+	}
+
+	// Artists
+	artists := make(map[string]*model.Artist)
+	err := fetchAll(ctx, client.GetAlbumArtists, func(it item) {
+		artist := r.lib.itemToArtist(ctx, it, r.addScanError)
+		artists[artist.ID] = artist
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list artists: %w", err)
+	}
+
+	// Albums
+	albums := make(map[string]*model.Album)
+	err = fetchAll(ctx, r.itemsOfType("MusicAlbum"), func(it item) {
+		album := r.lib.itemToAlbum(ctx, it, r.addScanError)
+		albums[album.ID] = album
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list albums: %w", err)
+	}
+
+	// Tracks (progress counts tracks since they dominate the sync)
+	var tracks []*model.Track
+	err = fetchAll(ctx, r.itemsOfTypeWithProgress("Audio"), func(it item) {
+		r.mu.Lock()
+		r.scanProgress.CurrentFile = it.Name
+		r.scanProgress.ProcessedFiles++
+		r.mu.Unlock()
+
+		track := r.lib.itemToTrack(it)
+		if album, ok := albums[track.AlbumID]; ok {
+			track.ArtworkPath = album.ArtworkPath
+		}
+		tracks = append(tracks, track)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list tracks: %w", err)
+	}
+
+	// Swap in the new library
+	r.lib.tracks.Clear()
+	for _, track := range tracks {
+		r.lib.tracks.Add(track)
+	}
+
+	r.lib.mu.Lock()
+	r.lib.albums = albums
+	r.lib.artists = artists
+	r.lib.mu.Unlock()
+
+	return nil
+}
+
+// GetScanProgress returns the current sync progress
+func (r *jellyfinTrackRepository) GetScanProgress() *repository.ScanProgress {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// Return a copy to avoid data races
+	progress := *r.scanProgress
+	progress.Errors = make([]string, len(r.scanProgress.Errors))
+	copy(progress.Errors, r.scanProgress.Errors)
+
+	return &progress
+}
+
+// OpenStream opens an authenticated audio stream for a track
+func (r *jellyfinTrackRepository) OpenStream(ctx context.Context, track *model.Track, method string, header http.Header) (*http.Response, error) {
+	if track.StreamURL == "" {
+		return nil, fmt.Errorf("track has no stream URL")
+	}
+	return r.lib.client.OpenStream(ctx, track.StreamURL, method, header)
+}
+
+// itemsOfType returns a page fetcher for the given item type
+func (r *jellyfinTrackRepository) itemsOfType(itemType string) func(context.Context, int, int) (*itemsResponse, error) {
+	return func(ctx context.Context, startIndex, limit int) (*itemsResponse, error) {
+		return r.lib.client.GetItems(ctx, itemType, startIndex, limit)
+	}
+}
+
+// itemsOfTypeWithProgress is like itemsOfType but records the total for progress reporting
+func (r *jellyfinTrackRepository) itemsOfTypeWithProgress(itemType string) func(context.Context, int, int) (*itemsResponse, error) {
+	return func(ctx context.Context, startIndex, limit int) (*itemsResponse, error) {
+		resp, err := r.lib.client.GetItems(ctx, itemType, startIndex, limit)
+		if err == nil {
+			r.mu.Lock()
+			r.scanProgress.TotalFiles = resp.TotalRecordCount
+			r.mu.Unlock()
+		}
+		return resp, err
+	}
+}
+
+// addScanError records a non-fatal sync error
+func (r *jellyfinTrackRepository) addScanError(message string) {
+	r.mu.Lock()
+	r.scanProgress.Errors = append(r.scanProgress.Errors, message)
+	r.mu.Unlock()
+}
+
+// fetchAll pages through a listing until all items have been visited
+func fetchAll(ctx context.Context, fetch func(context.Context, int, int) (*itemsResponse, error), visit func(item)) error {
+	for startIndex := 0; ; startIndex += pageSize {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		resp, err := fetch(ctx, startIndex, pageSize)
+		if err != nil {
+			return err
+		}
+
+		for _, it := range resp.Items {
+			visit(it)
+		}
+
+		if len(resp.Items) < pageSize || startIndex+len(resp.Items) >= resp.TotalRecordCount {
+			return nil
+		}
+	}
+}
+
+// itemToTrack converts a Jellyfin Audio item into a Track
+func (l *library) itemToTrack(it item) *model.Track {
+	track := &model.Track{
+		ID:         remote.ScopedID("track_", l.sourceID, it.ID),
+		SourceID:   l.sourceID,
+		SourceType: model.SourceTypeJellyfin,
+
+		Title:       it.Name,
+		Album:       it.Album,
+		AlbumArtist: it.AlbumArtist,
+		Year:        it.ProductionYear,
+		TrackNumber: it.IndexNumber,
+		DiscNumber:  it.ParentIndexNumber,
+		Duration:    ticksToDuration(it.RunTimeTicks),
+		Format:      strings.ToLower(it.Container),
+
+		ExternalID: it.ID,
+		StreamURL:  l.client.StreamURL(it.ID),
+
+		AddedAt:    time.Now(),
+		ModifiedAt: parseDate(it.DateCreated),
+	}
+
+	if len(it.Genres) > 0 {
+		track.Genre = it.Genres[0]
+	}
+
+	// Artist: prefer the linked artist item so the ID is stable
+	artistKey := ""
+	if len(it.ArtistItems) > 0 {
+		track.Artist = it.ArtistItems[0].Name
+		artistKey = it.ArtistItems[0].ID
+	} else if len(it.Artists) > 0 {
+		track.Artist = it.Artists[0]
+		artistKey = it.Artists[0]
+	}
+
+	// Audio properties from the first media source
+	if len(it.MediaSources) > 0 {
+		source := it.MediaSources[0]
+		track.FileSize = source.Size
+		track.BitRate = source.Bitrate / 1000
+		for _, stream := range source.MediaStreams {
+			if stream.Type == "Audio" {
+				track.SampleRate = stream.SampleRate
+				if track.BitRate == 0 {
+					track.BitRate = stream.BitRate / 1000
+				}
+				break
+			}
+		}
+	}
+
+	if track.Title == "" {
+		track.Title = "Unknown Title"
+	}
+	if track.Artist == "" {
+		track.Artist = "Unknown Artist"
+	}
+	if track.Album == "" {
+		track.Album = "Unknown Album"
+	}
+
+	track.AlbumID = remote.ScopedID("album_", l.sourceID, it.AlbumID)
+	track.ArtistID = remote.ScopedID("artist_", l.sourceID, artistKey)
+
+	return track
+}
+
+// itemToAlbum converts a Jellyfin MusicAlbum item into an Album, downloading its artwork
+func (l *library) itemToAlbum(ctx context.Context, it item, onError func(string)) *model.Album {
+	album := &model.Album{
+		ID:            remote.ScopedID("album_", l.sourceID, it.ID),
+		SourceID:      l.sourceID,
+		SourceType:    model.SourceTypeJellyfin,
+		Title:         it.Name,
+		Artist:        it.AlbumArtist,
+		Year:          it.ProductionYear,
+		TrackCount:    it.ChildCount,
+		TotalDuration: ticksToDuration(it.RunTimeTicks),
+		AddedAt:       parseDate(it.DateCreated),
+	}
+
+	if len(it.AlbumArtists) > 0 {
+		album.ArtistID = remote.ScopedID("artist_", l.sourceID, it.AlbumArtists[0].ID)
+	}
+	if len(it.Genres) > 0 {
+		album.Genre = it.Genres[0]
+	}
+
+	if imageTag := it.ImageTags["Primary"]; imageTag != "" {
+		artworkPath, err := l.saveImage(ctx, it.ID, imageTag)
+		if err != nil {
+			onError(fmt.Sprintf("%s: %v", it.Name, err))
+		} else {
+			album.ArtworkPath = artworkPath
+		}
+	}
+
+	return album
+}
+
+// itemToArtist converts a Jellyfin MusicArtist item into an Artist, downloading its image
+func (l *library) itemToArtist(ctx context.Context, it item, onError func(string)) *model.Artist {
+	artist := &model.Artist{
+		ID:         remote.ScopedID("artist_", l.sourceID, it.ID),
+		SourceID:   l.sourceID,
+		SourceType: model.SourceTypeJellyfin,
+		Name:       it.Name,
+		AlbumCount: it.ChildCount,
+		AddedAt:    parseDate(it.DateCreated),
+	}
+
+	if imageTag := it.ImageTags["Primary"]; imageTag != "" {
+		imagePath, err := l.saveImage(ctx, it.ID, imageTag)
+		if err != nil {
+			onError(fmt.Sprintf("%s: %v", it.Name, err))
+		} else {
+			artist.ImagePath = imagePath
+		}
+	}
+
+	return artist
+}
+
+// RestoreArtwork downloads an album or artist image again after the artwork
+// cache evicted it
+func (r *jellyfinTrackRepository) RestoreArtwork(ctx context.Context, filename string) (bool, error) {
+	return r.lib.artwork.Restore(ctx, filename)
+}
+
+// saveImage downloads an item's primary image once into the artwork cache directory
+// The image tag changes when the artwork changes, so it is part of the filename
+func (l *library) saveImage(ctx context.Context, itemID, imageTag string) (string, error) {
+	return l.artwork.Save(ctx, remote.ScopedID("jellyfin_", l.sourceID, itemID+"/"+imageTag), func(ctx context.Context) ([]byte, string, error) {
+		return l.client.GetPrimaryImage(ctx, itemID, imageTag)
+	})
+}
+
+// ticksToDuration converts Jellyfin ticks (100ns) to a Duration
+func ticksToDuration(ticks int64) time.Duration {
+	return time.Duration(ticks) * (time.Second / ticksPerSecond)
+}
+
+// parseDate parses a Jellyfin timestamp, falling back to now
+func parseDate(value string) time.Time {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t
+	}
+	return time.Now()
+}
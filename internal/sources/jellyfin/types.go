@@ -0,0 +1,68 @@
+package jellyfin
+
+// Jellyfin REST API JSON response types
+// See https://api.jellyfin.org/
+
+// ticksPerSecond converts Jellyfin RunTimeTicks (100ns units) to seconds
+const ticksPerSecond = 10_000_000
+
+type authResponse struct {
+	AccessToken string `json:"AccessToken"`
+	User        user   `json:"User"`
+}
+
+type user struct {
+	ID     string `json:"Id"`
+	Name   string `json:"Name"`
+	Policy struct {
+		IsAdministrator bool `json:"IsAdministrator"`
+	} `json:"Policy"`
+}
+
+type itemsResponse struct {
+	Items            []item `json:"Items"`
+	TotalRecordCount int    `json:"TotalRecordCount"`
+}
+
+type nameID struct {
+	Name string `json:"Name"`
+	ID   string `json:"Id"`
+}
+
+type item struct {
+	ID                   string            `json:"Id"`
+	Name                 string            `json:"Name"`
+	Type                 string            `json:"Type"`
+	Album                string            `json:"Album"`
+	AlbumID              string            `json:"AlbumId"`
+	AlbumArtist          string            `json:"AlbumArtist"`
+	AlbumArtists         []nameID          `json:"AlbumArtists"`
+	Artists              []string          `json:"Artists"`
+	ArtistItems          []nameID          `json:"ArtistItems"`
+	IndexNumber          int               `json:"IndexNumber"`
+	ParentIndexNumber    int               `json:"ParentIndexNumber"`
+	ProductionYear       int               `json:"ProductionYear"`
+	RunTimeTicks         int64             `json:"RunTimeTicks"`
+	Genres               []string          `json:"Genres"`
+	Container            string            `json:"Container"`
+	ChildCount           int               `json:"ChildCount"`
+	DateCreated          string            `json:"DateCreated"`
+	ImageTags            map[string]string `json:"ImageTags"`
+	AlbumPrimaryImageTag string            `json:"AlbumPrimaryImageTag"`
+	MediaSources         []mediaSource     `json:"MediaSources"`
+}
+
+type mediaSource struct {
+	Size         int64         `json:"Size"`
+	Bitrate      int           `json:"Bitrate"`
+	Container    string        `json:"Container"`
+	MediaStreams []mediaStream `json:"MediaStreams"`
+}
+
+type mediaStream struct {
+	Type       string `json:"Type"`
+	Codec      string `json:"Codec"`
+	BitRate    int    `json:"BitRate"`
+	SampleRate int    `json:"SampleRate"`
+	Channels   int    `json:"Channels"`
+}
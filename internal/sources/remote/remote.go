@@ -0,0 +1,79 @@
+package remote
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+
+	"GoMusic/internal/sources/artwork"
+)
+
+// ScopedID hashes a server ID together with the source ID so that two servers
+// using the same ID scheme never collide
+func ScopedID(prefix, sourceID, id string) string {
+	hash := sha256.Sum256([]byte(sourceID + "/" + id))
+	return prefix + hex.EncodeToString(hash[:8])
+}
+
+// Artwork downloads a source's images into the artwork cache and can download
+// them again after the cache evicted them
+// The zero value is ready to use
+type Artwork struct {
+	downloads map[string]artworkDownload
+	mu        sync.Mutex
+}
+
+// artworkDownload is how a cached image was obtained
+type artworkDownload struct {
+	key   string
+	fetch func(ctx context.Context) ([]byte, string, error)
+}
+
+// Save downloads an image once into the artwork cache, remembering it under
+// key, and returns the cached filename, which is served through the /artwork/
+// route
+// fetch returns the image and its content type; an empty image means the server
+// has none
+func (a *Artwork) Save(ctx context.Context, key string, fetch func(ctx context.Context) ([]byte, string, error)) (string, error) {
+	filename := artwork.Lookup(key)
+	if filename == "" {
+		data, contentType, err := fetch(ctx)
+		if err != nil || len(data) == 0 {
+			return "", err
+		}
+		if filename, err = artwork.StoreKeyed(key, data, artwork.Extension(contentType)); err != nil {
+			return "", err
+		}
+	}
+
+	a.mu.Lock()
+	if a.downloads == nil {
+		a.downloads = make(map[string]artworkDownload)
+	}
+	a.downloads[filename] = artworkDownload{key: key, fetch: fetch}
+	a.mu.Unlock()
+	return filename, nil
+}
+
+// Restore downloads the image cached as filename again and reports whether it
+// was one of this source's; an image that changed on the server since is not
+// restored
+func (a *Artwork) Restore(ctx context.Context, filename string) (bool, error) {
+	a.mu.Lock()
+	download, ok := a.downloads[filename]
+	a.mu.Unlock()
+	if !ok {
+		return false, nil
+	}
+
+	data, contentType, err := download.fetch(ctx)
+	if err != nil || len(data) == 0 {
+		return false, err
+	}
+	restored, err := artwork.StoreKeyed(download.key, data, artwork.Extension(contentType))
+	if err != nil {
+		return false, err
+	}
+	return restored == filename, nil
+}
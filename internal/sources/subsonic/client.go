@@ -0,0 +1,250 @@
+package subsonic
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"GoMusic/internal/domain/model"
+	"GoMusic/internal/sources/httpclient"
+)
+
+const (
+	// apiVersion is the Subsonic protocol version we speak (token auth needs 1.13.0+)
+	apiVersion = "1.16.1"
+	clientName = "GoMusic"
+)
+
+// Client talks to a Subsonic-compatible server (Navidrome, Airsonic, Gonic, ...)
+type Client struct {
+	baseURL  string
+	username string
+	password string
+	apiKey   string
+
+	http   *httpclient.Client
+	stream *httpclient.Client
+}
+
+// NewClient creates a Subsonic client from an API source configuration
+func NewClient(config *model.APISourceConfig) *Client {
+	return &Client{
+		baseURL:  strings.TrimSuffix(config.BaseURL, "/"),
+		username: config.Username,
+		password: config.Password,
+		apiKey:   config.APIKey,
+		http:     httpclient.New(config.Timeout, config.RateLimit),
+		stream:   httpclient.NewStreaming(config.RateLimit),
+	}
+}
+
+// Error is an error returned by the Subsonic API
+type Error struct {
+	Code    int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("subsonic error %d: %s", e.Code, e.Message)
+}
+
+// Ping checks connectivity and credentials
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.call(ctx, "ping", nil)
+	return err
+}
+
+// GetAlbumList2 returns a page of albums sorted by name
+func (c *Client) GetAlbumList2(ctx context.Context, offset, size int) ([]album, error) {
+	params := url.Values{}
+	params.Set("type", "alphabeticalByName")
+	params.Set("offset", strconv.Itoa(offset))
+	params.Set("size", strconv.Itoa(size))
+
+	resp, err := c.call(ctx, "getAlbumList2", params)
+	if err != nil {
+		return nil, err
+	}
+	if resp.AlbumList2 == nil {
+		return nil, nil
+	}
+	return resp.AlbumList2.Album, nil
+}
+
+// GetAlbum returns an album with its songs
+func (c *Client) GetAlbum(ctx context.Context, id string) (*album, error) {
+	params := url.Values{}
+	params.Set("id", id)
+
+	resp, err := c.call(ctx, "getAlbum", params)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Album == nil {
+		return nil, &Error{Code: errCodeNotFound, Message: "album not found"}
+	}
+	return resp.Album, nil
+}
+
+// Search3 searches songs on the server
+func (c *Client) Search3(ctx context.Context, query string, songCount int) ([]song, error) {
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("artistCount", "0")
+	params.Set("albumCount", "0")
+	params.Set("songCount", strconv.Itoa(songCount))
+
+	resp, err := c.call(ctx, "search3", params)
+	if err != nil {
+		return nil, err
+	}
+	if resp.SearchResult3 == nil {
+		return nil, nil
+	}
+	return resp.SearchResult3.Song, nil
+}
+
+// StreamURL returns the stream endpoint URL for a song without credentials
+// Credentials are added when the stream is opened so they never reach the frontend
+func (c *Client) StreamURL(id string) string {
+	params := url.Values{}
+	params.Set("id", id)
+	return c.endpoint("stream") + "?" + params.Encode()
+}
+
+// OpenStream requests the audio stream at streamURL with method (GET or HEAD),
+// forwarding the given header
+func (c *Client) OpenStream(ctx context.Context, streamURL string, method string, header http.Header) (*http.Response, error) {
+	streamReq, err := url.Parse(streamURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stream URL: %w", err)
+	}
+
+	params := streamReq.Query()
+	c.authenticate(params)
+	streamReq.RawQuery = params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, method, streamReq.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stream request: %w", err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	return c.stream.Do(req)
+}
+
+// GetCoverArt downloads cover art for the given cover art ID
+func (c *Client) GetCoverArt(ctx context.Context, id string) ([]byte, string, error) {
+	params := url.Values{}
+	params.Set("id", id)
+	c.authenticate(params)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoint("getCoverArt")+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch cover art: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("failed to fetch cover art: %s", resp.Status)
+	}
+
+	// Errors are returned as a Subsonic response instead of an image
+	contentType := resp.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "image/") {
+		return nil, "", fmt.Errorf("unexpected cover art content type: %s", contentType)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read cover art: %w", err)
+	}
+
+	return data, contentType, nil
+}
+
+// call performs a JSON API request and unwraps the subsonic-response envelope
+func (c *Client) call(ctx context.Context, endpoint string, params url.Values) (*response, error) {
+	if params == nil {
+		params = url.Values{}
+	}
+	c.authenticate(params)
+	params.Set("f", "json")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoint(endpoint)+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to %s failed: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("request to %s failed: %s", endpoint, resp.Status)
+	}
+
+	var env envelope
+	if err := json.NewDecoder(resp.Body).Decode(&env); err != nil {
+		return nil, fmt.Errorf("failed to parse %s response: %w", endpoint, err)
+	}
+
+	if env.Response.Status != "ok" {
+		if env.Response.Error != nil {
+			return nil, &Error{Code: env.Response.Error.Code, Message: env.Response.Error.Message}
+		}
+		return nil, fmt.Errorf("request to %s failed with status %q", endpoint, env.Response.Status)
+	}
+
+	return &env.Response, nil
+}
+
+// authenticate adds authentication parameters to a request
+// Uses the OpenSubsonic apiKey extension if configured, otherwise salted token auth
+func (c *Client) authenticate(params url.Values) {
+	params.Set("v", apiVersion)
+	params.Set("c", clientName)
+
+	if c.apiKey != "" {
+		params.Set("apiKey", c.apiKey)
+		return
+	}
+
+	salt := newSalt()
+	token := md5.Sum([]byte(c.password + salt))
+
+	params.Set("u", c.username)
+	params.Set("t", hex.EncodeToString(token[:]))
+	params.Set("s", salt)
+}
+
+// endpoint builds the URL of a REST endpoint
+func (c *Client) endpoint(name string) string {
+	return c.baseURL + "/rest/" + name
+}
+
+// newSalt generates a random salt for token authentication
+func newSalt() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "gomusic"
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,203 @@
+package subsonic
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"GoMusic/internal/domain/model"
+	"GoMusic/internal/domain/source/capability"
+	"GoMusic/internal/sources/artwork"
+)
+
+// fakeServer is a Subsonic server holding albums of one song each
+type fakeServer struct {
+	username string
+	password string
+	apiKey   string
+	albums   int
+	cover    []byte // served for album 0 only
+
+	mu         sync.Mutex
+	listCalls  []string // offset of each getAlbumList2 call
+	lastStream http.Header
+}
+
+func newFakeServer(t *testing.T, f *fakeServer) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// authorized checks the credentials of a request the way a server would
+func (f *fakeServer) authorized(params url.Values) bool {
+	if key := params.Get("apiKey"); key != "" {
+		return key == f.apiKey
+	}
+	token := md5.Sum([]byte(f.password + params.Get("s")))
+	return params.Get("u") == f.username && params.Get("s") != "" && params.Get("t") == hex.EncodeToString(token[:])
+}
+
+func (f *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	if !f.authorized(params) {
+		f.reply(w, response{Status: "failed", Error: &apiError{Code: errCodeWrongCredentials, Message: "Wrong username or password"}})
+		return
+	}
+
+	switch strings.TrimPrefix(r.URL.Path, "/rest/") {
+	case "ping":
+		f.reply(w, response{Status: "ok"})
+	case "getAlbumList2":
+		f.mu.Lock()
+		f.listCalls = append(f.listCalls, params.Get("offset"))
+		f.mu.Unlock()
+		offset, _ := strconv.Atoi(params.Get("offset"))
+		size, _ := strconv.Atoi(params.Get("size"))
+		list := &albumList{Album: []album{}}
+		for i := offset; i < f.albums && i < offset+size; i++ {
+			list.Album = append(list.Album, album{ID: fmt.Sprintf("al-%d", i), Name: fmt.Sprintf("Album %d", i), Artist: "Band"})
+		}
+		f.reply(w, response{Status: "ok", AlbumList2: list})
+	case "getAlbum":
+		id := params.Get("id")
+		full := &album{ID: id, Name: "Album " + strings.TrimPrefix(id, "al-"), Artist: "Band", Song: []song{{
+			ID: "so-" + strings.TrimPrefix(id, "al-"), Title: "Song", Album: "Album", AlbumID: id, Artist: "Band",
+			ArtistID: "ar-1", Track: 1, Duration: 200, Suffix: "FLAC", Created: "2024-01-02T03:04:05Z",
+		}}}
+		if id == "al-0" {
+			full.CoverArt = "cover-0"
+		}
+		f.reply(w, response{Status: "ok", Album: full})
+	case "getCoverArt":
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write(f.cover)
+	case "stream":
+		f.mu.Lock()
+		f.lastStream = r.Header.Clone()
+		f.mu.Unlock()
+		w.Header().Set("Content-Type", "audio/flac")
+		w.WriteHeader(http.StatusPartialContent)
+		io.WriteString(w, "audio")
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeServer) reply(w http.ResponseWriter, resp response) {
+	resp.Version = apiVersion
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(envelope{Response: resp})
+}
+
+func TestClientAuthentication(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   model.APISourceConfig
+		wantCode int // Subsonic error code, 0 for success
+	}{
+		{name: "token", config: model.APISourceConfig{Username: "admin", Password: "secret"}},
+		{name: "API key", config: model.APISourceConfig{APIKey: "key"}},
+		{name: "wrong password", config: model.APISourceConfig{Username: "admin", Password: "guess"}, wantCode: errCodeWrongCredentials},
+		{name: "wrong API key", config: model.APISourceConfig{APIKey: "other"}, wantCode: errCodeWrongCredentials},
+	}
+
+	server := newFakeServer(t, &fakeServer{username: "admin", password: "secret", apiKey: "key"})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			config.BaseURL = server.URL + "/"
+			err := NewClient(&config).Ping(context.Background())
+
+			var apiErr *Error
+			switch {
+			case tt.wantCode == 0 && err != nil:
+				t.Fatalf("Ping() error = %v", err)
+			case tt.wantCode != 0 && (!stderrors.As(err, &apiErr) || apiErr.Code != tt.wantCode):
+				t.Fatalf("Ping() error = %v, want code %d", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestScan(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	fake := &fakeServer{username: "admin", password: "secret", albums: albumPageSize + 1, cover: []byte("\xff\xd8\xff\xe0 cover")}
+	server := newFakeServer(t, fake)
+
+	repo := NewSubsonicTrackRepository("navidrome", &model.APISourceConfig{BaseURL: server.URL, Username: "admin", Password: "secret"})
+	if err := repo.Scan(context.Background()); err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if progress := repo.GetScanProgress(); len(progress.Errors) > 0 {
+		t.Fatalf("scan errors: %v", progress.Errors)
+	}
+
+	// Albums are listed a page at a time until a short page
+	if got := strings.Join(fake.listCalls, ","); got != "0,500" {
+		t.Errorf("getAlbumList2 offsets = %s, want 0,500", got)
+	}
+
+	tracks, err := repo.FindAll(context.Background(), nil)
+	if err != nil || len(tracks) != fake.albums {
+		t.Fatalf("FindAll() = %d tracks, %v, want %d", len(tracks), err, fake.albums)
+	}
+
+	first, err := repo.FindByID(context.Background(), generateTrackID("navidrome", "so-0"))
+	if err != nil {
+		t.Fatalf("FindByID() error = %v", err)
+	}
+	if first.Title != "Song" || first.Format != "flac" || first.ExternalID != "so-0" || first.AlbumArtist != "Band" {
+		t.Errorf("track = %+v", first)
+	}
+
+	// Cover art is cached once and served from the artwork folder
+	if first.ArtworkPath == "" {
+		t.Fatal("album 0 has no artwork")
+	}
+	dir, err := artwork.Dir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, first.ArtworkPath)); err != nil || string(data) != string(fake.cover) {
+		t.Errorf("cached artwork = %q, %v, want the server's cover", data, err)
+	}
+
+	// The stream URL reaches the frontend, so it carries no credentials; they
+	// are added when the stream is opened
+	streamURL, err := url.Parse(first.StreamURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, param := range []string{"u", "p", "t", "s", "apiKey"} {
+		if streamURL.Query().Has(param) {
+			t.Errorf("stream URL %s carries %q", first.StreamURL, param)
+		}
+	}
+	if streamURL.Path != "/rest/stream" || streamURL.Query().Get("id") != "so-0" {
+		t.Errorf("stream URL = %s", first.StreamURL)
+	}
+
+	resp, err := repo.(capability.StreamProvider).OpenStream(context.Background(), first, http.MethodGet, http.Header{"Range": {"bytes=0-4"}})
+	if err != nil {
+		t.Fatalf("OpenStream() error = %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent || fake.lastStream.Get("Range") != "bytes=0-4" {
+		t.Errorf("OpenStream() = %s with Range %q, want the authenticated, ranged stream", resp.Status, fake.lastStream.Get("Range"))
+	}
+}
@@ -0,0 +1,323 @@
+package subsonic
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"GoMusic/internal/domain/model"
+	"GoMusic/internal/domain/repository"
+	"GoMusic/internal/sources/cache"
+	"GoMusic/internal/sources/remote"
+	"GoMusic/internal/util/errors"
+)
+
+// albumPageSize is the number of albums requested per getAlbumList2 call (server maximum is 500)
+const albumPageSize = 500
+
+// subsonicTrackRepository implements TrackRepository for Subsonic-compatible servers
+// The library is synced into an in-memory cache; searches go to the server when reachable
+// This struct is unexported to enforce usage of the constructor
+type subsonicTrackRepository struct {
+	sourceID     string
+	client       *Client
+	cache        *cache.TrackCache
+	scanProgress *repository.ScanProgress
+	artwork      remote.Artwork
+	mu           sync.RWMutex
+}
+
+// NewSubsonicTrackRepository creates a new Subsonic track repository
+func NewSubsonicTrackRepository(sourceID string, config *model.APISourceConfig) repository.TrackRepository {
+	return &subsonicTrackRepository{
+		sourceID: sourceID,
+		client:   NewClient(config),
+		cache:    cache.NewTrackCache(),
+		scanProgress: &repository.ScanProgress{
+			IsScanning: false,
+		},
+	}
+}
+
+// FindByID finds a track by ID
+func (r *subsonicTrackRepository) FindByID(ctx context.Context, id string) (*model.Track, error) {
+	track := r.cache.Get(id)
+	if track == nil {
+		return nil, errors.ErrNotFound
+	}
+	return track, nil
+}
+
+// FindAll returns all synced tracks with optional filtering
+func (r *subsonicTrackRepository) FindAll(ctx context.Context, opts *repository.QueryOptions) ([]*model.Track, error) {
+	return r.cache.GetAll(opts), nil
+}
+
+// Create adds a new track to the repository
+func (r *subsonicTrackRepository) Create(ctx context.Context, track *model.Track) error {
+	if r.cache.Get(track.ID) != nil {
+		return errors.ErrAlreadyExists
+	}
+	r.cache.Add(track)
+	return nil
+}
+
+// Update updates an existing track
+func (r *subsonicTrackRepository) Update(ctx context.Context, track *model.Track) error {
+	if r.cache.Get(track.ID) == nil {
+		return errors.ErrNotFound
+	}
+	r.cache.Add(track)
+	return nil
+}
+
+// Delete removes a track from the repository
+func (r *subsonicTrackRepository) Delete(ctx context.Context, id string) error {
+	if r.cache.Get(id) == nil {
+		return errors.ErrNotFound
+	}
+	r.cache.Delete(id)
+	return nil
+}
+
+// FindByAlbum returns all tracks for a given album
+func (r *subsonicTrackRepository) FindByAlbum(ctx context.Context, albumID string) ([]*model.Track, error) {
+	return r.cache.FindByAlbum(albumID), nil
+}
+
+// FindByArtist returns all tracks for a given artist
+func (r *subsonicTrackRepository) FindByArtist(ctx context.Context, artistID string) ([]*model.Track, error) {
+	return r.cache.FindByArtist(artistID), nil
+}
+
+// Search searches the server with search3, falling back to the synced cache when offline
+func (r *subsonicTrackRepository) Search(ctx context.Context, query string, opts *repository.SearchOptions) ([]*model.Track, error) {
+	limit := 100
+	if opts != nil && opts.QueryOptions != nil && opts.Limit > 0 {
+		limit = opts.Limit
+	}
+
+	songs, err := r.client.Search3(ctx, query, limit)
+	if err != nil {
+		return r.cache.Search(query, opts), nil
+	}
+
+	tracks := make([]*model.Track, 0, len(songs))
+	for _, s := range songs {
+		track := r.cache.Get(generateTrackID(r.sourceID, s.ID))
+		if track == nil {
+			// Not synced yet: make it playable right away
+			track = r.songToTrack(s, "")
+			r.cache.Add(track)
+		}
+		tracks = append(tracks, track)
+	}
+
+	return tracks, nil
+}
+
+// GetSourceID returns the source ID
+func (r *subsonicTrackRepository) GetSourceID() string {
+	return r.sourceID
+}
+
+// GetSourceType returns the source type
+func (r *subsonicTrackRepository) GetSourceType() model.SourceType {
+	return model.SourceTypeAPISelfHosted
+}
+
+// Scan syncs the library from the server album by album
+func (r *subsonicTrackRepository) Scan(ctx context.Context) error {
+	r.mu.Lock()
+	if r.scanProgress.IsScanning {
+		r.mu.Unlock()
+		return errors.ErrScanInProgress
+	}
+	r.scanProgress.IsScanning = true
+	r.scanProgress.ProcessedFiles = 0
+	r.scanProgress.TotalFiles = 0
+	r.scanProgress.CurrentFile = ""
+	r.scanProgress.Errors = []string{}
+	r.mu.Unlock()
+
+	defer func() {
+		r.mu.Lock()
+		r.scanProgress.IsScanning = false
+		r.scanProgress.CurrentFile = ""
+		r.mu.Unlock()
+	}()
+
+	if err := r.client.Ping(ctx); err != nil {
+		return fmt.Errorf("failed to connect to server: %w", err)
+	}
+
+	// Collect all albums first so progress has a meaningful total
+	var albums []album
+	for offset := 0; ; offset += albumPageSize {
+		page, err := r.client.GetAlbumList2(ctx, offset, albumPageSize)
+		if err != nil {
+			return fmt.Errorf("failed to list albums: %w", err)
+		}
+		albums = append(albums, page...)
+		if len(page) < albumPageSize {
+			break
+		}
+	}
+
+	r.mu.Lock()
+	r.scanProgress.TotalFiles = len(albums)
+	r.mu.Unlock()
+
+	// Build the new library before swapping so the old one stays usable during sync
+	var tracks []*model.Track
+	for _, a := range albums {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		r.mu.Lock()
+		r.scanProgress.CurrentFile = a.Artist + " - " + a.Name
+		r.scanProgress.ProcessedFiles++
+		r.mu.Unlock()
+
+		full, err := r.client.GetAlbum(ctx, a.ID)
+		if err != nil {
+			r.addScanError(fmt.Sprintf("%s: %v", a.Name, err))
+			continue
+		}
+
+		artworkPath := ""
+		if full.CoverArt != "" {
+			artworkPath, err = r.saveCoverArt(ctx, full.CoverArt)
+			if err != nil {
+				// Log error but don't fail the album
+				r.addScanError(fmt.Sprintf("%s: %v", a.Name, err))
+			}
+		}
+
+		for _, s := range full.Song {
+			track := r.songToTrack(s, full.Artist)
+			track.ArtworkPath = artworkPath
+			tracks = append(tracks, track)
+		}
+	}
+
+	r.cache.Clear()
+	for _, track := range tracks {
+		r.cache.Add(track)
+	}
+
+	return nil
+}
+
+// GetScanProgress returns the current sync progress
+func (r *subsonicTrackRepository) GetScanProgress() *repository.ScanProgress {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// Return a copy to avoid data races
+	progress := *r.scanProgress
+	progress.Errors = make([]string, len(r.scanProgress.Errors))
+	copy(progress.Errors, r.scanProgress.Errors)
+
+	return &progress
+}
+
+// OpenStream opens an authenticated audio stream for a track
+func (r *subsonicTrackRepository) OpenStream(ctx context.Context, track *model.Track, method string, header http.Header) (*http.Response, error) {
+	if track.StreamURL == "" {
+		return nil, fmt.Errorf("track has no stream URL")
+	}
+	return r.client.OpenStream(ctx, track.StreamURL, method, header)
+}
+
+// addScanError records a non-fatal sync error
+func (r *subsonicTrackRepository) addScanError(message string) {
+	r.mu.Lock()
+	r.scanProgress.Errors = append(r.scanProgress.Errors, message)
+	r.mu.Unlock()
+}
+
+// songToTrack converts a Subsonic song into a Track
+func (r *subsonicTrackRepository) songToTrack(s song, albumArtist string) *model.Track {
+	track := &model.Track{
+		ID:         generateTrackID(r.sourceID, s.ID),
+		SourceID:   r.sourceID,
+		SourceType: model.SourceTypeAPISelfHosted,
+
+		Title:       s.Title,
+		Album:       s.Album,
+		Artist:      s.Artist,
+		AlbumArtist: albumArtist,
+		Genre:       s.Genre,
+		Year:        s.Year,
+		TrackNumber: s.Track,
+		DiscNumber:  s.DiscNumber,
+		Duration:    time.Duration(s.Duration) * time.Second,
+
+		FileSize:   s.Size,
+		Format:     strings.ToLower(s.Suffix),
+		BitRate:    s.BitRate,
+		SampleRate: s.SamplingRate,
+
+		ExternalID: s.ID,
+		StreamURL:  r.client.StreamURL(s.ID),
+
+		AddedAt:    time.Now(),
+		ModifiedAt: parseCreated(s.Created),
+	}
+
+	if track.Title == "" {
+		track.Title = "Unknown Title"
+	}
+	if track.Artist == "" {
+		track.Artist = "Unknown Artist"
+	}
+	if track.Album == "" {
+		track.Album = "Unknown Album"
+	}
+
+	// Server IDs are stable, so use them for grouping instead of display names
+	track.AlbumID = remote.ScopedID("album_", r.sourceID, s.AlbumID)
+	track.ArtistID = remote.ScopedID("artist_", r.sourceID, s.ArtistID)
+
+	return track
+}
+
+// saveCoverArt downloads cover art once into the artwork cache directory
+// Returns the cached filename, which is served through the /artwork/ route
+func (r *subsonicTrackRepository) saveCoverArt(ctx context.Context, coverArtID string) (string, error) {
+	return r.artwork.Save(ctx, remote.ScopedID("subsonic_", r.sourceID, coverArtID), func(ctx context.Context) ([]byte, string, error) {
+		data, contentType, err := r.client.GetCoverArt(ctx, coverArtID)
+		var apiErr *Error
+		if stderrors.As(err, &apiErr) && apiErr.Code == errCodeNotFound {
+			return nil, "", nil
+		}
+		return data, contentType, err
+	})
+}
+
+// RestoreArtwork downloads cover art again after the artwork cache evicted it
+func (r *subsonicTrackRepository) RestoreArtwork(ctx context.Context, filename string) (bool, error) {
+	return r.artwork.Restore(ctx, filename)
+}
+
+// ID generation functions
+
+func generateTrackID(sourceID, songID string) string {
+	return remote.ScopedID("track_", sourceID, songID)
+}
+
+// parseCreated parses the song creation timestamp, falling back to now
+func parseCreated(created string) time.Time {
+	if t, err := time.Parse(time.RFC3339, created); err == nil {
+		return t
+	}
+	return time.Now()
+}
@@ -0,0 +1,75 @@
+package subsonic
+
+// Subsonic REST API JSON response types
+// See http://www.subsonic.org/pages/api.jsp and https://opensubsonic.netlify.app/
+
+// envelope wraps every JSON response
+type envelope struct {
+	Response response `json:"subsonic-response"`
+}
+
+type response struct {
+	Status        string         `json:"status"`
+	Version       string         `json:"version"`
+	Type          string         `json:"type,omitempty"` // OpenSubsonic server name
+	OpenSubsonic  bool           `json:"openSubsonic,omitempty"`
+	Error         *apiError      `json:"error,omitempty"`
+	AlbumList2    *albumList     `json:"albumList2,omitempty"`
+	Album         *album         `json:"album,omitempty"`
+	SearchResult3 *searchResult3 `json:"searchResult3,omitempty"`
+}
+
+type apiError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type albumList struct {
+	Album []album `json:"album"`
+}
+
+type album struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Artist    string `json:"artist"`
+	ArtistID  string `json:"artistId"`
+	CoverArt  string `json:"coverArt"`
+	SongCount int    `json:"songCount"`
+	Duration  int    `json:"duration"`
+	Year      int    `json:"year"`
+	Genre     string `json:"genre"`
+	Song      []song `json:"song,omitempty"`
+}
+
+type song struct {
+	ID           string `json:"id"`
+	Title        string `json:"title"`
+	Album        string `json:"album"`
+	AlbumID      string `json:"albumId"`
+	Artist       string `json:"artist"`
+	ArtistID     string `json:"artistId"`
+	Track        int    `json:"track"`
+	DiscNumber   int    `json:"discNumber"`
+	Year         int    `json:"year"`
+	Genre        string `json:"genre"`
+	CoverArt     string `json:"coverArt"`
+	Size         int64  `json:"size"`
+	ContentType  string `json:"contentType"`
+	Suffix       string `json:"suffix"`
+	Duration     int    `json:"duration"` // seconds
+	BitRate      int    `json:"bitRate"`  // kbps
+	SamplingRate int    `json:"samplingRate,omitempty"`
+	Created      string `json:"created"`
+}
+
+type searchResult3 struct {
+	Album []album `json:"album"`
+	Song  []song  `json:"song"`
+}
+
+// Subsonic error codes
+const (
+	errCodeWrongCredentials = 40
+	errCodeNotAuthorized    = 50
+	errCodeNotFound         = 70
+)